@@ -0,0 +1,537 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	c "userbalance/internal/config"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+	"userbalance/internal/service"
+
+	"github.com/mailru/easyjson"
+)
+
+const (
+	outputTable string = "table"
+	outputJSON  string = "json"
+)
+
+// adminResolver записывается в решения по операциям на проверке, принятые через CLI
+const adminResolver string = "admin"
+
+const adminUsage string = `использование: userbalance admin [-config путь] [-<ключ конфигурации> значение] [-output table|json] <команда> [параметры]
+
+команды:
+  balance  -user ID                                         баланс пользователя
+  topup    -user ID -amount N [-date yyyy-mm-dd]            пополнение баланса
+  transfer -from ID -to ID -amount N [-date yyyy-mm-dd]     перевод средств
+  reserve  -user ID -service ID -order ID -amount N [-date] резервирование средств
+  confirm  -user ID -service ID -order ID -amount N [-date] списание зарезервированных средств
+  cancel   -user ID -service ID -order ID -amount N [-date] разрезервирование средств
+  history  -user ID [-sort date|amount] [-direction asc|desc] [-key K [-value V]] история пользователя, -key - с ключом метаданных
+  report   -year YYYY -month MM                             отчет по услугам за месяц
+  apikey create -name NAME -scopes scope1,scope2 [-signing] создание ключа API (и секрета подписи), выводятся один раз
+  apikey list                                               список ключей API
+  apikey signing -id ID                                     выдача нового секрета подписи запросов
+  apikey revoke -id ID                                      отзыв ключа API
+  limit list -user ID                                       лимиты пользователя по операциям
+  limit set -user ID -operation OP [-peroperation N] [-daily N] лимиты пользователя для операции, 0 - без лимита
+  limit delete -user ID -operation OP                       удаление лимитов пользователя, действуют значения по умолчанию
+  risk list [-decision D] [-status S] [-user ID] [-limit N] решения проверки операций на мошенничество
+  risk approve -id ID                                       выполнение операции, ожидающей проверки
+  risk reject -id ID                                        отклонение операции, ожидающей проверки
+
+операции с лимитами: topup, transfer, reserve
+topup, transfer, reserve, confirm и cancel принимают -comment - комментарий, сохраняемый в истории
+решения проверки: allow, review, deny; состояния операций на проверке: pending, approved, rejected
+права: balance:read, balance:topup, balance:transfer, reservations:write, reports:read, audit:read, risk:review
+`
+
+// admin выполняет операции сотрудников поддержки через service.Control,
+// поэтому ручные исправления попадают в историю и журнал так же, как запросы к API
+type admin struct {
+	control service.Control
+	auth    service.Auth
+	limits  service.Limits
+	risk    service.Risk
+	out     io.Writer
+	output  string
+}
+
+func runAdmin(args []string) error {
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	path := fs.String("config", defaultConfigPath, "example -config ./configs/config.yaml")
+	output := fs.String("output", outputTable, "output format: table or json")
+	overrides := c.BindFlags(fs)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), adminUsage) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("не указана команда")
+	}
+	if *output != outputTable && *output != outputJSON {
+		return fmt.Errorf("неизвестный формат вывода: %s", *output)
+	}
+
+	conf, _, err := loadConfig(*path, overrides)
+	if err != nil {
+		return err
+	}
+
+	db, err := repository.Connect(conf)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// операции сотрудников поддержки проверкой на мошенничество не останавливаются и выполняются без комиссии
+	repos := repository.NewRepository(db, conf)
+	control := service.NewControlService(repos.Control, repos.UnitOfWork, conf, nil, nil)
+	a := &admin{
+		control: control,
+		auth:    service.NewAuthService(repos.Control, conf),
+		limits:  service.NewLimitService(repos.Control, conf),
+		risk:    service.NewRiskService(control),
+		out:     os.Stdout,
+		output:  *output,
+	}
+
+	return a.run(context.Background(), fs.Arg(0), fs.Args()[1:])
+}
+
+func (a *admin) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "balance":
+		return a.balance(ctx, args)
+	case "topup":
+		return a.topup(ctx, args)
+	case "transfer":
+		return a.transfer(ctx, args)
+	case "reserve", "confirm", "cancel":
+		return a.transaction(ctx, command, args)
+	case "history":
+		return a.history(ctx, args)
+	case "report":
+		return a.report(ctx, args)
+	case "apikey":
+		return a.apikey(ctx, args)
+	case "limit":
+		return a.limit(ctx, args)
+	case "risk":
+		return a.riskReview(ctx, args)
+	default:
+		return fmt.Errorf("неизвестная команда: %s", command)
+	}
+}
+
+func (a *admin) balance(ctx context.Context, args []string) error {
+	var user models.User
+
+	fs := flag.NewFlagSet("balance", flag.ContinueOnError)
+	fs.IntVar(&user.Id, "user", 0, "user id")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := user.Validate(); err != nil {
+		return err
+	}
+
+	balance, err := a.control.GetBalance(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	if a.output == outputJSON {
+		return a.writeJSON(balance)
+	}
+	return a.writeTable([]string{"USERID", "BALANCE"}, []interface{}{balance.Id, balance.Balance})
+}
+
+func (a *admin) topup(ctx context.Context, args []string) error {
+	var replenishment models.Replenishment
+
+	fs := flag.NewFlagSet("topup", flag.ContinueOnError)
+	fs.IntVar(&replenishment.UserID, "user", 0, "user id")
+	fs.IntVar(&replenishment.Amount, "amount", 0, "amount")
+	fs.StringVar(&replenishment.Date, "date", "", "date yyyy-mm-dd, today by default")
+	fs.StringVar(&replenishment.Comment, "comment", "", "comment saved in the history")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := replenishment.Validate(); err != nil {
+		return err
+	}
+
+	if err := a.control.ReplenishmentBalance(ctx, &replenishment); err != nil {
+		return err
+	}
+	return a.writeMessage("OK")
+}
+
+func (a *admin) transfer(ctx context.Context, args []string) error {
+	var money models.Money
+
+	fs := flag.NewFlagSet("transfer", flag.ContinueOnError)
+	fs.IntVar(&money.FromUserID, "from", 0, "sender user id")
+	fs.IntVar(&money.ToUserID, "to", 0, "recipient user id")
+	fs.IntVar(&money.Amount, "amount", 0, "amount")
+	fs.StringVar(&money.Date, "date", "", "date yyyy-mm-dd, today by default")
+	fs.StringVar(&money.Comment, "comment", "", "comment saved in the history")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := money.Validate(); err != nil {
+		return err
+	}
+
+	if err := a.control.Transfer(ctx, &money); err != nil {
+		return err
+	}
+	return a.writeMessage("OK")
+}
+
+// transaction выполняет резервирование, списание либо разрезервирование средств по заказу
+func (a *admin) transaction(ctx context.Context, command string, args []string) error {
+	var transaction models.Transaction
+	var err error
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.IntVar(&transaction.UserID, "user", 0, "user id")
+	fs.IntVar(&transaction.ServiceID, "service", 0, "service id")
+	fs.IntVar(&transaction.OrderID, "order", 0, "order id")
+	fs.IntVar(&transaction.Amount, "amount", 0, "amount")
+	fs.StringVar(&transaction.Date, "date", "", "date yyyy-mm-dd, today by default")
+	fs.StringVar(&transaction.Comment, "comment", "", "comment saved in the history")
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	if err = transaction.Validate(); err != nil {
+		return err
+	}
+
+	switch command {
+	case "reserve":
+		err = a.control.Reservation(ctx, &transaction)
+	case "confirm":
+		err = a.control.Confirmation(ctx, &transaction)
+	case "cancel":
+		err = a.control.CancelReservation(ctx, &transaction)
+	}
+	if err != nil {
+		return err
+	}
+	return a.writeMessage("OK")
+}
+
+func (a *admin) history(ctx context.Context, args []string) error {
+	var requestHistory models.RequestHistory
+
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.IntVar(&requestHistory.UserID, "user", 0, "user id")
+	fs.StringVar(&requestHistory.SortField, "sort", "date", "sort field: date or amount")
+	fs.StringVar(&requestHistory.Direction, "direction", "asc", "sort direction: asc or desc")
+	fs.StringVar(&requestHistory.MetadataKey, "key", "", "only records with this metadata key")
+	fs.StringVar(&requestHistory.MetadataValue, "value", "", "only records with this metadata key value, requires -key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requestHistory.Validate(); err != nil {
+		return err
+	}
+
+	history, err := a.control.GetHistory(ctx, &requestHistory)
+	if err != nil {
+		return err
+	}
+
+	if a.output == outputJSON {
+		return a.writeJSON(&models.Histories{Entity: history})
+	}
+	rows := make([]interface{}, 0, len(history)*4)
+	for _, h := range history {
+		rows = append(rows, h.Date.Format(layout), h.Amount, h.Description, h.Comment)
+	}
+	return a.writeTable([]string{"DATE", "AMOUNT", "DESCRIPTION", "COMMENT"}, rows)
+}
+
+func (a *admin) report(ctx context.Context, args []string) error {
+	var requestReport models.RequestReport
+
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	fs.IntVar(&requestReport.Year, "year", 0, "year")
+	fs.IntVar(&requestReport.Month, "month", 0, "month")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := requestReport.Validate(); err != nil {
+		return err
+	}
+
+	path, err := a.control.CreateReport(ctx, &requestReport)
+	if err != nil {
+		return err
+	}
+	return a.writeMessage(path)
+}
+
+// apikey управляет ключами клиентов API: create, list, signing, revoke
+func (a *admin) apikey(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("не указана команда apikey: create, list, signing либо revoke")
+	}
+
+	switch args[0] {
+	case "create":
+		var key models.APIKey
+		var scopes string
+
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		fs.StringVar(&key.Name, "name", "", "client name")
+		fs.StringVar(&scopes, "scopes", "", "comma-separated scopes")
+		fs.BoolVar(&key.Signing, "signing", false, "issue request signing secret")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if scopes != "" {
+			key.Scopes = strings.Split(scopes, ",")
+		}
+
+		issued, err := a.auth.CreateAPIKey(ctx, &key)
+		if err != nil {
+			return err
+		}
+		if a.output == outputJSON {
+			return a.writeJSON(issued)
+		}
+		if issued.Signing {
+			return a.writeTable([]string{"ID", "NAME", "SCOPES", "KEY", "SIGNING SECRET"},
+				[]interface{}{issued.ID, issued.Name, strings.Join(issued.Scopes, ","), issued.Key, issued.SigningSecret})
+		}
+		return a.writeTable([]string{"ID", "NAME", "SCOPES", "KEY"},
+			[]interface{}{issued.ID, issued.Name, strings.Join(issued.Scopes, ","), issued.Key})
+	case "list":
+		keys, err := a.auth.GetAPIKeys(ctx)
+		if err != nil {
+			return err
+		}
+		if a.output == outputJSON {
+			return a.writeJSON(&models.APIKeys{Entity: keys})
+		}
+		rows := make([]interface{}, 0, len(keys)*6)
+		for _, key := range keys {
+			revoked := ""
+			if key.RevokedAt != nil {
+				revoked = key.RevokedAt.Format(layout)
+			}
+			rows = append(rows, key.ID, key.Name, strings.Join(key.Scopes, ","), key.Signing, key.CreatedAt.Format(layout), revoked)
+		}
+		return a.writeTable([]string{"ID", "NAME", "SCOPES", "SIGNING", "CREATED", "REVOKED"}, rows)
+	case "signing":
+		var id int
+
+		fs := flag.NewFlagSet("apikey signing", flag.ContinueOnError)
+		fs.IntVar(&id, "id", 0, "api key id")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if id <= 0 {
+			return errors.New("id ключа не может быть не указан либо <= 0")
+		}
+
+		secret, err := a.auth.RotateSigningSecret(ctx, id)
+		if err != nil {
+			return err
+		}
+		return a.writeMessage(secret)
+	case "revoke":
+		var id int
+
+		fs := flag.NewFlagSet("apikey revoke", flag.ContinueOnError)
+		fs.IntVar(&id, "id", 0, "api key id")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if id <= 0 {
+			return errors.New("id ключа не может быть не указан либо <= 0")
+		}
+
+		if err := a.auth.RevokeAPIKey(ctx, id); err != nil {
+			return err
+		}
+		return a.writeMessage("OK")
+	default:
+		return fmt.Errorf("неизвестная команда apikey: %s", args[0])
+	}
+}
+
+// limit управляет лимитами пользователей: list, set, delete
+func (a *admin) limit(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("не указана команда limit: list, set либо delete")
+	}
+	if args[0] != "list" && args[0] != "set" && args[0] != "delete" {
+		return fmt.Errorf("неизвестная команда limit: %s", args[0])
+	}
+
+	var limit models.SpendingLimit
+
+	fs := flag.NewFlagSet("limit "+args[0], flag.ContinueOnError)
+	fs.IntVar(&limit.UserID, "user", 0, "user id")
+	if args[0] != "list" {
+		fs.StringVar(&limit.Operation, "operation", "", "operation: topup, transfer or reserve")
+	}
+	if args[0] == "set" {
+		fs.IntVar(&limit.PerOperation, "peroperation", 0, "max amount of a single operation, 0 - unlimited")
+		fs.IntVar(&limit.Daily, "daily", 0, "max amount of operations in the last 24 hours, 0 - unlimited")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if limit.UserID <= 0 {
+		return errors.New("id пользователя не может быть не указан либо <= 0")
+	}
+
+	switch args[0] {
+	case "list":
+		limits, err := a.limits.GetSpendingLimits(ctx, limit.UserID)
+		if err != nil {
+			return err
+		}
+		if a.output == outputJSON {
+			return a.writeJSON(&models.SpendingLimits{Entity: limits})
+		}
+		rows := make([]interface{}, 0, len(limits)*4)
+		for _, l := range limits {
+			source := "default"
+			if l.Override {
+				source = "user"
+			}
+			rows = append(rows, l.Operation, l.PerOperation, l.Daily, source)
+		}
+		return a.writeTable([]string{"OPERATION", "PER OPERATION", "DAILY", "SOURCE"}, rows)
+	case "set":
+		if err := a.limits.SetSpendingLimit(ctx, &limit); err != nil {
+			return err
+		}
+		return a.writeMessage("OK")
+	case "delete":
+		if err := a.limits.DeleteSpendingLimit(ctx, limit.UserID, limit.Operation); err != nil {
+			return err
+		}
+		return a.writeMessage("OK")
+	default:
+		return fmt.Errorf("неизвестная команда limit: %s", args[0])
+	}
+}
+
+// riskReview показывает решения проверки операций и выполняет либо отклоняет операции,
+// ожидающие решения: list, approve, reject
+func (a *admin) riskReview(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("не указана команда risk: list, approve либо reject")
+	}
+
+	switch args[0] {
+	case "list":
+		var filter models.RiskFilter
+
+		fs := flag.NewFlagSet("risk list", flag.ContinueOnError)
+		fs.StringVar(&filter.Decision, "decision", "", "decision: allow, review or deny")
+		fs.StringVar(&filter.Status, "status", "", "review status: pending, approved or rejected")
+		fs.IntVar(&filter.UserID, "user", 0, "user id")
+		fs.IntVar(&filter.Limit, "limit", 0, "max number of decisions, latest first")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		decisions, err := a.risk.GetRiskDecisions(ctx, &filter)
+		if err != nil {
+			return err
+		}
+		if a.output == outputJSON {
+			return a.writeJSON(&models.RiskDecisions{Entity: decisions})
+		}
+		rows := make([]interface{}, 0, len(decisions)*8)
+		for _, d := range decisions {
+			rows = append(rows, d.ID, d.Operation.Type, d.Operation.UserID, d.Operation.Amount, d.Decision, d.Status,
+				d.CreatedAt.Format(layout), strings.Join(d.Reasons, "; "))
+		}
+		return a.writeTable([]string{"ID", "OPERATION", "USER", "AMOUNT", "DECISION", "STATUS", "CREATED", "REASONS"}, rows)
+	case "approve", "reject":
+		var id int
+
+		fs := flag.NewFlagSet("risk "+args[0], flag.ContinueOnError)
+		fs.IntVar(&id, "id", 0, "decision id")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if id <= 0 {
+			return errors.New("id решения не может быть не указан либо <= 0")
+		}
+
+		resolve := a.risk.ApproveRiskReview
+		if args[0] == "reject" {
+			resolve = a.risk.RejectRiskReview
+		}
+		decision, err := resolve(ctx, id, adminResolver)
+		if err != nil {
+			return err
+		}
+		if a.output == outputJSON {
+			return a.writeJSON(decision)
+		}
+		return a.writeMessage("OK")
+	default:
+		return fmt.Errorf("неизвестная команда risk: %s", args[0])
+	}
+}
+
+func (a *admin) writeMessage(message string) error {
+	if a.output == outputJSON {
+		return a.writeJSON(&models.Response{Message: message})
+	}
+	_, err := fmt.Fprintln(a.out, message)
+	return err
+}
+
+func (a *admin) writeJSON(v easyjson.Marshaler) error {
+	if _, err := easyjson.MarshalToWriter(v, a.out); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(a.out)
+	return err
+}
+
+// writeTable выводит значения cells построчно, по len(header) значений в строке
+func (a *admin) writeTable(header []string, cells []interface{}) error {
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+
+	for i, title := range header {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, title)
+	}
+	fmt.Fprintln(w)
+
+	for i, cell := range cells {
+		if i%len(header) > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, cell)
+		if i%len(header) == len(header)-1 {
+			fmt.Fprintln(w)
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"userbalance/internal/models"
+	mock_service "userbalance/internal/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmin_run(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockControl)
+
+	testTable := []struct {
+		name           string
+		output         string
+		command        string
+		args           []string
+		mockBehavior   mockBehavior
+		expectedOutput string
+		wantErr        bool
+	}{
+		{
+			name:    "OK balance table",
+			output:  outputTable,
+			command: "balance",
+			args:    []string{"-user", "1"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().GetBalance(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 100}, nil)
+			},
+			expectedOutput: "USERID  BALANCE\n1       100\n",
+		},
+
+		{
+			name:    "OK balance json",
+			output:  outputJSON,
+			command: "balance",
+			args:    []string{"-user", "1"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().GetBalance(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 100}, nil)
+			},
+			expectedOutput: "{\"userid\":1,\"balance\":100}\n",
+		},
+
+		{
+			name:    "OK topup",
+			output:  outputTable,
+			command: "topup",
+			args:    []string{"-user", "1", "-amount", "100", "-date", "2022-10-01"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().ReplenishmentBalance(gomock.Any(), &models.Replenishment{UserID: 1, Amount: 100, Date: "2022-10-01"}).Return(nil)
+			},
+			expectedOutput: "OK\n",
+		},
+
+		{
+			name:    "OK transfer json",
+			output:  outputJSON,
+			command: "transfer",
+			args:    []string{"-from", "1", "-to", "2", "-amount", "50", "-comment", "возврат долга"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().Transfer(gomock.Any(), &models.Money{FromUserID: 1, ToUserID: 2, Amount: 50,
+					Note: models.Note{Comment: "возврат долга"}}).Return(nil)
+			},
+			expectedOutput: "{\"message\":\"OK\"}\n",
+		},
+
+		{
+			name:    "OK reserve",
+			output:  outputTable,
+			command: "reserve",
+			args:    []string{"-user", "1", "-service", "2", "-order", "3", "-amount", "40"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().Reservation(gomock.Any(), &models.Transaction{UserID: 1, ServiceID: 2, OrderID: 3, Amount: 40}).Return(nil)
+			},
+			expectedOutput: "OK\n",
+		},
+
+		{
+			name:    "OK confirm",
+			output:  outputTable,
+			command: "confirm",
+			args:    []string{"-user", "1", "-service", "2", "-order", "3", "-amount", "40"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().Confirmation(gomock.Any(), &models.Transaction{UserID: 1, ServiceID: 2, OrderID: 3, Amount: 40}).Return(nil)
+			},
+			expectedOutput: "OK\n",
+		},
+
+		{
+			name:    "error cancel",
+			output:  outputTable,
+			command: "cancel",
+			args:    []string{"-user", "1", "-service", "2", "-order", "3", "-amount", "40"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().CancelReservation(gomock.Any(), &models.Transaction{UserID: 1, ServiceID: 2, OrderID: 3, Amount: 40}).Return(
+					errors.New("по указанным критериям не было резерва"))
+			},
+			wantErr: true,
+		},
+
+		{
+			name:    "OK history",
+			output:  outputTable,
+			command: "history",
+			args:    []string{"-user", "1", "-sort", "amount", "-direction", "desc"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().GetHistory(gomock.Any(), &models.RequestHistory{UserID: 1, SortField: "amount", Direction: "desc"}).Return(
+					[]models.History{
+						{Date: time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC), Amount: 100, Description: "Пополнение баланса"},
+						{Date: time.Date(2022, 10, 02, 0, 0, 0, 0, time.UTC), Amount: 50, Description: "Перевод средств пользователю 2",
+							Note: models.Note{Comment: "за обед"}},
+					}, nil)
+			},
+			expectedOutput: "DATE        AMOUNT  DESCRIPTION                     COMMENT\n" +
+				"2022-10-01  100     Пополнение баланса              \n" +
+				"2022-10-02  50      Перевод средств пользователю 2  за обед\n",
+		},
+
+		{
+			name:    "OK history by metadata",
+			output:  outputTable,
+			command: "history",
+			args:    []string{"-user", "1", "-key", "order", "-value", "15"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().GetHistory(gomock.Any(), &models.RequestHistory{
+					UserID: 1, SortField: "date", Direction: "asc", MetadataKey: "order", MetadataValue: "15",
+				}).Return([]models.History{}, nil)
+			},
+			expectedOutput: "DATE  AMOUNT  DESCRIPTION  COMMENT\n",
+		},
+
+		{
+			name:         "error history metadata value without key",
+			output:       outputTable,
+			command:      "history",
+			args:         []string{"-user", "1", "-value", "15"},
+			mockBehavior: func(s *mock_service.MockControl) {},
+			wantErr:      true,
+		},
+
+		{
+			name:    "OK report",
+			output:  outputTable,
+			command: "report",
+			args:    []string{"-year", "2022", "-month", "10"},
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().CreateReport(gomock.Any(), &models.RequestReport{Year: 2022, Month: 10}).Return("localhost:8081/file/1.csv", nil)
+			},
+			expectedOutput: "localhost:8081/file/1.csv\n",
+		},
+
+		{
+			name:         "error validation",
+			output:       outputTable,
+			command:      "transfer",
+			args:         []string{"-from", "1", "-to", "1", "-amount", "50"},
+			mockBehavior: func(s *mock_service.MockControl) {},
+			wantErr:      true,
+		},
+
+		{
+			name:         "error unknown command",
+			output:       outputTable,
+			command:      "withdraw",
+			mockBehavior: func(s *mock_service.MockControl) {},
+			wantErr:      true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			control := mock_service.NewMockControl(c)
+			testCase.mockBehavior(control)
+
+			var out bytes.Buffer
+			a := &admin{control: control, out: &out, output: testCase.output}
+
+			err := a.run(context.Background(), testCase.command, testCase.args)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedOutput, out.String())
+			}
+		})
+	}
+}
+
+func TestAdmin_apikey(t *testing.T) {
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	type mockBehavior func(s *mock_service.MockAuth)
+
+	testTable := []struct {
+		name           string
+		output         string
+		args           []string
+		mockBehavior   mockBehavior
+		expectedOutput string
+		wantErr        bool
+	}{
+		{
+			name:   "OK create",
+			output: outputTable,
+			args:   []string{"create", "-name", "billing", "-scopes", "balance:read,balance:topup"},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().CreateAPIKey(gomock.Any(), &models.APIKey{Name: "billing", Scopes: []string{"balance:read", "balance:topup"}}).Return(
+					&models.IssuedAPIKey{
+						APIKey: models.APIKey{ID: 1, Name: "billing", Scopes: []string{"balance:read", "balance:topup"}, CreatedAt: createdAt},
+						Key:    "ubk_0123",
+					}, nil)
+			},
+			expectedOutput: "ID  NAME     SCOPES                      KEY\n" +
+				"1   billing  balance:read,balance:topup  ubk_0123\n",
+		},
+
+		{
+			name:   "OK create signing",
+			output: outputTable,
+			args:   []string{"create", "-name", "billing", "-scopes", "balance:topup", "-signing"},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().CreateAPIKey(gomock.Any(), &models.APIKey{Name: "billing", Scopes: []string{"balance:topup"}, Signing: true}).Return(
+					&models.IssuedAPIKey{
+						APIKey:        models.APIKey{ID: 1, Name: "billing", Scopes: []string{"balance:topup"}, Signing: true, CreatedAt: createdAt},
+						Key:           "ubk_0123",
+						SigningSecret: "4567",
+					}, nil)
+			},
+			expectedOutput: "ID  NAME     SCOPES         KEY       SIGNING SECRET\n" +
+				"1   billing  balance:topup  ubk_0123  4567\n",
+		},
+
+		{
+			name:   "OK list json",
+			output: outputJSON,
+			args:   []string{"list"},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().GetAPIKeys(gomock.Any()).Return(
+					[]models.APIKey{{ID: 1, Name: "billing", Scopes: []string{"balance:read"}, CreatedAt: createdAt}}, nil)
+			},
+			expectedOutput: "{\"entity\":[{\"id\":1,\"name\":\"billing\",\"scopes\":[\"balance:read\"],\"signing\":false,\"createdat\":\"2022-10-01T00:00:00Z\"}]}\n",
+		},
+
+		{
+			name:   "OK revoke",
+			output: outputTable,
+			args:   []string{"revoke", "-id", "1"},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().RevokeAPIKey(gomock.Any(), 1).Return(nil)
+			},
+			expectedOutput: "OK\n",
+		},
+
+		{
+			name:   "OK signing",
+			output: outputTable,
+			args:   []string{"signing", "-id", "1"},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().RotateSigningSecret(gomock.Any(), 1).Return("4567", nil)
+			},
+			expectedOutput: "4567\n",
+		},
+
+		{
+			name:         "error revoke without id",
+			output:       outputTable,
+			args:         []string{"revoke"},
+			mockBehavior: func(s *mock_service.MockAuth) {},
+			wantErr:      true,
+		},
+
+		{
+			name:         "error unknown command",
+			output:       outputTable,
+			args:         []string{"rotate"},
+			mockBehavior: func(s *mock_service.MockAuth) {},
+			wantErr:      true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuth(c)
+			testCase.mockBehavior(auth)
+
+			var out bytes.Buffer
+			a := &admin{auth: auth, out: &out, output: testCase.output}
+
+			err := a.run(context.Background(), "apikey", testCase.args)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedOutput, out.String())
+			}
+		})
+	}
+}
+
+func TestAdmin_limit(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockLimits)
+
+	testTable := []struct {
+		name           string
+		output         string
+		args           []string
+		mockBehavior   mockBehavior
+		expectedOutput string
+		wantErr        bool
+	}{
+		{
+			name:   "OK list",
+			output: outputTable,
+			args:   []string{"list", "-user", "15"},
+			mockBehavior: func(s *mock_service.MockLimits) {
+				s.EXPECT().GetSpendingLimits(gomock.Any(), 15).Return([]models.SpendingLimit{
+					{UserID: 15, Operation: models.OperationTopup},
+					{UserID: 15, Operation: models.OperationTransfer, PerOperation: 500, Daily: 1000, Override: true},
+				}, nil)
+			},
+			expectedOutput: "OPERATION  PER OPERATION  DAILY  SOURCE\n" +
+				"topup      0              0      default\n" +
+				"transfer   500            1000   user\n",
+		},
+
+		{
+			name:   "OK set",
+			output: outputJSON,
+			args:   []string{"set", "-user", "15", "-operation", "transfer", "-daily", "1000"},
+			mockBehavior: func(s *mock_service.MockLimits) {
+				s.EXPECT().SetSpendingLimit(gomock.Any(), &models.SpendingLimit{UserID: 15, Operation: models.OperationTransfer, Daily: 1000}).Return(nil)
+			},
+			expectedOutput: "{\"message\":\"OK\"}\n",
+		},
+
+		{
+			name:   "OK delete",
+			output: outputTable,
+			args:   []string{"delete", "-user", "15", "-operation", "transfer"},
+			mockBehavior: func(s *mock_service.MockLimits) {
+				s.EXPECT().DeleteSpendingLimit(gomock.Any(), 15, models.OperationTransfer).Return(nil)
+			},
+			expectedOutput: "OK\n",
+		},
+
+		{
+			name:         "error without user",
+			output:       outputTable,
+			args:         []string{"list"},
+			mockBehavior: func(s *mock_service.MockLimits) {},
+			wantErr:      true,
+		},
+
+		{
+			name:         "error unknown command",
+			output:       outputTable,
+			args:         []string{"reset", "-user", "15"},
+			mockBehavior: func(s *mock_service.MockLimits) {},
+			wantErr:      true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			limits := mock_service.NewMockLimits(c)
+			testCase.mockBehavior(limits)
+
+			var out bytes.Buffer
+			a := &admin{limits: limits, out: &out, output: testCase.output}
+
+			err := a.run(context.Background(), "limit", testCase.args)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedOutput, out.String())
+			}
+		})
+	}
+}
+
+func TestAdmin_risk(t *testing.T) {
+	createdAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	type mockBehavior func(s *mock_service.MockRisk)
+
+	testTable := []struct {
+		name           string
+		output         string
+		args           []string
+		mockBehavior   mockBehavior
+		expectedOutput string
+		wantErr        bool
+	}{
+		{
+			name:   "OK list",
+			output: outputTable,
+			args:   []string{"list", "-status", "pending"},
+			mockBehavior: func(s *mock_service.MockRisk) {
+				s.EXPECT().GetRiskDecisions(gomock.Any(), &models.RiskFilter{Status: models.RiskStatusPending}).Return([]models.RiskDecision{{
+					ID:        7,
+					Decision:  models.RiskReview,
+					Reasons:   []string{"large-transfer", "new-account"},
+					Status:    models.RiskStatusPending,
+					Operation: models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100},
+					CreatedAt: createdAt,
+				}}, nil)
+			},
+			expectedOutput: "ID  OPERATION  USER  AMOUNT  DECISION  STATUS   CREATED     REASONS\n" +
+				"7   transfer   1     100     review    pending  2022-10-01  large-transfer; new-account\n",
+		},
+
+		{
+			name:   "OK approve",
+			output: outputTable,
+			args:   []string{"approve", "-id", "7"},
+			mockBehavior: func(s *mock_service.MockRisk) {
+				s.EXPECT().ApproveRiskReview(gomock.Any(), 7, adminResolver).Return(&models.RiskDecision{ID: 7}, nil)
+			},
+			expectedOutput: "OK\n",
+		},
+
+		{
+			name:   "OK reject json",
+			output: outputJSON,
+			args:   []string{"reject", "-id", "7"},
+			mockBehavior: func(s *mock_service.MockRisk) {
+				s.EXPECT().RejectRiskReview(gomock.Any(), 7, adminResolver).Return(&models.RiskDecision{
+					ID:        7,
+					Decision:  models.RiskReview,
+					Reasons:   []string{},
+					Status:    models.RiskStatusRejected,
+					Operation: models.RiskOperation{Type: models.OperationReserve, UserID: 1, ServiceID: 2, OrderID: 3, Amount: 100},
+					CreatedAt: createdAt,
+				}, nil)
+			},
+			expectedOutput: `{"id":7,"decision":"review","reasons":[],"status":"rejected",` +
+				`"operation":{"type":"reserve","userid":1,"serviceid":2,"orderid":3,"amount":100},"createdat":"2022-10-01T12:00:00Z"}` + "\n",
+		},
+
+		{
+			name:         "error without id",
+			output:       outputTable,
+			args:         []string{"approve"},
+			mockBehavior: func(s *mock_service.MockRisk) {},
+			wantErr:      true,
+		},
+
+		{
+			name:         "error unknown command",
+			output:       outputTable,
+			args:         []string{"retry", "-id", "7"},
+			mockBehavior: func(s *mock_service.MockRisk) {},
+			wantErr:      true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			risk := mock_service.NewMockRisk(c)
+			testCase.mockBehavior(risk)
+
+			var out bytes.Buffer
+			a := &admin{risk: risk, out: &out, output: testCase.output}
+
+			err := a.run(context.Background(), "risk", testCase.args)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedOutput, out.String())
+			}
+		})
+	}
+}
@@ -3,16 +3,28 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"flag"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 	c "userbalance/internal/config"
+	"userbalance/internal/fee"
 	"userbalance/internal/handler"
+	"userbalance/internal/logger"
+	"userbalance/internal/metrics"
+	"userbalance/internal/models"
 	"userbalance/internal/repository"
+	"userbalance/internal/risk"
 	"userbalance/internal/service"
+	"userbalance/internal/tlsconfig"
+	"userbalance/internal/tracing"
+
+	"github.com/mailru/easyjson"
 )
 
 // @title UserBalance API
@@ -22,51 +34,195 @@ import (
 // @host localhost:8081
 // @BasePath /
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued by `userbalance admin apikey create`
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT signed with jwtsecret: "Bearer <token>", scopes in the space-separated scope claim
+
+const (
+	defaultConfigPath string = "./configs/config.yaml"
+	layout            string = "2006-01-02"
+)
+
+// configWatchInterval - период проверки изменения файла конфигурации
+const configWatchInterval = 5 * time.Second
+
 func main() {
+	// сообщения библиотек, пишущих через стандартный log, выводятся в том же формате
+	log.SetFlags(0)
+	log.SetOutput(logger.Default().StdLogger(logger.LevelInfo).Writer())
+
 	var services *service.Service
 	var db *sql.DB
 	var err error
 	var conf *c.Config
 	var path string
-	var defaultPath string = "./configs/config.yaml"
 
-	path = defaultPath
+	if len(os.Args) > 1 && (os.Args[1] == "admin" || os.Args[1] == "migrate") {
+		if os.Args[1] == "admin" {
+			err = runAdmin(os.Args[2:])
+		} else {
+			err = runMigrate(os.Args[2:])
+		}
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			fatal("ошибка при выполнении команды", err)
+		}
+		return
+	}
 
-	flag.StringVar(&path, "config", "./configs/config.yaml", "example -config ./configs/config.yaml")
+	flag.StringVar(&path, "config", defaultConfigPath, "example -config ./configs/config.yaml")
 	migrationup := flag.Bool("migrationup", false, "use migrationup to perform migrationup")
 	migrationdown := flag.Bool("migrationdown", false, "use migrationdown to perform migrationdown")
+	rebuildsnapshots := flag.Bool("rebuildsnapshots", false, "use rebuildsnapshots to recreate balance snapshots from the ledger")
+	reconcile := flag.Bool("reconcile", false, "use reconcile to print balance discrepancies and exit")
+	auditverify := flag.Bool("auditverify", false, "use auditverify to verify the hash chains of the logs and exit")
+	auditcheckpoint := flag.Bool("auditcheckpoint", false, "use auditcheckpoint to save a signed checkpoint of the logs and exit")
+	format := flag.String("format", models.ReconciliationFormatCSV, "reconciliation output format: csv or json")
+	overrides := c.BindFlags(flag.CommandLine)
 
 	flag.Parse()
 
-	conf, err = c.GetConfig(path)
+	if conf, path, err = loadConfig(path, overrides); err != nil {
+		logger.Error("ошибка при чтении конфигурации", "error", err)
+		return
+	}
+	setLogLevel(conf)
+
+	if err = repository.Migration(conf, *migrationup, *migrationdown); err != nil {
+		logger.Error("ошибка при выполнении миграций", "error", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), conf)
 	if err != nil {
-		log.Printf("%s, use default config '%s'", err, defaultPath)
-		conf, err = c.GetConfig(defaultPath)
+		logger.Error("ошибка при настройке трассировки", "error", err)
+		return
+	}
+	defer shutdownTracing(context.Background())
+
+	if db, err = repository.Connect(conf); err != nil {
+		logger.Error("ошибка при подключении к БД", "error", err)
+		return
+	}
+
+	store := c.NewStore(conf, path, overrides)
+	store.OnReload(setLogLevel)
+	repos := repository.NewRepository(db, conf)
+
+	metrics.RegisterDB(db, conf.DBname)
+	metrics.RegisterTotals(repos.Control, time.Duration(conf.DBTimeout)*time.Second)
+
+	// правила проверки операций и комиссий перечитываются вместе с конфигурацией
+	var checker service.RiskChecker
+	if conf.RiskRules != "" {
+		engine, err := risk.NewEngine(conf.RiskRules)
 		if err != nil {
-			log.Println(err)
+			logger.Error("ошибка при чтении правил проверки операций", "error", err)
 			return
 		}
+		reloadRules(store, "правил проверки операций", engine.Reload)
+		checker = engine
 	}
 
-	if err = repository.Migration(conf, *migrationup, *migrationdown); err != nil {
-		log.Println(err)
+	var fees service.FeeCalculator
+	if conf.FeeRules != "" {
+		engine, err := fee.NewEngine(conf.FeeRules)
+		if err != nil {
+			logger.Error("ошибка при чтении правил комиссий", "error", err)
+			return
+		}
+		reloadRules(store, "правил комиссий", engine.Reload)
+		fees = engine
 	}
 
-	if db, err = repository.Connect(conf); err != nil {
-		log.Println(err)
+	services = service.NewService(repos, store, checker, fees)
+	handlers := handler.NewHandler(services)
+
+	if *rebuildsnapshots {
+		if err = services.RebuildSnapshots(context.Background()); err != nil {
+			logger.Error("ошибка при пересоздании снимков остатков", "error", err)
+		}
+	}
+
+	if *reconcile {
+		mismatches, err := printReconciliation(services, *format)
+		db.Close()
+		if err != nil {
+			fatal("ошибка при сверке остатков", err)
+		}
+		if mismatches > 0 {
+			os.Exit(1)
+		}
 		return
 	}
 
-	repos := repository.NewRepository(db)
-	services = service.NewService(repos, conf, db)
-	handlers := handler.NewHandler(services)
+	if *auditverify || *auditcheckpoint {
+		valid, err := runAudit(services, *auditcheckpoint)
+		db.Close()
+		if err != nil {
+			fatal("ошибка при проверке истории", err)
+		}
+		if !valid {
+			os.Exit(1)
+		}
+		return
+	}
+
+	workers, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	// фоновые процессы учитываются проверкой готовности /readyz
+	services.Go("snapshot", func() { services.Snapshot.Run(workers) })
+	if conf.ReconcileInterval > 0 {
+		services.Go("reconciliation", func() {
+			services.Reconciliation.Run(workers, time.Duration(conf.ReconcileInterval)*time.Minute)
+		})
+	}
+	if conf.AuditCheckpointInterval > 0 {
+		services.Go("audit", func() {
+			services.Audit.Run(workers, time.Duration(conf.AuditCheckpointInterval)*time.Minute)
+		})
+	}
+	if conf.SchedulerInterval > 0 {
+		services.Go("scheduler", func() {
+			services.Scheduler.Run(workers, time.Duration(conf.SchedulerInterval)*time.Second)
+		})
+	}
+	if conf.PendingExpireInterval > 0 {
+		services.Go("pendingtransfers", func() {
+			services.PendingTransfers.Run(workers, time.Duration(conf.PendingExpireInterval)*time.Second)
+		})
+	}
+
+	// конфигурация перечитывается по SIGHUP и при изменении файла
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go store.Watch(workers, configWatchInterval, reload)
 
 	server := new(Server)
-	server.conf = conf
+	server.conf = store
+
+	// сертификаты перечитываются вместе с конфигурацией и при изменении их файлов
+	if conf.TLSCertFile != "" {
+		certs, err := tlsconfig.NewReloader(conf.TLSCertFile, conf.TLSKeyFile, conf.TLSClientCAFile, conf.TLSClientAuth)
+		if err != nil {
+			fatal("ошибка при настройке TLS", err)
+		}
+		store.OnReload(func(*c.Config) {
+			if err := certs.Reload(); err != nil {
+				logger.Error("ошибка при перечитывании сертификатов", "error", err)
+			}
+		})
+		go certs.Watch(workers, configWatchInterval)
+		server.tls = certs.TLSConfig()
+	}
 
 	go func() {
 		if err := server.Run(conf.Port, handlers.Init()); err != nil {
-			log.Fatalf("ошибка при запуске http сервера: %s", err.Error())
+			fatal("ошибка при запуске http сервера", err)
 		}
 	}()
 
@@ -74,16 +230,120 @@ func main() {
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 	<-quit
 
-	log.Println("сервер останавливается")
+	// readyz начинает отвечать 503 до выключения http сервера, чтобы балансировщик успел
+	// исключить сервер и не направлял ему новые запросы. Повторный сигнал прерывает ожидание
+	services.Drain()
+	drain := time.Duration(store.Get().DrainPeriod) * time.Second
+	logger.Info("сервер останавливается", "drain_period", drain.String())
+	select {
+	case <-time.After(drain):
+	case <-quit:
+	}
+	stopWorkers()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(conf.ContexTimeout)*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(store.Get().ContexTimeout)*time.Second)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("произошла ошибка при выключении сервера: %s", err.Error())
+		fatal("произошла ошибка при выключении сервера", err)
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("ошибка при отправке трасс", "error", err)
 	}
 
 	if err := db.Close(); err != nil {
-		log.Fatalf("произошла ошибка при закрытии соединения с БД: %s", err.Error())
+		fatal("произошла ошибка при закрытии соединения с БД", err)
+	}
+
+}
+
+// setLogLevel применяет уровень логирования из конфигурации, в том числе перечитанной
+func setLogLevel(conf *c.Config) {
+	level, err := logger.ParseLevel(conf.LogLevel)
+	if err != nil {
+		logger.Warn("уровень логирования не изменен", "error", err)
+		return
+	}
+	logger.SetLevel(level)
+}
+
+// fatal пишет ошибку в лог и завершает процесс
+func fatal(msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// reloadRules перечитывает файл правил reload при каждом перечитывании конфигурации.
+// what - родительный падеж названия правил для сообщения об ошибке
+func reloadRules(store *c.Store, what string, reload func() error) {
+	store.OnReload(func(*c.Config) {
+		if err := reload(); err != nil {
+			logger.Error("ошибка при перечитывании "+what, "error", err)
+		}
+	})
+}
+
+// loadConfig читает конфигурацию по указанному пути, если файла нет - по пути по умолчанию,
+// а если нет и его - собирает ее из значений по умолчанию, переменных окружения и флагов.
+// Возвращает также путь до прочитанного файла
+func loadConfig(path string, flags *c.Flags) (*c.Config, string, error) {
+	conf, err := c.Load(path, flags)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return conf, path, err
+	}
+
+	if path != defaultConfigPath {
+		logger.Warn("файл конфигурации не найден, используется файл по умолчанию", "error", err, "path", defaultConfigPath)
+		if conf, err = c.Load(defaultConfigPath, flags); !errors.Is(err, fs.ErrNotExist) {
+			return conf, defaultConfigPath, err
+		}
+	}
+
+	logger.Warn("файл конфигурации не найден, используются значения по умолчанию и переменные окружения", "error", err)
+	conf, err = c.Load("", flags)
+	return conf, "", err
+}
+
+// printReconciliation выводит расхождения остатков в stdout и возвращает их количество
+func printReconciliation(services *service.Service, format string) (int, error) {
+	report, err := services.Reconcile(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	switch format {
+	case models.ReconciliationFormatJSON:
+		_, err = easyjson.MarshalToWriter(report, os.Stdout)
+	case models.ReconciliationFormatCSV:
+		err = service.WriteReconciliationCSV(os.Stdout, report)
+	default:
+		err = fmt.Errorf("неизвестный формат вывода: %s", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return report.Mismatches, nil
+}
+
+// runAudit сохраняет контрольную точку либо проверяет цепочки logs и выводит отчет в stdout
+func runAudit(services *service.Service, checkpoint bool) (bool, error) {
+	if checkpoint {
+		saved, err := services.Checkpoint(context.Background())
+		if err != nil {
+			return false, err
+		}
+		logger.Info("контрольная точка сохранена", "chains", len(saved.Heads))
+		return true, nil
+	}
+
+	report, err := services.Verify(context.Background())
+	if err != nil {
+		return false, err
+	}
+	if _, err = easyjson.MarshalToWriter(report, os.Stdout); err != nil {
+		return false, err
 	}
 
+	return report.Valid, nil
 }
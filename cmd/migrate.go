@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	c "userbalance/internal/config"
+	"userbalance/internal/repository"
+)
+
+const migrateUsage string = `использование: userbalance migrate [-config путь] [-<ключ конфигурации> значение] <команда>
+
+команды:
+  up [n]     применить n следующих миграций, без n - все
+  down [n]   откатить n последних миграций, без n - все
+  goto V     применить либо откатить миграции до версии V
+  version    текущая версия БД
+  force V    установить версию V без выполнения миграций и снять признак dirty
+  status     список миграций с отметкой о применении
+`
+
+// migrator - операции repository.Migrator, используемые подкомандой migrate
+type migrator interface {
+	Up(n int) error
+	Down(n int) error
+	Goto(version uint) error
+	Force(version int) error
+	Version() (uint, bool, error)
+	Status() ([]repository.MigrationStatus, error)
+}
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	path := fs.String("config", defaultConfigPath, "example -config ./configs/config.yaml")
+	overrides := c.BindFlags(fs)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), migrateUsage) }
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("не указана команда")
+	}
+
+	conf, _, err := loadConfig(*path, overrides)
+	if err != nil {
+		return err
+	}
+
+	m, err := repository.NewMigrator(conf)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return migrateCommand(m, os.Stdout, fs.Arg(0), fs.Args()[1:])
+}
+
+func migrateCommand(m migrator, out io.Writer, command string, args []string) error {
+	var err error
+
+	switch command {
+	case "up", "down":
+		n := 0
+		if len(args) > 0 {
+			if n, err = strconv.Atoi(args[0]); err != nil || n <= 0 {
+				return fmt.Errorf("количество миграций должно быть больше 0: %s", args[0])
+			}
+		}
+		if command == "up" {
+			err = m.Up(n)
+		} else {
+			err = m.Down(n)
+		}
+	case "goto":
+		var version uint64
+		if len(args) == 0 {
+			return errors.New("не указана версия")
+		}
+		if version, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+			return fmt.Errorf("неверно указана версия: %s", args[0])
+		}
+		err = m.Goto(uint(version))
+	case "force":
+		var version int
+		if len(args) == 0 {
+			return errors.New("не указана версия")
+		}
+		if version, err = strconv.Atoi(args[0]); err != nil {
+			return fmt.Errorf("неверно указана версия: %s", args[0])
+		}
+		err = m.Force(version)
+	case "version", "status":
+	default:
+		return fmt.Errorf("неизвестная команда: %s", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	if command == "status" {
+		return printMigrationStatus(m, out)
+	}
+	return printMigrationVersion(m, out)
+}
+
+func printMigrationVersion(m migrator, out io.Writer) error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		_, err = fmt.Fprintf(out, "версия: %d (dirty)\n", version)
+	} else {
+		_, err = fmt.Fprintf(out, "версия: %d\n", version)
+	}
+	return err
+}
+
+func printMigrationStatus(m migrator, out io.Writer) error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE")
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case status.Dirty:
+			state = "dirty"
+		case status.Applied:
+			state = "applied"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, state)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"userbalance/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMigrator запоминает вызванные операции и хранит текущую версию
+type fakeMigrator struct {
+	calls   []string
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (f *fakeMigrator) Up(n int) error {
+	f.calls = append(f.calls, "up")
+	f.version += uint(n)
+	return f.err
+}
+
+func (f *fakeMigrator) Down(n int) error {
+	f.calls = append(f.calls, "down")
+	f.version -= uint(n)
+	return f.err
+}
+
+func (f *fakeMigrator) Goto(version uint) error {
+	f.calls = append(f.calls, "goto")
+	f.version = version
+	return f.err
+}
+
+func (f *fakeMigrator) Force(version int) error {
+	f.calls = append(f.calls, "force")
+	f.version = uint(version)
+	f.dirty = false
+	return f.err
+}
+
+func (f *fakeMigrator) Version() (uint, bool, error) {
+	return f.version, f.dirty, nil
+}
+
+func (f *fakeMigrator) Status() ([]repository.MigrationStatus, error) {
+	return []repository.MigrationStatus{
+		{Version: 1, Name: "create_id_sequence", Applied: true},
+		{Version: 2, Name: "create_users_table", Applied: true, Dirty: f.dirty},
+		{Version: 3, Name: "create_services_table"},
+	}, nil
+}
+
+func TestMigrateCommand(t *testing.T) {
+	testTable := []struct {
+		name           string
+		migrator       *fakeMigrator
+		command        string
+		args           []string
+		expectedCalls  []string
+		expectedOutput string
+		wantErr        bool
+	}{
+		{
+			name:           "OK up n",
+			migrator:       &fakeMigrator{version: 3},
+			command:        "up",
+			args:           []string{"2"},
+			expectedCalls:  []string{"up"},
+			expectedOutput: "версия: 5\n",
+		},
+
+		{
+			name:           "OK down n",
+			migrator:       &fakeMigrator{version: 3},
+			command:        "down",
+			args:           []string{"1"},
+			expectedCalls:  []string{"down"},
+			expectedOutput: "версия: 2\n",
+		},
+
+		{
+			name:           "OK goto",
+			migrator:       &fakeMigrator{version: 3},
+			command:        "goto",
+			args:           []string{"7"},
+			expectedCalls:  []string{"goto"},
+			expectedOutput: "версия: 7\n",
+		},
+
+		{
+			name:           "OK force",
+			migrator:       &fakeMigrator{version: 9, dirty: true},
+			command:        "force",
+			args:           []string{"8"},
+			expectedCalls:  []string{"force"},
+			expectedOutput: "версия: 8\n",
+		},
+
+		{
+			name:           "OK version dirty",
+			migrator:       &fakeMigrator{version: 9, dirty: true},
+			command:        "version",
+			expectedOutput: "версия: 9 (dirty)\n",
+		},
+
+		{
+			name:     "OK status",
+			migrator: &fakeMigrator{version: 2, dirty: true},
+			command:  "status",
+			expectedOutput: "VERSION  NAME                   STATE\n" +
+				"1        create_id_sequence     applied\n" +
+				"2        create_users_table     dirty\n" +
+				"3        create_services_table  pending\n",
+		},
+
+		{
+			name:     "error wrong n",
+			migrator: &fakeMigrator{},
+			command:  "up",
+			args:     []string{"-1"},
+			wantErr:  true,
+		},
+
+		{
+			name:     "error goto without version",
+			migrator: &fakeMigrator{},
+			command:  "goto",
+			wantErr:  true,
+		},
+
+		{
+			name:          "error migration",
+			migrator:      &fakeMigrator{err: errors.New("Dirty database version 9. Fix and force version.")},
+			command:       "up",
+			expectedCalls: []string{"up"},
+			wantErr:       true,
+		},
+
+		{
+			name:     "error unknown command",
+			migrator: &fakeMigrator{},
+			command:  "drop",
+			wantErr:  true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := migrateCommand(testCase.migrator, &out, testCase.command, testCase.args)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expectedOutput, out.String())
+			}
+			assert.Equal(t, testCase.expectedCalls, testCase.migrator.calls)
+		})
+	}
+}
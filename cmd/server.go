@@ -2,26 +2,46 @@ package main
 
 import (
 	"context"
-	"log"
+	"crypto/tls"
+	"net"
 	"net/http"
 	"time"
 	"userbalance/internal/config"
+	"userbalance/internal/logger"
 )
 
 type Server struct {
 	httpServer *http.Server
-	conf       *config.Config
+	conf       config.Source
+	// tls задан, если сервер принимает HTTPS (ключи tlscertfile и tlskeyfile)
+	tls *tls.Config
 }
 
 func (s *Server) Run(port string, handler http.Handler) error {
+	conf := s.conf.Get()
+
+	// ReadTimeout и WriteTimeout http.Server нельзя менять у работающего сервера, поэтому
+	// они применяются к соединению в setDeadlines при начале каждого запроса и учитывают
+	// перечитанную конфигурацию. Заголовки и простой соединения ограничены значением при запуске.
+	// Это верно только для HTTP/1.1, поэтому HTTP/2 отключен пустым TLSNextProto
 	s.httpServer = &http.Server{
-		Addr:         port,
-		Handler:      handler,
-		ReadTimeout:  time.Duration(s.conf.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(s.conf.WriteTimeout) * time.Second,
+		Addr:              port,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Duration(conf.ReadTimeout) * time.Second,
+		IdleTimeout:       time.Duration(conf.ReadTimeout) * time.Second,
+		ConnState:         s.setDeadlines,
+		ErrorLog:          logger.Default().StdLogger(logger.LevelError),
+		TLSConfig:         s.tls,
+		TLSNextProto:      map[string]func(*http.Server, *tls.Conn, http.Handler){},
+	}
+
+	if s.tls != nil {
+		logger.Info("сервер запущен", "port", port, "tls", true)
+		// сертификат передается через TLSConfig и может быть перечитан без перезапуска
+		return s.httpServer.ListenAndServeTLS("", "")
 	}
 
-	log.Println("сервер запущен")
+	logger.Info("сервер запущен", "port", port)
 
 	return s.httpServer.ListenAndServe()
 }
@@ -29,3 +49,20 @@ func (s *Server) Run(port string, handler http.Handler) error {
 func (s *Server) Shutdown(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
+
+// setDeadlines ограничивает время чтения тела запроса и записи ответа действующими таймаутами
+func (s *Server) setDeadlines(c net.Conn, state http.ConnState) {
+	if state != http.StateActive {
+		return
+	}
+
+	conf := s.conf.Get()
+	now := time.Now()
+
+	if conf.ReadTimeout > 0 {
+		c.SetReadDeadline(now.Add(time.Duration(conf.ReadTimeout) * time.Second))
+	}
+	if conf.WriteTimeout > 0 {
+		c.SetWriteDeadline(now.Add(time.Duration(conf.WriteTimeout) * time.Second))
+	}
+}
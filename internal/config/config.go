@@ -1,48 +1,373 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
 	"io"
-	"log"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 
+	validation "github.com/go-ozzo/ozzo-validation"
 	"gopkg.in/yaml.v3"
 )
 
+// EnvPrefix - префикс переменных окружения: значение ключа dbhost задается переменной USERBALANCE_DBHOST
+const EnvPrefix string = "USERBALANCE_"
+
+// Config - настройки сервиса. Ключи с тегом immutable:"true" при перезагрузке конфигурации не меняются
 type Config struct {
-	Host           string `yaml:"host"`
-	Port           string `yaml:"port"`
-	DBHost         string `yaml:"dbhost"`
-	DBPort         int    `yaml:"dbport"`
-	User           string `yaml:"user"`
-	Password       string `yaml:"password"`
-	DBname         string `yaml:"dbname"`
-	ConnectionType string `yaml:"connectiontype"`
-	ContexTimeout  int    `yaml:"contextimeout"`
-	MigrationPath  string `yaml:"migrationpath"`
-	ReadTimeout    int    `yaml:"readtimeout"`
-	WriteTimeout   int    `yaml:"writetimeout"`
+	Host                    string  `yaml:"host"`
+	Port                    string  `yaml:"port" immutable:"true"`
+	DBHost                  string  `yaml:"dbhost" immutable:"true"`
+	DBPort                  int     `yaml:"dbport" immutable:"true"`
+	User                    string  `yaml:"user" immutable:"true"`
+	Password                string  `yaml:"password" immutable:"true"`
+	PasswordFile            string  `yaml:"password_file" immutable:"true"`
+	DBname                  string  `yaml:"dbname" immutable:"true"`
+	ConnectionType          string  `yaml:"connectiontype" immutable:"true"`
+	DBSSLMode               string  `yaml:"dbsslmode" immutable:"true"`
+	ContexTimeout           int     `yaml:"contextimeout"`
+	DBTimeout               int     `yaml:"dbtimeout"`
+	BatchTimeout            int     `yaml:"batchtimeout"`
+	ReadTimeout             int     `yaml:"readtimeout"`
+	WriteTimeout            int     `yaml:"writetimeout"`
+	DrainPeriod             int     `yaml:"drainperiod"`
+	TxMaxAttempts           int     `yaml:"txmaxattempts" immutable:"true"`
+	TxRetryBackoff          int     `yaml:"txretrybackoff" immutable:"true"`
+	ReconcileInterval       int     `yaml:"reconcileinterval" immutable:"true"`
+	AuditKey                string  `yaml:"auditkey" immutable:"true"`
+	AuditKeyFile            string  `yaml:"auditkey_file" immutable:"true"`
+	AuditCheckpointFile     string  `yaml:"auditcheckpointfile" immutable:"true"`
+	AuditCheckpointInterval int     `yaml:"auditcheckpointinterval" immutable:"true"`
+	LogLevel                string  `yaml:"loglevel"`
+	TracingEndpoint         string  `yaml:"tracingendpoint" immutable:"true"`
+	TracingInsecure         bool    `yaml:"tracinginsecure" immutable:"true"`
+	TracingSampleRatio      float64 `yaml:"tracingsampleratio" immutable:"true"`
+	AuthRequired            bool    `yaml:"authrequired" immutable:"true"`
+	JWTSecret               string  `yaml:"jwtsecret"`
+	JWTSecretFile           string  `yaml:"jwtsecret_file"`
+	JWTIssuer               string  `yaml:"jwtissuer"`
+	JWTAudience             string  `yaml:"jwtaudience"`
+	RateLimitClientRate     float64 `yaml:"ratelimitclientrate"`
+	RateLimitClientBurst    int     `yaml:"ratelimitclientburst"`
+	RateLimitUserRate       float64 `yaml:"ratelimituserrate"`
+	RateLimitUserBurst      int     `yaml:"ratelimituserburst"`
+	SignedRoutes            string  `yaml:"signedroutes"`
+	SignatureSkew           int     `yaml:"signatureskew"`
+	MaxRequestBody          int     `yaml:"maxrequestbody"`
+	LimitTopupOperation     int     `yaml:"limittopupoperation"`
+	LimitTopupDaily         int     `yaml:"limittopupdaily"`
+	LimitTransferOperation  int     `yaml:"limittransferoperation"`
+	LimitTransferDaily      int     `yaml:"limittransferdaily"`
+	LimitReserveOperation   int     `yaml:"limitreserveoperation"`
+	LimitReserveDaily       int     `yaml:"limitreservedaily"`
+	TLSCertFile             string  `yaml:"tlscertfile" immutable:"true"`
+	TLSKeyFile              string  `yaml:"tlskeyfile" immutable:"true"`
+	TLSClientCAFile         string  `yaml:"tlsclientcafile" immutable:"true"`
+	TLSClientAuth           string  `yaml:"tlsclientauth" immutable:"true"`
+	RiskRules               string  `yaml:"riskrules" immutable:"true"`
+	RiskReserveMin          int     `yaml:"riskreservemin"`
+	SchedulerInterval       int     `yaml:"schedulerinterval" immutable:"true"`
+	ScheduleRetries         int     `yaml:"scheduleretries"`
+	ScheduleRetryDelay      int     `yaml:"scheduleretrydelay"`
+	PendingTransferTTL      int     `yaml:"pendingtransferttl"`
+	PendingExpireInterval   int     `yaml:"pendingexpireinterval" immutable:"true"`
+	FeeRules                string  `yaml:"feerules" immutable:"true"`
+	FeeUserID               int     `yaml:"feeuserid" immutable:"true"`
+}
+
+// Default возвращает значения, используемые для ключей, не указанных ни в одном источнике
+func Default() *Config {
+	return &Config{
+		Host:                  "localhost",
+		Port:                  ":8081",
+		DBHost:                "localhost",
+		DBPort:                5432,
+		User:                  "postgres",
+		DBname:                "postgres",
+		ConnectionType:        "postgres",
+		DBSSLMode:             "disable",
+		ContexTimeout:         5,
+		DBTimeout:             5,
+		BatchTimeout:          8,
+		ReadTimeout:           10,
+		WriteTimeout:          10,
+		DrainPeriod:           5,
+		TxMaxAttempts:         3,
+		TxRetryBackoff:        20,
+		LogLevel:              "info",
+		TracingSampleRatio:    1,
+		AuthRequired:          true,
+		SignatureSkew:         300,
+		MaxRequestBody:        1 << 20,
+		TLSClientAuth:         "required",
+		SchedulerInterval:     30,
+		ScheduleRetries:       3,
+		ScheduleRetryDelay:    60,
+		PendingTransferTTL:    4320,
+		PendingExpireInterval: 60,
+	}
 }
 
+// Flags - флаги командной строки с именами ключей конфигурации, например -dbhost
+type Flags struct {
+	fs     *flag.FlagSet
+	values map[string]*string
+}
+
+// BindFlags регистрирует в fs флаг для каждого ключа конфигурации
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs, values: make(map[string]*string)}
+	for _, key := range keys() {
+		f.values[key] = fs.String(key, "", fmt.Sprintf("overrides %s from the config file and %s%s", key, EnvPrefix, strings.ToUpper(key)))
+	}
+	return f
+}
+
+// GetConfig читает конфигурацию из файла с учетом значений по умолчанию и переменных окружения
 func GetConfig(path string) (*Config, error) {
+	return Load(path, nil)
+}
 
-	conf := &Config{}
-	in, err := os.Open(path)
+// Load собирает конфигурацию, каждый следующий источник переопределяет предыдущий:
+// значения по умолчанию, файл path, переменные окружения USERBALANCE_*, флаги командной строки.
+// Секреты, заданные ключами *_file, читаются из указанных файлов
+func Load(path string, flags *Flags) (*Config, error) {
+	conf := Default()
+
+	if path != "" {
+		if err := conf.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range keys() {
+		name := EnvPrefix + strings.ToUpper(key)
+		if value, ok := os.LookupEnv(name); ok {
+			if err := conf.set(key, value); err != nil {
+				return nil, fmt.Errorf("переменная окружения %s: %w", name, err)
+			}
+		}
+	}
+
+	if flags != nil {
+		var err error
+		flags.fs.Visit(func(f *flag.Flag) {
+			if value, ok := flags.values[f.Name]; ok && err == nil {
+				if err = conf.set(f.Name, *value); err != nil {
+					err = fmt.Errorf("флаг -%s: %w", f.Name, err)
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := conf.readSecrets(); err != nil {
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("неверная конфигурация: %w", err)
+	}
+
+	return conf, nil
+}
+
+func (c *Config) Validate() error {
+	return validation.ValidateStruct(c,
+		validation.Field(&c.Port,
+			validation.Required.Error("порт не может быть не указан")),
+		validation.Field(&c.DBHost,
+			validation.Required.Error("адрес БД не может быть не указан")),
+		validation.Field(&c.DBPort,
+			validation.Required.Error("порт БД не может быть не указан"),
+			validation.Min(1).Error("порт БД должен быть от 1 до 65535"),
+			validation.Max(65535).Error("порт БД должен быть от 1 до 65535")),
+		validation.Field(&c.ConnectionType,
+			validation.In("postgres", "mysql").Error("тип БД должен быть postgres либо mysql")),
+		validation.Field(&c.DBSSLMode,
+			validation.In("disable", "allow", "prefer", "require", "verify-ca", "verify-full").
+				Error("режим SSL БД должен быть disable, allow, prefer, require, verify-ca либо verify-full")),
+		validation.Field(&c.ContexTimeout, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.DBTimeout, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.BatchTimeout,
+			validation.Min(0).Error("значение не может быть < 0"),
+			validation.By(lessThanWriteTimeout(c.WriteTimeout))),
+		validation.Field(&c.ReadTimeout, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.WriteTimeout, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.DrainPeriod, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.TxMaxAttempts, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.TxRetryBackoff, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.ReconcileInterval, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.AuditCheckpointInterval, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.LogLevel,
+			validation.In("debug", "info", "warn", "error").Error("уровень логирования должен быть debug, info, warn либо error")),
+		validation.Field(&c.TracingSampleRatio,
+			validation.Min(0.0).Error("доля трасс должна быть от 0 до 1"),
+			validation.Max(1.0).Error("доля трасс должна быть от 0 до 1")),
+		validation.Field(&c.RateLimitClientRate, validation.Min(0.0).Error("значение не может быть < 0")),
+		validation.Field(&c.RateLimitClientBurst, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.RateLimitUserRate, validation.Min(0.0).Error("значение не может быть < 0")),
+		validation.Field(&c.RateLimitUserBurst, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.SignatureSkew,
+			validation.Required.Error("допустимое расхождение часов должно быть > 0"),
+			validation.Min(1).Error("допустимое расхождение часов должно быть > 0")),
+		validation.Field(&c.MaxRequestBody,
+			validation.Required.Error("размер тела запроса должен быть > 0"),
+			validation.Min(1).Error("размер тела запроса должен быть > 0")),
+		validation.Field(&c.LimitTopupOperation, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.LimitTopupDaily, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.LimitTransferOperation, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.LimitTransferDaily, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.LimitReserveOperation, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.LimitReserveDaily, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.TLSCertFile,
+			validation.By(requiredWith(c.TLSKeyFile != "", "сертификат сервера не может быть не указан при указанном ключе"))),
+		validation.Field(&c.TLSKeyFile,
+			validation.By(requiredWith(c.TLSCertFile != "", "ключ сервера не может быть не указан при указанном сертификате"))),
+		validation.Field(&c.TLSClientCAFile,
+			validation.By(emptyWithout(c.TLSCertFile != "", "проверка сертификатов клиентов требует сертификата сервера"))),
+		validation.Field(&c.TLSClientAuth,
+			validation.In("optional", "required").Error("режим проверки сертификатов клиентов должен быть optional либо required")),
+		validation.Field(&c.RiskReserveMin, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.SchedulerInterval, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.ScheduleRetries, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.ScheduleRetryDelay,
+			validation.Required.Error("задержка повторной попытки должна быть > 0"),
+			validation.Min(1).Error("задержка повторной попытки должна быть > 0")),
+		validation.Field(&c.PendingTransferTTL,
+			validation.Required.Error("срок подтверждения перевода должен быть > 0"),
+			validation.Min(1).Error("срок подтверждения перевода должен быть > 0")),
+		validation.Field(&c.PendingExpireInterval, validation.Min(0).Error("значение не может быть < 0")),
+		validation.Field(&c.FeeUserID,
+			validation.Min(0).Error("значение не может быть < 0"),
+			validation.By(requiredWith(c.FeeRules != "", "счет комиссий не может быть не указан при указанных правилах комиссий"))))
+}
+
+// lessThanWriteTimeout требует, чтобы таймаут пакета истекал раньше таймаута записи ответа,
+// который отсчитывается от начала запроса: иначе ответ на долгий пакет не будет отправлен
+func lessThanWriteTimeout(writeTimeout int) validation.RuleFunc {
+	return func(value interface{}) error {
+		if timeout := value.(int); writeTimeout > 0 && (timeout == 0 || timeout >= writeTimeout) {
+			return errors.New("таймаут пакета должен быть меньше таймаута записи ответа writetimeout")
+		}
+		return nil
+	}
+}
+
+// requiredWith требует значения ключа, если задан связанный с ним ключ
+func requiredWith(related bool, msg string) validation.RuleFunc {
+	return func(value interface{}) error {
+		if related && validation.IsEmpty(value) {
+			return errors.New(msg)
+		}
+		return nil
+	}
+}
+
+// emptyWithout запрещает значение ключа, если не задан ключ, без которого оно не действует
+func emptyWithout(related bool, msg string) validation.RuleFunc {
+	return func(value interface{}) error {
+		if !related && value.(string) != "" {
+			return errors.New(msg)
+		}
+		return nil
+	}
+}
+
+// readFile разбирает YAML-файл, неизвестные ключи считаются ошибкой
+func (c *Config) readFile(path string) error {
+	in, err := os.ReadFile(path)
 	if err != nil {
-		return conf, err
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(in))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("ошибка в файле конфигурации %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) readSecrets() error {
+	secrets := []struct {
+		file  string
+		value *string
+	}{
+		{c.PasswordFile, &c.Password},
+		{c.AuditKeyFile, &c.AuditKey},
+		{c.JWTSecretFile, &c.JWTSecret},
+	}
+
+	for _, secret := range secrets {
+		if secret.file == "" {
+			continue
+		}
+		value, err := os.ReadFile(secret.file)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения секрета: %w", err)
+		}
+		*secret.value = strings.TrimRight(string(value), "\r\n")
 	}
-	defer func() {
-		if err := in.Close(); err != nil {
-			log.Println(err)
+
+	return nil
+}
+
+// set присваивает значение ключу конфигурации по его имени в YAML
+func (c *Config) set(key string, value string) error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("yaml") != key {
+			continue
 		}
-	}()
 
-	buf := make([]byte, 1024)
-	n, err := in.Read(buf)
-	if err != nil && err != io.EOF {
-		log.Println(err)
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("значение %q должно быть целым числом", value)
+			}
+			field.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("значение %q должно быть true либо false", value)
+			}
+			field.SetBool(b)
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("значение %q должно быть числом", value)
+			}
+			field.SetFloat(f)
+		default:
+			return fmt.Errorf("неподдерживаемый тип ключа %s", key)
+		}
+		return nil
 	}
 
-	err = yaml.Unmarshal(buf[:n], conf)
+	return fmt.Errorf("неизвестный ключ %s", key)
+}
 
-	return conf, err
+// keys возвращает имена всех ключей конфигурации
+func keys() []string {
+	t := reflect.TypeOf(Config{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, t.Field(i).Tag.Get("yaml"))
+	}
+	return keys
 }
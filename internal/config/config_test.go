@@ -0,0 +1,166 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	testTable := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		args    []string
+		check   func(t *testing.T, conf *Config)
+		wantErr string
+	}{
+		{
+			name: "OK defaults",
+			check: func(t *testing.T, conf *Config) {
+				assert.Equal(t, Default(), conf)
+			},
+		},
+
+		{
+			name: "OK file over defaults",
+			file: "dbhost : \"db\"\nwritetimeout : 30\n",
+			check: func(t *testing.T, conf *Config) {
+				assert.Equal(t, "db", conf.DBHost)
+				assert.Equal(t, 30, conf.WriteTimeout)
+				assert.Equal(t, 10, conf.ReadTimeout)
+			},
+		},
+
+		{
+			name: "OK env over file",
+			file: "dbhost : \"db\"\ndbport : 5432\n",
+			env:  map[string]string{"USERBALANCE_DBHOST": "postgres.local", "USERBALANCE_DBPORT": "6432"},
+			check: func(t *testing.T, conf *Config) {
+				assert.Equal(t, "postgres.local", conf.DBHost)
+				assert.Equal(t, 6432, conf.DBPort)
+			},
+		},
+
+		{
+			name: "OK flags over env",
+			env:  map[string]string{"USERBALANCE_DBHOST": "postgres.local"},
+			args: []string{"-dbhost", "127.0.0.1", "-port", ":9000"},
+			check: func(t *testing.T, conf *Config) {
+				assert.Equal(t, "127.0.0.1", conf.DBHost)
+				assert.Equal(t, ":9000", conf.Port)
+			},
+		},
+
+		{
+			name:    "error unknown key",
+			file:    "port : \":8081\"\neritetimeout : 10\n",
+			wantErr: "field eritetimeout not found",
+		},
+
+		{
+			name:    "error env not a number",
+			env:     map[string]string{"USERBALANCE_DBPORT": "abc"},
+			wantErr: "USERBALANCE_DBPORT",
+		},
+
+		{
+			name:    "error invalid value",
+			args:    []string{"-connectiontype", "oracle"},
+			wantErr: "тип БД должен быть postgres либо mysql",
+		},
+
+		{
+			name:    "error tls key without certificate",
+			args:    []string{"-tlskeyfile", "server.key"},
+			wantErr: "сертификат сервера не может быть не указан",
+		},
+
+		{
+			name:    "error client ca without tls",
+			args:    []string{"-tlsclientcafile", "ca.pem"},
+			wantErr: "проверка сертификатов клиентов требует сертификата сервера",
+		},
+
+		{
+			name:    "error db ssl mode",
+			env:     map[string]string{"USERBALANCE_DBSSLMODE": "on"},
+			wantErr: "режим SSL БД",
+		},
+
+		{
+			name:    "error fee rules without fee account",
+			args:    []string{"-feerules", "configs/fees.yaml"},
+			wantErr: "счет комиссий не может быть не указан",
+		},
+
+		{
+			name:    "error zero max request body",
+			args:    []string{"-maxrequestbody", "0"},
+			wantErr: "размер тела запроса должен быть > 0",
+		},
+
+		{
+			name:    "error batch timeout longer than write timeout",
+			args:    []string{"-batchtimeout", "30"},
+			wantErr: "таймаут пакета должен быть меньше таймаута записи ответа",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			for key, value := range testCase.env {
+				t.Setenv(key, value)
+			}
+
+			var path string
+			if testCase.file != "" {
+				path = writeFile(t, "config.yaml", testCase.file)
+			}
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags := BindFlags(fs)
+			require.NoError(t, fs.Parse(testCase.args))
+
+			conf, err := Load(path, flags)
+
+			if testCase.wantErr != "" {
+				assert.ErrorContains(t, err, testCase.wantErr)
+			} else {
+				require.NoError(t, err)
+				testCase.check(t, conf)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	password := writeFile(t, "password", "s3cret\n")
+	key := writeFile(t, "auditkey", "audit-key")
+
+	t.Setenv("USERBALANCE_PASSWORD_FILE", password)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := BindFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-auditkey_file", key}))
+
+	conf, err := Load(writeFile(t, "config.yaml", "password : \"from-file\"\n"), flags)
+
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", conf.Password)
+	assert.Equal(t, "audit-key", conf.AuditKey)
+
+	t.Setenv("USERBALANCE_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	_, err = Load("", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+	"userbalance/internal/logger"
+)
+
+// Source возвращает актуальную конфигурацию. Его реализуют *Config (неизменяемая конфигурация)
+// и *Store (конфигурация, перечитываемая во время работы)
+type Source interface {
+	Get() *Config
+}
+
+func (c *Config) Get() *Config {
+	return c
+}
+
+// Store хранит текущую конфигурацию и атомарно подменяет ее при перезагрузке.
+// Ключи с тегом immutable:"true" применяются только при запуске: их изменения отклоняются
+type Store struct {
+	current atomic.Value
+	path    string
+	flags   *Flags
+
+	mu       sync.Mutex
+	modTime  time.Time
+	onReload []func(conf *Config)
+}
+
+func NewStore(conf *Config, path string, flags *Flags) *Store {
+	s := &Store{path: path, flags: flags}
+	s.current.Store(conf)
+	if info, err := os.Stat(path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return s
+}
+
+// OnReload регистрирует fn, которая вызывается с новой конфигурацией после каждой перезагрузки
+func (s *Store) OnReload(fn func(conf *Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onReload = append(s.onReload, fn)
+}
+
+func (s *Store) Get() *Config {
+	return s.current.Load().(*Config)
+}
+
+// Reload перечитывает конфигурацию из тех же источников, что и при запуске.
+// При ошибке продолжает действовать прежняя конфигурация
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conf, err := Load(s.path, s.flags)
+	if err != nil {
+		return err
+	}
+
+	old := s.Get()
+	for _, key := range keepImmutable(old, conf) {
+		logger.Warn("параметр не может быть изменен без перезапуска, используется прежнее значение", "param", key)
+	}
+
+	s.current.Store(conf)
+	for _, fn := range s.onReload {
+		fn(conf)
+	}
+	logger.Info("конфигурация перечитана")
+
+	return nil
+}
+
+// Watch перечитывает конфигурацию при получении сигнала из reload
+// и при изменении файла конфигурации, который проверяется с интервалом interval
+func (s *Store) Watch(ctx context.Context, interval time.Duration, reload <-chan os.Signal) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+		case <-ticker.C:
+			if !s.fileChanged() {
+				continue
+			}
+		}
+
+		if err := s.Reload(); err != nil {
+			logger.Error("ошибка при перечитывании конфигурации", "error", err)
+		}
+	}
+}
+
+func (s *Store) fileChanged() bool {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if info.ModTime().Equal(s.modTime) {
+		return false
+	}
+	s.modTime = info.ModTime()
+	return true
+}
+
+// keepImmutable переносит в next прежние значения неизменяемых ключей и возвращает имена
+// ключей, изменения которых были отклонены
+func keepImmutable(prev, next *Config) []string {
+	rejected := make([]string, 0)
+
+	p := reflect.ValueOf(prev).Elem()
+	n := reflect.ValueOf(next).Elem()
+	t := p.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("immutable") != "true" {
+			continue
+		}
+		if !reflect.DeepEqual(p.Field(i).Interface(), n.Field(i).Interface()) {
+			rejected = append(rejected, t.Field(i).Tag.Get("yaml"))
+			n.Field(i).Set(p.Field(i))
+		}
+	}
+
+	return rejected
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreReload(t *testing.T) {
+	path := writeFile(t, "config.yaml", "dbhost : \"db\"\nwritetimeout : 10\n")
+
+	conf, err := Load(path, nil)
+	require.NoError(t, err)
+	store := NewStore(conf, path, nil)
+
+	var reloaded *Config
+	store.OnReload(func(conf *Config) { reloaded = conf })
+
+	require.NoError(t, os.WriteFile(path, []byte("dbhost : \"other\"\nwritetimeout : 30\nloglevel : \"debug\"\n"), 0600))
+	require.NoError(t, store.Reload())
+
+	assert.Equal(t, store.Get(), reloaded)
+	assert.Equal(t, "debug", store.Get().LogLevel)
+	assert.Equal(t, 30, store.Get().WriteTimeout)
+	assert.Equal(t, "db", store.Get().DBHost)
+	assert.Equal(t, 10, conf.WriteTimeout, "прежняя конфигурация не должна меняться")
+
+	require.NoError(t, os.WriteFile(path, []byte("writetimeout : -1\n"), 0600))
+	assert.Error(t, store.Reload())
+	assert.Equal(t, 30, store.Get().WriteTimeout)
+}
+
+func TestStoreWatch(t *testing.T) {
+	path := writeFile(t, "config.yaml", "dbtimeout : 5\n")
+
+	conf, err := Load(path, nil)
+	require.NoError(t, err)
+	store := NewStore(conf, path, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reload := make(chan os.Signal, 1)
+	go store.Watch(ctx, 10*time.Millisecond, reload)
+
+	t.Run("file changed", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("dbtimeout : 7\n"), 0600))
+		// время изменения файла может совпасть с прежним при грубой точности файловой системы
+		future := time.Now().Add(time.Second)
+		require.NoError(t, os.Chtimes(path, future, future))
+
+		assert.Eventually(t, func() bool { return store.Get().DBTimeout == 7 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		t.Setenv("USERBALANCE_DBTIMEOUT", "9")
+		reload <- os.Interrupt
+
+		assert.Eventually(t, func() bool { return store.Get().DBTimeout == 9 }, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestKeepImmutable(t *testing.T) {
+	prev := Default()
+	next := Default()
+	next.DBHost = "other"
+	next.Password = "secret"
+	next.ReadTimeout = 30
+
+	rejected := keepImmutable(prev, next)
+
+	assert.Equal(t, []string{"dbhost", "password"}, rejected)
+	assert.Equal(t, prev.DBHost, next.DBHost)
+	assert.Equal(t, prev.Password, next.Password)
+	assert.Equal(t, 30, next.ReadTimeout)
+}
@@ -0,0 +1,134 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// 2022-10-01 - суббота
+	after := time.Date(2022, 10, 01, 12, 30, 15, 0, time.UTC)
+
+	testTable := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name:  "every minute",
+			spec:  "* * * * *",
+			after: after,
+			want:  time.Date(2022, 10, 01, 12, 31, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "strictly after",
+			spec:  "31 12 * * *",
+			after: time.Date(2022, 10, 01, 12, 31, 0, 0, time.UTC),
+			want:  time.Date(2022, 10, 02, 12, 31, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "step",
+			spec:  "*/15 * * * *",
+			after: after,
+			want:  time.Date(2022, 10, 01, 12, 45, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "range and list",
+			spec:  "0 9-11,18 * * *",
+			after: after,
+			want:  time.Date(2022, 10, 01, 18, 0, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "monthly",
+			spec:  "@monthly",
+			after: after,
+			want:  time.Date(2022, 11, 01, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "end of year",
+			spec:  "0 0 1 1 *",
+			after: after,
+			want:  time.Date(2023, 01, 01, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "weekday",
+			spec:  "0 10 * * 1-5",
+			after: after,
+			want:  time.Date(2022, 10, 03, 10, 0, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "sunday as 7",
+			spec:  "0 10 * * 7",
+			after: after,
+			want:  time.Date(2022, 10, 02, 10, 0, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "day of month or weekday",
+			spec:  "0 0 15 * 1",
+			after: after,
+			want:  time.Date(2022, 10, 03, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "leap day",
+			spec:  "0 0 29 2 *",
+			after: after,
+			want:  time.Date(2024, 02, 29, 0, 0, 0, 0, time.UTC),
+		},
+
+		{
+			name:  "never",
+			spec:  "0 0 30 2 *",
+			after: after,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			schedule, err := Parse(testCase.spec)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.want, schedule.Next(testCase.after))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	testTable := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "OK", spec: "0 0 1 * *"},
+		{name: "OK descriptor", spec: "@daily"},
+		{name: "OK start with step", spec: "5/20 * * * *"},
+		{name: "error fields", spec: "0 0 1 *", wantErr: true},
+		{name: "error range", spec: "60 * * * *", wantErr: true},
+		{name: "error reversed range", spec: "0 10-9 * * *", wantErr: true},
+		{name: "error step", spec: "*/0 * * * *", wantErr: true},
+		{name: "error value", spec: "0 0 * jan *", wantErr: true},
+		{name: "error unknown descriptor", spec: "@every 1h", wantErr: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Parse(testCase.spec)
+			if testCase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Package fee рассчитывает комиссию за операции по правилам из YAML-файла
+package fee
+
+import (
+	"userbalance/internal/models"
+	"userbalance/internal/rulefile"
+)
+
+// Engine рассчитывает комиссию по правилам из файла, перечитываемого методом Reload
+type Engine struct {
+	*rulefile.File[Rules]
+}
+
+// NewEngine загружает правила из файла path
+func NewEngine(path string) (*Engine, error) {
+	rules, err := rulefile.New(path, Load)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{rules}, nil
+}
+
+// Quote рассчитывает комиссию по первому в порядке файла правилу, которое относится к операции.
+// Если ни одно правило не подошло, операция выполняется без комиссии
+func (e *Engine) Quote(request *models.FeeRequest) *models.FeeQuote {
+	quote := &models.FeeQuote{
+		Operation: request.Operation,
+		ServiceID: request.ServiceID,
+		Amount:    request.Amount,
+		Total:     request.Amount,
+	}
+
+	for _, rule := range e.Rules().Rules {
+		if !rule.applies(request.Operation, request.ServiceID) {
+			continue
+		}
+		quote.Fee = rule.fee(request.Amount)
+		quote.Total = request.Amount + quote.Fee
+		quote.Rule = rule.Name
+		break
+	}
+
+	return quote
+}
@@ -0,0 +1,166 @@
+package fee
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"userbalance/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRules(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "fees.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+const rules = `
+rules:
+  - name: free-service
+    services: [3]
+    operations: [reserve]
+  - name: delivery
+    services: [1, 2]
+    fixed: 50
+    percent: 1.5
+    max: 1000
+  - name: transfer
+    operations: [transfer]
+    percent: 0.1
+    min: 10
+`
+
+func TestEngine_Quote(t *testing.T) {
+	engine, err := NewEngine(writeRules(t, rules))
+	require.NoError(t, err)
+
+	testTable := []struct {
+		name    string
+		request models.FeeRequest
+		want    models.FeeQuote
+	}{
+		{
+			name:    "percent with min",
+			request: models.FeeRequest{Operation: models.OperationTransfer, Amount: 1000},
+			want:    models.FeeQuote{Operation: models.OperationTransfer, Amount: 1000, Fee: 10, Total: 1010, Rule: "transfer"},
+		},
+
+		{
+			name:    "percent rounded up",
+			request: models.FeeRequest{Operation: models.OperationTransfer, Amount: 100001},
+			want:    models.FeeQuote{Operation: models.OperationTransfer, Amount: 100001, Fee: 101, Total: 100102, Rule: "transfer"},
+		},
+
+		{
+			name:    "fixed and percent",
+			request: models.FeeRequest{Operation: models.OperationReserve, ServiceID: 2, Amount: 2000},
+			want:    models.FeeQuote{Operation: models.OperationReserve, ServiceID: 2, Amount: 2000, Fee: 80, Total: 2080, Rule: "delivery"},
+		},
+
+		{
+			name:    "max",
+			request: models.FeeRequest{Operation: models.OperationReserve, ServiceID: 1, Amount: 1000000},
+			want:    models.FeeQuote{Operation: models.OperationReserve, ServiceID: 1, Amount: 1000000, Fee: 1000, Total: 1001000, Rule: "delivery"},
+		},
+
+		{
+			name:    "first matching rule wins",
+			request: models.FeeRequest{Operation: models.OperationReserve, ServiceID: 3, Amount: 5000},
+			want:    models.FeeQuote{Operation: models.OperationReserve, ServiceID: 3, Amount: 5000, Total: 5000, Rule: "free-service"},
+		},
+
+		{
+			name:    "no rule",
+			request: models.FeeRequest{Operation: models.OperationReserve, ServiceID: 4, Amount: 5000},
+			want:    models.FeeQuote{Operation: models.OperationReserve, ServiceID: 4, Amount: 5000, Total: 5000},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, &testCase.want, engine.Quote(&testCase.request))
+		})
+	}
+}
+
+func TestEngine_Reload(t *testing.T) {
+	path := writeRules(t, rules)
+	engine, err := NewEngine(path)
+	require.NoError(t, err)
+
+	request := &models.FeeRequest{Operation: models.OperationTransfer, Amount: 1000}
+
+	require.NoError(t, os.WriteFile(path, []byte("rules: []\n"), 0600))
+	require.NoError(t, engine.Reload())
+	assert.Equal(t, 0, engine.Quote(request).Fee)
+
+	// файл с ошибкой не заменяет действующие правила
+	require.NoError(t, os.WriteFile(path, []byte("rules:\n  - {name: broken, percent: 200}\n"), 0600))
+	assert.Error(t, engine.Reload())
+	assert.Equal(t, 0, engine.Quote(request).Fee)
+}
+
+func TestLoad(t *testing.T) {
+	testTable := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "OK",
+			content: rules,
+		},
+
+		{
+			name:    "error unknown operation",
+			content: "rules:\n  - {name: a, operations: [topup], fixed: 10}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error services for transfer",
+			content: "rules:\n  - {name: a, operations: [transfer], services: [1], fixed: 10}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error negative fixed",
+			content: "rules:\n  - {name: a, fixed: -10}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error max less than min",
+			content: "rules:\n  - {name: a, percent: 1, min: 100, max: 50}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error long name",
+			content: "rules:\n  - {name: " + strings.Repeat("a", MaxNameLength+1) + ", fixed: 10}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error duplicate name",
+			content: "rules:\n  - {name: a, fixed: 10}\n  - {name: a, fixed: 20}\n",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := Load(writeRules(t, testCase.content))
+			if testCase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, got.Rules, 3)
+			assert.Equal(t, []int{1, 2}, got.Rules[1].Services)
+		})
+	}
+}
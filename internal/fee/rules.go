@@ -0,0 +1,152 @@
+package fee
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"userbalance/internal/models"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxNameLength - наибольшая длина имени правила. Имя записывается в описание строки истории
+// счета комиссий, а описания в logs и ledger ограничены 100 символами
+const MaxNameLength int = 32
+
+// Rule - правило комиссии за операции Operations, для резервирования - за услуги Services.
+// Комиссия - Fixed плюс Percent процентов суммы с округлением вверх, но не меньше
+// Min и, если Max указан, не больше Max
+type Rule struct {
+	Name       string   `yaml:"name"`
+	Operations []string `yaml:"operations"`
+	Services   []int    `yaml:"services"`
+	Fixed      int      `yaml:"fixed"`
+	Percent    float64  `yaml:"percent"`
+	Min        int      `yaml:"min"`
+	Max        int      `yaml:"max"`
+}
+
+// Rules - содержимое файла правил
+type Rules struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Load читает и проверяет файл правил
+func Load(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules Rules
+	if err = yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе файла правил %s: %w", path, err)
+	}
+
+	names := make(map[string]bool, len(rules.Rules))
+	for i, rule := range rules.Rules {
+		if err = rule.Validate(); err != nil {
+			return nil, fmt.Errorf("правило %d (%s): %w", i+1, rule.Name, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("правило %s задано несколько раз", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+
+	return &rules, nil
+}
+
+func (r Rule) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Name,
+			validation.Required.Error("имя правила не может быть не указано"),
+			validation.RuneLength(0, MaxNameLength).Error(fmt.Sprintf("имя правила не может быть длиннее %d символов", MaxNameLength))),
+		validation.Field(&r.Operations, validation.By(r.validateOperations)),
+		validation.Field(&r.Services, validation.By(r.validateServices)),
+		validation.Field(&r.Fixed, validation.Min(0).Error("фиксированная комиссия не может быть < 0")),
+		validation.Field(&r.Percent,
+			validation.Min(float64(0)).Error("процент не может быть < 0"),
+			validation.Max(float64(100)).Error("процент не может быть > 100")),
+		validation.Field(&r.Min, validation.Min(0).Error("минимальная комиссия не может быть < 0")),
+		validation.Field(&r.Max,
+			validation.Min(0).Error("максимальная комиссия не может быть < 0"),
+			validation.By(func(value interface{}) error {
+				if r.Max > 0 && r.Max < r.Min {
+					return errors.New("максимальная комиссия не может быть меньше минимальной")
+				}
+				return nil
+			})))
+}
+
+// validateOperations проверяет, что правило относится только к операциям из models.FeeOperations
+func (r Rule) validateOperations(value interface{}) error {
+	for _, operation := range r.Operations {
+		if operation != models.OperationTransfer && operation != models.OperationReserve {
+			return errors.New("комиссия взимается только за операции transfer и reserve")
+		}
+	}
+	return nil
+}
+
+// validateServices проверяет, что услуги указаны только для правила резервирования
+func (r Rule) validateServices(value interface{}) error {
+	if len(r.Services) == 0 {
+		return nil
+	}
+	for _, operation := range r.Operations {
+		if operation != models.OperationReserve {
+			return errors.New("услуги указываются только для правил резервирования")
+		}
+	}
+	for _, service := range r.Services {
+		if service <= 0 {
+			return errors.New("id услуги не может быть <= 0")
+		}
+	}
+	return nil
+}
+
+// applies сообщает, что правило относится к операции operation: без списка операций -
+// ко всем операциям, правило с услугами - только к резервированию этих услуг
+func (r Rule) applies(operation string, serviceId int) bool {
+	if len(r.Services) > 0 {
+		if operation != models.OperationReserve || !contains(r.Services, serviceId) {
+			return false
+		}
+	}
+	if len(r.Operations) == 0 {
+		return true
+	}
+	for _, o := range r.Operations {
+		if o == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// fee рассчитывает комиссию с суммы amount. Процент считается в сотых долях процента,
+// чтобы 0.1% от 1000 давал ровно 1, а не 1.0000000000000002
+func (r Rule) fee(amount int) int {
+	bp := int64(math.Round(r.Percent * 100))
+	fee := r.Fixed + int((int64(amount)*bp+9999)/10000)
+	if fee < r.Min {
+		fee = r.Min
+	}
+	if r.Max > 0 && fee > r.Max {
+		fee = r.Max
+	}
+	return fee
+}
+
+func contains(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
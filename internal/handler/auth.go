@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+)
+
+const apiKeyHeader string = "X-API-Key"
+
+// publicRoutes доступны без аутентификации: проверки состояния, метрики и документация
+var publicRoutes = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+	"/swagger": true,
+}
+
+// routeScopes - права, необходимые для маршрутов. Ключ "МЕТОД маршрут" задает права для метода
+// и имеет приоритет над ключом маршрута. Остальные маршруты, кроме publicRoutes, требуют только
+// аутентификации: права операций пакета проверяет обработчик /batch
+var routeScopes = map[string]string{
+	"/":                                      models.ScopeBalanceRead,
+	"/users/{id:[0-9]+}/balance":             models.ScopeBalanceRead,
+	"/history":                               models.ScopeBalanceRead,
+	"/topup":                                 models.ScopeBalanceTopup,
+	"/transfer":                              models.ScopeBalanceTransfer,
+	"/reserv":                                models.ScopeReservationsWrite,
+	"/confirm":                               models.ScopeReservationsWrite,
+	"/cancel":                                models.ScopeReservationsWrite,
+	"/report":                                models.ScopeReportsRead,
+	"/file/":                                 models.ScopeReportsRead,
+	"/reconciliation":                        models.ScopeReportsRead,
+	"/audit/verify":                          models.ScopeAuditRead,
+	"/risk/decisions":                        models.ScopeRiskReview,
+	"/risk/reviews/{id:[0-9]+}/approve":      models.ScopeRiskReview,
+	"/risk/reviews/{id:[0-9]+}/reject":       models.ScopeRiskReview,
+	"/schedules":                             models.ScopeSchedulesRead,
+	"POST /schedules":                        models.ScopeSchedulesWrite,
+	"/schedules/{id:[0-9]+}":                 models.ScopeSchedulesRead,
+	"/schedules/{id:[0-9]+}/runs":            models.ScopeSchedulesRead,
+	"/schedules/{id:[0-9]+}/pause":           models.ScopeSchedulesWrite,
+	"/schedules/{id:[0-9]+}/resume":          models.ScopeSchedulesWrite,
+	"/schedules/{id:[0-9]+}/cancel":          models.ScopeSchedulesWrite,
+	"/transfers/pending":                     models.ScopeBalanceRead,
+	"POST /transfers/pending":                models.ScopeBalanceTransfer,
+	"/transfers/pending/{id:[0-9]+}":         models.ScopeBalanceRead,
+	"/transfers/pending/{id:[0-9]+}/accept":  models.ScopeBalanceTransfer,
+	"/transfers/pending/{id:[0-9]+}/decline": models.ScopeBalanceTransfer,
+	"/fees/quote":                            models.ScopeBalanceRead,
+}
+
+// batchScopes - права, необходимые для операций пакета и операций по расписанию
+var batchScopes = map[string]string{
+	models.OperationTopup:    models.ScopeBalanceTopup,
+	models.OperationTransfer: models.ScopeBalanceTransfer,
+	models.OperationReserve:  models.ScopeReservationsWrite,
+	models.OperationConfirm:  models.ScopeReservationsWrite,
+	models.OperationCancel:   models.ScopeReservationsWrite,
+}
+
+type principalKey struct{}
+
+// authenticate проверяет ключ API из заголовка X-API-Key, JWT из заголовка
+// Authorization: Bearer либо сертификат клиента и права клиента на маршрут
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		template := routeTemplate(r)
+		if publicRoutes[template] || !h.services.Required() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := h.principal(r)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			if errors.Is(err, service.ErrUnauthenticated) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="userbalance"`)
+				Error(err, w, r, http.StatusUnauthorized)
+				return
+			}
+			Error(err, w, r, http.StatusInternalServerError)
+			return
+		}
+		logger.AddAttrs(r.Context(), "client", principal.Client, "auth", principal.Method)
+
+		if scope := routeScope(r.Method, template); scope != "" && !principal.HasScope(scope) {
+			w.Header().Set("Content-Type", "application/json")
+			Error(fmt.Errorf("недостаточно прав: требуется %s", scope), w, r, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+// routeScope возвращает права, необходимые для запроса method к маршруту template
+func routeScope(method string, template string) string {
+	if scope, ok := routeScopes[method+" "+template]; ok {
+		return scope
+	}
+	return routeScopes[template]
+}
+
+func (h *Handler) principal(r *http.Request) (*models.Principal, error) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return h.services.AuthenticateAPIKey(r.Context(), key)
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if token := strings.TrimPrefix(authorization, "Bearer "); token != authorization && token != "" {
+		return h.services.AuthenticateToken(r.Context(), token)
+	}
+
+	// цепочка проверена при установке соединения, если задан tlsclientcafile
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return h.services.AuthenticateCertificate(r.Context(), r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	}
+
+	return nil, fmt.Errorf("%w: передайте ключ в заголовке %s, токен в заголовке Authorization либо сертификат клиента", service.ErrUnauthenticated, apiKeyHeader)
+}
+
+// checkScopes проверяет права клиента на каждую операцию пакета
+func checkScopes(ctx context.Context, operations []models.BatchOperation) error {
+	for i, operation := range operations {
+		if err := checkScope(ctx, operation.Type); err != nil {
+			return fmt.Errorf("операция %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// checkScope проверяет права клиента на операцию operation.
+// Без аутентификации (authrequired: false) клиента в контексте нет и проверка не выполняется
+func checkScope(ctx context.Context, operation string) error {
+	principal, ok := ctx.Value(principalKey{}).(*models.Principal)
+	if !ok {
+		return nil
+	}
+
+	// операции неизвестного типа отклоняет сервис
+	if scope, ok := batchScopes[operation]; ok && !principal.HasScope(scope) {
+		return fmt.Errorf("недостаточно прав: требуется %s", scope)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+	mock_service "userbalance/internal/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_authenticate(t *testing.T) {
+	billing := &models.Principal{Client: "billing", Method: models.AuthMethodAPIKey, Scopes: []string{models.ScopeBalanceRead}}
+	shop := &models.Principal{Client: "shop", Method: models.AuthMethodJWT, Scopes: []string{models.ScopeBalanceTopup}}
+	reader := &models.Principal{Client: "reader", Method: models.AuthMethodAPIKey, Scopes: []string{models.ScopeSchedulesRead}}
+
+	type mockBehavior func(s *mock_service.MockAuth)
+
+	testTable := []struct {
+		name               string
+		method             string
+		path               string
+		headers            map[string]string
+		clientCert         string
+		body               string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+	}{
+		{
+			name:   "OK api key",
+			method: "GET",
+			path:   "/users/1/balance",
+			headers: map[string]string{
+				"X-API-Key": "ubk_key",
+			},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(true)
+				s.EXPECT().AuthenticateAPIKey(gomock.Any(), "ubk_key").Return(billing, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name:       "OK client certificate",
+			method:     "GET",
+			path:       "/users/1/balance",
+			clientCert: "billing",
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(true)
+				s.EXPECT().AuthenticateCertificate(gomock.Any(), "billing").Return(
+					&models.Principal{Client: "billing", Method: models.AuthMethodCertificate, Scopes: []string{models.ScopeBalanceRead}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name:   "OK jwt",
+			method: "POST",
+			path:   "/topup",
+			headers: map[string]string{
+				"Authorization": "Bearer token",
+			},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(true)
+				s.EXPECT().AuthenticateToken(gomock.Any(), "token").Return(shop, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name:               "OK public route",
+			method:             "GET",
+			path:               "/healthz",
+			mockBehavior:       func(s *mock_service.MockAuth) {},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name:   "OK auth not required",
+			method: "POST",
+			path:   "/topup",
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(false)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name:   "error no credentials",
+			method: "POST",
+			path:   "/topup",
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(true)
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+
+		{
+			name:   "error invalid key",
+			method: "POST",
+			path:   "/topup",
+			headers: map[string]string{
+				"X-API-Key": "ubk_revoked",
+			},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(true)
+				s.EXPECT().AuthenticateAPIKey(gomock.Any(), "ubk_revoked").Return(nil, fmt.Errorf("%w: ключ отозван", service.ErrUnauthenticated))
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+
+		{
+			name:   "error missing scope",
+			method: "POST",
+			path:   "/topup",
+			headers: map[string]string{
+				"X-API-Key": "ubk_key",
+			},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(true)
+				s.EXPECT().AuthenticateAPIKey(gomock.Any(), "ubk_key").Return(billing, nil)
+			},
+			expectedStatusCode: http.StatusForbidden,
+		},
+
+		{
+			name:   "OK method scope",
+			method: "GET",
+			path:   "/schedules",
+			headers: map[string]string{
+				"X-API-Key": "ubk_reader",
+			},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(true)
+				s.EXPECT().AuthenticateAPIKey(gomock.Any(), "ubk_reader").Return(reader, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name:   "error method scope",
+			method: "POST",
+			path:   "/schedules",
+			headers: map[string]string{
+				"X-API-Key": "ubk_reader",
+			},
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(true)
+				s.EXPECT().AuthenticateAPIKey(gomock.Any(), "ubk_reader").Return(reader, nil)
+			},
+			expectedStatusCode: http.StatusForbidden,
+		},
+
+		{
+			name:   "error batch operation scope",
+			method: "POST",
+			path:   "/batch",
+			headers: map[string]string{
+				"Authorization": "Bearer token",
+			},
+			body: `{"mode":"atomic","operations":[{"type":"transfer","fromuserid":1,"touserid":2,"amount":10}]}`,
+			mockBehavior: func(s *mock_service.MockAuth) {
+				s.EXPECT().Required().Return(true)
+				s.EXPECT().AuthenticateToken(gomock.Any(), "token").Return(shop, nil)
+			},
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuth(c)
+			testCase.mockBehavior(auth)
+
+			h := NewHandler(&service.Service{Auth: auth})
+			ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+			r := mux.NewRouter()
+			r.HandleFunc("/users/{id:[0-9]+}/balance", ok).Methods("GET")
+			r.HandleFunc("/topup", ok).Methods("POST")
+			r.HandleFunc("/healthz", ok).Methods("GET")
+			r.HandleFunc("/schedules", ok).Methods("GET", "POST")
+			r.HandleFunc("/batch", h.batch).Methods("POST")
+			r.Use(logRequests, h.authenticate)
+
+			req := httptest.NewRequest(testCase.method, testCase.path, bytes.NewBufferString(testCase.body))
+			for name, value := range testCase.headers {
+				req.Header.Set(name, value)
+			}
+			if testCase.clientCert != "" {
+				cert := &x509.Certificate{Subject: pkix.Name{CommonName: testCase.clientCert}}
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			if testCase.expectedStatusCode == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package handler
 
 import (
-	"log"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
+	"userbalance/internal/logger"
 	"userbalance/internal/models"
+	"userbalance/internal/service"
 
+	"github.com/gorilla/mux"
 	"github.com/mailru/easyjson"
 )
 
@@ -16,7 +21,11 @@ import (
 // @Produce  json
 // @Param input body models.User true "user id"
 // @Success 200 {object} models.User
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
 // @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router / [post]
 func (h *Handler) getBalance(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -26,28 +35,94 @@ func (h *Handler) getBalance(w http.ResponseWriter, r *http.Request) {
 	var newUser *models.User
 
 	if err = easyjson.UnmarshalFromReader(r.Body, &user); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
+	logger.AddAttrs(r.Context(), "userid", user.Id)
 
 	if err = user.Validate(); err != nil {
-		Error(err, w, http.StatusBadRequest)
+		Error(err, w, r, http.StatusBadRequest)
 		return
 	}
 
-	if newUser, err = h.services.GetBalance(user.Id); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+	if newUser, err = h.services.GetBalance(r.Context(), user.Id); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	_, err = easyjson.MarshalToWriter(newUser, w)
 	if err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 }
 
+// @Summary Get Balance At
+// @Tags balance
+// @Description getting the user's main and reserved balance at the specified moment
+// @ID get-balance-at
+// @Produce  json
+// @Param id path int true "user id"
+// @Param at query string false "moment in RFC3339 format or date yyyy-mm-dd (end of the day), current time by default"
+// @Success 200 {object} models.BalanceAt
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /users/{id}/balance [get]
+func (h *Handler) getBalanceAt(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var request models.BalanceAt
+	var balance *models.BalanceAt
+
+	if request.UserID, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		Error(errors.New("неверно указан id пользователя"), w, r, http.StatusBadRequest)
+		return
+	}
+	logger.AddAttrs(r.Context(), "userid", request.UserID)
+
+	if err = request.Validate(); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if request.At, err = parseMoment(r.URL.Query().Get("at")); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if balance, err = h.services.GetBalanceAt(r.Context(), request.UserID, request.At); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(balance, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseMoment разбирает момент времени в формате RFC3339 либо дату, означающую конец этих суток
+func parseMoment(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, errors.New("момент времени должен быть в формате RFC3339 либо yyyy-mm-dd")
+}
+
 // @Summary Replenishment Balance
 // @Tags balance
 // @Description replenishment of the user's balance
@@ -56,7 +131,12 @@ func (h *Handler) getBalance(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param input body models.Replenishment true "replenishment information"
 // @Success 200 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 422 {object} models.LimitExceeded
 // @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /topup [post]
 func (h *Handler) replenishmentBalance(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -65,17 +145,18 @@ func (h *Handler) replenishmentBalance(w http.ResponseWriter, r *http.Request) {
 	var replenishment models.Replenishment
 
 	if err = easyjson.UnmarshalFromReader(r.Body, &replenishment); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
+	logger.AddAttrs(r.Context(), "userid", replenishment.UserID)
 
 	if err = replenishment.Validate(); err != nil {
-		Error(err, w, http.StatusBadRequest)
+		Error(err, w, r, http.StatusBadRequest)
 		return
 	}
 
-	if err = h.services.ReplenishmentBalance(&replenishment); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+	if err = h.services.ReplenishmentBalance(r.Context(), &replenishment); err != nil {
+		operationError(err, w, r)
 		return
 	}
 
@@ -85,20 +166,26 @@ func (h *Handler) replenishmentBalance(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err = easyjson.MarshalToWriter(response, w)
 	if err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 }
 
 // @Summary Money transfer
 // @Tags balance
-// @Description money transfer between users
+// @Description money transfer between users. 202 - the transfer is held for review by an administrator, 422 - a spending limit is exceeded (models.LimitExceeded) or the transfer is denied by fraud screening (models.RiskResponse)
 // @ID transfer
 // @Accept  json
 // @Produce  json
 // @Param input body models.Money true "transfer information"
 // @Success 200 {object} models.Response
+// @Success 202 {object} models.RiskResponse
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 422 {object} models.LimitExceeded
 // @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /transfer [post]
 func (h *Handler) transfer(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -107,17 +194,18 @@ func (h *Handler) transfer(w http.ResponseWriter, r *http.Request) {
 	var money models.Money
 
 	if err = easyjson.UnmarshalFromReader(r.Body, &money); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
+	logger.AddAttrs(r.Context(), "fromuserid", money.FromUserID, "touserid", money.ToUserID)
 
 	if err = money.Validate(); err != nil {
-		Error(err, w, http.StatusBadRequest)
+		Error(err, w, r, http.StatusBadRequest)
 		return
 	}
 
-	if err := h.services.Transfer(&money); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+	if err := h.services.Transfer(r.Context(), &money); err != nil {
+		operationError(err, w, r)
 		return
 	}
 
@@ -127,20 +215,24 @@ func (h *Handler) transfer(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err = easyjson.MarshalToWriter(response, w)
 	if err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 }
 
 // @Summary Get History
 // @Tags info
-// @Description getting user history
+// @Description getting user history. metadatakey selects records with the metadata key, together with metadatavalue - with the key value
 // @ID get-history
 // @Accept  json
 // @Produce  json
 // @Param input body models.RequestHistory true "history request information"
 // @Success 200 {object} []models.History
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
 // @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /history [post]
 func (h *Handler) getHistory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -150,17 +242,18 @@ func (h *Handler) getHistory(w http.ResponseWriter, r *http.Request) {
 	var history []models.History
 
 	if err = easyjson.UnmarshalFromReader(r.Body, &requestHistory); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
+	logger.AddAttrs(r.Context(), "userid", requestHistory.UserID)
 
 	if err = requestHistory.Validate(); err != nil {
-		Error(err, w, http.StatusBadRequest)
+		Error(err, w, r, http.StatusBadRequest)
 		return
 	}
 
-	if history, err = h.services.GetHistory(&requestHistory); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+	if history, err = h.services.GetHistory(r.Context(), &requestHistory); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 
@@ -169,7 +262,7 @@ func (h *Handler) getHistory(w http.ResponseWriter, r *http.Request) {
 		Entity: history,
 	}, w)
 	if err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 }
@@ -182,7 +275,11 @@ func (h *Handler) getHistory(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param input body models.RequestReport true "report request information"
 // @Success 200 {object} []models.Report
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
 // @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /report [post]
 func (h *Handler) createReport(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -192,17 +289,17 @@ func (h *Handler) createReport(w http.ResponseWriter, r *http.Request) {
 	var reportPath string
 
 	if err = easyjson.UnmarshalFromReader(r.Body, &requestReport); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 
 	if err = requestReport.Validate(); err != nil {
-		Error(err, w, http.StatusBadRequest)
+		Error(err, w, r, http.StatusBadRequest)
 		return
 	}
 
-	if reportPath, err = h.services.CreateReport(&requestReport); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+	if reportPath, err = h.services.CreateReport(r.Context(), &requestReport); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 
@@ -212,20 +309,26 @@ func (h *Handler) createReport(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err = easyjson.MarshalToWriter(response, w)
 	if err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 }
 
 // @Summary Reservation of funds
 // @Tags balance
-// @Description reservation of funds
+// @Description reservation of funds. 202 - the reservation is held for review by an administrator, 422 - a spending limit is exceeded (models.LimitExceeded) or the reservation is denied by fraud screening (models.RiskResponse)
 // @ID reservation
 // @Accept  json
 // @Produce  json
 // @Param input body models.Transaction true "transaction info"
 // @Success 200 {object} models.Response
+// @Success 202 {object} models.RiskResponse
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 422 {object} models.LimitExceeded
 // @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /reserv [post]
 func (h *Handler) reservation(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -234,17 +337,18 @@ func (h *Handler) reservation(w http.ResponseWriter, r *http.Request) {
 	var transaction models.Transaction
 
 	if err = easyjson.UnmarshalFromReader(r.Body, &transaction); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
+	logger.AddAttrs(r.Context(), "userid", transaction.UserID)
 
 	if err = transaction.Validate(); err != nil {
-		Error(err, w, http.StatusBadRequest)
+		Error(err, w, r, http.StatusBadRequest)
 		return
 	}
 
-	if err = h.services.Reservation(&transaction); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+	if err = h.services.Reservation(r.Context(), &transaction); err != nil {
+		operationError(err, w, r)
 		return
 	}
 
@@ -254,7 +358,7 @@ func (h *Handler) reservation(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err = easyjson.MarshalToWriter(response, w)
 	if err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 }
@@ -267,7 +371,11 @@ func (h *Handler) reservation(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param input body models.Transaction true "transaction info"
 // @Success 200 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
 // @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /confirm [post]
 func (h *Handler) confirmation(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -276,17 +384,18 @@ func (h *Handler) confirmation(w http.ResponseWriter, r *http.Request) {
 	var transaction models.Transaction
 
 	if err = easyjson.UnmarshalFromReader(r.Body, &transaction); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
+	logger.AddAttrs(r.Context(), "userid", transaction.UserID)
 
 	if err = transaction.Validate(); err != nil {
-		Error(err, w, http.StatusBadRequest)
+		Error(err, w, r, http.StatusBadRequest)
 		return
 	}
 
-	if err = h.services.Confirmation(&transaction); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+	if err = h.services.Confirmation(r.Context(), &transaction); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 
@@ -296,7 +405,7 @@ func (h *Handler) confirmation(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err = easyjson.MarshalToWriter(response, w)
 	if err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 }
@@ -309,7 +418,11 @@ func (h *Handler) confirmation(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param input body models.Transaction true "transaction info"
 // @Success 200 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
 // @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /cancel [post]
 func (h *Handler) cancelReservation(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -318,17 +431,18 @@ func (h *Handler) cancelReservation(w http.ResponseWriter, r *http.Request) {
 	var transaction models.Transaction
 
 	if err = easyjson.UnmarshalFromReader(r.Body, &transaction); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
+	logger.AddAttrs(r.Context(), "userid", transaction.UserID)
 
 	if err = transaction.Validate(); err != nil {
-		Error(err, w, http.StatusBadRequest)
+		Error(err, w, r, http.StatusBadRequest)
 		return
 	}
 
-	if err = h.services.CancelReservation(&transaction); err != nil {
-		Error(err, w, http.StatusInternalServerError)
+	if err = h.services.CancelReservation(r.Context(), &transaction); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 
@@ -338,19 +452,240 @@ func (h *Handler) cancelReservation(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, err = easyjson.MarshalToWriter(response, w)
 	if err != nil {
-		Error(err, w, http.StatusInternalServerError)
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Batch operations
+// @Tags balance
+// @Description execution of several operations in one request, atomically or independently
+// @ID batch
+// @Accept  json
+// @Produce  json
+// @Param input body models.RequestBatch true "batch of operations"
+// @Success 200 {object} models.BatchResults
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Failure 503 {object} models.BatchResults
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /batch [post]
+func (h *Handler) batch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var requestBatch models.RequestBatch
+	var results *models.BatchResults
+
+	if err = easyjson.UnmarshalFromReader(r.Body, &requestBatch); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	if err = requestBatch.Validate(); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if err = checkScopes(r.Context(), requestBatch.Operations); err != nil {
+		Error(err, w, r, http.StatusForbidden)
+		return
+	}
+
+	status := http.StatusOK
+	results, err = h.services.Batch(r.Context(), &requestBatch)
+	switch {
+	case errors.Is(err, service.ErrBatchInterrupted):
+		// результаты отправляются и при сбое: в независимом режиме часть операций уже выполнена
+		status = http.StatusServiceUnavailable
+		logger.ErrorContext(r.Context(), "ошибка при обработке запроса", "status", status, "error", err)
+	case err != nil:
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(status)
+	_, err = easyjson.MarshalToWriter(results, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Reconciliation
+// @Tags reconciliation
+// @Description comparison of account balances with balances recomputed from logs, reservations and report
+// @ID reconciliation
+// @Produce  json
+// @Produce  text/csv
+// @Param format query string false "output format: json (default) or csv"
+// @Success 200 {object} models.ReconciliationReport
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /reconciliation [get]
+func (h *Handler) reconciliation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var report *models.ReconciliationReport
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.ReconciliationFormatJSON
+	}
+	if format != models.ReconciliationFormatJSON && format != models.ReconciliationFormatCSV {
+		Error(errors.New("формат должен быть json либо csv"), w, r, http.StatusBadRequest)
+		return
+	}
+
+	if report, err = h.services.Reconcile(r.Context()); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	if format == models.ReconciliationFormatCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(http.StatusOK)
+		if err = service.WriteReconciliationCSV(w, report); err != nil {
+			logger.ErrorContext(r.Context(), "ошибка при отправке отчета сверки", "error", err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(report, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Audit verification
+// @Tags audit
+// @Description verification of the hash chains of the logs and of the signed checkpoints, reports the first broken link
+// @ID audit-verify
+// @Produce  json
+// @Success 200 {object} models.AuditReport
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /audit/verify [get]
+func (h *Handler) auditVerify(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var report *models.AuditReport
+
+	if report, err = h.services.Verify(r.Context()); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(report, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
 		return
 	}
 }
 
-func Error(err error, w http.ResponseWriter, status int) {
-	log.Println(err.Error())
+// @Summary Liveness
+// @Tags health
+// @Description the process is alive and serves http requests
+// @ID healthz
+// @Produce  json
+// @Success 200 {object} models.Response
+// @Router /healthz [get]
+func (h *Handler) healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	res, err := easyjson.Marshal(&models.Response{Message: models.HealthStatusOK})
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// @Summary Readiness
+// @Tags health
+// @Description the database is available, migrations are at the expected version and background workers are running; fails while the server is shutting down
+// @ID readyz
+// @Produce  json
+// @Success 200 {object} models.Readiness
+// @Failure 503 {object} models.Readiness
+// @Router /readyz [get]
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	readiness := h.services.Ready(r.Context())
+
+	status := http.StatusOK
+	if !readiness.Ready() {
+		status = http.StatusServiceUnavailable
+		logger.WarnContext(r.Context(), "сервер не готов принимать запросы", "status", readiness.Status)
+	}
+
+	res, err := easyjson.Marshal(readiness)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write(res)
+}
+
+// operationError отвечает на ошибку операции с балансом: на превышение лимита и операцию,
+// отклоненную проверкой на мошенничество, - 422, на операцию, отправленную на проверку, - 202
+// с номером решения, на остальные ошибки - 500
+func operationError(err error, w http.ResponseWriter, r *http.Request) {
+	var limitErr *service.LimitExceededError
+	var riskErr *service.RiskError
+
+	switch {
+	case errors.As(err, &limitErr):
+		writeOperationError(err, w, r, http.StatusUnprocessableEntity, limitErr.Response())
+	case errors.As(err, &riskErr) && riskErr.Decision.Decision == models.RiskReview:
+		writeOperationError(err, w, r, http.StatusAccepted, riskErr.Response())
+	case errors.As(err, &riskErr):
+		writeOperationError(err, w, r, http.StatusUnprocessableEntity, riskErr.Response())
+	default:
+		Error(err, w, r, http.StatusInternalServerError)
+	}
+}
+
+func writeOperationError(err error, w http.ResponseWriter, r *http.Request, status int, response easyjson.Marshaler) {
+	logger.InfoContext(r.Context(), "ошибка при обработке запроса", "status", status, "error", err)
+	w.WriteHeader(status)
+	if _, err = easyjson.MarshalToWriter(response, w); err != nil {
+		logger.ErrorContext(r.Context(), "ошибка при отправке ответа", "error", err)
+	}
+}
+
+// Error отправляет ошибку клиенту и пишет ее в лог: ошибки клиента (4xx) на уровне info,
+// ошибки сервиса на уровне error
+func Error(err error, w http.ResponseWriter, r *http.Request, status int) {
+	level := logger.LevelError
+	if status < http.StatusInternalServerError {
+		level = logger.LevelInfo
+	}
+	logger.Default().Log(r.Context(), level, "ошибка при обработке запроса", "status", status, "error", err)
+
 	response := &models.Response{
 		Message: err.Error(),
 	}
 	res, err := easyjson.Marshal(response)
 	if err != nil {
-		log.Println(err.Error())
+		logger.ErrorContext(r.Context(), "ошибка при отправке ответа", "error", err)
 		return
 	}
 	w.WriteHeader(status)
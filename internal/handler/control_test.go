@@ -3,6 +3,7 @@ package handler
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -35,7 +36,7 @@ func TestHandler_getBalance(t *testing.T) {
 				Id: 1,
 			},
 			mockBehavior: func(s *mock_service.MockControl, user models.User) {
-				s.EXPECT().GetBalance(user.Id).Return(
+				s.EXPECT().GetBalance(gomock.Any(), user.Id).Return(
 					&models.User{
 						Id:      1,
 						Balance: 100}, nil)
@@ -51,7 +52,7 @@ func TestHandler_getBalance(t *testing.T) {
 				Id: 10,
 			},
 			mockBehavior: func(s *mock_service.MockControl, user models.User) {
-				s.EXPECT().GetBalance(user.Id).Return(
+				s.EXPECT().GetBalance(gomock.Any(), user.Id).Return(
 					nil, errors.New("wrong userid"))
 			},
 			expectedStatusCode:  http.StatusInternalServerError,
@@ -94,6 +95,78 @@ func TestHandler_getBalance(t *testing.T) {
 	}
 }
 
+func TestHandler_getBalanceAt(t *testing.T) {
+	at := time.Date(2022, 10, 01, 12, 0, 0, 0, time.UTC)
+
+	type mockBehavior func(s *mock_service.MockControl)
+
+	testTable := []struct {
+		name                string
+		target              string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			target: "/users/1/balance?at=2022-10-01T12:00:00Z",
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().GetBalanceAt(gomock.Any(), 1, at).Return(
+					&models.BalanceAt{
+						UserID:  1,
+						Balance: 100,
+						Reserve: 20,
+						At:      at}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"userid":1,"balance":100,"reserve":20,"at":"2022-10-01T12:00:00Z"}`,
+		},
+
+		{
+			name:   "error service",
+			target: "/users/1/balance",
+			mockBehavior: func(s *mock_service.MockControl) {
+				s.EXPECT().GetBalanceAt(gomock.Any(), 1, gomock.Any()).Return(
+					nil, errors.New("some error"))
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"some error"}`,
+		},
+
+		{
+			name:                "error wrong moment",
+			target:              "/users/1/balance?at=yesterday",
+			mockBehavior:        func(s *mock_service.MockControl) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"момент времени должен быть в формате RFC3339 либо yyyy-mm-dd"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			control := mock_service.NewMockControl(c)
+			testCase.mockBehavior(control)
+
+			services := &service.Service{Control: control}
+			h := NewHandler(services)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/users/{id:[0-9]+}/balance", h.getBalanceAt).Methods("GET")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
 func TestHandler_replenishmentBalance(t *testing.T) {
 
 	type mockBehavior func(s *mock_service.MockControl, replenishment models.Replenishment)
@@ -115,7 +188,7 @@ func TestHandler_replenishmentBalance(t *testing.T) {
 				Date:   "2022-11-01",
 			},
 			mockBehavior: func(s *mock_service.MockControl, replenishment models.Replenishment) {
-				s.EXPECT().ReplenishmentBalance(&replenishment).Return(nil)
+				s.EXPECT().ReplenishmentBalance(gomock.Any(), &replenishment).Return(nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"баланс пополнен"}`,
@@ -188,12 +261,100 @@ func TestHandler_transfer(t *testing.T) {
 				Date:       "2022-08-01",
 			},
 			mockBehavior: func(s *mock_service.MockControl, money models.Money) {
-				s.EXPECT().Transfer(&money).Return(nil)
+				s.EXPECT().Transfer(gomock.Any(), &money).Return(nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"message":"перевод стредств выполнен"}`,
+		},
+
+		{
+			name:      "OK with comment and metadata",
+			inputBody: `{"fromuserid":1,"touserid":2,"amount":100,"comment":"за обед","metadata":{"order":"15"}}`,
+			inputMoney: models.Money{
+				FromUserID: 1,
+				ToUserID:   2,
+				Amount:     100,
+				Note:       models.Note{Comment: "за обед", Metadata: models.Metadata{"order": "15"}},
+			},
+			mockBehavior: func(s *mock_service.MockControl, money models.Money) {
+				s.EXPECT().Transfer(gomock.Any(), &money).Return(nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"перевод стредств выполнен"}`,
 		},
 
+		{
+			name:                "error empty metadata key",
+			inputBody:           `{"fromuserid":1,"touserid":2,"amount":100,"metadata":{"":"15"}}`,
+			mockBehavior:        func(s *mock_service.MockControl, money models.Money) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"metadata: ключ метаданных не может быть пустым."}`,
+		},
+
+		{
+			name:      "error limit exceeded",
+			inputBody: `{"fromuserid":1,"touserid":2,"amount":400,"date":"2022-08-01"}`,
+			inputMoney: models.Money{
+				FromUserID: 1,
+				ToUserID:   2,
+				Amount:     400,
+				Date:       "2022-08-01",
+			},
+			mockBehavior: func(s *mock_service.MockControl, money models.Money) {
+				s.EXPECT().Transfer(gomock.Any(), &money).Return(&service.LimitExceededError{
+					Operation: models.OperationTransfer,
+					Kind:      models.LimitDaily,
+					Limit:     1000,
+					Remaining: 300,
+				})
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedRequestBody: `{"message":"превышен лимит операций: сумма операций transfer за сутки не может превышать 1000, доступно 300",` +
+				`"code":"limit_exceeded","operation":"transfer","kind":"daily","limit":1000,"remaining":300}`,
+		},
+
+		{
+			name:      "accepted for review",
+			inputBody: `{"fromuserid":1,"touserid":2,"amount":400,"date":"2022-08-01"}`,
+			inputMoney: models.Money{
+				FromUserID: 1,
+				ToUserID:   2,
+				Amount:     400,
+				Date:       "2022-08-01",
+			},
+			mockBehavior: func(s *mock_service.MockControl, money models.Money) {
+				s.EXPECT().Transfer(gomock.Any(), &money).Return(&service.RiskError{Decision: &models.RiskDecision{
+					ID:       7,
+					Decision: models.RiskReview,
+					Reasons:  []string{"large-transfer: сумма 400 не меньше 300"},
+				}})
+			},
+			expectedStatusCode: http.StatusAccepted,
+			expectedRequestBody: `{"message":"операция отправлена на проверку: large-transfer: сумма 400 не меньше 300",` +
+				`"code":"risk_review","id":7,"decision":"review","reasons":["large-transfer: сумма 400 не меньше 300"]}`,
+		},
+
+		{
+			name:      "error denied",
+			inputBody: `{"fromuserid":1,"touserid":2,"amount":400,"date":"2022-08-01"}`,
+			inputMoney: models.Money{
+				FromUserID: 1,
+				ToUserID:   2,
+				Amount:     400,
+				Date:       "2022-08-01",
+			},
+			mockBehavior: func(s *mock_service.MockControl, money models.Money) {
+				s.EXPECT().Transfer(gomock.Any(), &money).Return(&service.RiskError{Decision: &models.RiskDecision{
+					ID:       8,
+					Decision: models.RiskDeny,
+					Reasons:  []string{"many-recipients"},
+				}})
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedRequestBody: `{"message":"операция отклонена проверкой на мошенничество: many-recipients",` +
+				`"code":"risk_denied","id":8,"decision":"deny","reasons":["many-recipients"]}`,
+		},
+
 		{
 			name:                "error fromUserId <=0",
 			inputBody:           `{"fromuserid":-1,"touserid":2,"amount":100,"date":"2022-08-01"}`,
@@ -274,7 +435,7 @@ func TestHandler_getHistory(t *testing.T) {
 				Direction: "desc",
 			},
 			mockBehavior: func(s *mock_service.MockControl, requestHistory models.RequestHistory) {
-				s.EXPECT().GetHistory(&requestHistory).Return([]models.History{{
+				s.EXPECT().GetHistory(gomock.Any(), &requestHistory).Return([]models.History{{
 					Date:        time.Date(2022, 11, 01, 0, 0, 0, 0, time.UTC),
 					Amount:      500,
 					Description: "Пополнение баланса",
@@ -284,6 +445,36 @@ func TestHandler_getHistory(t *testing.T) {
 			expectedRequestBody: `{"entity":[{"date":"2022-11-01T00:00:00Z","amount":500,"description":"Пополнение баланса"}]}`,
 		},
 
+		{
+			name:      "OK by metadata key",
+			inputBody: `{"userid":1,"sortfield":"date","direction":"asc","metadatakey":"order"}`,
+			inputRequestHistory: models.RequestHistory{
+				UserID:      1,
+				SortField:   "date",
+				Direction:   "asc",
+				MetadataKey: "order",
+			},
+			mockBehavior: func(s *mock_service.MockControl, requestHistory models.RequestHistory) {
+				s.EXPECT().GetHistory(gomock.Any(), &requestHistory).Return([]models.History{{
+					Date:        time.Date(2022, 11, 01, 0, 0, 0, 0, time.UTC),
+					Amount:      50,
+					Description: "Перевод средств пользователю 2",
+					Note:        models.Note{Comment: "за обед", Metadata: models.Metadata{"order": "15"}},
+				}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedRequestBody: `{"entity":[{"date":"2022-11-01T00:00:00Z","amount":50,"description":"Перевод средств пользователю 2",` +
+				`"comment":"за обед","metadata":{"order":"15"}}]}`,
+		},
+
+		{
+			name:                "error metadata value without key",
+			inputBody:           `{"userid":1,"metadatavalue":"15"}`,
+			mockBehavior:        func(s *mock_service.MockControl, requestHistory models.RequestHistory) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"metadatavalue: значение метаданных указывается вместе с ключом."}`,
+		},
+
 		{
 			name:      "error userId <= 0",
 			inputBody: `{"userid":0,"sortfield":"","direction":""}`,
@@ -340,7 +531,7 @@ func TestHandler_createReport(t *testing.T) {
 				Year:  2022,
 			},
 			mockBehavior: func(s *mock_service.MockControl, requestReport models.RequestReport) {
-				s.EXPECT().CreateReport(&requestReport).Return("localhost:8081/file/report.cvs", nil)
+				s.EXPECT().CreateReport(gomock.Any(), &requestReport).Return("localhost:8081/file/report.cvs", nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"localhost:8081/file/report.cvs"}`,
@@ -413,7 +604,7 @@ func TestHandler_reservation(t *testing.T) {
 				OrderID:   12,
 			},
 			mockBehavior: func(s *mock_service.MockControl, transaction models.Transaction) {
-				s.EXPECT().Reservation(&transaction).Return(nil)
+				s.EXPECT().Reservation(gomock.Any(), &transaction).Return(nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"резервирование средств прошло успешно"}`,
@@ -500,7 +691,7 @@ func TestHandler_confirmation(t *testing.T) {
 				OrderID:   12,
 			},
 			mockBehavior: func(s *mock_service.MockControl, transaction models.Transaction) {
-				s.EXPECT().Confirmation(&transaction).Return(nil)
+				s.EXPECT().Confirmation(gomock.Any(), &transaction).Return(nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"средства из резерва были списаны успешно"}`,
@@ -587,7 +778,7 @@ func TestHandler_cancelReservation(t *testing.T) {
 				OrderID:   12,
 			},
 			mockBehavior: func(s *mock_service.MockControl, transaction models.Transaction) {
-				s.EXPECT().CancelReservation(&transaction).Return(nil)
+				s.EXPECT().CancelReservation(gomock.Any(), &transaction).Return(nil)
 			},
 			expectedStatusCode:  http.StatusOK,
 			expectedRequestBody: `{"message":"разрезервирование средств прошло успешно"}`,
@@ -651,3 +842,357 @@ func TestHandler_cancelReservation(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_batch(t *testing.T) {
+
+	type mockBehavior func(s *mock_service.MockControl, requestBatch models.RequestBatch)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		inputBatch          models.RequestBatch
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"mode":"atomic","operations":[{"type":"topup","userid":1,"amount":100}]}`,
+			inputBatch: models.RequestBatch{
+				Mode: models.BatchModeAtomic,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 1, Amount: 100},
+				},
+			},
+			mockBehavior: func(s *mock_service.MockControl, requestBatch models.RequestBatch) {
+				s.EXPECT().Batch(gomock.Any(), &requestBatch).Return(&models.BatchResults{
+					Mode:      models.BatchModeAtomic,
+					Committed: true,
+					Entity: []models.BatchResult{
+						{Index: 0, Type: models.OperationTopup, Status: models.BatchStatusOK},
+					},
+				}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"mode":"atomic","committed":true,"entity":[{"index":0,"type":"topup","status":"ok"}]}`,
+		},
+
+		{
+			name:                "error wrong mode",
+			inputBody:           `{"mode":"all","operations":[{"type":"topup","userid":1,"amount":100}]}`,
+			mockBehavior:        func(s *mock_service.MockControl, requestBatch models.RequestBatch) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"mode: режим выполнения должен быть atomic или independent."}`,
+		},
+
+		{
+			name:                "error empty operations",
+			inputBody:           `{"mode":"independent","operations":[]}`,
+			mockBehavior:        func(s *mock_service.MockControl, requestBatch models.RequestBatch) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"operations: список операций не может быть пустым."}`,
+		},
+
+		{
+			name:      "error service",
+			inputBody: `{"mode":"atomic","operations":[{"type":"topup","userid":1,"amount":100}]}`,
+			inputBatch: models.RequestBatch{
+				Mode: models.BatchModeAtomic,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 1, Amount: 100},
+				},
+			},
+			mockBehavior: func(s *mock_service.MockControl, requestBatch models.RequestBatch) {
+				s.EXPECT().Batch(gomock.Any(), &requestBatch).Return(nil, errors.New("db error"))
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"db error"}`,
+		},
+
+		{
+			name:      "error interrupted",
+			inputBody: `{"mode":"independent","operations":[{"type":"topup","userid":1,"amount":100},{"type":"topup","userid":2,"amount":100}]}`,
+			inputBatch: models.RequestBatch{
+				Mode: models.BatchModeIndependent,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 1, Amount: 100},
+					{Type: models.OperationTopup, UserID: 2, Amount: 100},
+				},
+			},
+			mockBehavior: func(s *mock_service.MockControl, requestBatch models.RequestBatch) {
+				s.EXPECT().Batch(gomock.Any(), &requestBatch).Return(&models.BatchResults{
+					Mode:      models.BatchModeIndependent,
+					Committed: true,
+					Entity: []models.BatchResult{
+						{Index: 0, Type: models.OperationTopup, Status: models.BatchStatusOK},
+						{Index: 1, Type: models.OperationTopup, Status: models.BatchStatusError, Message: "db error"},
+					},
+				}, fmt.Errorf("%w: db error", service.ErrBatchInterrupted))
+			},
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedRequestBody: `{"mode":"independent","committed":true,"entity":[{"index":0,"type":"topup","status":"ok"},{"index":1,"type":"topup","status":"error","message":"db error"}]}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			control := mock_service.NewMockControl(c)
+			testCase.mockBehavior(control, testCase.inputBatch)
+
+			services := &service.Service{Control: control}
+			h := NewHandler(services)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/batch", h.batch).Methods("POST")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/batch",
+				bytes.NewBufferString(testCase.inputBody))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_reconciliation(t *testing.T) {
+	checkedAt := time.Date(2022, 10, 01, 12, 0, 0, 0, time.UTC)
+	report := &models.ReconciliationReport{
+		CheckedAt:  checkedAt,
+		Users:      2,
+		Mismatches: 1,
+		Entity: []models.BalanceCheck{
+			{UserID: 2, Balance: 120, ExpectedBalance: 100},
+		},
+	}
+
+	type mockBehavior func(s *mock_service.MockReconciliation)
+
+	testTable := []struct {
+		name                string
+		target              string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK json",
+			target: "/reconciliation",
+			mockBehavior: func(s *mock_service.MockReconciliation) {
+				s.EXPECT().Reconcile(gomock.Any()).Return(report, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"checkedat":"2022-10-01T12:00:00Z","users":2,"mismatches":1,"entity":[{"userid":2,"balance":120,"expectedbalance":100,"reserve":0,"expectedreserve":0,"reservedetails":0}]}`,
+		},
+
+		{
+			name:   "OK csv",
+			target: "/reconciliation?format=csv",
+			mockBehavior: func(s *mock_service.MockReconciliation) {
+				s.EXPECT().Reconcile(gomock.Any()).Return(report, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: "userid;balance;expectedbalance;reserve;expectedreserve;reservedetails\n2;120;100;0;0;0\n",
+		},
+
+		{
+			name:   "error service",
+			target: "/reconciliation",
+			mockBehavior: func(s *mock_service.MockReconciliation) {
+				s.EXPECT().Reconcile(gomock.Any()).Return(nil, errors.New("some error"))
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"some error"}`,
+		},
+
+		{
+			name:                "error wrong format",
+			target:              "/reconciliation?format=xml",
+			mockBehavior:        func(s *mock_service.MockReconciliation) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"формат должен быть json либо csv"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			reconciliation := mock_service.NewMockReconciliation(c)
+			testCase.mockBehavior(reconciliation)
+
+			services := &service.Service{Reconciliation: reconciliation}
+			h := NewHandler(services)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/reconciliation", h.reconciliation).Methods("GET")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.target, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_auditVerify(t *testing.T) {
+	checkedAt := time.Date(2022, 10, 01, 12, 0, 0, 0, time.UTC)
+
+	type mockBehavior func(s *mock_service.MockAudit)
+
+	testTable := []struct {
+		name                string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockAudit) {
+				s.EXPECT().Verify(gomock.Any()).Return(
+					&models.AuditReport{CheckedAt: checkedAt, Records: 10, Checkpoints: 1, Valid: true}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"checkedat":"2022-10-01T12:00:00Z","records":10,"checkpoints":1,"valid":true}`,
+		},
+
+		{
+			name: "OK broken",
+			mockBehavior: func(s *mock_service.MockAudit) {
+				s.EXPECT().Verify(gomock.Any()).Return(
+					&models.AuditReport{
+						CheckedAt: checkedAt,
+						Records:   3,
+						Broken:    &models.AuditBreak{LogID: 7, UserID: 1, Reason: "ссылка на предыдущую запись нарушена"},
+					}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"checkedat":"2022-10-01T12:00:00Z","records":3,"checkpoints":0,"valid":false,"broken":{"logid":7,"userid":1,"reason":"ссылка на предыдущую запись нарушена"}}`,
+		},
+
+		{
+			name: "error service",
+			mockBehavior: func(s *mock_service.MockAudit) {
+				s.EXPECT().Verify(gomock.Any()).Return(nil, errors.New("some error"))
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"some error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			audit := mock_service.NewMockAudit(c)
+			testCase.mockBehavior(audit)
+
+			services := &service.Service{Audit: audit}
+			h := NewHandler(services)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/audit/verify", h.auditVerify).Methods("GET")
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/audit/verify", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_healthz(t *testing.T) {
+	h := NewHandler(&service.Service{})
+
+	r := mux.NewRouter()
+	r.HandleFunc("/healthz", h.healthz).Methods("GET")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"message":"ok"}`, w.Body.String())
+}
+
+func TestHandler_readyz(t *testing.T) {
+
+	type mockBehavior func(s *mock_service.MockHealth)
+
+	testTable := []struct {
+		name                string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(s *mock_service.MockHealth) {
+				s.EXPECT().Ready(gomock.Any()).Return(&models.Readiness{
+					Status: models.HealthStatusOK,
+					Checks: []models.HealthCheck{{Name: "database", Status: models.HealthStatusOK}},
+				})
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"status":"ok","checks":[{"name":"database","status":"ok"}]}`,
+		},
+
+		{
+			name: "error database unavailable",
+			mockBehavior: func(s *mock_service.MockHealth) {
+				s.EXPECT().Ready(gomock.Any()).Return(&models.Readiness{
+					Status: models.HealthStatusFail,
+					Checks: []models.HealthCheck{{Name: "database", Status: models.HealthStatusFail, Error: "connection refused"}},
+				})
+			},
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedRequestBody: `{"status":"fail","checks":[{"name":"database","status":"fail","error":"connection refused"}]}`,
+		},
+
+		{
+			name: "draining",
+			mockBehavior: func(s *mock_service.MockHealth) {
+				s.EXPECT().Ready(gomock.Any()).Return(&models.Readiness{
+					Status: models.HealthStatusDraining,
+					Checks: []models.HealthCheck{},
+				})
+			},
+			expectedStatusCode:  http.StatusServiceUnavailable,
+			expectedRequestBody: `{"status":"draining","checks":[]}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			health := mock_service.NewMockHealth(c)
+			testCase.mockBehavior(health)
+
+			services := &service.Service{Health: health}
+			h := NewHandler(services)
+
+			r := mux.NewRouter()
+			r.HandleFunc("/readyz", h.readyz).Methods("GET")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+
+	"github.com/mailru/easyjson"
+)
+
+// @Summary Fee quote
+// @Tags fees
+// @Description calculates the fee for a transfer or reservation without executing it. The fee is charged from the payer's main balance on top of the amount, total is the amount the balance must cover
+// @ID quote-fee
+// @Accept  json
+// @Produce  json
+// @Param input body models.FeeRequest true "operation information"
+// @Success 200 {object} models.FeeQuote
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /fees/quote [post]
+func (h *Handler) quoteFee(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var request models.FeeRequest
+	var quote *models.FeeQuote
+
+	if err = easyjson.UnmarshalFromReader(r.Body, &request); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+	logger.AddAttrs(r.Context(), "operation", request.Operation)
+
+	if err = request.Validate(); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if quote, err = h.services.QuoteFee(r.Context(), &request); err != nil {
+		if errors.Is(err, service.ErrServiceNotFound) {
+			Error(err, w, r, http.StatusNotFound)
+			return
+		}
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(quote, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+	mock_service "userbalance/internal/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_quoteFee(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockFees)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK transfer",
+			inputBody: `{"operation":"transfer","amount":1000}`,
+			mockBehavior: func(s *mock_service.MockFees) {
+				s.EXPECT().QuoteFee(gomock.Any(), &models.FeeRequest{Operation: models.OperationTransfer, Amount: 1000}).
+					Return(&models.FeeQuote{Operation: models.OperationTransfer, Amount: 1000, Fee: 10, Total: 1010, Rule: "transfer"}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"operation":"transfer","amount":1000,"fee":10,"total":1010,"rule":"transfer"}`,
+		},
+
+		{
+			name:      "OK reserve without fee",
+			inputBody: `{"operation":"reserve","serviceid":3,"amount":500}`,
+			mockBehavior: func(s *mock_service.MockFees) {
+				s.EXPECT().QuoteFee(gomock.Any(), &models.FeeRequest{Operation: models.OperationReserve, ServiceID: 3, Amount: 500}).
+					Return(&models.FeeQuote{Operation: models.OperationReserve, ServiceID: 3, Amount: 500, Total: 500}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"operation":"reserve","serviceid":3,"amount":500,"fee":0,"total":500}`,
+		},
+
+		{
+			name:                "error unknown operation",
+			inputBody:           `{"operation":"topup","amount":1000}`,
+			mockBehavior:        func(s *mock_service.MockFees) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"operation: комиссия рассчитывается для операций transfer и reserve."}`,
+		},
+
+		{
+			name:                "error reserve without service",
+			inputBody:           `{"operation":"reserve","amount":1000}`,
+			mockBehavior:        func(s *mock_service.MockFees) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"serviceid: id услуги не может быть не указан для резервирования."}`,
+		},
+
+		{
+			name:      "error service not found",
+			inputBody: `{"operation":"reserve","serviceid":9,"amount":1000}`,
+			mockBehavior: func(s *mock_service.MockFees) {
+				s.EXPECT().QuoteFee(gomock.Any(), gomock.Any()).Return(nil, service.ErrServiceNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"message":"услуга не найдена"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			fees := mock_service.NewMockFees(c)
+			testCase.mockBehavior(fees)
+
+			h := NewHandler(&service.Service{Fees: fees})
+
+			r := mux.NewRouter()
+			r.HandleFunc("/fees/quote", h.quoteFee).Methods("POST")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("POST", "/fees/quote", bytes.NewBufferString(testCase.inputBody)))
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"net/http"
+	"userbalance/internal/metrics"
 	"userbalance/internal/service"
+	"userbalance/internal/tracing"
 
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -21,6 +23,7 @@ func NewHandler(services *service.Service) *Handler {
 func (h *Handler) Init() *mux.Router {
 	r := mux.NewRouter()
 	r.HandleFunc("/", h.getBalance).Methods("POST")
+	r.HandleFunc("/users/{id:[0-9]+}/balance", h.getBalanceAt).Methods("GET")
 	r.HandleFunc("/topup", h.replenishmentBalance).Methods("POST")
 	r.HandleFunc("/transfer", h.transfer).Methods("POST")
 	r.HandleFunc("/history", h.getHistory).Methods("POST")
@@ -28,11 +31,35 @@ func (h *Handler) Init() *mux.Router {
 	r.HandleFunc("/reserv", h.reservation).Methods("POST")
 	r.HandleFunc("/confirm", h.confirmation).Methods("POST")
 	r.HandleFunc("/cancel", h.cancelReservation).Methods("POST")
+	r.HandleFunc("/batch", h.batch).Methods("POST")
+	r.HandleFunc("/reconciliation", h.reconciliation).Methods("GET")
+	r.HandleFunc("/audit/verify", h.auditVerify).Methods("GET")
+	r.HandleFunc("/risk/decisions", h.getRiskDecisions).Methods("GET")
+	r.HandleFunc("/risk/reviews/{id:[0-9]+}/approve", h.approveRiskReview).Methods("POST")
+	r.HandleFunc("/risk/reviews/{id:[0-9]+}/reject", h.rejectRiskReview).Methods("POST")
+	r.HandleFunc("/schedules", h.createSchedule).Methods("POST")
+	r.HandleFunc("/schedules", h.getSchedules).Methods("GET")
+	r.HandleFunc("/schedules/{id:[0-9]+}", h.getSchedule).Methods("GET")
+	r.HandleFunc("/schedules/{id:[0-9]+}/runs", h.getScheduleRuns).Methods("GET")
+	r.HandleFunc("/schedules/{id:[0-9]+}/pause", h.pauseSchedule).Methods("POST")
+	r.HandleFunc("/schedules/{id:[0-9]+}/resume", h.resumeSchedule).Methods("POST")
+	r.HandleFunc("/schedules/{id:[0-9]+}/cancel", h.cancelSchedule).Methods("POST")
+	r.HandleFunc("/transfers/pending", h.createPendingTransfer).Methods("POST")
+	r.HandleFunc("/transfers/pending", h.getPendingTransfers).Methods("GET")
+	r.HandleFunc("/transfers/pending/{id:[0-9]+}", h.getPendingTransfer).Methods("GET")
+	r.HandleFunc("/transfers/pending/{id:[0-9]+}/accept", h.acceptPendingTransfer).Methods("POST")
+	r.HandleFunc("/transfers/pending/{id:[0-9]+}/decline", h.declinePendingTransfer).Methods("POST")
+	r.HandleFunc("/fees/quote", h.quoteFee).Methods("POST")
+	r.HandleFunc("/healthz", h.healthz).Methods("GET")
+	r.HandleFunc("/readyz", h.readyz).Methods("GET")
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
 
 	fileServer := http.FileServer(http.Dir("./file/"))
 	r.PathPrefix("/file/").Handler(http.StripPrefix("/file/", fileServer))
 
 	r.PathPrefix("/swagger").Handler(httpSwagger.WrapHandler)
 
+	r.Use(tracing.Middleware, logRequests, metrics.Middleware, h.authenticate, h.rateLimit, h.verifySignature)
+
 	return r
 }
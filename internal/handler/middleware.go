@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+	"userbalance/internal/httpstatus"
+	"userbalance/internal/logger"
+
+	"github.com/gorilla/mux"
+)
+
+const requestIDHeader string = "X-Request-ID"
+
+// requestIDPattern ограничивает идентификатор, принятый от клиента, чтобы в лог
+// не попадали произвольные строки
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// logRequests присваивает запросу идентификатор: переданный клиентом в X-Request-ID
+// либо новый, возвращает его в ответе и пишет запрос в лог после обработки.
+// Тело запроса и заголовки в лог не попадают, id пользователей добавляют обработчики
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = logger.NewRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		ctx := logger.WithRequestID(r.Context(), id)
+		recorder := httpstatus.Wrap(w)
+		start := time.Now()
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		logger.InfoContext(ctx, "запрос обработан",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.Status,
+			"latency_ms", float64(time.Since(start).Microseconds())/1000)
+	})
+}
+
+// routeTemplate возвращает шаблон маршрута mux, по которому обрабатывается запрос
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"userbalance/internal/logger"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogRequests(t *testing.T) {
+	testTable := []struct {
+		name      string
+		requestID string
+		generated bool
+	}{
+		{
+			name:      "request id from client",
+			requestID: "3f2a-17",
+		},
+		{
+			name:      "no request id",
+			generated: true,
+		},
+		{
+			name:      "invalid request id",
+			requestID: "id with spaces\n",
+			generated: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var got string
+
+			r := mux.NewRouter()
+			r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				got = logger.RequestID(r.Context())
+				w.WriteHeader(http.StatusNoContent)
+			})
+			r.Use(logRequests)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			if testCase.requestID != "" {
+				req.Header.Set(requestIDHeader, testCase.requestID)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, got, w.Header().Get(requestIDHeader))
+			if testCase.generated {
+				assert.Len(t, got, 32)
+			} else {
+				assert.Equal(t, testCase.requestID, got)
+			}
+		})
+	}
+}
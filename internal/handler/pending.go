@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+
+	"github.com/gorilla/mux"
+	"github.com/mailru/easyjson"
+)
+
+// @Summary Create pending transfer
+// @Tags pending transfers
+// @Description holds the amount on the sender's reserve account until the recipient accepts or declines the transfer. Transfers not accepted within pendingtransferttl minutes are returned to the sender. Balance, limit and fraud checks are the same as for /transfer
+// @ID create-pending-transfer
+// @Accept  json
+// @Produce  json
+// @Param input body models.Money true "transfer information"
+// @Success 201 {object} models.PendingTransfer
+// @Success 202 {object} models.RiskResponse
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 422 {object} models.LimitExceeded
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /transfers/pending [post]
+func (h *Handler) createPendingTransfer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var money models.Money
+	var transfer *models.PendingTransfer
+
+	if err = easyjson.UnmarshalFromReader(r.Body, &money); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+	logger.AddAttrs(r.Context(), "fromuserid", money.FromUserID, "touserid", money.ToUserID)
+
+	if err = money.Validate(); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if transfer, err = h.services.CreatePendingTransfer(r.Context(), &money); err != nil {
+		operationError(err, w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, err = easyjson.MarshalToWriter(transfer, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Pending transfers
+// @Tags pending transfers
+// @Description transfers sent or received by the user, latest first
+// @ID pending-transfers
+// @Produce  json
+// @Param userid query int false "sender or recipient id"
+// @Param status query string false "pending, accepted, declined or expired"
+// @Param limit query int false "max number of transfers, 100 by default"
+// @Success 200 {object} models.PendingTransfers
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /transfers/pending [get]
+func (h *Handler) getPendingTransfers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var transfers []models.PendingTransfer
+
+	query := r.URL.Query()
+	filter := models.PendingTransferFilter{Status: query.Get("status")}
+	if filter.UserID, err = queryInt(query.Get("userid")); err != nil {
+		Error(errors.New("неверно указан id пользователя"), w, r, http.StatusBadRequest)
+		return
+	}
+	if filter.Limit, err = queryInt(query.Get("limit")); err != nil {
+		Error(errors.New("неверно указано количество записей"), w, r, http.StatusBadRequest)
+		return
+	}
+
+	if err = filter.Validate(); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if transfers, err = h.services.GetPendingTransfers(r.Context(), &filter); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(&models.PendingTransfers{Entity: transfers}, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Pending transfer
+// @Tags pending transfers
+// @ID pending-transfer
+// @Produce  json
+// @Param id path int true "transfer id"
+// @Success 200 {object} models.PendingTransfer
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /transfers/pending/{id} [get]
+func (h *Handler) getPendingTransfer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var id int
+	var transfer *models.PendingTransfer
+
+	if id, err = pendingTransferID(r); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if transfer, err = h.services.GetPendingTransfer(r.Context(), id); err != nil {
+		pendingTransferError(err, w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(transfer, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Accept pending transfer
+// @Tags pending transfers
+// @Description credits the held amount to the recipient. A transfer past its deadline cannot be accepted
+// @ID accept-pending-transfer
+// @Accept  json
+// @Produce  json
+// @Param id path int true "transfer id"
+// @Param input body models.PendingTransferAction true "recipient id"
+// @Success 200 {object} models.PendingTransfer
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /transfers/pending/{id}/accept [post]
+func (h *Handler) acceptPendingTransfer(w http.ResponseWriter, r *http.Request) {
+	h.pendingTransferAction(w, r, h.services.AcceptPendingTransfer)
+}
+
+// @Summary Decline pending transfer
+// @Tags pending transfers
+// @Description returns the held amount to the sender
+// @ID decline-pending-transfer
+// @Accept  json
+// @Produce  json
+// @Param id path int true "transfer id"
+// @Param input body models.PendingTransferAction true "recipient id"
+// @Success 200 {object} models.PendingTransfer
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /transfers/pending/{id}/decline [post]
+func (h *Handler) declinePendingTransfer(w http.ResponseWriter, r *http.Request) {
+	h.pendingTransferAction(w, r, h.services.DeclinePendingTransfer)
+}
+
+// pendingTransferAction выполняет action над переводом из пути запроса по решению получателя из тела запроса
+func (h *Handler) pendingTransferAction(w http.ResponseWriter, r *http.Request,
+	action func(ctx context.Context, id int, userId int) (*models.PendingTransfer, error)) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var id int
+	var request models.PendingTransferAction
+	var transfer *models.PendingTransfer
+
+	if id, err = pendingTransferID(r); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+	if err = easyjson.UnmarshalFromReader(r.Body, &request); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+	logger.AddAttrs(r.Context(), "userid", request.UserID)
+
+	if err = request.Validate(); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if transfer, err = action(r.Context(), id, request.UserID); err != nil {
+		pendingTransferError(err, w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(transfer, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// pendingTransferID возвращает id перевода, ожидающего подтверждения, из пути запроса
+func pendingTransferID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		return 0, errors.New("неверно указан id перевода")
+	}
+	logger.AddAttrs(r.Context(), "transfer", id)
+	return id, nil
+}
+
+func pendingTransferError(err error, w http.ResponseWriter, r *http.Request) {
+	switch {
+	case errors.Is(err, service.ErrPendingTransferNotFound), errors.Is(err, service.ErrUserNotFound):
+		Error(err, w, r, http.StatusNotFound)
+	case errors.Is(err, service.ErrNotTransferRecipient):
+		Error(err, w, r, http.StatusForbidden)
+	case errors.Is(err, service.ErrPendingTransferResolved):
+		Error(err, w, r, http.StatusConflict)
+	default:
+		Error(err, w, r, http.StatusInternalServerError)
+	}
+}
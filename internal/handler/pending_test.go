@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+	mock_service "userbalance/internal/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_pendingTransfer(t *testing.T) {
+	createdAt := time.Date(2022, 10, 01, 12, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+
+	type mockBehavior func(s *mock_service.MockPendingTransfers)
+
+	testTable := []struct {
+		name                string
+		method              string
+		target              string
+		inputBody           string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK create",
+			method:    "POST",
+			target:    "/transfers/pending",
+			inputBody: `{"fromuserid":1,"touserid":2,"amount":100}`,
+			mockBehavior: func(s *mock_service.MockPendingTransfers) {
+				s.EXPECT().CreatePendingTransfer(gomock.Any(), &models.Money{FromUserID: 1, ToUserID: 2, Amount: 100}).
+					Return(&models.PendingTransfer{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 100,
+						Status: models.PendingTransferPending, ExpiresAt: expiresAt, CreatedAt: createdAt}, nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedRequestBody: `{"id":7,"fromuserid":1,"touserid":2,"amount":100,"status":"pending",` +
+				`"expiresat":"2022-10-04T12:00:00Z","createdat":"2022-10-01T12:00:00Z"}`,
+		},
+
+		{
+			name:      "error create insufficient funds",
+			method:    "POST",
+			target:    "/transfers/pending",
+			inputBody: `{"fromuserid":1,"touserid":2,"amount":100}`,
+			mockBehavior: func(s *mock_service.MockPendingTransfers) {
+				s.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Return(nil, service.ErrInsufficientFunds)
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"недостаточно средств"}`,
+		},
+
+		{
+			name:   "OK list",
+			method: "GET",
+			target: "/transfers/pending?userid=2&status=pending",
+			mockBehavior: func(s *mock_service.MockPendingTransfers) {
+				s.EXPECT().GetPendingTransfers(gomock.Any(), &models.PendingTransferFilter{UserID: 2, Status: models.PendingTransferPending}).
+					Return([]models.PendingTransfer{}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"entity":[]}`,
+		},
+
+		{
+			name:                "error list wrong status",
+			method:              "GET",
+			target:              "/transfers/pending?status=done",
+			mockBehavior:        func(s *mock_service.MockPendingTransfers) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"status: состояние может быть pending, accepted, declined либо expired."}`,
+		},
+
+		{
+			name:   "error not found",
+			method: "GET",
+			target: "/transfers/pending/7",
+			mockBehavior: func(s *mock_service.MockPendingTransfers) {
+				s.EXPECT().GetPendingTransfer(gomock.Any(), 7).Return(nil, service.ErrPendingTransferNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"message":"перевод, ожидающий подтверждения, не найден"}`,
+		},
+
+		{
+			name:      "OK accept",
+			method:    "POST",
+			target:    "/transfers/pending/7/accept",
+			inputBody: `{"userid":2}`,
+			mockBehavior: func(s *mock_service.MockPendingTransfers) {
+				s.EXPECT().AcceptPendingTransfer(gomock.Any(), 7, 2).
+					Return(&models.PendingTransfer{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 100,
+						Status: models.PendingTransferAccepted, ExpiresAt: expiresAt, CreatedAt: createdAt, ResolvedAt: &createdAt}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedRequestBody: `{"id":7,"fromuserid":1,"touserid":2,"amount":100,"status":"accepted",` +
+				`"expiresat":"2022-10-04T12:00:00Z","createdat":"2022-10-01T12:00:00Z","resolvedat":"2022-10-01T12:00:00Z"}`,
+		},
+
+		{
+			name:                "error accept validation",
+			method:              "POST",
+			target:              "/transfers/pending/7/accept",
+			inputBody:           `{}`,
+			mockBehavior:        func(s *mock_service.MockPendingTransfers) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"userid: id получателя не может быть не указан либо \u003c= 0."}`,
+		},
+
+		{
+			name:      "error accept other recipient",
+			method:    "POST",
+			target:    "/transfers/pending/7/accept",
+			inputBody: `{"userid":3}`,
+			mockBehavior: func(s *mock_service.MockPendingTransfers) {
+				s.EXPECT().AcceptPendingTransfer(gomock.Any(), 7, 3).Return(nil, service.ErrNotTransferRecipient)
+			},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"message":"перевод адресован другому пользователю"}`,
+		},
+
+		{
+			name:      "error decline resolved",
+			method:    "POST",
+			target:    "/transfers/pending/7/decline",
+			inputBody: `{"userid":2}`,
+			mockBehavior: func(s *mock_service.MockPendingTransfers) {
+				s.EXPECT().DeclinePendingTransfer(gomock.Any(), 7, 2).Return(nil,
+					fmt.Errorf("%w: %s", service.ErrPendingTransferResolved, models.PendingTransferExpired))
+			},
+			expectedStatusCode:  http.StatusConflict,
+			expectedRequestBody: `{"message":"перевод не ожидает подтверждения: expired"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			pending := mock_service.NewMockPendingTransfers(c)
+			testCase.mockBehavior(pending)
+
+			h := NewHandler(&service.Service{PendingTransfers: pending})
+
+			r := mux.NewRouter()
+			r.HandleFunc("/transfers/pending", h.createPendingTransfer).Methods("POST")
+			r.HandleFunc("/transfers/pending", h.getPendingTransfers).Methods("GET")
+			r.HandleFunc("/transfers/pending/{id:[0-9]+}", h.getPendingTransfer).Methods("GET")
+			r.HandleFunc("/transfers/pending/{id:[0-9]+}/accept", h.acceptPendingTransfer).Methods("POST")
+			r.HandleFunc("/transfers/pending/{id:[0-9]+}/decline", h.declinePendingTransfer).Methods("POST")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(testCase.method, testCase.target, bytes.NewBufferString(testCase.inputBody)))
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
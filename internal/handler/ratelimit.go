@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+	"userbalance/internal/models"
+
+	"github.com/mailru/easyjson"
+)
+
+// userLimitedRoutes блокируют строки пользователей, поэтому для них действует также ограничение
+// частоты операций с каждым пользователем из тела запроса
+var userLimitedRoutes = map[string]bool{
+	"/topup":             true,
+	"/transfer":          true,
+	"/reserv":            true,
+	"/confirm":           true,
+	"/cancel":            true,
+	"/batch":             true,
+	"/transfers/pending": true,
+}
+
+// errRequestBodyTooLarge - тело запроса, которое читается целиком до обработчика, больше maxrequestbody
+var errRequestBodyTooLarge = errors.New("тело запроса слишком большое")
+
+// rateLimit ограничивает частоту запросов клиента API, а без аутентификации - адреса клиента,
+// и частоту операций с пользователями. Отклоненный запрос получает 429 и заголовок Retry-After
+func (h *Handler) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		template := routeTemplate(r)
+		if publicRoutes[template] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if allowed, retryAfter := h.services.AllowClient(r.Context(), clientKey(r)); !allowed {
+			tooManyRequests(w, r, retryAfter, errors.New("превышена частота запросов клиента"))
+			return
+		}
+
+		if userLimitedRoutes[template] {
+			userIds, err := requestUserIDs(r, h.services.RateLimit.MaxRequestBody())
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				Error(err, w, r, http.StatusRequestEntityTooLarge)
+				return
+			}
+			for _, userId := range userIds {
+				if allowed, retryAfter := h.services.AllowUser(r.Context(), userId); !allowed {
+					tooManyRequests(w, r, retryAfter, fmt.Errorf("превышена частота операций с пользователем %d", userId))
+					return
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey - имя аутентифицированного клиента либо IP-адрес, если аутентификация отключена
+func clientKey(r *http.Request) string {
+	if principal, ok := r.Context().Value(principalKey{}).(*models.Principal); ok {
+		return principal.Client
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// requestUserIDs читает id пользователей из тела запроса (одной операции либо пакета)
+// и возвращает тело обработчику. Ошибки разбора сообщит обработчик, ограничение по пользователям
+// в этом случае не применяется. Ошибка возвращается, только если тело больше limit байт
+func requestUserIDs(r *http.Request, limit int64) ([]int, error) {
+	body, err := readBody(r, limit)
+	if err != nil {
+		if errors.Is(err, errRequestBodyTooLarge) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	var batch models.RequestBatch
+	if err = easyjson.Unmarshal(body, &batch); err != nil {
+		return nil, nil
+	}
+	operations := batch.Operations
+	if len(operations) == 0 {
+		var operation models.BatchOperation
+		if err = easyjson.Unmarshal(body, &operation); err != nil {
+			return nil, nil
+		}
+		operations = []models.BatchOperation{operation}
+	}
+
+	ids := make([]int, 0, len(operations))
+	seen := make(map[int]bool)
+	for _, operation := range operations {
+		for _, id := range []int{operation.UserID, operation.FromUserID, operation.ToUserID} {
+			if id > 0 && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// readBody читает тело запроса не больше limit байт и возвращает его обработчику.
+// Читается на байт больше limit, чтобы отличить тело длиной limit от более длинного
+func readBody(r *http.Request, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%w: больше %d байт", errRequestBodyTooLarge, limit)
+	}
+	return body, err
+}
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, err error) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	Error(err, w, r, http.StatusTooManyRequests)
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"userbalance/internal/service"
+	mock_service "userbalance/internal/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_rateLimit(t *testing.T) {
+
+	type mockBehavior func(s *mock_service.MockRateLimit)
+
+	testTable := []struct {
+		name               string
+		path               string
+		body               string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+		expectedRetryAfter string
+	}{
+		{
+			name: "OK",
+			path: "/transfer",
+			body: `{"fromuserid":1,"touserid":2,"amount":100}`,
+			mockBehavior: func(s *mock_service.MockRateLimit) {
+				s.EXPECT().AllowClient(gomock.Any(), "ip:192.0.2.1").Return(true, time.Duration(0))
+				s.EXPECT().AllowUser(gomock.Any(), 1).Return(true, time.Duration(0))
+				s.EXPECT().AllowUser(gomock.Any(), 2).Return(true, time.Duration(0))
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name: "OK batch users",
+			path: "/batch",
+			body: `{"mode":"atomic","operations":[{"type":"topup","userid":3,"amount":10},{"type":"reserve","userid":3,"serviceid":1,"orderid":1,"amount":5}]}`,
+			mockBehavior: func(s *mock_service.MockRateLimit) {
+				s.EXPECT().AllowClient(gomock.Any(), "ip:192.0.2.1").Return(true, time.Duration(0))
+				s.EXPECT().AllowUser(gomock.Any(), 3).Return(true, time.Duration(0))
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name: "OK pending transfer users",
+			path: "/transfers/pending",
+			body: `{"fromuserid":1,"touserid":2,"amount":100}`,
+			mockBehavior: func(s *mock_service.MockRateLimit) {
+				s.EXPECT().AllowClient(gomock.Any(), "ip:192.0.2.1").Return(true, time.Duration(0))
+				s.EXPECT().AllowUser(gomock.Any(), 1).Return(true, time.Duration(0))
+				s.EXPECT().AllowUser(gomock.Any(), 2).Return(true, time.Duration(0))
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name:               "OK public route",
+			path:               "/healthz",
+			mockBehavior:       func(s *mock_service.MockRateLimit) {},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name: "client limited",
+			path: "/transfer",
+			body: `{"fromuserid":1,"touserid":2,"amount":100}`,
+			mockBehavior: func(s *mock_service.MockRateLimit) {
+				s.EXPECT().AllowClient(gomock.Any(), "ip:192.0.2.1").Return(false, 1500*time.Millisecond)
+			},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedRetryAfter: "2",
+		},
+
+		{
+			name: "user limited",
+			path: "/transfer",
+			body: `{"fromuserid":1,"touserid":2,"amount":100}`,
+			mockBehavior: func(s *mock_service.MockRateLimit) {
+				s.EXPECT().AllowClient(gomock.Any(), "ip:192.0.2.1").Return(true, time.Duration(0))
+				s.EXPECT().AllowUser(gomock.Any(), 1).Return(false, 200*time.Millisecond)
+			},
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedRetryAfter: "1",
+		},
+
+		{
+			name: "body too large",
+			path: "/transfer",
+			body: `{"fromuserid":1,"touserid":2,"amount":100,"comment":"` + strings.Repeat("a", 1024) + `"}`,
+			mockBehavior: func(s *mock_service.MockRateLimit) {
+				s.EXPECT().AllowClient(gomock.Any(), "ip:192.0.2.1").Return(true, time.Duration(0))
+			},
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			limits := mock_service.NewMockRateLimit(c)
+			limits.EXPECT().MaxRequestBody().Return(int64(1024)).AnyTimes()
+			testCase.mockBehavior(limits)
+
+			h := NewHandler(&service.Service{RateLimit: limits})
+
+			// обработчик должен получить тело запроса целиком
+			echo := func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, testCase.body, string(body))
+				w.WriteHeader(http.StatusOK)
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/transfer", echo).Methods("POST")
+			r.HandleFunc("/batch", echo).Methods("POST")
+			r.HandleFunc("/transfers/pending", echo).Methods("POST")
+			r.HandleFunc("/healthz", echo).Methods("POST")
+			r.Use(h.rateLimit)
+
+			req := httptest.NewRequest("POST", testCase.path, bytes.NewBufferString(testCase.body))
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRetryAfter, w.Header().Get("Retry-After"))
+		})
+	}
+}
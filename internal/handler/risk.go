@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+
+	"github.com/gorilla/mux"
+	"github.com/mailru/easyjson"
+)
+
+// @Summary Fraud screening decisions
+// @Tags risk
+// @Description decisions of fraud screening of transfers and reservations, latest first
+// @ID risk-decisions
+// @Produce  json
+// @Param decision query string false "allow, review or deny"
+// @Param status query string false "review status: pending, approved or rejected"
+// @Param userid query int false "user id"
+// @Param limit query int false "max number of decisions, 100 by default"
+// @Success 200 {object} models.RiskDecisions
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /risk/decisions [get]
+func (h *Handler) getRiskDecisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var decisions []models.RiskDecision
+
+	query := r.URL.Query()
+	filter := models.RiskFilter{
+		Decision: query.Get("decision"),
+		Status:   query.Get("status"),
+	}
+	if filter.UserID, err = queryInt(query.Get("userid")); err != nil {
+		Error(errors.New("неверно указан id пользователя"), w, r, http.StatusBadRequest)
+		return
+	}
+	if filter.Limit, err = queryInt(query.Get("limit")); err != nil {
+		Error(errors.New("неверно указано количество записей"), w, r, http.StatusBadRequest)
+		return
+	}
+
+	if err = filter.Validate(); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if decisions, err = h.services.GetRiskDecisions(r.Context(), &filter); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(&models.RiskDecisions{Entity: decisions}, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Approve operation held for review
+// @Tags risk
+// @Description executes the transfer or reservation held for review by fraud screening, the operation is not screened again
+// @ID approve-risk-review
+// @Produce  json
+// @Param id path int true "decision id"
+// @Success 200 {object} models.RiskDecision
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 422 {object} models.LimitExceeded
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /risk/reviews/{id}/approve [post]
+func (h *Handler) approveRiskReview(w http.ResponseWriter, r *http.Request) {
+	h.resolveRiskReview(w, r, h.services.ApproveRiskReview)
+}
+
+// @Summary Reject operation held for review
+// @Tags risk
+// @Description rejects the transfer or reservation held for review by fraud screening
+// @ID reject-risk-review
+// @Produce  json
+// @Param id path int true "decision id"
+// @Success 200 {object} models.RiskDecision
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /risk/reviews/{id}/reject [post]
+func (h *Handler) rejectRiskReview(w http.ResponseWriter, r *http.Request) {
+	h.resolveRiskReview(w, r, h.services.RejectRiskReview)
+}
+
+// resolveRiskReview принимает решение resolve по операции на проверке от имени клиента запроса
+func (h *Handler) resolveRiskReview(w http.ResponseWriter, r *http.Request,
+	resolve func(ctx context.Context, id int, resolvedBy string) (*models.RiskDecision, error)) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var id int
+	var decision *models.RiskDecision
+
+	if id, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil || id <= 0 {
+		Error(errors.New("неверно указан id решения"), w, r, http.StatusBadRequest)
+		return
+	}
+	logger.AddAttrs(r.Context(), "riskdecision", id)
+
+	var resolvedBy string
+	if principal, ok := r.Context().Value(principalKey{}).(*models.Principal); ok {
+		resolvedBy = principal.Client
+	}
+
+	if decision, err = resolve(r.Context(), id, resolvedBy); err != nil {
+		switch {
+		case errors.Is(err, service.ErrRiskReviewNotFound):
+			Error(err, w, r, http.StatusNotFound)
+		case errors.Is(err, service.ErrRiskReviewResolved):
+			Error(err, w, r, http.StatusConflict)
+		default:
+			operationError(err, w, r)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(decision, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// queryInt разбирает необязательный целочисленный параметр запроса
+func queryInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
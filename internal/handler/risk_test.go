@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+	mock_service "userbalance/internal/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_getRiskDecisions(t *testing.T) {
+	createdAt := time.Date(2022, 10, 01, 12, 0, 0, 0, time.UTC)
+
+	type mockBehavior func(s *mock_service.MockRisk)
+
+	testTable := []struct {
+		name                string
+		target              string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			target: "/risk/decisions?decision=review&status=pending&userid=1",
+			mockBehavior: func(s *mock_service.MockRisk) {
+				s.EXPECT().GetRiskDecisions(gomock.Any(), &models.RiskFilter{Decision: models.RiskReview, Status: models.RiskStatusPending, UserID: 1}).
+					Return([]models.RiskDecision{{
+						ID:        7,
+						Decision:  models.RiskReview,
+						Reasons:   []string{"large-transfer"},
+						Status:    models.RiskStatusPending,
+						Operation: models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100},
+						CreatedAt: createdAt,
+					}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedRequestBody: `{"entity":[{"id":7,"decision":"review","reasons":["large-transfer"],"status":"pending",` +
+				`"operation":{"type":"transfer","userid":1,"touserid":2,"amount":100},"createdat":"2022-10-01T12:00:00Z"}]}`,
+		},
+
+		{
+			name:                "error wrong status",
+			target:              "/risk/decisions?status=done",
+			mockBehavior:        func(s *mock_service.MockRisk) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"status: состояние может быть pending, approved либо rejected."}`,
+		},
+
+		{
+			name:                "error wrong limit",
+			target:              "/risk/decisions?limit=ten",
+			mockBehavior:        func(s *mock_service.MockRisk) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"неверно указано количество записей"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			risk := mock_service.NewMockRisk(c)
+			testCase.mockBehavior(risk)
+
+			h := NewHandler(&service.Service{Risk: risk})
+
+			r := mux.NewRouter()
+			r.HandleFunc("/risk/decisions", h.getRiskDecisions).Methods("GET")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", testCase.target, nil))
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_resolveRiskReview(t *testing.T) {
+	createdAt := time.Date(2022, 10, 01, 12, 0, 0, 0, time.UTC)
+	resolvedAt := createdAt.Add(time.Hour)
+
+	type mockBehavior func(s *mock_service.MockRisk)
+
+	testTable := []struct {
+		name                string
+		target              string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK approve",
+			target: "/risk/reviews/7/approve",
+			mockBehavior: func(s *mock_service.MockRisk) {
+				s.EXPECT().ApproveRiskReview(gomock.Any(), 7, "support").Return(&models.RiskDecision{
+					ID:         7,
+					Decision:   models.RiskReview,
+					Reasons:    []string{"large-transfer"},
+					Status:     models.RiskStatusApproved,
+					Operation:  models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100},
+					CreatedAt:  createdAt,
+					ResolvedAt: &resolvedAt,
+					ResolvedBy: "support",
+				}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedRequestBody: `{"id":7,"decision":"review","reasons":["large-transfer"],"status":"approved",` +
+				`"operation":{"type":"transfer","userid":1,"touserid":2,"amount":100},"createdat":"2022-10-01T12:00:00Z",` +
+				`"resolvedat":"2022-10-01T13:00:00Z","resolvedby":"support"}`,
+		},
+
+		{
+			name:   "error reject not found",
+			target: "/risk/reviews/7/reject",
+			mockBehavior: func(s *mock_service.MockRisk) {
+				s.EXPECT().RejectRiskReview(gomock.Any(), 7, "support").Return(nil, service.ErrRiskReviewNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"message":"операция, ожидающая проверки, не найдена"}`,
+		},
+
+		{
+			name:   "error already resolved",
+			target: "/risk/reviews/7/approve",
+			mockBehavior: func(s *mock_service.MockRisk) {
+				s.EXPECT().ApproveRiskReview(gomock.Any(), 7, "support").Return(nil,
+					fmt.Errorf("%w: %s", service.ErrRiskReviewResolved, models.RiskStatusRejected))
+			},
+			expectedStatusCode:  http.StatusConflict,
+			expectedRequestBody: `{"message":"по операции уже принято решение: rejected"}`,
+		},
+
+		{
+			name:   "error operation failed",
+			target: "/risk/reviews/7/approve",
+			mockBehavior: func(s *mock_service.MockRisk) {
+				s.EXPECT().ApproveRiskReview(gomock.Any(), 7, "support").Return(nil, errors.New("some error"))
+			},
+			expectedStatusCode:  http.StatusInternalServerError,
+			expectedRequestBody: `{"message":"some error"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			risk := mock_service.NewMockRisk(c)
+			testCase.mockBehavior(risk)
+
+			h := NewHandler(&service.Service{Risk: risk})
+
+			r := mux.NewRouter()
+			r.HandleFunc("/risk/reviews/{id:[0-9]+}/approve", h.approveRiskReview).Methods("POST")
+			r.HandleFunc("/risk/reviews/{id:[0-9]+}/reject", h.rejectRiskReview).Methods("POST")
+
+			principal := &models.Principal{Client: "support", Scopes: []string{models.ScopeRiskReview}}
+			req := httptest.NewRequest("POST", testCase.target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), principalKey{}, principal))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
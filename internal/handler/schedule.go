@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+
+	"github.com/gorilla/mux"
+	"github.com/mailru/easyjson"
+)
+
+// @Summary Create scheduled operation
+// @Tags schedules
+// @Description schedules a transfer or reservation: once at runat, every interval starting at runat (at once if runat is omitted) or by a cron expression. Due operations are executed with the same balance, limit and fraud checks as /transfer and /reserv, failed attempts are retried on insufficient funds
+// @ID create-schedule
+// @Accept  json
+// @Produce  json
+// @Param input body models.Schedule true "operation and schedule: kind once, interval or cron"
+// @Success 201 {object} models.Schedule
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /schedules [post]
+func (h *Handler) createSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var schedule models.Schedule
+
+	if err = easyjson.UnmarshalFromReader(r.Body, &schedule); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+	logger.AddAttrs(r.Context(), "userid", schedule.UserID, "operation", schedule.Operation)
+
+	if err = schedule.Validate(); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if err = checkScope(r.Context(), schedule.Operation); err != nil {
+		Error(err, w, r, http.StatusForbidden)
+		return
+	}
+
+	if err = h.services.CreateSchedule(r.Context(), &schedule); err != nil {
+		scheduleError(err, w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, err = easyjson.MarshalToWriter(&schedule, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Scheduled operations
+// @Tags schedules
+// @Description scheduled operations, latest created first
+// @ID schedules
+// @Produce  json
+// @Param userid query int false "user id"
+// @Param status query string false "active, paused, completed, failed or canceled"
+// @Param limit query int false "max number of operations, 100 by default"
+// @Success 200 {object} models.Schedules
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /schedules [get]
+func (h *Handler) getSchedules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var schedules []models.Schedule
+
+	query := r.URL.Query()
+	filter := models.ScheduleFilter{Status: query.Get("status")}
+	if filter.UserID, err = queryInt(query.Get("userid")); err != nil {
+		Error(errors.New("неверно указан id пользователя"), w, r, http.StatusBadRequest)
+		return
+	}
+	if filter.Limit, err = queryInt(query.Get("limit")); err != nil {
+		Error(errors.New("неверно указано количество записей"), w, r, http.StatusBadRequest)
+		return
+	}
+
+	if err = filter.Validate(); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if schedules, err = h.services.GetSchedules(r.Context(), &filter); err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(&models.Schedules{Entity: schedules}, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Scheduled operation
+// @Tags schedules
+// @ID schedule
+// @Produce  json
+// @Param id path int true "scheduled operation id"
+// @Success 200 {object} models.Schedule
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /schedules/{id} [get]
+func (h *Handler) getSchedule(w http.ResponseWriter, r *http.Request) {
+	h.scheduleAction(w, r, h.services.GetSchedule)
+}
+
+// @Summary Scheduled operation runs
+// @Tags schedules
+// @Description execution history of a scheduled operation, latest first
+// @ID schedule-runs
+// @Produce  json
+// @Param id path int true "scheduled operation id"
+// @Param limit query int false "max number of runs, 100 by default"
+// @Success 200 {object} models.ScheduleRuns
+// @Failure 400 {object} models.Response
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /schedules/{id}/runs [get]
+func (h *Handler) getScheduleRuns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var id, limit int
+	var runs []models.ScheduleRun
+
+	if id, err = scheduleID(r); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+	if limit, err = queryInt(r.URL.Query().Get("limit")); err != nil || limit < 0 {
+		Error(errors.New("неверно указано количество записей"), w, r, http.StatusBadRequest)
+		return
+	}
+
+	if runs, err = h.services.GetScheduleRuns(r.Context(), id, limit); err != nil {
+		scheduleError(err, w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(&models.ScheduleRuns{Entity: runs}, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Pause scheduled operation
+// @Tags schedules
+// @Description runs of a paused operation are skipped
+// @ID pause-schedule
+// @Produce  json
+// @Param id path int true "scheduled operation id"
+// @Success 200 {object} models.Schedule
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /schedules/{id}/pause [post]
+func (h *Handler) pauseSchedule(w http.ResponseWriter, r *http.Request) {
+	h.scheduleAction(w, r, h.services.PauseSchedule)
+}
+
+// @Summary Resume scheduled operation
+// @Tags schedules
+// @Description runs missed while the operation was paused are not executed, a one-off operation past its time is executed at once
+// @ID resume-schedule
+// @Produce  json
+// @Param id path int true "scheduled operation id"
+// @Success 200 {object} models.Schedule
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /schedules/{id}/resume [post]
+func (h *Handler) resumeSchedule(w http.ResponseWriter, r *http.Request) {
+	h.scheduleAction(w, r, h.services.ResumeSchedule)
+}
+
+// @Summary Cancel scheduled operation
+// @Tags schedules
+// @ID cancel-schedule
+// @Produce  json
+// @Param id path int true "scheduled operation id"
+// @Success 200 {object} models.Schedule
+// @Failure 401 {object} models.Response
+// @Failure 403 {object} models.Response
+// @Failure 404 {object} models.Response
+// @Failure 409 {object} models.Response
+// @Failure 500 {object} models.Response
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /schedules/{id}/cancel [post]
+func (h *Handler) cancelSchedule(w http.ResponseWriter, r *http.Request) {
+	h.scheduleAction(w, r, h.services.CancelSchedule)
+}
+
+// scheduleAction выполняет action над операцией по расписанию из пути запроса и возвращает ее
+func (h *Handler) scheduleAction(w http.ResponseWriter, r *http.Request,
+	action func(ctx context.Context, id int) (*models.Schedule, error)) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var err error
+	var id int
+	var schedule *models.Schedule
+
+	if id, err = scheduleID(r); err != nil {
+		Error(err, w, r, http.StatusBadRequest)
+		return
+	}
+
+	if schedule, err = action(r.Context(), id); err != nil {
+		scheduleError(err, w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = easyjson.MarshalToWriter(schedule, w)
+	if err != nil {
+		Error(err, w, r, http.StatusInternalServerError)
+		return
+	}
+}
+
+// scheduleID возвращает id операции по расписанию из пути запроса
+func scheduleID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		return 0, errors.New("неверно указан id операции по расписанию")
+	}
+	logger.AddAttrs(r.Context(), "schedule", id)
+	return id, nil
+}
+
+func scheduleError(err error, w http.ResponseWriter, r *http.Request) {
+	switch {
+	case errors.Is(err, service.ErrScheduleNotFound), errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrServiceNotFound):
+		Error(err, w, r, http.StatusNotFound)
+	case errors.Is(err, service.ErrScheduleState):
+		Error(err, w, r, http.StatusConflict)
+	default:
+		Error(err, w, r, http.StatusInternalServerError)
+	}
+}
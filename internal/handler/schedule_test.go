@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+	mock_service "userbalance/internal/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_createSchedule(t *testing.T) {
+	runAt := time.Date(2022, 10, 01, 12, 0, 0, 0, time.UTC)
+
+	type mockBehavior func(s *mock_service.MockScheduler)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		scopes              []string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			inputBody: `{"operation":"transfer","userid":1,"touserid":2,"amount":100,"kind":"interval","runat":"2022-10-01T12:00:00Z","interval":"24h"}`,
+			scopes:    []string{models.ScopeBalanceTransfer},
+			mockBehavior: func(s *mock_service.MockScheduler) {
+				s.EXPECT().CreateSchedule(gomock.Any(), &models.Schedule{
+					Operation: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100,
+					Kind: models.ScheduleInterval, RunAt: &runAt, Interval: "24h",
+				}).DoAndReturn(func(ctx context.Context, schedule *models.Schedule) error {
+					schedule.ID = 5
+					schedule.Status = models.ScheduleActive
+					schedule.NextRunAt = &runAt
+					schedule.CreatedAt = runAt
+					schedule.UpdatedAt = runAt
+					return nil
+				})
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedRequestBody: `{"id":5,"operation":"transfer","userid":1,"touserid":2,"amount":100,"kind":"interval",` +
+				`"runat":"2022-10-01T12:00:00Z","interval":"24h","status":"active","nextrunat":"2022-10-01T12:00:00Z",` +
+				`"attempt":0,"createdat":"2022-10-01T12:00:00Z","updatedat":"2022-10-01T12:00:00Z"}`,
+		},
+
+		{
+			name:                "error validation",
+			inputBody:           `{"operation":"transfer","userid":1,"touserid":2,"amount":100,"kind":"interval","interval":"30s"}`,
+			scopes:              []string{models.ScopeBalanceTransfer},
+			mockBehavior:        func(s *mock_service.MockScheduler) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"interval: интервал не может быть меньше минуты."}`,
+		},
+
+		{
+			name:                "error operation scope",
+			inputBody:           `{"operation":"reserve","userid":1,"serviceid":1,"orderid":1,"amount":100,"kind":"cron","cron":"@daily"}`,
+			scopes:              []string{models.ScopeBalanceTransfer},
+			mockBehavior:        func(s *mock_service.MockScheduler) {},
+			expectedStatusCode:  http.StatusForbidden,
+			expectedRequestBody: `{"message":"недостаточно прав: требуется reservations:write"}`,
+		},
+
+		{
+			name:      "error user not found",
+			inputBody: `{"operation":"transfer","userid":1,"touserid":2,"amount":100,"kind":"once","runat":"2022-10-01T12:00:00Z"}`,
+			scopes:    []string{models.ScopeBalanceTransfer},
+			mockBehavior: func(s *mock_service.MockScheduler) {
+				s.EXPECT().CreateSchedule(gomock.Any(), gomock.Any()).Return(service.ErrUserNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: fmt.Sprintf(`{"message":"%s"}`, service.ErrUserNotFound),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			scheduler := mock_service.NewMockScheduler(c)
+			testCase.mockBehavior(scheduler)
+
+			h := NewHandler(&service.Service{Scheduler: scheduler})
+
+			r := mux.NewRouter()
+			r.HandleFunc("/schedules", h.createSchedule).Methods("POST")
+
+			principal := &models.Principal{Client: "billing", Scopes: testCase.scopes}
+			req := httptest.NewRequest("POST", "/schedules", bytes.NewBufferString(testCase.inputBody))
+			req = req.WithContext(context.WithValue(req.Context(), principalKey{}, principal))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_scheduleAction(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockScheduler)
+
+	testTable := []struct {
+		name                string
+		method              string
+		target              string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK list",
+			method: "GET",
+			target: "/schedules?userid=1&status=active",
+			mockBehavior: func(s *mock_service.MockScheduler) {
+				s.EXPECT().GetSchedules(gomock.Any(), &models.ScheduleFilter{UserID: 1, Status: models.ScheduleActive}).
+					Return([]models.Schedule{}, nil)
+			},
+			expectedStatusCode:  http.StatusOK,
+			expectedRequestBody: `{"entity":[]}`,
+		},
+
+		{
+			name:                "error list wrong status",
+			method:              "GET",
+			target:              "/schedules?status=done",
+			mockBehavior:        func(s *mock_service.MockScheduler) {},
+			expectedStatusCode:  http.StatusBadRequest,
+			expectedRequestBody: `{"message":"status: состояние может быть active, paused, completed, failed либо canceled."}`,
+		},
+
+		{
+			name:   "OK runs",
+			method: "GET",
+			target: "/schedules/5/runs?limit=10",
+			mockBehavior: func(s *mock_service.MockScheduler) {
+				s.EXPECT().GetScheduleRuns(gomock.Any(), 5, 10).Return([]models.ScheduleRun{{
+					ID: 1, ScheduleID: 5, Attempt: 1, Status: models.RunRetry, Error: "недостаточно средств",
+					DueAt:      time.Date(2022, 10, 01, 12, 0, 0, 0, time.UTC),
+					ExecutedAt: time.Date(2022, 10, 01, 12, 0, 5, 0, time.UTC),
+				}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedRequestBody: `{"entity":[{"id":1,"scheduleid":5,"attempt":1,"status":"retry","error":"недостаточно средств",` +
+				`"dueat":"2022-10-01T12:00:00Z","executedat":"2022-10-01T12:00:05Z"}]}`,
+		},
+
+		{
+			name:   "error not found",
+			method: "GET",
+			target: "/schedules/5",
+			mockBehavior: func(s *mock_service.MockScheduler) {
+				s.EXPECT().GetSchedule(gomock.Any(), 5).Return(nil, service.ErrScheduleNotFound)
+			},
+			expectedStatusCode:  http.StatusNotFound,
+			expectedRequestBody: `{"message":"операция по расписанию не найдена"}`,
+		},
+
+		{
+			name:   "OK pause",
+			method: "POST",
+			target: "/schedules/5/pause",
+			mockBehavior: func(s *mock_service.MockScheduler) {
+				s.EXPECT().PauseSchedule(gomock.Any(), 5).Return(&models.Schedule{ID: 5, Status: models.SchedulePaused}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedRequestBody: `{"id":5,"operation":"","userid":0,"amount":0,"kind":"","status":"paused","attempt":0,` +
+				`"createdat":"0001-01-01T00:00:00Z","updatedat":"0001-01-01T00:00:00Z"}`,
+		},
+
+		{
+			name:   "error resume wrong state",
+			method: "POST",
+			target: "/schedules/5/resume",
+			mockBehavior: func(s *mock_service.MockScheduler) {
+				s.EXPECT().ResumeSchedule(gomock.Any(), 5).Return(nil,
+					fmt.Errorf("%w %s", service.ErrScheduleState, models.ScheduleCompleted))
+			},
+			expectedStatusCode:  http.StatusConflict,
+			expectedRequestBody: `{"message":"действие недоступно для операции в состоянии completed"}`,
+		},
+
+		{
+			name:   "error cancel wrong state",
+			method: "POST",
+			target: "/schedules/5/cancel",
+			mockBehavior: func(s *mock_service.MockScheduler) {
+				s.EXPECT().CancelSchedule(gomock.Any(), 5).Return(nil,
+					fmt.Errorf("%w %s", service.ErrScheduleState, models.ScheduleCanceled))
+			},
+			expectedStatusCode:  http.StatusConflict,
+			expectedRequestBody: `{"message":"действие недоступно для операции в состоянии canceled"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			scheduler := mock_service.NewMockScheduler(c)
+			testCase.mockBehavior(scheduler)
+
+			h := NewHandler(&service.Service{Scheduler: scheduler})
+
+			r := mux.NewRouter()
+			r.HandleFunc("/schedules", h.getSchedules).Methods("GET")
+			r.HandleFunc("/schedules/{id:[0-9]+}", h.getSchedule).Methods("GET")
+			r.HandleFunc("/schedules/{id:[0-9]+}/runs", h.getScheduleRuns).Methods("GET")
+			r.HandleFunc("/schedules/{id:[0-9]+}/pause", h.pauseSchedule).Methods("POST")
+			r.HandleFunc("/schedules/{id:[0-9]+}/resume", h.resumeSchedule).Methods("POST")
+			r.HandleFunc("/schedules/{id:[0-9]+}/cancel", h.cancelSchedule).Methods("POST")
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(testCase.method, testCase.target, nil))
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
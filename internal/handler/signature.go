@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+	"userbalance/internal/signature"
+)
+
+// verifySignature проверяет HMAC-подпись запросов к маршрутам из signedroutes.
+// Подпись вычисляется от тела запроса, поэтому тело не больше maxrequestbody читается целиком
+// и возвращается обработчику
+func (h *Handler) verifySignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.services.SignatureRequired(routeTemplate(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := readBody(r, h.services.Signature.MaxRequestBody())
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			if errors.Is(err, errRequestBodyTooLarge) {
+				Error(err, w, r, http.StatusRequestEntityTooLarge)
+				return
+			}
+			Error(err, w, r, http.StatusBadRequest)
+			return
+		}
+
+		principal, _ := r.Context().Value(principalKey{}).(*models.Principal)
+		err = h.services.VerifySignature(r.Context(), principal, &models.SignedRequest{
+			Method:    r.Method,
+			URI:       r.URL.RequestURI(),
+			Timestamp: r.Header.Get(signature.HeaderTimestamp),
+			Nonce:     r.Header.Get(signature.HeaderNonce),
+			Body:      body,
+			Signature: r.Header.Get(signature.HeaderSignature),
+		})
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			if errors.Is(err, service.ErrInvalidSignature) {
+				Error(err, w, r, http.StatusUnauthorized)
+				return
+			}
+			Error(err, w, r, http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"userbalance/internal/models"
+	"userbalance/internal/service"
+	mock_service "userbalance/internal/service/mocks"
+	"userbalance/internal/signature"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_verifySignature(t *testing.T) {
+
+	type mockBehavior func(s *mock_service.MockSignature)
+
+	const body = `{"userid":15,"amount":100}`
+	principal := &models.Principal{Client: "billing", SigningSecret: "secret"}
+
+	signed := &models.SignedRequest{
+		Method:    "POST",
+		URI:       "/topup?source=billing",
+		Timestamp: "1664582400",
+		Nonce:     "n1",
+		Body:      []byte(body),
+		Signature: "abc",
+	}
+
+	testTable := []struct {
+		name               string
+		path               string
+		headers            map[string]string
+		mockBehavior       mockBehavior
+		expectedStatusCode int
+	}{
+		{
+			name: "OK",
+			path: "/topup?source=billing",
+			headers: map[string]string{
+				signature.HeaderTimestamp: "1664582400",
+				signature.HeaderNonce:     "n1",
+				signature.HeaderSignature: "abc",
+			},
+			mockBehavior: func(s *mock_service.MockSignature) {
+				s.EXPECT().SignatureRequired("/topup").Return(true)
+				s.EXPECT().MaxRequestBody().Return(int64(1024))
+				s.EXPECT().VerifySignature(gomock.Any(), principal, signed).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name: "OK route without signature",
+			path: "/transfer",
+			mockBehavior: func(s *mock_service.MockSignature) {
+				s.EXPECT().SignatureRequired("/transfer").Return(false)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+
+		{
+			name: "invalid signature",
+			path: "/topup",
+			mockBehavior: func(s *mock_service.MockSignature) {
+				s.EXPECT().SignatureRequired("/topup").Return(true)
+				s.EXPECT().MaxRequestBody().Return(int64(1024))
+				s.EXPECT().VerifySignature(gomock.Any(), principal, gomock.Any()).Return(service.ErrInvalidSignature)
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+
+		{
+			name: "nonce store error",
+			path: "/topup",
+			mockBehavior: func(s *mock_service.MockSignature) {
+				s.EXPECT().SignatureRequired("/topup").Return(true)
+				s.EXPECT().MaxRequestBody().Return(int64(1024))
+				s.EXPECT().VerifySignature(gomock.Any(), principal, gomock.Any()).Return(errors.New("some error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+
+		{
+			name: "body too large",
+			path: "/topup",
+			mockBehavior: func(s *mock_service.MockSignature) {
+				s.EXPECT().SignatureRequired("/topup").Return(true)
+				s.EXPECT().MaxRequestBody().Return(int64(len(body) - 1))
+			},
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			signatures := mock_service.NewMockSignature(c)
+			testCase.mockBehavior(signatures)
+
+			h := NewHandler(&service.Service{Signature: signatures})
+
+			// обработчик должен получить тело запроса целиком
+			echo := func(w http.ResponseWriter, r *http.Request) {
+				got, _ := io.ReadAll(r.Body)
+				assert.Equal(t, body, string(got))
+				w.WriteHeader(http.StatusOK)
+			}
+
+			withPrincipal := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+				})
+			}
+
+			r := mux.NewRouter()
+			r.HandleFunc("/topup", echo).Methods("POST")
+			r.HandleFunc("/transfer", echo).Methods("POST")
+			r.Use(withPrincipal, h.verifySignature)
+
+			req := httptest.NewRequest("POST", testCase.path, bytes.NewBufferString(body))
+			for key, value := range testCase.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+		})
+	}
+}
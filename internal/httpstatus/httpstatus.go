@@ -0,0 +1,24 @@
+// Package httpstatus запоминает код ответа, записанный обработчиком, для middleware
+package httpstatus
+
+import "net/http"
+
+// Recorder запоминает код ответа, записанный обработчиком
+type Recorder struct {
+	http.ResponseWriter
+	Status int
+}
+
+// Wrap оборачивает w, код ответа по умолчанию - 200. Если w уже обернут внешним middleware,
+// возвращается он же, поэтому ответ оборачивается один раз на всю цепочку middleware
+func Wrap(w http.ResponseWriter) *Recorder {
+	if recorder, ok := w.(*Recorder); ok {
+		return recorder
+	}
+	return &Recorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (r *Recorder) WriteHeader(status int) {
+	r.Status = status
+	r.ResponseWriter.WriteHeader(status)
+}
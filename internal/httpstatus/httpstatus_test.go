@@ -0,0 +1,23 @@
+package httpstatus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	outer := Wrap(w)
+	assert.Equal(t, http.StatusOK, outer.Status)
+
+	inner := Wrap(outer)
+	assert.Same(t, outer, inner)
+
+	inner.WriteHeader(http.StatusNotFound)
+	assert.Equal(t, http.StatusNotFound, outer.Status)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+type requestKey struct{}
+
+// request - данные запроса, которыми дополняются все записи, сделанные с его контекстом
+type request struct {
+	id string
+
+	mu    sync.Mutex
+	attrs []interface{}
+}
+
+func (r *request) attributes() []interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.attrs
+}
+
+// WithRequestID возвращает контекст запроса с идентификатором id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{id: id})
+}
+
+// RequestID возвращает идентификатор запроса из ctx либо пустую строку
+func RequestID(ctx context.Context) string {
+	if req := fromContext(ctx); req != nil {
+		return req.id
+	}
+	return ""
+}
+
+// AddAttrs добавляет атрибуты ко всем последующим записям запроса, например id пользователей,
+// которые становятся известны только после разбора тела запроса
+func AddAttrs(ctx context.Context, args ...interface{}) {
+	req := fromContext(ctx)
+	if req == nil {
+		return
+	}
+
+	req.mu.Lock()
+	defer req.mu.Unlock()
+	attrs := make([]interface{}, 0, len(req.attrs)+len(args))
+	attrs = append(attrs, req.attrs...)
+	req.attrs = append(attrs, args...)
+}
+
+// NewRequestID возвращает случайный идентификатор запроса
+func NewRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func fromContext(ctx context.Context) *request {
+	if ctx == nil {
+		return nil
+	}
+	req, _ := ctx.Value(requestKey{}).(*request)
+	return req
+}
@@ -0,0 +1,261 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Level - уровень важности записи, значения совпадают с уровнями log/slog
+type Level int32
+
+const (
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+)
+
+func (l Level) String() string {
+	switch {
+	case l < LevelInfo:
+		return "DEBUG"
+	case l < LevelWarn:
+		return "INFO"
+	case l < LevelError:
+		return "WARN"
+	}
+	return "ERROR"
+}
+
+// ParseLevel разбирает уровень, заданный в конфигурации: debug, info, warn либо error
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("неизвестный уровень логирования: %s", s)
+}
+
+// значения ключей, в имени которых встречаются эти слова, не попадают в лог
+var sensitive = []string{"password", "secret", "token", "key", "authorization", "signature"}
+
+const redacted string = "***"
+
+// Logger пишет записи в формате JSON по одной в строке. Записи, сделанные с контекстом
+// запроса, дополняются его идентификатором, атрибутами, добавленными через AddAttrs,
+// и идентификаторами трассы и span'а
+type Logger struct {
+	mu    *sync.Mutex
+	out   io.Writer
+	level *int32
+	attrs []interface{}
+}
+
+func New(out io.Writer, level Level) *Logger {
+	l := &Logger{
+		mu:    &sync.Mutex{},
+		out:   out,
+		level: new(int32),
+	}
+	l.SetLevel(level)
+	return l
+}
+
+var std = New(os.Stderr, LevelInfo)
+
+func Default() *Logger {
+	return std
+}
+
+// SetLevel меняет минимальный уровень записей, в том числе у логгеров, полученных через With
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreInt32(l.level, int32(level))
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return level >= Level(atomic.LoadInt32(l.level))
+}
+
+// With возвращает логгер, добавляющий args к каждой записи
+func (l *Logger) With(args ...interface{}) *Logger {
+	attrs := make([]interface{}, 0, len(l.attrs)+len(args))
+	attrs = append(attrs, l.attrs...)
+	attrs = append(attrs, args...)
+	return &Logger{mu: l.mu, out: l.out, level: l.level, attrs: attrs}
+}
+
+// Log пишет запись msg с атрибутами args - парами ключ, значение
+func (l *Logger) Log(ctx context.Context, level Level, msg string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"time":`)
+	writeValue(buf, time.Now().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeValue(buf, level.String())
+	buf.WriteString(`,"msg":`)
+	writeValue(buf, msg)
+
+	if req := fromContext(ctx); req != nil {
+		buf.WriteString(`,"request_id":`)
+		writeValue(buf, req.id)
+		writeAttrs(buf, req.attributes())
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		buf.WriteString(`,"trace_id":`)
+		writeValue(buf, span.TraceID().String())
+		buf.WriteString(`,"span_id":`)
+		writeValue(buf, span.SpanID().String())
+	}
+	writeAttrs(buf, l.attrs)
+	writeAttrs(buf, args)
+	buf.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(buf.Bytes())
+}
+
+func (l *Logger) Debug(msg string, args ...interface{}) {
+	l.Log(context.Background(), LevelDebug, msg, args...)
+}
+
+func (l *Logger) Info(msg string, args ...interface{}) {
+	l.Log(context.Background(), LevelInfo, msg, args...)
+}
+
+func (l *Logger) Warn(msg string, args ...interface{}) {
+	l.Log(context.Background(), LevelWarn, msg, args...)
+}
+
+func (l *Logger) Error(msg string, args ...interface{}) {
+	l.Log(context.Background(), LevelError, msg, args...)
+}
+
+func (l *Logger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	l.Log(ctx, LevelDebug, msg, args...)
+}
+
+func (l *Logger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	l.Log(ctx, LevelInfo, msg, args...)
+}
+
+func (l *Logger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	l.Log(ctx, LevelWarn, msg, args...)
+}
+
+func (l *Logger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	l.Log(ctx, LevelError, msg, args...)
+}
+
+// StdLogger возвращает *log.Logger, который пишет каждую строку записью уровня level,
+// для библиотек, принимающих только стандартный логгер
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(writerFunc(func(p []byte) (int, error) {
+		l.Log(context.Background(), level, strings.TrimRight(string(p), "\n"))
+		return len(p), nil
+	}), "", 0)
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// функции пакета пишут в логгер по умолчанию
+
+func SetLevel(level Level) { std.SetLevel(level) }
+
+func Debug(msg string, args ...interface{}) { std.Debug(msg, args...) }
+
+func Info(msg string, args ...interface{}) { std.Info(msg, args...) }
+
+func Warn(msg string, args ...interface{}) { std.Warn(msg, args...) }
+
+func Error(msg string, args ...interface{}) { std.Error(msg, args...) }
+
+func DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	std.DebugContext(ctx, msg, args...)
+}
+
+func InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	std.InfoContext(ctx, msg, args...)
+}
+
+func WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	std.WarnContext(ctx, msg, args...)
+}
+
+func ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	std.ErrorContext(ctx, msg, args...)
+}
+
+// writeAttrs дописывает пары ключ, значение; значение без ключа записывается под ключом !BADKEY
+func writeAttrs(buf *bytes.Buffer, args []interface{}) {
+	for i := 0; i < len(args); i++ {
+		key, ok := args[i].(string)
+		if ok && i < len(args)-1 {
+			i++
+		} else {
+			key, ok = "!BADKEY", false
+		}
+
+		buf.WriteByte(',')
+		writeValue(buf, key)
+		buf.WriteByte(':')
+		if ok && isSensitive(key) {
+			writeValue(buf, redacted)
+		} else {
+			writeValue(buf, args[i])
+		}
+	}
+}
+
+func writeValue(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	case time.Time:
+		// кодируется в RFC3339, а не через String
+	case fmt.Stringer:
+		value = v.String()
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(encoded)
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range sensitive {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
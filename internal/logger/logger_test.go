@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	records := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("запись не в формате JSON: %s", line)
+		}
+		delete(record, "time")
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogger_Log(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "req-1")
+	AddAttrs(ctx, "userid", 15)
+
+	testTable := []struct {
+		name  string
+		level Level
+		log   func(l *Logger)
+		want  []map[string]interface{}
+	}{
+		{
+			name:  "attributes",
+			level: LevelInfo,
+			log: func(l *Logger) {
+				l.Info("перевод выполнен", "amount", 100, "error", errors.New("some error"))
+			},
+			want: []map[string]interface{}{
+				{"level": "INFO", "msg": "перевод выполнен", "amount": float64(100), "error": "some error"},
+			},
+		},
+		{
+			name:  "level filter",
+			level: LevelWarn,
+			log: func(l *Logger) {
+				l.Debug("debug")
+				l.Info("info")
+				l.Warn("warn")
+				l.Error("error")
+			},
+			want: []map[string]interface{}{
+				{"level": "WARN", "msg": "warn"},
+				{"level": "ERROR", "msg": "error"},
+			},
+		},
+		{
+			name:  "request context",
+			level: LevelInfo,
+			log: func(l *Logger) {
+				l.InfoContext(ctx, "запрос обработан", "status", 200)
+			},
+			want: []map[string]interface{}{
+				{"level": "INFO", "msg": "запрос обработан", "request_id": "req-1", "userid": float64(15), "status": float64(200)},
+			},
+		},
+		{
+			name:  "trace context",
+			level: LevelInfo,
+			log: func(l *Logger) {
+				span := trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+					SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+				})
+				l.InfoContext(trace.ContextWithSpanContext(context.Background(), span), "запрос обработан")
+			},
+			want: []map[string]interface{}{
+				{"level": "INFO", "msg": "запрос обработан", "trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7"},
+			},
+		},
+		{
+			name:  "sensitive keys",
+			level: LevelInfo,
+			log: func(l *Logger) {
+				l.With("password", "postgres").Info("подключение", "AuditKey", "secret", "Authorization", "Bearer x", "user", "postgres")
+			},
+			want: []map[string]interface{}{
+				{"level": "INFO", "msg": "подключение", "password": "***", "AuditKey": "***", "Authorization": "***", "user": "postgres"},
+			},
+		},
+		{
+			name:  "value without key",
+			level: LevelInfo,
+			log: func(l *Logger) {
+				l.Info("сообщение", "status", 200, 15)
+			},
+			want: []map[string]interface{}{
+				{"level": "INFO", "msg": "сообщение", "status": float64(200), "!BADKEY": float64(15)},
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			testCase.log(New(buf, testCase.level))
+
+			assert.Equal(t, testCase.want, decode(t, buf))
+		})
+	}
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := New(buf, LevelInfo)
+	child := l.With("component", "snapshot")
+
+	child.Debug("скрыто")
+	l.SetLevel(LevelDebug)
+	child.Debug("видно")
+
+	assert.Equal(t, []map[string]interface{}{
+		{"level": "DEBUG", "msg": "видно", "component": "snapshot"},
+	}, decode(t, buf))
+}
+
+func TestLogger_StdLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	New(buf, LevelInfo).StdLogger(LevelError).Println("http: TLS handshake error")
+
+	assert.Equal(t, []map[string]interface{}{
+		{"level": "ERROR", "msg": "http: TLS handshake error"},
+	}, decode(t, buf))
+}
+
+func TestParseLevel(t *testing.T) {
+	testTable := []struct {
+		input   string
+		want    Level
+		wantErr bool
+	}{
+		{input: "debug", want: LevelDebug},
+		{input: "INFO", want: LevelInfo},
+		{input: "", want: LevelInfo},
+		{input: "warn", want: LevelWarn},
+		{input: "error", want: LevelError},
+		{input: "trace", want: LevelInfo, wantErr: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.input, func(t *testing.T) {
+			got, err := ParseLevel(testCase.input)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.want, got)
+		})
+	}
+}
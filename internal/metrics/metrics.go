@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+	"userbalance/internal/httpstatus"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace string = "userbalance"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	operations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operations_total",
+		Help:      "Number of balance operations by type and result.",
+	}, []string{"operation", "result"})
+
+	operationAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operation_amount_total",
+		Help:      "Sum of amounts of successful balance operations by type.",
+	}, []string{"operation"})
+
+	operationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "errors_total",
+		Help:      "Number of failed balance operations by type and domain error.",
+	}, []string{"operation", "type"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Number of requests rejected by rate limits by limit kind: client or user.",
+	}, []string{"limit"})
+
+	reconciliationMismatches = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "reconciliation_mismatches",
+		Help:      "Number of balance mismatches found by the last reconciliation.",
+	})
+
+	reconciliationLastRun = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "reconciliation_last_run_timestamp_seconds",
+		Help:      "Unix time of the last reconciliation.",
+	})
+)
+
+const (
+	resultOK    string = "ok"
+	resultError string = "error"
+)
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware считает запросы и время их обработки по шаблону маршрута mux,
+// а не по пути запроса, чтобы id в пути не порождали новые ряды
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		recorder := httpstatus.Wrap(w)
+		start := time.Now()
+
+		next.ServeHTTP(recorder, r)
+
+		httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.Status)).Inc()
+	})
+}
+
+// ObserveOperation учитывает успешную операцию и ее сумму
+func ObserveOperation(operation string, amount int) {
+	operations.WithLabelValues(operation, resultOK).Inc()
+	operationAmount.WithLabelValues(operation).Add(float64(amount))
+}
+
+// ObserveError учитывает операцию, завершившуюся ошибкой errorType
+func ObserveError(operation string, errorType string) {
+	operations.WithLabelValues(operation, resultError).Inc()
+	operationErrors.WithLabelValues(operation, errorType).Inc()
+}
+
+// ObserveRateLimited учитывает запрос, отклоненный ограничением частоты limit
+func ObserveRateLimited(limit string) {
+	rateLimited.WithLabelValues(limit).Inc()
+}
+
+// ObserveReconciliation сохраняет количество расхождений и время последней сверки
+func ObserveReconciliation(mismatches int, checkedAt time.Time) {
+	reconciliationMismatches.Set(float64(mismatches))
+	reconciliationLastRun.Set(float64(checkedAt.Unix()))
+}
+
+// RegisterDB публикует статистику пула соединений db
+func RegisterDB(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// TotalsSource возвращает суммарные остатки, его реализует repository.Control
+type TotalsSource interface {
+	GetTotals(ctx context.Context) (*models.Totals, error)
+}
+
+// RegisterTotals публикует суммарные остатки пользователей, которые запрашиваются из src
+// при каждом сборе метрик не дольше timeout
+func RegisterTotals(src TotalsSource, timeout time.Duration) {
+	prometheus.MustRegister(newTotalsCollector(src, timeout))
+}
+
+type totalsCollector struct {
+	src     TotalsSource
+	timeout time.Duration
+
+	users        *prometheus.Desc
+	balance      *prometheus.Desc
+	reserve      *prometheus.Desc
+	reservations *prometheus.Desc
+}
+
+func newTotalsCollector(src TotalsSource, timeout time.Duration) *totalsCollector {
+	return &totalsCollector{
+		src:          src,
+		timeout:      timeout,
+		users:        prometheus.NewDesc(namespace+"_users", "Number of users.", nil, nil),
+		balance:      prometheus.NewDesc(namespace+"_balance_total", "Total funds on main accounts.", nil, nil),
+		reserve:      prometheus.NewDesc(namespace+"_reserved_total", "Total reserved funds.", nil, nil),
+		reservations: prometheus.NewDesc(namespace+"_reservations", "Number of open reservations.", nil, nil),
+	}
+}
+
+func (c *totalsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.users
+	ch <- c.balance
+	ch <- c.reserve
+	ch <- c.reservations
+}
+
+func (c *totalsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	totals, err := c.src.GetTotals(ctx)
+	if err != nil {
+		logger.Error("ошибка при получении суммарных остатков", "error", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(totals.Users))
+	ch <- prometheus.MustNewConstMetric(c.balance, prometheus.GaugeValue, float64(totals.Balance))
+	ch <- prometheus.MustNewConstMetric(c.reserve, prometheus.GaugeValue, float64(totals.Reserve))
+	ch <- prometheus.MustNewConstMetric(c.reservations, prometheus.GaugeValue, float64(totals.Reservations))
+}
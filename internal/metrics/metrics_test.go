@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"userbalance/internal/models"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type totalsFunc func(ctx context.Context) (*models.Totals, error)
+
+func (f totalsFunc) GetTotals(ctx context.Context) (*models.Totals, error) {
+	return f(ctx)
+}
+
+func TestMiddleware(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/users/{id:[0-9]+}/balance", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}).Methods("GET")
+	r.HandleFunc("/topup", func(w http.ResponseWriter, r *http.Request) {}).Methods("POST")
+	r.Use(Middleware)
+
+	for _, path := range []string{"/users/1/balance", "/users/2/balance"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topup", nil))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(httpRequests.WithLabelValues("/users/{id:[0-9]+}/balance", "GET", "400")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues("/topup", "POST", "200")))
+	assert.Equal(t, 2, testutil.CollectAndCount(httpDuration))
+}
+
+func TestObserve(t *testing.T) {
+	ObserveOperation(models.OperationTopup, 100)
+	ObserveOperation(models.OperationTopup, 50)
+	ObserveError(models.OperationTransfer, "insufficient_funds")
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(operations.WithLabelValues(models.OperationTopup, resultOK)))
+	assert.Equal(t, float64(150), testutil.ToFloat64(operationAmount.WithLabelValues(models.OperationTopup)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(operations.WithLabelValues(models.OperationTransfer, resultError)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(operationErrors.WithLabelValues(models.OperationTransfer, "insufficient_funds")))
+}
+
+func TestObserveReconciliation(t *testing.T) {
+	checkedAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	ObserveReconciliation(3, checkedAt)
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(reconciliationMismatches))
+	assert.Equal(t, float64(checkedAt.Unix()), testutil.ToFloat64(reconciliationLastRun))
+}
+
+func TestTotalsCollector(t *testing.T) {
+	testTable := []struct {
+		name  string
+		src   totalsFunc
+		want  string
+		count int
+	}{
+		{
+			name: "OK",
+			src: func(ctx context.Context) (*models.Totals, error) {
+				return &models.Totals{Users: 2, Balance: 300, Reserve: 50, Reservations: 1}, nil
+			},
+			want: `
+# HELP userbalance_balance_total Total funds on main accounts.
+# TYPE userbalance_balance_total gauge
+userbalance_balance_total 300
+# HELP userbalance_reservations Number of open reservations.
+# TYPE userbalance_reservations gauge
+userbalance_reservations 1
+# HELP userbalance_reserved_total Total reserved funds.
+# TYPE userbalance_reserved_total gauge
+userbalance_reserved_total 50
+# HELP userbalance_users Number of users.
+# TYPE userbalance_users gauge
+userbalance_users 2
+`,
+			count: 4,
+		},
+		{
+			name: "error",
+			src: func(ctx context.Context) (*models.Totals, error) {
+				return nil, errors.New("some error")
+			},
+			count: 0,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			collector := newTotalsCollector(testCase.src, 0)
+
+			assert.Equal(t, testCase.count, testutil.CollectAndCount(collector))
+			if testCase.want != "" {
+				assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(testCase.want)))
+			}
+		})
+	}
+}
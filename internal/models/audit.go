@@ -0,0 +1,72 @@
+//go:generate easyjson -no_std_marshalers audit.go
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+//easyjson:json
+type (
+	// AuditRecord - запись logs вместе с хэшем ее содержимого и хэшем предыдущей записи пользователя
+	AuditRecord struct {
+		ID          int       `json:"id"`
+		UserID      int       `json:"userid"`
+		Date        time.Time `json:"date"`
+		Amount      int       `json:"amount"`
+		Description string    `json:"description"`
+		PrevHash    string    `json:"prevhash"`
+		Hash        string    `json:"hash"`
+		Note
+	}
+
+	// AuditBreak - первое найденное нарушение цепочки
+	AuditBreak struct {
+		LogID  int    `json:"logid"`
+		UserID int    `json:"userid"`
+		Reason string `json:"reason"`
+	}
+
+	AuditReport struct {
+		CheckedAt   time.Time   `json:"checkedat"`
+		Records     int         `json:"records"`
+		Checkpoints int         `json:"checkpoints"`
+		Valid       bool        `json:"valid"`
+		Broken      *AuditBreak `json:"broken,omitempty"`
+	}
+
+	// ChainHead - последняя запись цепочки пользователя
+	ChainHead struct {
+		UserID int    `json:"userid"`
+		LogID  int    `json:"logid"`
+		Hash   string `json:"hash"`
+	}
+
+	// Checkpoint - подписанный снимок последних записей всех цепочек,
+	// позволяет обнаружить удаление или подмену записей, сделанных до него
+	Checkpoint struct {
+		TakenAt   time.Time   `json:"takenat"`
+		Heads     []ChainHead `json:"heads"`
+		Signature string      `json:"signature"`
+	}
+)
+
+// LogHash считает хэш записи logs, связанный с хэшем предыдущей записи пользователя.
+// Для записи без комментария и метаданных формат должен совпадать с миграцией 000011_add_hash_chain_to_logs,
+// иначе они добавляются перед хэшем предыдущей записи, метаданные - JSON-объектом с отсортированными ключами
+func LogHash(userId int, date time.Time, amount int, description string, note Note, prevHash string) string {
+	content := fmt.Sprintf("%d|%s|%d|%s", userId, date.Format("2006-01-02"), amount, description)
+	if !note.IsEmpty() {
+		metadata, _ := note.Metadata.Value()
+		content = fmt.Sprintf("%s|%s|%s", content, note.Comment, metadata)
+	}
+	sum := sha256.Sum256([]byte(content + "|" + prevHash))
+	return hex.EncodeToString(sum[:])
+}
+
+// ComputeHash пересчитывает хэш записи по ее содержимому
+func (a AuditRecord) ComputeHash() string {
+	return LogHash(a.UserID, a.Date, a.Amount, a.Description, a.Note, a.PrevHash)
+}
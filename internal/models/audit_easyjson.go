@@ -0,0 +1,483 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjsonF2c44427DecodeUserbalanceInternalModels(in *jlexer.Lexer, out *Checkpoint) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "takenat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.TakenAt).UnmarshalJSON(data))
+			}
+		case "heads":
+			if in.IsNull() {
+				in.Skip()
+				out.Heads = nil
+			} else {
+				in.Delim('[')
+				if out.Heads == nil {
+					if !in.IsDelim(']') {
+						out.Heads = make([]ChainHead, 0, 2)
+					} else {
+						out.Heads = []ChainHead{}
+					}
+				} else {
+					out.Heads = (out.Heads)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 ChainHead
+					(v1).UnmarshalEasyJSON(in)
+					out.Heads = append(out.Heads, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "signature":
+			out.Signature = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF2c44427EncodeUserbalanceInternalModels(out *jwriter.Writer, in Checkpoint) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"takenat\":"
+		out.RawString(prefix[1:])
+		out.Raw((in.TakenAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"heads\":"
+		out.RawString(prefix)
+		if in.Heads == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Heads {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"signature\":"
+		out.RawString(prefix)
+		out.String(string(in.Signature))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Checkpoint) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF2c44427EncodeUserbalanceInternalModels(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Checkpoint) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF2c44427DecodeUserbalanceInternalModels(l, v)
+}
+func easyjsonF2c44427DecodeUserbalanceInternalModels1(in *jlexer.Lexer, out *ChainHead) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "userid":
+			out.UserID = int(in.Int())
+		case "logid":
+			out.LogID = int(in.Int())
+		case "hash":
+			out.Hash = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF2c44427EncodeUserbalanceInternalModels1(out *jwriter.Writer, in ChainHead) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"logid\":"
+		out.RawString(prefix)
+		out.Int(int(in.LogID))
+	}
+	{
+		const prefix string = ",\"hash\":"
+		out.RawString(prefix)
+		out.String(string(in.Hash))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ChainHead) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF2c44427EncodeUserbalanceInternalModels1(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ChainHead) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF2c44427DecodeUserbalanceInternalModels1(l, v)
+}
+func easyjsonF2c44427DecodeUserbalanceInternalModels2(in *jlexer.Lexer, out *AuditReport) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "checkedat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CheckedAt).UnmarshalJSON(data))
+			}
+		case "records":
+			out.Records = int(in.Int())
+		case "checkpoints":
+			out.Checkpoints = int(in.Int())
+		case "valid":
+			out.Valid = bool(in.Bool())
+		case "broken":
+			if in.IsNull() {
+				in.Skip()
+				out.Broken = nil
+			} else {
+				if out.Broken == nil {
+					out.Broken = new(AuditBreak)
+				}
+				(*out.Broken).UnmarshalEasyJSON(in)
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF2c44427EncodeUserbalanceInternalModels2(out *jwriter.Writer, in AuditReport) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"checkedat\":"
+		out.RawString(prefix[1:])
+		out.Raw((in.CheckedAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"records\":"
+		out.RawString(prefix)
+		out.Int(int(in.Records))
+	}
+	{
+		const prefix string = ",\"checkpoints\":"
+		out.RawString(prefix)
+		out.Int(int(in.Checkpoints))
+	}
+	{
+		const prefix string = ",\"valid\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Valid))
+	}
+	if in.Broken != nil {
+		const prefix string = ",\"broken\":"
+		out.RawString(prefix)
+		(*in.Broken).MarshalEasyJSON(out)
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v AuditReport) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF2c44427EncodeUserbalanceInternalModels2(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *AuditReport) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF2c44427DecodeUserbalanceInternalModels2(l, v)
+}
+func easyjsonF2c44427DecodeUserbalanceInternalModels3(in *jlexer.Lexer, out *AuditRecord) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = int(in.Int())
+		case "userid":
+			out.UserID = int(in.Int())
+		case "date":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Date).UnmarshalJSON(data))
+			}
+		case "amount":
+			out.Amount = int(in.Int())
+		case "description":
+			out.Description = string(in.String())
+		case "prevhash":
+			out.PrevHash = string(in.String())
+		case "hash":
+			out.Hash = string(in.String())
+		case "comment":
+			out.Comment = string(in.String())
+		case "metadata":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Metadata = make(Metadata)
+				} else {
+					out.Metadata = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v4 string
+					v4 = string(in.String())
+					(out.Metadata)[key] = v4
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF2c44427EncodeUserbalanceInternalModels3(out *jwriter.Writer, in AuditRecord) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.ID))
+	}
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix)
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"date\":"
+		out.RawString(prefix)
+		out.Raw((in.Date).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Int(int(in.Amount))
+	}
+	{
+		const prefix string = ",\"description\":"
+		out.RawString(prefix)
+		out.String(string(in.Description))
+	}
+	{
+		const prefix string = ",\"prevhash\":"
+		out.RawString(prefix)
+		out.String(string(in.PrevHash))
+	}
+	{
+		const prefix string = ",\"hash\":"
+		out.RawString(prefix)
+		out.String(string(in.Hash))
+	}
+	if in.Comment != "" {
+		const prefix string = ",\"comment\":"
+		out.RawString(prefix)
+		out.String(string(in.Comment))
+	}
+	if len(in.Metadata) != 0 {
+		const prefix string = ",\"metadata\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v5First := true
+			for v5Name, v5Value := range in.Metadata {
+				if v5First {
+					v5First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v5Name))
+				out.RawByte(':')
+				out.String(string(v5Value))
+			}
+			out.RawByte('}')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v AuditRecord) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF2c44427EncodeUserbalanceInternalModels3(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *AuditRecord) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF2c44427DecodeUserbalanceInternalModels3(l, v)
+}
+func easyjsonF2c44427DecodeUserbalanceInternalModels4(in *jlexer.Lexer, out *AuditBreak) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "logid":
+			out.LogID = int(in.Int())
+		case "userid":
+			out.UserID = int(in.Int())
+		case "reason":
+			out.Reason = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF2c44427EncodeUserbalanceInternalModels4(out *jwriter.Writer, in AuditBreak) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"logid\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.LogID))
+	}
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix)
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"reason\":"
+		out.RawString(prefix)
+		out.String(string(in.Reason))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v AuditBreak) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF2c44427EncodeUserbalanceInternalModels4(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *AuditBreak) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF2c44427DecodeUserbalanceInternalModels4(l, v)
+}
@@ -0,0 +1,101 @@
+//go:generate easyjson -no_std_marshalers auth.go
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Права клиентов API
+const (
+	ScopeBalanceRead       string = "balance:read"
+	ScopeBalanceTopup      string = "balance:topup"
+	ScopeBalanceTransfer   string = "balance:transfer"
+	ScopeReservationsWrite string = "reservations:write"
+	ScopeReportsRead       string = "reports:read"
+	ScopeAuditRead         string = "audit:read"
+	ScopeRiskReview        string = "risk:review"
+	ScopeSchedulesRead     string = "schedules:read"
+	ScopeSchedulesWrite    string = "schedules:write"
+)
+
+// Scopes - все права, которые могут быть выданы клиенту
+var Scopes = []string{
+	ScopeBalanceRead,
+	ScopeBalanceTopup,
+	ScopeBalanceTransfer,
+	ScopeReservationsWrite,
+	ScopeReportsRead,
+	ScopeAuditRead,
+	ScopeRiskReview,
+	ScopeSchedulesRead,
+	ScopeSchedulesWrite,
+}
+
+// Способы аутентификации клиента
+const (
+	AuthMethodAPIKey      string = "apikey"
+	AuthMethodJWT         string = "jwt"
+	AuthMethodCertificate string = "certificate"
+)
+
+//easyjson:json
+type (
+	// APIKey - ключ клиента API. Сам ключ не хранится, в БД записывается только его хэш
+	APIKey struct {
+		ID        int        `json:"id"`
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		Signing   bool       `json:"signing"`
+		CreatedAt time.Time  `json:"createdat"`
+		RevokedAt *time.Time `json:"revokedat,omitempty"`
+
+		// SigningSecret - секрет подписи запросов клиента HMAC, пустой, если подпись клиенту не выдана
+		SigningSecret string `json:"-"`
+	}
+
+	APIKeys struct {
+		Entity []APIKey `json:"entity"`
+	}
+
+	// IssuedAPIKey - созданный ключ, значения Key и SigningSecret выводятся один раз при создании
+	IssuedAPIKey struct {
+		APIKey
+		Key           string `json:"key,omitempty"`
+		SigningSecret string `json:"signingsecret,omitempty"`
+	}
+)
+
+func (k APIKey) Validate() error {
+	scopes := make([]interface{}, 0, len(Scopes))
+	for _, scope := range Scopes {
+		scopes = append(scopes, scope)
+	}
+
+	return validation.ValidateStruct(&k,
+		validation.Field(&k.Name,
+			validation.Required.Error("имя клиента не может быть не указано"),
+			validation.Length(1, 100).Error("имя клиента не может быть длиннее 100 символов")),
+		validation.Field(&k.Scopes,
+			validation.Required.Error("права клиента не могут быть не указаны"),
+			validation.Each(validation.In(scopes...).Error("неизвестное право"))))
+}
+
+// Principal - аутентифицированный клиент, от имени которого выполняется запрос
+type Principal struct {
+	Client        string
+	Method        string
+	Scopes        []string
+	SigningSecret string
+}
+
+// HasScope сообщает, что клиенту выдано право scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
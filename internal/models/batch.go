@@ -0,0 +1,122 @@
+//go:generate easyjson -no_std_marshalers batch.go
+package models
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	BatchModeAtomic      string = "atomic"
+	BatchModeIndependent string = "independent"
+
+	OperationTopup    string = "topup"
+	OperationTransfer string = "transfer"
+	OperationReserve  string = "reserve"
+	OperationConfirm  string = "confirm"
+	OperationCancel   string = "cancel"
+
+	BatchStatusOK      string = "ok"
+	BatchStatusError   string = "error"
+	BatchStatusSkipped string = "skipped"
+
+	MaxBatchOperations int = 10000
+)
+
+//easyjson:json
+type (
+	BatchOperation struct {
+		Type       string `json:"type"`
+		UserID     int    `json:"userid"`
+		FromUserID int    `json:"fromuserid"`
+		ToUserID   int    `json:"touserid"`
+		Amount     int    `json:"amount"`
+		Date       string `json:"date"`
+		ServiceID  int    `json:"serviceid"`
+		OrderID    int    `json:"orderid"`
+		Note
+	}
+
+	RequestBatch struct {
+		Mode       string           `json:"mode"`
+		Operations []BatchOperation `json:"operations"`
+	}
+
+	BatchResult struct {
+		Index   int    `json:"index"`
+		Type    string `json:"type"`
+		Status  string `json:"status"`
+		Message string `json:"message,omitempty"`
+	}
+
+	BatchResults struct {
+		Mode      string        `json:"mode"`
+		Committed bool          `json:"committed"`
+		Entity    []BatchResult `json:"entity"`
+	}
+)
+
+func (o BatchOperation) Replenishment() *Replenishment {
+	return &Replenishment{
+		UserID: o.UserID,
+		Amount: o.Amount,
+		Date:   o.Date,
+		Note:   o.Note,
+	}
+}
+
+func (o BatchOperation) Money() *Money {
+	return &Money{
+		FromUserID: o.FromUserID,
+		ToUserID:   o.ToUserID,
+		Amount:     o.Amount,
+		Date:       o.Date,
+		Note:       o.Note,
+	}
+}
+
+func (o BatchOperation) Transaction() *Transaction {
+	return &Transaction{
+		UserID:    o.UserID,
+		Amount:    o.Amount,
+		Date:      o.Date,
+		ServiceID: o.ServiceID,
+		OrderID:   o.OrderID,
+		Note:      o.Note,
+	}
+}
+
+// UserIDs возвращает id пользователей, чьи строки блокирует операция
+func (o BatchOperation) UserIDs() []int {
+	if o.Type == OperationTransfer {
+		return []int{o.FromUserID, o.ToUserID}
+	}
+	return []int{o.UserID}
+}
+
+func (o BatchOperation) Validate() error {
+	switch o.Type {
+	case OperationTopup:
+		return o.Replenishment().Validate()
+	case OperationTransfer:
+		return o.Money().Validate()
+	case OperationReserve, OperationConfirm, OperationCancel:
+		return o.Transaction().Validate()
+	default:
+		return errors.New("неизвестный тип операции")
+	}
+}
+
+func (r RequestBatch) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(
+			&r.Mode,
+			validation.Required.Error("режим выполнения не может быть не указан"),
+			validation.In(BatchModeAtomic, BatchModeIndependent).Error("режим выполнения должен быть atomic или independent")),
+		validation.Field(
+			&r.Operations,
+			validation.Required.Error("список операций не может быть пустым"),
+			validation.Length(1, MaxBatchOperations).Error("превышено максимальное количество операций в пакете"),
+			validation.Skip))
+}
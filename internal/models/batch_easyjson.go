@@ -0,0 +1,428 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson917759c2DecodeUserbalanceInternalModels(in *jlexer.Lexer, out *RequestBatch) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "mode":
+			out.Mode = string(in.String())
+		case "operations":
+			if in.IsNull() {
+				in.Skip()
+				out.Operations = nil
+			} else {
+				in.Delim('[')
+				if out.Operations == nil {
+					if !in.IsDelim(']') {
+						out.Operations = make([]BatchOperation, 0, 0)
+					} else {
+						out.Operations = []BatchOperation{}
+					}
+				} else {
+					out.Operations = (out.Operations)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 BatchOperation
+					(v1).UnmarshalEasyJSON(in)
+					out.Operations = append(out.Operations, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson917759c2EncodeUserbalanceInternalModels(out *jwriter.Writer, in RequestBatch) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"mode\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Mode))
+	}
+	{
+		const prefix string = ",\"operations\":"
+		out.RawString(prefix)
+		if in.Operations == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Operations {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v RequestBatch) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson917759c2EncodeUserbalanceInternalModels(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *RequestBatch) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson917759c2DecodeUserbalanceInternalModels(l, v)
+}
+func easyjson917759c2DecodeUserbalanceInternalModels1(in *jlexer.Lexer, out *BatchResults) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "mode":
+			out.Mode = string(in.String())
+		case "committed":
+			out.Committed = bool(in.Bool())
+		case "entity":
+			if in.IsNull() {
+				in.Skip()
+				out.Entity = nil
+			} else {
+				in.Delim('[')
+				if out.Entity == nil {
+					if !in.IsDelim(']') {
+						out.Entity = make([]BatchResult, 0, 1)
+					} else {
+						out.Entity = []BatchResult{}
+					}
+				} else {
+					out.Entity = (out.Entity)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v4 BatchResult
+					(v4).UnmarshalEasyJSON(in)
+					out.Entity = append(out.Entity, v4)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson917759c2EncodeUserbalanceInternalModels1(out *jwriter.Writer, in BatchResults) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"mode\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Mode))
+	}
+	{
+		const prefix string = ",\"committed\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Committed))
+	}
+	{
+		const prefix string = ",\"entity\":"
+		out.RawString(prefix)
+		if in.Entity == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v5, v6 := range in.Entity {
+				if v5 > 0 {
+					out.RawByte(',')
+				}
+				(v6).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v BatchResults) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson917759c2EncodeUserbalanceInternalModels1(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *BatchResults) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson917759c2DecodeUserbalanceInternalModels1(l, v)
+}
+func easyjson917759c2DecodeUserbalanceInternalModels2(in *jlexer.Lexer, out *BatchResult) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "index":
+			out.Index = int(in.Int())
+		case "type":
+			out.Type = string(in.String())
+		case "status":
+			out.Status = string(in.String())
+		case "message":
+			out.Message = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson917759c2EncodeUserbalanceInternalModels2(out *jwriter.Writer, in BatchResult) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"index\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.Index))
+	}
+	{
+		const prefix string = ",\"type\":"
+		out.RawString(prefix)
+		out.String(string(in.Type))
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	if in.Message != "" {
+		const prefix string = ",\"message\":"
+		out.RawString(prefix)
+		out.String(string(in.Message))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v BatchResult) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson917759c2EncodeUserbalanceInternalModels2(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *BatchResult) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson917759c2DecodeUserbalanceInternalModels2(l, v)
+}
+func easyjson917759c2DecodeUserbalanceInternalModels3(in *jlexer.Lexer, out *BatchOperation) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "type":
+			out.Type = string(in.String())
+		case "userid":
+			out.UserID = int(in.Int())
+		case "fromuserid":
+			out.FromUserID = int(in.Int())
+		case "touserid":
+			out.ToUserID = int(in.Int())
+		case "amount":
+			out.Amount = int(in.Int())
+		case "date":
+			out.Date = string(in.String())
+		case "serviceid":
+			out.ServiceID = int(in.Int())
+		case "orderid":
+			out.OrderID = int(in.Int())
+		case "comment":
+			out.Comment = string(in.String())
+		case "metadata":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Metadata = make(Metadata)
+				} else {
+					out.Metadata = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v7 string
+					v7 = string(in.String())
+					(out.Metadata)[key] = v7
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson917759c2EncodeUserbalanceInternalModels3(out *jwriter.Writer, in BatchOperation) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"type\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Type))
+	}
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix)
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"fromuserid\":"
+		out.RawString(prefix)
+		out.Int(int(in.FromUserID))
+	}
+	{
+		const prefix string = ",\"touserid\":"
+		out.RawString(prefix)
+		out.Int(int(in.ToUserID))
+	}
+	{
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Int(int(in.Amount))
+	}
+	{
+		const prefix string = ",\"date\":"
+		out.RawString(prefix)
+		out.String(string(in.Date))
+	}
+	{
+		const prefix string = ",\"serviceid\":"
+		out.RawString(prefix)
+		out.Int(int(in.ServiceID))
+	}
+	{
+		const prefix string = ",\"orderid\":"
+		out.RawString(prefix)
+		out.Int(int(in.OrderID))
+	}
+	if in.Comment != "" {
+		const prefix string = ",\"comment\":"
+		out.RawString(prefix)
+		out.String(string(in.Comment))
+	}
+	if len(in.Metadata) != 0 {
+		const prefix string = ",\"metadata\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v8First := true
+			for v8Name, v8Value := range in.Metadata {
+				if v8First {
+					v8First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v8Name))
+				out.RawByte(':')
+				out.String(string(v8Value))
+			}
+			out.RawByte('}')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v BatchOperation) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson917759c2EncodeUserbalanceInternalModels3(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *BatchOperation) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson917759c2DecodeUserbalanceInternalModels3(l, v)
+}
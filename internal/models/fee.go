@@ -0,0 +1,55 @@
+//go:generate easyjson -no_std_marshalers fee.go
+package models
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// OperationFee - операция списания комиссии в журнале. Комиссия записывается отдельно
+// от перевода или резервирования и не учитывается в их лимитах и проверках
+const OperationFee string = "fee"
+
+// FeeOperations - операции, за которые взимается комиссия
+var FeeOperations = []string{OperationTransfer, OperationReserve}
+
+//easyjson:json
+type (
+	// FeeRequest - операция, комиссию за которую нужно рассчитать. ServiceID указывается
+	// для резервирования
+	FeeRequest struct {
+		Operation string `json:"operation"`
+		ServiceID int    `json:"serviceid,omitempty"`
+		Amount    int    `json:"amount"`
+	}
+
+	// FeeQuote - комиссия за операцию: Total списывается с основного счета плательщика,
+	// Rule - правило, по которому рассчитана комиссия, пустое - операция без комиссии
+	FeeQuote struct {
+		Operation string `json:"operation"`
+		ServiceID int    `json:"serviceid,omitempty"`
+		Amount    int    `json:"amount"`
+		Fee       int    `json:"fee"`
+		Total     int    `json:"total"`
+		Rule      string `json:"rule,omitempty"`
+	}
+)
+
+func (f FeeRequest) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.Operation,
+			validation.Required.Error("операция не может быть не указана"),
+			validation.In(OperationTransfer, OperationReserve).Error("комиссия рассчитывается для операций transfer и reserve")),
+		validation.Field(&f.ServiceID,
+			validation.Min(0).Error("id услуги не может быть < 0"),
+			validation.By(func(value interface{}) error {
+				if f.Operation == OperationReserve && f.ServiceID == 0 {
+					return errors.New("id услуги не может быть не указан для резервирования")
+				}
+				return nil
+			})),
+		validation.Field(&f.Amount,
+			validation.Required.Error("сумма не может быть не указана либо <= 0"),
+			validation.Min(1).Error("сумма не может быть <= 0")))
+}
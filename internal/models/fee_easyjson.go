@@ -0,0 +1,172 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson8a3086aeDecodeUserbalanceInternalModels(in *jlexer.Lexer, out *FeeRequest) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "operation":
+			out.Operation = string(in.String())
+		case "serviceid":
+			out.ServiceID = int(in.Int())
+		case "amount":
+			out.Amount = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson8a3086aeEncodeUserbalanceInternalModels(out *jwriter.Writer, in FeeRequest) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"operation\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Operation))
+	}
+	if in.ServiceID != 0 {
+		const prefix string = ",\"serviceid\":"
+		out.RawString(prefix)
+		out.Int(int(in.ServiceID))
+	}
+	{
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Int(int(in.Amount))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v FeeRequest) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson8a3086aeEncodeUserbalanceInternalModels(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *FeeRequest) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson8a3086aeDecodeUserbalanceInternalModels(l, v)
+}
+func easyjson8a3086aeDecodeUserbalanceInternalModels1(in *jlexer.Lexer, out *FeeQuote) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "operation":
+			out.Operation = string(in.String())
+		case "serviceid":
+			out.ServiceID = int(in.Int())
+		case "amount":
+			out.Amount = int(in.Int())
+		case "fee":
+			out.Fee = int(in.Int())
+		case "total":
+			out.Total = int(in.Int())
+		case "rule":
+			out.Rule = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson8a3086aeEncodeUserbalanceInternalModels1(out *jwriter.Writer, in FeeQuote) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"operation\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Operation))
+	}
+	if in.ServiceID != 0 {
+		const prefix string = ",\"serviceid\":"
+		out.RawString(prefix)
+		out.Int(int(in.ServiceID))
+	}
+	{
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Int(int(in.Amount))
+	}
+	{
+		const prefix string = ",\"fee\":"
+		out.RawString(prefix)
+		out.Int(int(in.Fee))
+	}
+	{
+		const prefix string = ",\"total\":"
+		out.RawString(prefix)
+		out.Int(int(in.Total))
+	}
+	if in.Rule != "" {
+		const prefix string = ",\"rule\":"
+		out.RawString(prefix)
+		out.String(string(in.Rule))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v FeeQuote) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson8a3086aeEncodeUserbalanceInternalModels1(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *FeeQuote) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson8a3086aeDecodeUserbalanceInternalModels1(l, v)
+}
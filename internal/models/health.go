@@ -0,0 +1,28 @@
+//go:generate easyjson -no_std_marshalers health.go
+package models
+
+const (
+	HealthStatusOK       string = "ok"
+	HealthStatusFail     string = "fail"
+	HealthStatusDraining string = "draining"
+)
+
+//easyjson:json
+type (
+	// HealthCheck - результат одной проверки готовности: БД, миграций или фонового процесса
+	HealthCheck struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	Readiness struct {
+		Status string        `json:"status"`
+		Checks []HealthCheck `json:"checks"`
+	}
+)
+
+// Ready сообщает, что сервер готов принимать запросы
+func (r *Readiness) Ready() bool {
+	return r.Status == HealthStatusOK
+}
@@ -2,6 +2,8 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -13,16 +15,21 @@ type (
 		Date        time.Time `json:"date"`
 		Amount      int       `json:"amount"`
 		Description string    `json:"description"`
+		Note
 	}
 
 	Histories struct {
 		Entity []History `json:"entity"`
 	}
 
+	// RequestHistory - запрос истории пользователя. MetadataKey отбирает записи, в метаданных
+	// которых есть этот ключ, а вместе с MetadataValue - ключ с этим значением
 	RequestHistory struct {
-		UserID    int    `json:"userid"`
-		SortField string `json:"sortfield"`
-		Direction string `json:"direction"`
+		UserID        int    `json:"userid"`
+		SortField     string `json:"sortfield"`
+		Direction     string `json:"direction"`
+		MetadataKey   string `json:"metadatakey,omitempty"`
+		MetadataValue string `json:"metadatavalue,omitempty"`
 	}
 )
 
@@ -31,5 +38,16 @@ func (r RequestHistory) Validate() error {
 		validation.Field(
 			&r.UserID,
 			validation.Required.Error("id пользователя не может быть <= 0"),
-			validation.Min(1).Error("id пользователя не может быть <= 0")))
+			validation.Min(1).Error("id пользователя не может быть <= 0")),
+		validation.Field(
+			&r.MetadataKey,
+			validation.RuneLength(0, MaxMetadataKeyLength).Error(fmt.Sprintf("ключ метаданных не может быть длиннее %d символов", MaxMetadataKeyLength))),
+		validation.Field(
+			&r.MetadataValue,
+			validation.By(func(value interface{}) error {
+				if r.MetadataKey == "" && r.MetadataValue != "" {
+					return errors.New("значение метаданных указывается вместе с ключом")
+				}
+				return nil
+			})))
 }
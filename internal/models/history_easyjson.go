@@ -42,6 +42,10 @@ func easyjson40eb0d12DecodeUserbalanceInternalModels(in *jlexer.Lexer, out *Requ
 			out.SortField = string(in.String())
 		case "direction":
 			out.Direction = string(in.String())
+		case "metadatakey":
+			out.MetadataKey = string(in.String())
+		case "metadatavalue":
+			out.MetadataValue = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -71,6 +75,16 @@ func easyjson40eb0d12EncodeUserbalanceInternalModels(out *jwriter.Writer, in Req
 		out.RawString(prefix)
 		out.String(string(in.Direction))
 	}
+	if in.MetadataKey != "" {
+		const prefix string = ",\"metadatakey\":"
+		out.RawString(prefix)
+		out.String(string(in.MetadataKey))
+	}
+	if in.MetadataValue != "" {
+		const prefix string = ",\"metadatavalue\":"
+		out.RawString(prefix)
+		out.String(string(in.MetadataValue))
+	}
 	out.RawByte('}')
 }
 
@@ -110,6 +124,28 @@ func easyjson40eb0d12DecodeUserbalanceInternalModels1(in *jlexer.Lexer, out *His
 			out.Amount = int(in.Int())
 		case "description":
 			out.Description = string(in.String())
+		case "comment":
+			out.Comment = string(in.String())
+		case "metadata":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Metadata = make(Metadata)
+				} else {
+					out.Metadata = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v1 string
+					v1 = string(in.String())
+					(out.Metadata)[key] = v1
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -139,6 +175,30 @@ func easyjson40eb0d12EncodeUserbalanceInternalModels1(out *jwriter.Writer, in Hi
 		out.RawString(prefix)
 		out.String(string(in.Description))
 	}
+	if in.Comment != "" {
+		const prefix string = ",\"comment\":"
+		out.RawString(prefix)
+		out.String(string(in.Comment))
+	}
+	if len(in.Metadata) != 0 {
+		const prefix string = ",\"metadata\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v2First := true
+			for v2Name, v2Value := range in.Metadata {
+				if v2First {
+					v2First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v2Name))
+				out.RawByte(':')
+				out.String(string(v2Value))
+			}
+			out.RawByte('}')
+		}
+	}
 	out.RawByte('}')
 }
 
@@ -178,7 +238,7 @@ func easyjson40eb0d12DecodeUserbalanceInternalModels2(in *jlexer.Lexer, out *His
 				in.Delim('[')
 				if out.Entity == nil {
 					if !in.IsDelim(']') {
-						out.Entity = make([]History, 0, 1)
+						out.Entity = make([]History, 0, 0)
 					} else {
 						out.Entity = []History{}
 					}
@@ -186,9 +246,9 @@ func easyjson40eb0d12DecodeUserbalanceInternalModels2(in *jlexer.Lexer, out *His
 					out.Entity = (out.Entity)[:0]
 				}
 				for !in.IsDelim(']') {
-					var v1 History
-					(v1).UnmarshalEasyJSON(in)
-					out.Entity = append(out.Entity, v1)
+					var v3 History
+					(v3).UnmarshalEasyJSON(in)
+					out.Entity = append(out.Entity, v3)
 					in.WantComma()
 				}
 				in.Delim(']')
@@ -214,11 +274,11 @@ func easyjson40eb0d12EncodeUserbalanceInternalModels2(out *jwriter.Writer, in Hi
 			out.RawString("null")
 		} else {
 			out.RawByte('[')
-			for v2, v3 := range in.Entity {
-				if v2 > 0 {
+			for v4, v5 := range in.Entity {
+				if v4 > 0 {
 					out.RawByte(',')
 				}
-				(v3).MarshalEasyJSON(out)
+				(v5).MarshalEasyJSON(out)
 			}
 			out.RawByte(']')
 		}
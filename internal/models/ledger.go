@@ -0,0 +1,52 @@
+//go:generate easyjson -no_std_marshalers ledger.go
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+const (
+	AccountMain    string = "main"
+	AccountReserve string = "reserve"
+)
+
+//easyjson:json
+type (
+	// LedgerEntry - изменение основного или резервного счета пользователя со знаком
+	LedgerEntry struct {
+		ID          int       `json:"id"`
+		UserID      int       `json:"userid"`
+		Account     string    `json:"account"`
+		Amount      int       `json:"amount"`
+		CreatedAt   time.Time `json:"createdat"`
+		Description string    `json:"description"`
+		// Operation - операция, сделавшая запись, по записям операций считаются лимиты
+		Operation string `json:"operation,omitempty"`
+		// CounterpartyID - второй участник перевода, по нему считаются получатели переводов пользователя
+		CounterpartyID int `json:"counterpartyid,omitempty"`
+	}
+
+	BalanceAt struct {
+		UserID  int       `json:"userid"`
+		Balance int       `json:"balance"`
+		Reserve int       `json:"reserve"`
+		At      time.Time `json:"at"`
+	}
+
+	// Snapshot - остатки пользователя по всем записям журнала, сделанным до TakenAt
+	Snapshot struct {
+		UserID  int
+		TakenAt time.Time
+		Balance int
+		Reserve int
+	}
+)
+
+func (b BalanceAt) Validate() error {
+	return validation.ValidateStruct(&b,
+		validation.Field(&b.UserID,
+			validation.Required.Error("id пользователя не может быть не указан либо <= 0"),
+			validation.Min(1).Error("id пользователя не может быть <= 0")))
+}
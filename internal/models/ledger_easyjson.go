@@ -0,0 +1,272 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson5d9943f7DecodeUserbalanceInternalModels(in *jlexer.Lexer, out *Snapshot) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "UserID":
+			out.UserID = int(in.Int())
+		case "TakenAt":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.TakenAt).UnmarshalJSON(data))
+			}
+		case "Balance":
+			out.Balance = int(in.Int())
+		case "Reserve":
+			out.Reserve = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5d9943f7EncodeUserbalanceInternalModels(out *jwriter.Writer, in Snapshot) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"UserID\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"TakenAt\":"
+		out.RawString(prefix)
+		out.Raw((in.TakenAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"Balance\":"
+		out.RawString(prefix)
+		out.Int(int(in.Balance))
+	}
+	{
+		const prefix string = ",\"Reserve\":"
+		out.RawString(prefix)
+		out.Int(int(in.Reserve))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Snapshot) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson5d9943f7EncodeUserbalanceInternalModels(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Snapshot) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson5d9943f7DecodeUserbalanceInternalModels(l, v)
+}
+func easyjson5d9943f7DecodeUserbalanceInternalModels1(in *jlexer.Lexer, out *LedgerEntry) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = int(in.Int())
+		case "userid":
+			out.UserID = int(in.Int())
+		case "account":
+			out.Account = string(in.String())
+		case "amount":
+			out.Amount = int(in.Int())
+		case "createdat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CreatedAt).UnmarshalJSON(data))
+			}
+		case "description":
+			out.Description = string(in.String())
+		case "operation":
+			out.Operation = string(in.String())
+		case "counterpartyid":
+			out.CounterpartyID = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5d9943f7EncodeUserbalanceInternalModels1(out *jwriter.Writer, in LedgerEntry) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.ID))
+	}
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix)
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"account\":"
+		out.RawString(prefix)
+		out.String(string(in.Account))
+	}
+	{
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Int(int(in.Amount))
+	}
+	{
+		const prefix string = ",\"createdat\":"
+		out.RawString(prefix)
+		out.Raw((in.CreatedAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"description\":"
+		out.RawString(prefix)
+		out.String(string(in.Description))
+	}
+	if in.Operation != "" {
+		const prefix string = ",\"operation\":"
+		out.RawString(prefix)
+		out.String(string(in.Operation))
+	}
+	if in.CounterpartyID != 0 {
+		const prefix string = ",\"counterpartyid\":"
+		out.RawString(prefix)
+		out.Int(int(in.CounterpartyID))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v LedgerEntry) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson5d9943f7EncodeUserbalanceInternalModels1(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *LedgerEntry) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson5d9943f7DecodeUserbalanceInternalModels1(l, v)
+}
+func easyjson5d9943f7DecodeUserbalanceInternalModels2(in *jlexer.Lexer, out *BalanceAt) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "userid":
+			out.UserID = int(in.Int())
+		case "balance":
+			out.Balance = int(in.Int())
+		case "reserve":
+			out.Reserve = int(in.Int())
+		case "at":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.At).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5d9943f7EncodeUserbalanceInternalModels2(out *jwriter.Writer, in BalanceAt) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"balance\":"
+		out.RawString(prefix)
+		out.Int(int(in.Balance))
+	}
+	{
+		const prefix string = ",\"reserve\":"
+		out.RawString(prefix)
+		out.Int(int(in.Reserve))
+	}
+	{
+		const prefix string = ",\"at\":"
+		out.RawString(prefix)
+		out.Raw((in.At).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v BalanceAt) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson5d9943f7EncodeUserbalanceInternalModels2(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *BalanceAt) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson5d9943f7DecodeUserbalanceInternalModels2(l, v)
+}
@@ -0,0 +1,64 @@
+//go:generate easyjson -no_std_marshalers limit.go
+package models
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Виды лимитов: на сумму одной операции и на сумму операций за последние сутки
+const (
+	LimitPerOperation string = "operation"
+	LimitDaily        string = "daily"
+
+	// ErrorCodeLimitExceeded - код ответа на операцию, превысившую лимит
+	ErrorCodeLimitExceeded string = "limit_exceeded"
+)
+
+// LimitedOperations - операции, для которых действуют лимиты
+var LimitedOperations = []string{OperationTopup, OperationTransfer, OperationReserve}
+
+//easyjson:json
+type (
+	// SpendingLimit - лимиты операции пользователя, нулевое значение снимает ограничение.
+	// Лимиты пользователя заменяют значения по умолчанию из конфигурации
+	SpendingLimit struct {
+		UserID       int    `json:"userid"`
+		Operation    string `json:"operation"`
+		PerOperation int    `json:"peroperation"`
+		Daily        int    `json:"daily"`
+		// Override - лимиты заданы для пользователя, а не взяты из конфигурации
+		Override bool `json:"override"`
+	}
+
+	SpendingLimits struct {
+		Entity []SpendingLimit `json:"entity"`
+	}
+
+	// LimitExceeded - ответ на операцию, превысившую лимит: Limit - значение лимита вида Kind,
+	// Remaining - сумма, которую пользователь может провести этой операцией сейчас
+	LimitExceeded struct {
+		Message   string `json:"message"`
+		Code      string `json:"code"`
+		Operation string `json:"operation"`
+		Kind      string `json:"kind"`
+		Limit     int    `json:"limit"`
+		Remaining int    `json:"remaining"`
+	}
+)
+
+func (l SpendingLimit) Validate() error {
+	operations := make([]interface{}, 0, len(LimitedOperations))
+	for _, operation := range LimitedOperations {
+		operations = append(operations, operation)
+	}
+
+	return validation.ValidateStruct(&l,
+		validation.Field(&l.UserID,
+			validation.Required.Error("id пользователя не может быть не указан либо <= 0"),
+			validation.Min(1).Error("id пользователя не может быть <= 0")),
+		validation.Field(&l.Operation,
+			validation.Required.Error("операция не может быть не указана"),
+			validation.In(operations...).Error("лимиты действуют только для topup, transfer и reserve")),
+		validation.Field(&l.PerOperation, validation.Min(0).Error("лимит не может быть < 0")),
+		validation.Field(&l.Daily, validation.Min(0).Error("лимит не может быть < 0")))
+}
@@ -0,0 +1,270 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson2eec66abDecodeUserbalanceInternalModels(in *jlexer.Lexer, out *SpendingLimits) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "entity":
+			if in.IsNull() {
+				in.Skip()
+				out.Entity = nil
+			} else {
+				in.Delim('[')
+				if out.Entity == nil {
+					if !in.IsDelim(']') {
+						out.Entity = make([]SpendingLimit, 0, 1)
+					} else {
+						out.Entity = []SpendingLimit{}
+					}
+				} else {
+					out.Entity = (out.Entity)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 SpendingLimit
+					(v1).UnmarshalEasyJSON(in)
+					out.Entity = append(out.Entity, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2eec66abEncodeUserbalanceInternalModels(out *jwriter.Writer, in SpendingLimits) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"entity\":"
+		out.RawString(prefix[1:])
+		if in.Entity == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Entity {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v SpendingLimits) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2eec66abEncodeUserbalanceInternalModels(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *SpendingLimits) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2eec66abDecodeUserbalanceInternalModels(l, v)
+}
+func easyjson2eec66abDecodeUserbalanceInternalModels1(in *jlexer.Lexer, out *SpendingLimit) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "userid":
+			out.UserID = int(in.Int())
+		case "operation":
+			out.Operation = string(in.String())
+		case "peroperation":
+			out.PerOperation = int(in.Int())
+		case "daily":
+			out.Daily = int(in.Int())
+		case "override":
+			out.Override = bool(in.Bool())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2eec66abEncodeUserbalanceInternalModels1(out *jwriter.Writer, in SpendingLimit) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"operation\":"
+		out.RawString(prefix)
+		out.String(string(in.Operation))
+	}
+	{
+		const prefix string = ",\"peroperation\":"
+		out.RawString(prefix)
+		out.Int(int(in.PerOperation))
+	}
+	{
+		const prefix string = ",\"daily\":"
+		out.RawString(prefix)
+		out.Int(int(in.Daily))
+	}
+	{
+		const prefix string = ",\"override\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Override))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v SpendingLimit) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2eec66abEncodeUserbalanceInternalModels1(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *SpendingLimit) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2eec66abDecodeUserbalanceInternalModels1(l, v)
+}
+func easyjson2eec66abDecodeUserbalanceInternalModels2(in *jlexer.Lexer, out *LimitExceeded) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "message":
+			out.Message = string(in.String())
+		case "code":
+			out.Code = string(in.String())
+		case "operation":
+			out.Operation = string(in.String())
+		case "kind":
+			out.Kind = string(in.String())
+		case "limit":
+			out.Limit = int(in.Int())
+		case "remaining":
+			out.Remaining = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2eec66abEncodeUserbalanceInternalModels2(out *jwriter.Writer, in LimitExceeded) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"message\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Message))
+	}
+	{
+		const prefix string = ",\"code\":"
+		out.RawString(prefix)
+		out.String(string(in.Code))
+	}
+	{
+		const prefix string = ",\"operation\":"
+		out.RawString(prefix)
+		out.String(string(in.Operation))
+	}
+	{
+		const prefix string = ",\"kind\":"
+		out.RawString(prefix)
+		out.String(string(in.Kind))
+	}
+	{
+		const prefix string = ",\"limit\":"
+		out.RawString(prefix)
+		out.Int(int(in.Limit))
+	}
+	{
+		const prefix string = ",\"remaining\":"
+		out.RawString(prefix)
+		out.Int(int(in.Remaining))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v LimitExceeded) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2eec66abEncodeUserbalanceInternalModels2(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *LimitExceeded) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2eec66abDecodeUserbalanceInternalModels2(l, v)
+}
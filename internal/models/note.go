@@ -0,0 +1,101 @@
+//go:generate easyjson -no_std_marshalers note.go
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Ограничения комментария и метаданных операции
+const (
+	MaxCommentLength       int = 255
+	MaxMetadataKeys        int = 16
+	MaxMetadataKeyLength   int = 64
+	MaxMetadataValueLength int = 255
+)
+
+// Metadata - произвольные пары ключ-значение операции, в БД хранятся как jsonb
+type Metadata map[string]string
+
+//easyjson:json
+type (
+	// Note - необязательные комментарий и метаданные пополнения, перевода или резервирования,
+	// сохраняются в записях истории операции и возвращаются в /history
+	Note struct {
+		Comment  string   `json:"comment,omitempty"`
+		Metadata Metadata `json:"metadata,omitempty"`
+	}
+)
+
+func (n Note) IsEmpty() bool {
+	return n.Comment == "" && len(n.Metadata) == 0
+}
+
+func (n Note) Validate() error {
+	return validation.ValidateStruct(&n,
+		validation.Field(&n.Comment,
+			validation.RuneLength(0, MaxCommentLength).Error(fmt.Sprintf("комментарий не может быть длиннее %d символов", MaxCommentLength))),
+		validation.Field(&n.Metadata, validation.By(validateMetadata)))
+}
+
+func validateMetadata(value interface{}) error {
+	metadata, _ := value.(Metadata)
+	if len(metadata) > MaxMetadataKeys {
+		return fmt.Errorf("метаданные не могут содержать больше %d ключей", MaxMetadataKeys)
+	}
+	for key, val := range metadata {
+		if key == "" {
+			return errors.New("ключ метаданных не может быть пустым")
+		}
+		if utf8.RuneCountInString(key) > MaxMetadataKeyLength {
+			return fmt.Errorf("ключ метаданных не может быть длиннее %d символов", MaxMetadataKeyLength)
+		}
+		if utf8.RuneCountInString(val) > MaxMetadataValueLength {
+			return fmt.Errorf("значение метаданных не может быть длиннее %d символов", MaxMetadataValueLength)
+		}
+	}
+	return nil
+}
+
+// Value сохраняет метаданные как JSON-объект, пустые - как {}
+func (m Metadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return "{}", nil
+	}
+	b, err := json.Marshal(map[string]string(m))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan читает метаданные из jsonb, пустой объект читается как nil
+func (m *Metadata) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("неподдерживаемый тип метаданных %T", src)
+	}
+
+	var metadata map[string]string
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return err
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	*m = metadata
+	return nil
+}
@@ -0,0 +1,73 @@
+//go:generate easyjson -no_std_marshalers pending.go
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Состояния перевода, ожидающего подтверждения получателем: expired - не подтвержден в срок
+const (
+	PendingTransferPending  string = "pending"
+	PendingTransferAccepted string = "accepted"
+	PendingTransferDeclined string = "declined"
+	PendingTransferExpired  string = "expired"
+)
+
+// Операции завершения перевода, ожидающего подтверждения. Удержание средств при его создании
+// записывается в журнал как transfer и учитывается в лимитах переводов
+const (
+	OperationTransferAccept  string = "transfer_accept"
+	OperationTransferDecline string = "transfer_decline"
+	OperationTransferExpire  string = "transfer_expire"
+)
+
+//easyjson:json
+type (
+	// PendingTransfer - перевод, ожидающий подтверждения получателем. До подтверждения сумма
+	// удерживается на резервном счете отправителя, после ExpiresAt возвращается отправителю
+	PendingTransfer struct {
+		ID         int        `json:"id"`
+		FromUserID int        `json:"fromuserid"`
+		ToUserID   int        `json:"touserid"`
+		Amount     int        `json:"amount"`
+		Status     string     `json:"status"`
+		ExpiresAt  time.Time  `json:"expiresat"`
+		CreatedAt  time.Time  `json:"createdat"`
+		ResolvedAt *time.Time `json:"resolvedat,omitempty"`
+	}
+
+	PendingTransfers struct {
+		Entity []PendingTransfer `json:"entity"`
+	}
+
+	// PendingTransferAction - подтверждение либо отклонение перевода получателем UserID
+	PendingTransferAction struct {
+		UserID int `json:"userid"`
+	}
+
+	// PendingTransferFilter - отбор переводов, UserID - отправитель либо получатель,
+	// пустые поля не ограничивают выборку
+	PendingTransferFilter struct {
+		UserID int    `json:"userid"`
+		Status string `json:"status"`
+		Limit  int    `json:"limit"`
+	}
+)
+
+func (a PendingTransferAction) Validate() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.UserID,
+			validation.Required.Error("id получателя не может быть не указан либо <= 0"),
+			validation.Min(1).Error("id получателя не может быть <= 0")))
+}
+
+func (f PendingTransferFilter) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.UserID, validation.Min(0).Error("id пользователя не может быть < 0")),
+		validation.Field(&f.Status,
+			validation.In(PendingTransferPending, PendingTransferAccepted, PendingTransferDeclined, PendingTransferExpired).
+				Error("состояние может быть pending, accepted, declined либо expired")),
+		validation.Field(&f.Limit, validation.Min(0).Error("количество записей не может быть < 0")))
+}
@@ -0,0 +1,337 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+	time "time"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson6e0e5e1fDecodeUserbalanceInternalModels(in *jlexer.Lexer, out *PendingTransfers) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "entity":
+			if in.IsNull() {
+				in.Skip()
+				out.Entity = nil
+			} else {
+				in.Delim('[')
+				if out.Entity == nil {
+					if !in.IsDelim(']') {
+						out.Entity = make([]PendingTransfer, 0, 0)
+					} else {
+						out.Entity = []PendingTransfer{}
+					}
+				} else {
+					out.Entity = (out.Entity)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 PendingTransfer
+					(v1).UnmarshalEasyJSON(in)
+					out.Entity = append(out.Entity, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6e0e5e1fEncodeUserbalanceInternalModels(out *jwriter.Writer, in PendingTransfers) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"entity\":"
+		out.RawString(prefix[1:])
+		if in.Entity == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Entity {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PendingTransfers) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6e0e5e1fEncodeUserbalanceInternalModels(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PendingTransfers) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6e0e5e1fDecodeUserbalanceInternalModels(l, v)
+}
+func easyjson6e0e5e1fDecodeUserbalanceInternalModels1(in *jlexer.Lexer, out *PendingTransferFilter) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "userid":
+			out.UserID = int(in.Int())
+		case "status":
+			out.Status = string(in.String())
+		case "limit":
+			out.Limit = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6e0e5e1fEncodeUserbalanceInternalModels1(out *jwriter.Writer, in PendingTransferFilter) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	{
+		const prefix string = ",\"limit\":"
+		out.RawString(prefix)
+		out.Int(int(in.Limit))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PendingTransferFilter) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6e0e5e1fEncodeUserbalanceInternalModels1(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PendingTransferFilter) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6e0e5e1fDecodeUserbalanceInternalModels1(l, v)
+}
+func easyjson6e0e5e1fDecodeUserbalanceInternalModels2(in *jlexer.Lexer, out *PendingTransferAction) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "userid":
+			out.UserID = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6e0e5e1fEncodeUserbalanceInternalModels2(out *jwriter.Writer, in PendingTransferAction) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.UserID))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PendingTransferAction) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6e0e5e1fEncodeUserbalanceInternalModels2(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PendingTransferAction) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6e0e5e1fDecodeUserbalanceInternalModels2(l, v)
+}
+func easyjson6e0e5e1fDecodeUserbalanceInternalModels3(in *jlexer.Lexer, out *PendingTransfer) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = int(in.Int())
+		case "fromuserid":
+			out.FromUserID = int(in.Int())
+		case "touserid":
+			out.ToUserID = int(in.Int())
+		case "amount":
+			out.Amount = int(in.Int())
+		case "status":
+			out.Status = string(in.String())
+		case "expiresat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.ExpiresAt).UnmarshalJSON(data))
+			}
+		case "createdat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CreatedAt).UnmarshalJSON(data))
+			}
+		case "resolvedat":
+			if in.IsNull() {
+				in.Skip()
+				out.ResolvedAt = nil
+			} else {
+				if out.ResolvedAt == nil {
+					out.ResolvedAt = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.ResolvedAt).UnmarshalJSON(data))
+				}
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson6e0e5e1fEncodeUserbalanceInternalModels3(out *jwriter.Writer, in PendingTransfer) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.ID))
+	}
+	{
+		const prefix string = ",\"fromuserid\":"
+		out.RawString(prefix)
+		out.Int(int(in.FromUserID))
+	}
+	{
+		const prefix string = ",\"touserid\":"
+		out.RawString(prefix)
+		out.Int(int(in.ToUserID))
+	}
+	{
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Int(int(in.Amount))
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	{
+		const prefix string = ",\"expiresat\":"
+		out.RawString(prefix)
+		out.Raw((in.ExpiresAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"createdat\":"
+		out.RawString(prefix)
+		out.Raw((in.CreatedAt).MarshalJSON())
+	}
+	if in.ResolvedAt != nil {
+		const prefix string = ",\"resolvedat\":"
+		out.RawString(prefix)
+		out.Raw((*in.ResolvedAt).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PendingTransfer) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson6e0e5e1fEncodeUserbalanceInternalModels3(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PendingTransfer) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson6e0e5e1fDecodeUserbalanceInternalModels3(l, v)
+}
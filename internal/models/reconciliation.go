@@ -0,0 +1,37 @@
+//go:generate easyjson -no_std_marshalers reconciliation.go
+package models
+
+import "time"
+
+const (
+	ReconciliationFormatJSON string = "json"
+	ReconciliationFormatCSV  string = "csv"
+)
+
+//easyjson:json
+type (
+	// BalanceCheck - остатки пользователя в таблицах счетов и остатки, восстановленные по logs,
+	// money_reserve_details и report
+	BalanceCheck struct {
+		UserID          int `json:"userid"`
+		Balance         int `json:"balance"`
+		ExpectedBalance int `json:"expectedbalance"`
+		Reserve         int `json:"reserve"`
+		ExpectedReserve int `json:"expectedreserve"`
+		ReserveDetails  int `json:"reservedetails"`
+	}
+
+	ReconciliationReport struct {
+		CheckedAt  time.Time      `json:"checkedat"`
+		Users      int            `json:"users"`
+		Mismatches int            `json:"mismatches"`
+		Entity     []BalanceCheck `json:"entity"`
+	}
+)
+
+// Matches сообщает, что остатки пользователя совпадают с восстановленными по журналам
+func (b BalanceCheck) Matches() bool {
+	return b.Balance == b.ExpectedBalance &&
+		b.Reserve == b.ExpectedReserve &&
+		b.Reserve == b.ReserveDetails
+}
@@ -0,0 +1,213 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjsonEae9a35fDecodeUserbalanceInternalModels(in *jlexer.Lexer, out *ReconciliationReport) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "checkedat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CheckedAt).UnmarshalJSON(data))
+			}
+		case "users":
+			out.Users = int(in.Int())
+		case "mismatches":
+			out.Mismatches = int(in.Int())
+		case "entity":
+			if in.IsNull() {
+				in.Skip()
+				out.Entity = nil
+			} else {
+				in.Delim('[')
+				if out.Entity == nil {
+					if !in.IsDelim(']') {
+						out.Entity = make([]BalanceCheck, 0, 1)
+					} else {
+						out.Entity = []BalanceCheck{}
+					}
+				} else {
+					out.Entity = (out.Entity)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 BalanceCheck
+					(v1).UnmarshalEasyJSON(in)
+					out.Entity = append(out.Entity, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonEae9a35fEncodeUserbalanceInternalModels(out *jwriter.Writer, in ReconciliationReport) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"checkedat\":"
+		out.RawString(prefix[1:])
+		out.Raw((in.CheckedAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"users\":"
+		out.RawString(prefix)
+		out.Int(int(in.Users))
+	}
+	{
+		const prefix string = ",\"mismatches\":"
+		out.RawString(prefix)
+		out.Int(int(in.Mismatches))
+	}
+	{
+		const prefix string = ",\"entity\":"
+		out.RawString(prefix)
+		if in.Entity == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Entity {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ReconciliationReport) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonEae9a35fEncodeUserbalanceInternalModels(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ReconciliationReport) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonEae9a35fDecodeUserbalanceInternalModels(l, v)
+}
+func easyjsonEae9a35fDecodeUserbalanceInternalModels1(in *jlexer.Lexer, out *BalanceCheck) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "userid":
+			out.UserID = int(in.Int())
+		case "balance":
+			out.Balance = int(in.Int())
+		case "expectedbalance":
+			out.ExpectedBalance = int(in.Int())
+		case "reserve":
+			out.Reserve = int(in.Int())
+		case "expectedreserve":
+			out.ExpectedReserve = int(in.Int())
+		case "reservedetails":
+			out.ReserveDetails = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonEae9a35fEncodeUserbalanceInternalModels1(out *jwriter.Writer, in BalanceCheck) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"balance\":"
+		out.RawString(prefix)
+		out.Int(int(in.Balance))
+	}
+	{
+		const prefix string = ",\"expectedbalance\":"
+		out.RawString(prefix)
+		out.Int(int(in.ExpectedBalance))
+	}
+	{
+		const prefix string = ",\"reserve\":"
+		out.RawString(prefix)
+		out.Int(int(in.Reserve))
+	}
+	{
+		const prefix string = ",\"expectedreserve\":"
+		out.RawString(prefix)
+		out.Int(int(in.ExpectedReserve))
+	}
+	{
+		const prefix string = ",\"reservedetails\":"
+		out.RawString(prefix)
+		out.Int(int(in.ReserveDetails))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v BalanceCheck) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonEae9a35fEncodeUserbalanceInternalModels1(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *BalanceCheck) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonEae9a35fDecodeUserbalanceInternalModels1(l, v)
+}
@@ -0,0 +1,117 @@
+//go:generate easyjson -no_std_marshalers risk.go
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Решения проверки операции на мошенничество
+const (
+	RiskAllow  string = "allow"
+	RiskReview string = "review"
+	RiskDeny   string = "deny"
+)
+
+// Состояния операции, отправленной на проверку администратору
+const (
+	RiskStatusPending  string = "pending"
+	RiskStatusApproved string = "approved"
+	RiskStatusRejected string = "rejected"
+)
+
+// Коды ответа на операцию, остановленную проверкой
+const (
+	ErrorCodeRiskReview string = "risk_review"
+	ErrorCodeRiskDenied string = "risk_denied"
+)
+
+// RiskOperations - операции, которые проверяются перед выполнением
+var RiskOperations = []string{OperationTransfer, OperationReserve}
+
+//easyjson:json
+type (
+	// RiskOperation - операция, оцениваемая перед выполнением. Для операции, отправленной
+	// на проверку, по этим полям она выполняется после одобрения. Pending - перевод,
+	// ожидающий подтверждения получателем
+	RiskOperation struct {
+		Type      string `json:"type"`
+		UserID    int    `json:"userid"`
+		ToUserID  int    `json:"touserid,omitempty"`
+		ServiceID int    `json:"serviceid,omitempty"`
+		OrderID   int    `json:"orderid,omitempty"`
+		Amount    int    `json:"amount"`
+		Date      string `json:"date,omitempty"`
+		Pending   bool   `json:"pending,omitempty"`
+		Note
+	}
+
+	// RiskDecision - решение по операции и сработавшие правила. Status задан только для
+	// решения review: операция ожидает решения администратора, одобрена либо отклонена
+	RiskDecision struct {
+		ID         int           `json:"id"`
+		Decision   string        `json:"decision"`
+		Reasons    []string      `json:"reasons"`
+		Status     string        `json:"status,omitempty"`
+		Operation  RiskOperation `json:"operation"`
+		CreatedAt  time.Time     `json:"createdat"`
+		ResolvedAt *time.Time    `json:"resolvedat,omitempty"`
+		ResolvedBy string        `json:"resolvedby,omitempty"`
+	}
+
+	RiskDecisions struct {
+		Entity []RiskDecision `json:"entity"`
+	}
+
+	// RiskFilter - отбор решений, пустые поля не ограничивают выборку
+	RiskFilter struct {
+		Decision string `json:"decision"`
+		Status   string `json:"status"`
+		UserID   int    `json:"userid"`
+		Limit    int    `json:"limit"`
+	}
+
+	// RiskResponse - ответ на операцию, отклоненную проверкой либо отправленную администратору,
+	// ID - номер решения, по которому администратор одобряет или отклоняет операцию
+	RiskResponse struct {
+		Message  string   `json:"message"`
+		Code     string   `json:"code"`
+		ID       int      `json:"id"`
+		Decision string   `json:"decision"`
+		Reasons  []string `json:"reasons"`
+	}
+)
+
+// Transaction возвращает резервирование, отправленное на проверку
+func (o RiskOperation) Transaction() *Transaction {
+	return &Transaction{
+		UserID:    o.UserID,
+		Amount:    o.Amount,
+		Date:      o.Date,
+		ServiceID: o.ServiceID,
+		OrderID:   o.OrderID,
+		Note:      o.Note,
+	}
+}
+
+// Money возвращает перевод, отправленный на проверку
+func (o RiskOperation) Money() *Money {
+	return &Money{
+		FromUserID: o.UserID,
+		ToUserID:   o.ToUserID,
+		Amount:     o.Amount,
+		Date:       o.Date,
+		Note:       o.Note,
+	}
+}
+
+func (f RiskFilter) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.Decision,
+			validation.In(RiskAllow, RiskReview, RiskDeny).Error("решение может быть allow, review либо deny")),
+		validation.Field(&f.Status,
+			validation.In(RiskStatusPending, RiskStatusApproved, RiskStatusRejected).Error("состояние может быть pending, approved либо rejected")),
+		validation.Field(&f.UserID, validation.Min(0).Error("id пользователя не может быть < 0")),
+		validation.Field(&f.Limit, validation.Min(0).Error("количество записей не может быть < 0")))
+}
@@ -0,0 +1,219 @@
+//go:generate easyjson -no_std_marshalers schedule.go
+package models
+
+import (
+	"errors"
+	"time"
+	"userbalance/internal/cron"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Виды расписаний: однократное выполнение в RunAt, повтор через Interval начиная с RunAt
+// и повтор по расписанию Cron
+const (
+	ScheduleOnce     string = "once"
+	ScheduleInterval string = "interval"
+	ScheduleCron     string = "cron"
+)
+
+// Состояния расписания. completed и failed - однократная операция выполнена
+// либо не выполнена после всех попыток
+const (
+	ScheduleActive    string = "active"
+	SchedulePaused    string = "paused"
+	ScheduleCompleted string = "completed"
+	ScheduleFailed    string = "failed"
+	ScheduleCanceled  string = "canceled"
+)
+
+// Результаты выполнения операции по расписанию: retry - операция не выполнена и будет повторена
+const (
+	RunSucceeded string = "succeeded"
+	RunRetry     string = "retry"
+	RunFailed    string = "failed"
+)
+
+// MinScheduleInterval - наименьший интервал повторяющейся операции
+const MinScheduleInterval = time.Minute
+
+// ScheduledOperationTypes - операции, которые можно выполнять по расписанию
+var ScheduledOperationTypes = []string{OperationTransfer, OperationReserve}
+
+//easyjson:json
+type (
+	// Schedule - операция, выполняемая по расписанию: перевод пользователю ToUserID либо
+	// резервирование по заказу OrderID услуги ServiceID. NextRunAt - время следующего выполнения
+	// либо повторной попытки, Attempt - количество неудачных попыток текущего выполнения
+	Schedule struct {
+		ID        int        `json:"id"`
+		Operation string     `json:"operation"`
+		UserID    int        `json:"userid"`
+		ToUserID  int        `json:"touserid,omitempty"`
+		ServiceID int        `json:"serviceid,omitempty"`
+		OrderID   int        `json:"orderid,omitempty"`
+		Amount    int        `json:"amount"`
+		Kind      string     `json:"kind"`
+		RunAt     *time.Time `json:"runat,omitempty"`
+		Interval  string     `json:"interval,omitempty"`
+		Cron      string     `json:"cron,omitempty"`
+		Status    string     `json:"status"`
+		NextRunAt *time.Time `json:"nextrunat,omitempty"`
+		Attempt   int        `json:"attempt"`
+		CreatedAt time.Time  `json:"createdat"`
+		UpdatedAt time.Time  `json:"updatedat"`
+	}
+
+	Schedules struct {
+		Entity []Schedule `json:"entity"`
+	}
+
+	// ScheduleRun - попытка выполнения операции по расписанию, DueAt - время, на которое она была назначена
+	ScheduleRun struct {
+		ID         int       `json:"id"`
+		ScheduleID int       `json:"scheduleid"`
+		Attempt    int       `json:"attempt"`
+		Status     string    `json:"status"`
+		Error      string    `json:"error,omitempty"`
+		DueAt      time.Time `json:"dueat"`
+		ExecutedAt time.Time `json:"executedat"`
+	}
+
+	ScheduleRuns struct {
+		Entity []ScheduleRun `json:"entity"`
+	}
+
+	// ScheduleFilter - отбор расписаний, пустые поля не ограничивают выборку
+	ScheduleFilter struct {
+		UserID int    `json:"userid"`
+		Status string `json:"status"`
+		Limit  int    `json:"limit"`
+	}
+)
+
+// Transaction возвращает резервирование, выполняемое по расписанию
+func (s Schedule) Transaction() *Transaction {
+	return &Transaction{
+		UserID:    s.UserID,
+		Amount:    s.Amount,
+		ServiceID: s.ServiceID,
+		OrderID:   s.OrderID,
+	}
+}
+
+// Money возвращает перевод, выполняемый по расписанию
+func (s Schedule) Money() *Money {
+	return &Money{
+		FromUserID: s.UserID,
+		ToUserID:   s.ToUserID,
+		Amount:     s.Amount,
+	}
+}
+
+// Every возвращает интервал повторяющейся операции
+func (s Schedule) Every() time.Duration {
+	every, _ := time.ParseDuration(s.Interval)
+	return every
+}
+
+func (s Schedule) Validate() error {
+	operations := make([]interface{}, 0, len(ScheduledOperationTypes))
+	for _, operation := range ScheduledOperationTypes {
+		operations = append(operations, operation)
+	}
+	transfer, reserve := s.Operation == OperationTransfer, s.Operation == OperationReserve
+
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Operation,
+			validation.Required.Error("операция не может быть не указана"),
+			validation.In(operations...).Error("по расписанию выполняются только transfer и reserve")),
+		validation.Field(&s.UserID,
+			validation.Required.Error("id пользователя не может быть не указан либо <= 0"),
+			validation.Min(1).Error("id пользователя не может быть <= 0")),
+		validation.Field(&s.ToUserID,
+			validation.By(requiredIf(transfer, "id получателя не может быть не указан либо <= 0")),
+			validation.By(emptyUnless(transfer, "получатель указывается только для transfer")),
+			validation.NotIn(s.UserID).Error("невозможно перевести самому себе")),
+		validation.Field(&s.ServiceID,
+			validation.By(requiredIf(reserve, "id услуги не может быть не указан либо <= 0")),
+			validation.By(emptyUnless(reserve, "услуга указывается только для reserve"))),
+		validation.Field(&s.OrderID,
+			validation.By(requiredIf(reserve, "номер заказа не может быть не указан либо <= 0")),
+			validation.By(emptyUnless(reserve, "заказ указывается только для reserve"))),
+		validation.Field(&s.Amount,
+			validation.Required.Error("сумма операции должна быть больше 0"),
+			validation.Min(1).Error("сумма операции должна быть больше 0")),
+		validation.Field(&s.Kind,
+			validation.Required.Error("вид расписания не может быть не указан"),
+			validation.In(ScheduleOnce, ScheduleInterval, ScheduleCron).Error("вид расписания может быть once, interval либо cron")),
+		validation.Field(&s.RunAt,
+			validation.By(func(value interface{}) error {
+				if s.Kind == ScheduleOnce && s.RunAt == nil {
+					return errors.New("время выполнения не может быть не указано")
+				}
+				return nil
+			})),
+		validation.Field(&s.Interval,
+			validation.By(emptyUnless(s.Kind == ScheduleInterval, "интервал указывается только для вида interval")),
+			validation.By(s.validateInterval)),
+		validation.Field(&s.Cron,
+			validation.By(emptyUnless(s.Kind == ScheduleCron, "расписание cron указывается только для вида cron")),
+			validation.By(s.validateCron)))
+}
+
+func (s Schedule) validateInterval(value interface{}) error {
+	if s.Kind != ScheduleInterval {
+		return nil
+	}
+	every, err := time.ParseDuration(s.Interval)
+	if err != nil {
+		return errors.New("интервал должен быть указан как 30m, 24h или 720h")
+	}
+	if every < MinScheduleInterval {
+		return errors.New("интервал не может быть меньше минуты")
+	}
+	return nil
+}
+
+func (s Schedule) validateCron(value interface{}) error {
+	if s.Kind != ScheduleCron {
+		return nil
+	}
+	schedule, err := cron.Parse(s.Cron)
+	if err != nil {
+		return err
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return errors.New("по расписанию cron операция никогда не выполнится")
+	}
+	return nil
+}
+
+func (f ScheduleFilter) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.UserID, validation.Min(0).Error("id пользователя не может быть < 0")),
+		validation.Field(&f.Status,
+			validation.In(ScheduleActive, SchedulePaused, ScheduleCompleted, ScheduleFailed, ScheduleCanceled).
+				Error("состояние может быть active, paused, completed, failed либо canceled")),
+		validation.Field(&f.Limit, validation.Min(0).Error("количество записей не может быть < 0")))
+}
+
+// requiredIf требует положительного значения поля, если выполняется cond
+func requiredIf(cond bool, msg string) validation.RuleFunc {
+	return func(value interface{}) error {
+		if cond && value.(int) <= 0 {
+			return errors.New(msg)
+		}
+		return nil
+	}
+}
+
+// emptyUnless запрещает значение поля, если не выполняется cond
+func emptyUnless(cond bool, msg string) validation.RuleFunc {
+	return func(value interface{}) error {
+		if !cond && !validation.IsEmpty(value) {
+			return errors.New(msg)
+		}
+		return nil
+	}
+}
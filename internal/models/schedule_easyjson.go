@@ -0,0 +1,533 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+	time "time"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjsonA7c3c05fDecodeUserbalanceInternalModels(in *jlexer.Lexer, out *Schedules) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "entity":
+			if in.IsNull() {
+				in.Skip()
+				out.Entity = nil
+			} else {
+				in.Delim('[')
+				if out.Entity == nil {
+					if !in.IsDelim(']') {
+						out.Entity = make([]Schedule, 0, 0)
+					} else {
+						out.Entity = []Schedule{}
+					}
+				} else {
+					out.Entity = (out.Entity)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 Schedule
+					(v1).UnmarshalEasyJSON(in)
+					out.Entity = append(out.Entity, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonA7c3c05fEncodeUserbalanceInternalModels(out *jwriter.Writer, in Schedules) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"entity\":"
+		out.RawString(prefix[1:])
+		if in.Entity == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Entity {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Schedules) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonA7c3c05fEncodeUserbalanceInternalModels(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Schedules) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonA7c3c05fDecodeUserbalanceInternalModels(l, v)
+}
+func easyjsonA7c3c05fDecodeUserbalanceInternalModels1(in *jlexer.Lexer, out *ScheduleRuns) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "entity":
+			if in.IsNull() {
+				in.Skip()
+				out.Entity = nil
+			} else {
+				in.Delim('[')
+				if out.Entity == nil {
+					if !in.IsDelim(']') {
+						out.Entity = make([]ScheduleRun, 0, 0)
+					} else {
+						out.Entity = []ScheduleRun{}
+					}
+				} else {
+					out.Entity = (out.Entity)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v4 ScheduleRun
+					(v4).UnmarshalEasyJSON(in)
+					out.Entity = append(out.Entity, v4)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonA7c3c05fEncodeUserbalanceInternalModels1(out *jwriter.Writer, in ScheduleRuns) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"entity\":"
+		out.RawString(prefix[1:])
+		if in.Entity == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v5, v6 := range in.Entity {
+				if v5 > 0 {
+					out.RawByte(',')
+				}
+				(v6).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ScheduleRuns) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonA7c3c05fEncodeUserbalanceInternalModels1(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ScheduleRuns) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonA7c3c05fDecodeUserbalanceInternalModels1(l, v)
+}
+func easyjsonA7c3c05fDecodeUserbalanceInternalModels2(in *jlexer.Lexer, out *ScheduleRun) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = int(in.Int())
+		case "scheduleid":
+			out.ScheduleID = int(in.Int())
+		case "attempt":
+			out.Attempt = int(in.Int())
+		case "status":
+			out.Status = string(in.String())
+		case "error":
+			out.Error = string(in.String())
+		case "dueat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.DueAt).UnmarshalJSON(data))
+			}
+		case "executedat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.ExecutedAt).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonA7c3c05fEncodeUserbalanceInternalModels2(out *jwriter.Writer, in ScheduleRun) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.ID))
+	}
+	{
+		const prefix string = ",\"scheduleid\":"
+		out.RawString(prefix)
+		out.Int(int(in.ScheduleID))
+	}
+	{
+		const prefix string = ",\"attempt\":"
+		out.RawString(prefix)
+		out.Int(int(in.Attempt))
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	if in.Error != "" {
+		const prefix string = ",\"error\":"
+		out.RawString(prefix)
+		out.String(string(in.Error))
+	}
+	{
+		const prefix string = ",\"dueat\":"
+		out.RawString(prefix)
+		out.Raw((in.DueAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"executedat\":"
+		out.RawString(prefix)
+		out.Raw((in.ExecutedAt).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ScheduleRun) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonA7c3c05fEncodeUserbalanceInternalModels2(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ScheduleRun) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonA7c3c05fDecodeUserbalanceInternalModels2(l, v)
+}
+func easyjsonA7c3c05fDecodeUserbalanceInternalModels3(in *jlexer.Lexer, out *ScheduleFilter) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "userid":
+			out.UserID = int(in.Int())
+		case "status":
+			out.Status = string(in.String())
+		case "limit":
+			out.Limit = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonA7c3c05fEncodeUserbalanceInternalModels3(out *jwriter.Writer, in ScheduleFilter) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.UserID))
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	{
+		const prefix string = ",\"limit\":"
+		out.RawString(prefix)
+		out.Int(int(in.Limit))
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ScheduleFilter) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonA7c3c05fEncodeUserbalanceInternalModels3(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ScheduleFilter) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonA7c3c05fDecodeUserbalanceInternalModels3(l, v)
+}
+func easyjsonA7c3c05fDecodeUserbalanceInternalModels4(in *jlexer.Lexer, out *Schedule) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = int(in.Int())
+		case "operation":
+			out.Operation = string(in.String())
+		case "userid":
+			out.UserID = int(in.Int())
+		case "touserid":
+			out.ToUserID = int(in.Int())
+		case "serviceid":
+			out.ServiceID = int(in.Int())
+		case "orderid":
+			out.OrderID = int(in.Int())
+		case "amount":
+			out.Amount = int(in.Int())
+		case "kind":
+			out.Kind = string(in.String())
+		case "runat":
+			if in.IsNull() {
+				in.Skip()
+				out.RunAt = nil
+			} else {
+				if out.RunAt == nil {
+					out.RunAt = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.RunAt).UnmarshalJSON(data))
+				}
+			}
+		case "interval":
+			out.Interval = string(in.String())
+		case "cron":
+			out.Cron = string(in.String())
+		case "status":
+			out.Status = string(in.String())
+		case "nextrunat":
+			if in.IsNull() {
+				in.Skip()
+				out.NextRunAt = nil
+			} else {
+				if out.NextRunAt == nil {
+					out.NextRunAt = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.NextRunAt).UnmarshalJSON(data))
+				}
+			}
+		case "attempt":
+			out.Attempt = int(in.Int())
+		case "createdat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.CreatedAt).UnmarshalJSON(data))
+			}
+		case "updatedat":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.UpdatedAt).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonA7c3c05fEncodeUserbalanceInternalModels4(out *jwriter.Writer, in Schedule) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.ID))
+	}
+	{
+		const prefix string = ",\"operation\":"
+		out.RawString(prefix)
+		out.String(string(in.Operation))
+	}
+	{
+		const prefix string = ",\"userid\":"
+		out.RawString(prefix)
+		out.Int(int(in.UserID))
+	}
+	if in.ToUserID != 0 {
+		const prefix string = ",\"touserid\":"
+		out.RawString(prefix)
+		out.Int(int(in.ToUserID))
+	}
+	if in.ServiceID != 0 {
+		const prefix string = ",\"serviceid\":"
+		out.RawString(prefix)
+		out.Int(int(in.ServiceID))
+	}
+	if in.OrderID != 0 {
+		const prefix string = ",\"orderid\":"
+		out.RawString(prefix)
+		out.Int(int(in.OrderID))
+	}
+	{
+		const prefix string = ",\"amount\":"
+		out.RawString(prefix)
+		out.Int(int(in.Amount))
+	}
+	{
+		const prefix string = ",\"kind\":"
+		out.RawString(prefix)
+		out.String(string(in.Kind))
+	}
+	if in.RunAt != nil {
+		const prefix string = ",\"runat\":"
+		out.RawString(prefix)
+		out.Raw((*in.RunAt).MarshalJSON())
+	}
+	if in.Interval != "" {
+		const prefix string = ",\"interval\":"
+		out.RawString(prefix)
+		out.String(string(in.Interval))
+	}
+	if in.Cron != "" {
+		const prefix string = ",\"cron\":"
+		out.RawString(prefix)
+		out.String(string(in.Cron))
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	if in.NextRunAt != nil {
+		const prefix string = ",\"nextrunat\":"
+		out.RawString(prefix)
+		out.Raw((*in.NextRunAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"attempt\":"
+		out.RawString(prefix)
+		out.Int(int(in.Attempt))
+	}
+	{
+		const prefix string = ",\"createdat\":"
+		out.RawString(prefix)
+		out.Raw((in.CreatedAt).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"updatedat\":"
+		out.RawString(prefix)
+		out.Raw((in.UpdatedAt).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Schedule) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonA7c3c05fEncodeUserbalanceInternalModels4(w, v)
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Schedule) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonA7c3c05fDecodeUserbalanceInternalModels4(l, v)
+}
@@ -0,0 +1,11 @@
+package models
+
+// SignedRequest - части запроса, от которых клиент вычисляет подпись HMAC
+type SignedRequest struct {
+	Method    string
+	URI       string
+	Timestamp string
+	Nonce     string
+	Body      []byte
+	Signature string
+}
@@ -13,12 +13,14 @@ type (
 		Date      string `json:"date"`
 		ServiceID int    `json:"serviceid"`
 		OrderID   int    `json:"orderid"`
+		Note
 	}
 
 	Replenishment struct {
 		UserID int    `json:"userid"`
 		Amount int    `json:"amount"`
 		Date   string `json:"date"`
+		Note
 	}
 
 	Money struct {
@@ -26,6 +28,7 @@ type (
 		ToUserID   int    `json:"touserid"`
 		Amount     int    `json:"amount"`
 		Date       string `json:"date"`
+		Note
 	}
 )
 
@@ -38,7 +41,8 @@ func (r Replenishment) Validate() error {
 		validation.Field(
 			&r.Amount,
 			validation.Required.Error("сумма пополнения должна быть больше 0"),
-			validation.Min(1).Error("сумма пополнения должна быть больше 0")))
+			validation.Min(1).Error("сумма пополнения должна быть больше 0")),
+		validation.Field(&r.Note))
 }
 
 func (m Money) Validate() error {
@@ -52,7 +56,8 @@ func (m Money) Validate() error {
 			validation.NotIn(m.FromUserID).Error("невозможно перевести самому себе")),
 		validation.Field(&m.Amount,
 			validation.Required.Error("сумма перевода должна быть больше 0"),
-			validation.Min(1).Error("сумма перевода должна быть больше 0")))
+			validation.Min(1).Error("сумма перевода должна быть больше 0")),
+		validation.Field(&m.Note))
 }
 
 func (t Transaction) Validate() error {
@@ -68,5 +73,6 @@ func (t Transaction) Validate() error {
 			validation.Min(1).Error("номер заказа не может быть <= 0")),
 		validation.Field(&t.ServiceID,
 			validation.Required.Error("id услуги не может быть <= 0"),
-			validation.Min(1).Error("id услуги не может быть <= 0")))
+			validation.Min(1).Error("id услуги не может быть <= 0")),
+		validation.Field(&t.Note))
 }
@@ -15,3 +15,11 @@ func (u User) Validate() error {
 			validation.Required.Error("id пользователя не может быть не указан либо <= 0"),
 			validation.Min(1).Error("id пользователя не может быть <= 0")))
 }
+
+// Totals - суммарные остатки по всем пользователям
+type Totals struct {
+	Users        int
+	Balance      int
+	Reserve      int
+	Reservations int
+}
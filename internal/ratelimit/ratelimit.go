@@ -0,0 +1,91 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval - период удаления заполненных корзин, чтобы память не росла с числом клиентов и пользователей
+const sweepInterval = time.Minute
+
+// Limit - ограничение: корзина вмещает Burst запросов и пополняется на Rate запросов в секунду
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled сообщает, что ограничение задано: при нулевых значениях запросы не ограничиваются
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Store хранит корзины по ключам. MemoryStore подходит для одного экземпляра сервиса,
+// для нескольких экземпляров реализация должна хранить корзины в общем хранилище
+type Store interface {
+	// Allow забирает из корзины key один запрос. Если корзина пуста, возвращает false
+	// и время, через которое запрос будет разрешен
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// full сообщает, что к моменту now корзина снова заполнена и ее можно не хранить
+func (b *bucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.updated).Seconds()*b.limit.Rate >= float64(b.limit.Burst)
+}
+
+// MemoryStore хранит корзины в памяти процесса
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, b := range s.buckets {
+			if b.full(now) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	// ограничение могло измениться при перезагрузке конфигурации
+	b.limit = limit
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second)), nil
+}
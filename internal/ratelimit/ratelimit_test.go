@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Allow(t *testing.T) {
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 2, Burst: 3}
+
+	testTable := []struct {
+		name      string
+		steps     []time.Duration
+		want      []bool
+		wantRetry time.Duration
+	}{
+		{
+			name:  "OK burst",
+			steps: []time.Duration{0, 0, 0},
+			want:  []bool{true, true, true},
+		},
+
+		{
+			name:      "limited after burst",
+			steps:     []time.Duration{0, 0, 0, 0},
+			want:      []bool{true, true, true, false},
+			wantRetry: 500 * time.Millisecond,
+		},
+
+		{
+			name:      "refill",
+			steps:     []time.Duration{0, 0, 0, 500 * time.Millisecond, 0},
+			want:      []bool{true, true, true, true, false},
+			wantRetry: 500 * time.Millisecond,
+		},
+
+		{
+			name:  "refill up to burst",
+			steps: []time.Duration{0, 0, 0, time.Hour, 0, 0},
+			want:  []bool{true, true, true, true, true, true},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			current := now
+			s := NewMemoryStore()
+			s.now = func() time.Time { return current }
+
+			var retry time.Duration
+			for i, step := range testCase.steps {
+				current = current.Add(step)
+
+				allowed, after, err := s.Allow(context.Background(), "client:billing", limit)
+				require.NoError(t, err)
+				assert.Equal(t, testCase.want[i], allowed, "запрос %d", i)
+				retry = after
+			}
+			assert.Equal(t, testCase.wantRetry, retry)
+		})
+	}
+}
+
+func TestMemoryStore_Keys(t *testing.T) {
+	s := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	allowed, _, _ := s.Allow(context.Background(), "user:1", limit)
+	assert.True(t, allowed)
+	allowed, _, _ = s.Allow(context.Background(), "user:1", limit)
+	assert.False(t, allowed)
+	allowed, _, _ = s.Allow(context.Background(), "user:2", limit)
+	assert.True(t, allowed)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	current := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return current }
+
+	s.Allow(context.Background(), "user:1", Limit{Rate: 1, Burst: 10})
+	s.Allow(context.Background(), "user:2", Limit{Rate: 0.001, Burst: 10})
+	assert.Len(t, s.buckets, 2)
+
+	current = current.Add(sweepInterval)
+	s.Allow(context.Background(), "user:3", Limit{Rate: 1, Burst: 10})
+
+	// корзина user:1 пополнилась и удалена, user:2 пополняется медленно и сохраняется
+	assert.Len(t, s.buckets, 2)
+	assert.NotContains(t, s.buckets, "user:1")
+	assert.Contains(t, s.buckets, "user:2")
+}
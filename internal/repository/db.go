@@ -9,6 +9,16 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// mysqlTLS сопоставляет режимы sslmode PostgreSQL значениям параметра tls драйвера MySQL
+var mysqlTLS = map[string]string{
+	"disable":     "false",
+	"allow":       "preferred",
+	"prefer":      "preferred",
+	"require":     "skip-verify",
+	"verify-ca":   "true",
+	"verify-full": "true",
+}
+
 func Connect(conf *c.Config) (*sql.DB, error) {
 	var err error
 	var conn string
@@ -16,17 +26,18 @@ func Connect(conf *c.Config) (*sql.DB, error) {
 
 	switch conf.ConnectionType {
 	case "postgres":
-		conn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", conf.DBHost, conf.DBPort, conf.User, conf.Password, conf.DBname)
+		conn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s", conf.DBHost, conf.DBPort, conf.User, conf.Password, conf.DBname, conf.DBSSLMode)
 		if db, err = sql.Open(conf.ConnectionType, conn); err != nil {
 			return nil, err
 		}
 	case "mysql":
 		cfg := mysql.Config{
-			User:   conf.User,
-			Passwd: conf.Password,
-			Net:    "tcp",
-			Addr:   fmt.Sprintf("%s:%d", conf.DBHost, conf.DBPort),
-			DBName: conf.DBname,
+			User:      conf.User,
+			Passwd:    conf.Password,
+			Net:       "tcp",
+			Addr:      fmt.Sprintf("%s:%d", conf.DBHost, conf.DBPort),
+			DBName:    conf.DBname,
+			TLSConfig: mysqlTLS[conf.DBSSLMode],
 		}
 		if db, err = sql.Open(conf.ConnectionType, cfg.FormatDSN()); err != nil {
 			return nil, err
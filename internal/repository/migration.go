@@ -1,40 +1,203 @@
 package repository
 
 import (
-	"fmt"
+	"errors"
+	"io/fs"
+	"net"
+	"net/url"
+	"strconv"
 	c "userbalance/internal/config"
+	"userbalance/migrations"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	_ "github.com/lib/pq"
 )
 
+// MigrationStatus - состояние одной миграции относительно текущей версии БД
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+	Dirty   bool
+}
+
+// Migrator применяет миграции, встроенные в бинарный файл
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+func NewMigrator(conf *c.Config) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, migrationURL(conf))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{m: m, source: src}, nil
+}
+
+// migrationURL собирает адрес БД для migrate. Имя пользователя, пароль и имя БД экранируются,
+// поэтому могут содержать @, :, /, ? и %
+func migrationURL(conf *c.Config) string {
+	u := url.URL{
+		Scheme:   conf.ConnectionType,
+		User:     url.UserPassword(conf.User, conf.Password),
+		Host:     net.JoinHostPort(conf.DBHost, strconv.Itoa(conf.DBPort)),
+		Path:     "/" + conf.DBname,
+		RawQuery: url.Values{"sslmode": []string{conf.DBSSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// Up применяет n следующих миграций, при n <= 0 - все непримененные
+func (m *Migrator) Up(n int) error {
+	if n <= 0 {
+		return noChange(m.m.Up())
+	}
+	return noChange(m.m.Steps(n))
+}
+
+// Down откатывает n последних миграций, при n <= 0 - все примененные
+func (m *Migrator) Down(n int) error {
+	if n <= 0 {
+		return noChange(m.m.Down())
+	}
+	return noChange(m.m.Steps(-n))
+}
+
+// Goto применяет либо откатывает миграции до указанной версии
+func (m *Migrator) Goto(version uint) error {
+	return noChange(m.m.Migrate(version))
+}
+
+// Force устанавливает версию без выполнения миграций и снимает признак dirty.
+// Используется после ручного исправления БД, на которой миграция завершилась с ошибкой
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+// Version возвращает текущую версию БД, 0 - если миграции не применялись
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Status возвращает все встроенные миграции с отметкой о применении
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	return migrationStatus(m.source, version, dirty)
+}
+
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.m.Close()
+	if sourceErr != nil {
+		return sourceErr
+	}
+	return dbErr
+}
+
+func migrationStatus(src source.Driver, current uint, dirty bool) ([]MigrationStatus, error) {
+	statuses := make([]MigrationStatus, 0)
+
+	version, err := src.First()
+	for err == nil {
+		var name string
+		if name, err = migrationName(src, version); err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, MigrationStatus{
+			Version: version,
+			Name:    name,
+			Applied: version <= current,
+			Dirty:   dirty && version == current,
+		})
+
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return statuses, nil
+}
+
+// LatestMigration возвращает версию последней миграции, встроенной в бинарный файл,
+// то есть версию, до которой должна быть обновлена БД
+func LatestMigration() (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	for err == nil {
+		var next uint
+		if next, err = src.Next(version); err == nil {
+			version = next
+		}
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func migrationName(src source.Driver, version uint) (string, error) {
+	r, name, err := src.ReadUp(version)
+	if err != nil {
+		return "", err
+	}
+	return name, r.Close()
+}
+
+// noChange не считает ошибкой отсутствие миграций для применения
+func noChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// Migration выполняет миграции при запуске сервера с флагами -migrationdown и -migrationup
 func Migration(conf *c.Config, up, down bool) error {
-	m, err := migrate.New(
-		"file://"+conf.MigrationPath,
-		fmt.Sprintf("%s://%s:%s@%s:%d/%s?sslmode=disable",
-			conf.ConnectionType,
-			conf.User,
-			conf.Password,
-			conf.DBHost,
-			conf.DBPort,
-			conf.DBname))
+	if !up && !down {
+		return nil
+	}
+
+	m, err := NewMigrator(conf)
 	if err != nil {
 		return err
 	}
+	defer m.Close()
 
 	if down {
-		if err := m.Down(); err != nil {
+		if err := m.Down(0); err != nil {
 			return err
 		}
 	}
 
 	if up {
-		if err := m.Up(); err != nil {
+		if err := m.Up(0); err != nil {
 			return err
 		}
 	}
 
-	return err
+	return nil
 }
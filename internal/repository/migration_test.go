@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"net/url"
+	"testing"
+	c "userbalance/internal/config"
+	"userbalance/migrations"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationStatus(t *testing.T) {
+	src, err := iofs.New(migrations.FS, ".")
+	require.NoError(t, err)
+	defer src.Close()
+
+	testTable := []struct {
+		name    string
+		current uint
+		dirty   bool
+		applied int
+	}{
+		{
+			name:    "OK no migrations applied",
+			current: 0,
+		},
+
+		{
+			name:    "OK partially applied",
+			current: 7,
+			applied: 7,
+		},
+
+		{
+			name:    "OK dirty",
+			current: 9,
+			dirty:   true,
+			applied: 9,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := migrationStatus(src, testCase.current, testCase.dirty)
+
+			require.NoError(t, err)
+			require.NotEmpty(t, got)
+			assert.Equal(t, uint(1), got[0].Version)
+			assert.Equal(t, "create_id_sequence", got[0].Name)
+
+			applied := 0
+			for i, status := range got {
+				// версии встроенных миграций идут подряд без пропусков
+				assert.Equal(t, uint(i+1), status.Version)
+				if status.Applied {
+					applied++
+				}
+				assert.Equal(t, testCase.dirty && status.Version == testCase.current, status.Dirty)
+			}
+			assert.Equal(t, testCase.applied, applied)
+		})
+	}
+}
+
+func TestLatestMigration(t *testing.T) {
+	src, err := iofs.New(migrations.FS, ".")
+	require.NoError(t, err)
+	defer src.Close()
+
+	statuses, err := migrationStatus(src, 0, false)
+	require.NoError(t, err)
+
+	got, err := LatestMigration()
+	require.NoError(t, err)
+	assert.Equal(t, statuses[len(statuses)-1].Version, got)
+}
+
+func TestMigrationURL(t *testing.T) {
+	conf := &c.Config{
+		ConnectionType: "postgres",
+		User:           "user@corp",
+		Password:       "p@ss:w/rd?%20#",
+		DBHost:         "db.local",
+		DBPort:         5432,
+		DBname:         "balance",
+		DBSSLMode:      "require",
+	}
+
+	u, err := url.Parse(migrationURL(conf))
+	require.NoError(t, err)
+
+	password, _ := u.User.Password()
+	assert.Equal(t, "postgres", u.Scheme)
+	assert.Equal(t, "user@corp", u.User.Username())
+	assert.Equal(t, "p@ss:w/rd?%20#", password)
+	assert.Equal(t, "db.local:5432", u.Host)
+	assert.Equal(t, "/balance", u.Path)
+	assert.Equal(t, "require", u.Query().Get("sslmode"))
+}
@@ -5,14 +5,52 @@
 package mock_repository
 
 import (
-	sql "database/sql"
+	context "context"
 	reflect "reflect"
 	time "time"
 	models "userbalance/internal/models"
+	repository "userbalance/internal/repository"
 
 	gomock "github.com/golang/mock/gomock"
 )
 
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// WithinTx mocks base method.
+func (m *MockUnitOfWork) WithinTx(ctx context.Context, fn func(repository.Control) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockUnitOfWorkMockRecorder) WithinTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*MockUnitOfWork)(nil).WithinTx), ctx, fn)
+}
+
 // MockControl is a mock of Control interface.
 type MockControl struct {
 	ctrl     *gomock.Controller
@@ -36,205 +74,854 @@ func (m *MockControl) EXPECT() *MockControlMockRecorder {
 	return m.recorder
 }
 
-// DeleteMoneyReserveDetailsTx mocks base method.
-func (m *MockControl) DeleteMoneyReserveDetailsTx(tx *sql.Tx, userId, serviceId, orderId, amount int, date time.Time) (int64, error) {
+// DeleteMoneyReserveDetails mocks base method.
+func (m *MockControl) DeleteMoneyReserveDetails(ctx context.Context, userId, serviceId, orderId, amount int, date time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMoneyReserveDetails", ctx, userId, serviceId, orderId, amount, date)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMoneyReserveDetails indicates an expected call of DeleteMoneyReserveDetails.
+func (mr *MockControlMockRecorder) DeleteMoneyReserveDetails(ctx, userId, serviceId, orderId, amount, date interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMoneyReserveDetails", reflect.TypeOf((*MockControl)(nil).DeleteMoneyReserveDetails), ctx, userId, serviceId, orderId, amount, date)
+}
+
+// DeleteSnapshots mocks base method.
+func (m *MockControl) DeleteSnapshots(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSnapshots", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSnapshots indicates an expected call of DeleteSnapshots.
+func (mr *MockControlMockRecorder) DeleteSnapshots(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSnapshots", reflect.TypeOf((*MockControl)(nil).DeleteSnapshots), ctx)
+}
+
+// DeleteSpendingLimit mocks base method.
+func (m *MockControl) DeleteSpendingLimit(ctx context.Context, userId int, operation string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteMoneyReserveDetailsTx", tx, userId, serviceId, orderId, amount, date)
+	ret := m.ctrl.Call(m, "DeleteSpendingLimit", ctx, userId, operation)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DeleteMoneyReserveDetailsTx indicates an expected call of DeleteMoneyReserveDetailsTx.
-func (mr *MockControlMockRecorder) DeleteMoneyReserveDetailsTx(tx, userId, serviceId, orderId, amount, date interface{}) *gomock.Call {
+// DeleteSpendingLimit indicates an expected call of DeleteSpendingLimit.
+func (mr *MockControlMockRecorder) DeleteSpendingLimit(ctx, userId, operation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSpendingLimit", reflect.TypeOf((*MockControl)(nil).DeleteSpendingLimit), ctx, userId, operation)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockControl) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", ctx, hash)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockControlMockRecorder) GetAPIKeyByHash(ctx, hash interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMoneyReserveDetailsTx", reflect.TypeOf((*MockControl)(nil).DeleteMoneyReserveDetailsTx), tx, userId, serviceId, orderId, amount, date)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockControl)(nil).GetAPIKeyByHash), ctx, hash)
 }
 
-// GetBalanceReserveAccountsTx mocks base method.
-func (m *MockControl) GetBalanceReserveAccountsTx(tx *sql.Tx, userId int) (int, error) {
+// GetAPIKeyByName mocks base method.
+func (m *MockControl) GetAPIKeyByName(ctx context.Context, name string) (*models.APIKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBalanceReserveAccountsTx", tx, userId)
+	ret := m.ctrl.Call(m, "GetAPIKeyByName", ctx, name)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByName indicates an expected call of GetAPIKeyByName.
+func (mr *MockControlMockRecorder) GetAPIKeyByName(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByName", reflect.TypeOf((*MockControl)(nil).GetAPIKeyByName), ctx, name)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockControl) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockControlMockRecorder) GetAPIKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockControl)(nil).GetAPIKeys), ctx)
+}
+
+// GetBalanceChecks mocks base method.
+func (m *MockControl) GetBalanceChecks(ctx context.Context) ([]models.BalanceCheck, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceChecks", ctx)
+	ret0, _ := ret[0].([]models.BalanceCheck)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceChecks indicates an expected call of GetBalanceChecks.
+func (mr *MockControlMockRecorder) GetBalanceChecks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceChecks", reflect.TypeOf((*MockControl)(nil).GetBalanceChecks), ctx)
+}
+
+// GetBalanceReserveAccounts mocks base method.
+func (m *MockControl) GetBalanceReserveAccounts(ctx context.Context, userId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceReserveAccounts", ctx, userId)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetBalanceReserveAccountsTx indicates an expected call of GetBalanceReserveAccountsTx.
-func (mr *MockControlMockRecorder) GetBalanceReserveAccountsTx(tx, userId interface{}) *gomock.Call {
+// GetBalanceReserveAccounts indicates an expected call of GetBalanceReserveAccounts.
+func (mr *MockControlMockRecorder) GetBalanceReserveAccounts(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceReserveAccounts", reflect.TypeOf((*MockControl)(nil).GetBalanceReserveAccounts), ctx, userId)
+}
+
+// GetChainHeads mocks base method.
+func (m *MockControl) GetChainHeads(ctx context.Context) ([]models.ChainHead, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChainHeads", ctx)
+	ret0, _ := ret[0].([]models.ChainHead)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChainHeads indicates an expected call of GetChainHeads.
+func (mr *MockControlMockRecorder) GetChainHeads(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChainHeads", reflect.TypeOf((*MockControl)(nil).GetChainHeads), ctx)
+}
+
+// GetDueScheduleForUpdate mocks base method.
+func (m *MockControl) GetDueScheduleForUpdate(ctx context.Context, now time.Time, exclude []int) (*models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueScheduleForUpdate", ctx, now, exclude)
+	ret0, _ := ret[0].(*models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueScheduleForUpdate indicates an expected call of GetDueScheduleForUpdate.
+func (mr *MockControlMockRecorder) GetDueScheduleForUpdate(ctx, now, exclude interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueScheduleForUpdate", reflect.TypeOf((*MockControl)(nil).GetDueScheduleForUpdate), ctx, now, exclude)
+}
+
+// GetExpiredPendingTransferForUpdate mocks base method.
+func (m *MockControl) GetExpiredPendingTransferForUpdate(ctx context.Context, now time.Time) (*models.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredPendingTransferForUpdate", ctx, now)
+	ret0, _ := ret[0].(*models.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredPendingTransferForUpdate indicates an expected call of GetExpiredPendingTransferForUpdate.
+func (mr *MockControlMockRecorder) GetExpiredPendingTransferForUpdate(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredPendingTransferForUpdate", reflect.TypeOf((*MockControl)(nil).GetExpiredPendingTransferForUpdate), ctx, now)
+}
+
+// GetFirstLedgerDate mocks base method.
+func (m *MockControl) GetFirstLedgerDate(ctx context.Context) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFirstLedgerDate", ctx)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFirstLedgerDate indicates an expected call of GetFirstLedgerDate.
+func (mr *MockControlMockRecorder) GetFirstLedgerDate(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceReserveAccountsTx", reflect.TypeOf((*MockControl)(nil).GetBalanceReserveAccountsTx), tx, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirstLedgerDate", reflect.TypeOf((*MockControl)(nil).GetFirstLedgerDate), ctx)
 }
 
 // GetHistory mocks base method.
-func (m *MockControl) GetHistory(requestHistory *models.RequestHistory) ([]models.History, error) {
+func (m *MockControl) GetHistory(ctx context.Context, requestHistory *models.RequestHistory) ([]models.History, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetHistory", requestHistory)
+	ret := m.ctrl.Call(m, "GetHistory", ctx, requestHistory)
 	ret0, _ := ret[0].([]models.History)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetHistory indicates an expected call of GetHistory.
-func (mr *MockControlMockRecorder) GetHistory(requestHistory interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) GetHistory(ctx, requestHistory interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockControl)(nil).GetHistory), ctx, requestHistory)
+}
+
+// GetLastSnapshot mocks base method.
+func (m *MockControl) GetLastSnapshot(ctx context.Context, userId int, at time.Time) (*models.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastSnapshot", ctx, userId, at)
+	ret0, _ := ret[0].(*models.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastSnapshot indicates an expected call of GetLastSnapshot.
+func (mr *MockControlMockRecorder) GetLastSnapshot(ctx, userId, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastSnapshot", reflect.TypeOf((*MockControl)(nil).GetLastSnapshot), ctx, userId, at)
+}
+
+// GetLedgerSum mocks base method.
+func (m *MockControl) GetLedgerSum(ctx context.Context, userId int, fromDate, toDate time.Time) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLedgerSum", ctx, userId, fromDate, toDate)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLedgerSum indicates an expected call of GetLedgerSum.
+func (mr *MockControlMockRecorder) GetLedgerSum(ctx, userId, fromDate, toDate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLedgerSum", reflect.TypeOf((*MockControl)(nil).GetLedgerSum), ctx, userId, fromDate, toDate)
+}
+
+// GetMigrationVersion mocks base method.
+func (m *MockControl) GetMigrationVersion(ctx context.Context) (uint, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMigrationVersion", ctx)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetMigrationVersion indicates an expected call of GetMigrationVersion.
+func (mr *MockControlMockRecorder) GetMigrationVersion(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMigrationVersion", reflect.TypeOf((*MockControl)(nil).GetMigrationVersion), ctx)
+}
+
+// GetOperationCount mocks base method.
+func (m *MockControl) GetOperationCount(ctx context.Context, userId int, operation string, from time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOperationCount", ctx, userId, operation, from)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOperationCount indicates an expected call of GetOperationCount.
+func (mr *MockControlMockRecorder) GetOperationCount(ctx, userId, operation, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOperationCount", reflect.TypeOf((*MockControl)(nil).GetOperationCount), ctx, userId, operation, from)
+}
+
+// GetOperationSum mocks base method.
+func (m *MockControl) GetOperationSum(ctx context.Context, userId int, operation string, from time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOperationSum", ctx, userId, operation, from)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOperationSum indicates an expected call of GetOperationSum.
+func (mr *MockControlMockRecorder) GetOperationSum(ctx, userId, operation, from interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockControl)(nil).GetHistory), requestHistory)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOperationSum", reflect.TypeOf((*MockControl)(nil).GetOperationSum), ctx, userId, operation, from)
+}
+
+// GetPendingTransfer mocks base method.
+func (m *MockControl) GetPendingTransfer(ctx context.Context, id int) (*models.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingTransfer", ctx, id)
+	ret0, _ := ret[0].(*models.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingTransfer indicates an expected call of GetPendingTransfer.
+func (mr *MockControlMockRecorder) GetPendingTransfer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingTransfer", reflect.TypeOf((*MockControl)(nil).GetPendingTransfer), ctx, id)
+}
+
+// GetPendingTransferForUpdate mocks base method.
+func (m *MockControl) GetPendingTransferForUpdate(ctx context.Context, id int) (*models.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingTransferForUpdate", ctx, id)
+	ret0, _ := ret[0].(*models.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingTransferForUpdate indicates an expected call of GetPendingTransferForUpdate.
+func (mr *MockControlMockRecorder) GetPendingTransferForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingTransferForUpdate", reflect.TypeOf((*MockControl)(nil).GetPendingTransferForUpdate), ctx, id)
+}
+
+// GetPendingTransfers mocks base method.
+func (m *MockControl) GetPendingTransfers(ctx context.Context, filter *models.PendingTransferFilter) ([]models.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingTransfers", ctx, filter)
+	ret0, _ := ret[0].([]models.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingTransfers indicates an expected call of GetPendingTransfers.
+func (mr *MockControlMockRecorder) GetPendingTransfers(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingTransfers", reflect.TypeOf((*MockControl)(nil).GetPendingTransfers), ctx, filter)
+}
+
+// GetRecipientCount mocks base method.
+func (m *MockControl) GetRecipientCount(ctx context.Context, userId, toUserId int, from time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecipientCount", ctx, userId, toUserId, from)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecipientCount indicates an expected call of GetRecipientCount.
+func (mr *MockControlMockRecorder) GetRecipientCount(ctx, userId, toUserId, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecipientCount", reflect.TypeOf((*MockControl)(nil).GetRecipientCount), ctx, userId, toUserId, from)
 }
 
 // GetReport mocks base method.
-func (m *MockControl) GetReport(fromDate, toDate time.Time) (map[string]int, error) {
+func (m *MockControl) GetReport(ctx context.Context, fromDate, toDate time.Time) (map[string]int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetReport", fromDate, toDate)
+	ret := m.ctrl.Call(m, "GetReport", ctx, fromDate, toDate)
 	ret0, _ := ret[0].(map[string]int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetReport indicates an expected call of GetReport.
-func (mr *MockControlMockRecorder) GetReport(fromDate, toDate interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) GetReport(ctx, fromDate, toDate interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReport", reflect.TypeOf((*MockControl)(nil).GetReport), fromDate, toDate)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReport", reflect.TypeOf((*MockControl)(nil).GetReport), ctx, fromDate, toDate)
+}
+
+// GetRiskDecisionForUpdate mocks base method.
+func (m *MockControl) GetRiskDecisionForUpdate(ctx context.Context, id int) (*models.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRiskDecisionForUpdate", ctx, id)
+	ret0, _ := ret[0].(*models.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRiskDecisionForUpdate indicates an expected call of GetRiskDecisionForUpdate.
+func (mr *MockControlMockRecorder) GetRiskDecisionForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRiskDecisionForUpdate", reflect.TypeOf((*MockControl)(nil).GetRiskDecisionForUpdate), ctx, id)
+}
+
+// GetRiskDecisions mocks base method.
+func (m *MockControl) GetRiskDecisions(ctx context.Context, filter *models.RiskFilter) ([]models.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRiskDecisions", ctx, filter)
+	ret0, _ := ret[0].([]models.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRiskDecisions indicates an expected call of GetRiskDecisions.
+func (mr *MockControlMockRecorder) GetRiskDecisions(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRiskDecisions", reflect.TypeOf((*MockControl)(nil).GetRiskDecisions), ctx, filter)
+}
+
+// GetSchedule mocks base method.
+func (m *MockControl) GetSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedule", ctx, id)
+	ret0, _ := ret[0].(*models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedule indicates an expected call of GetSchedule.
+func (mr *MockControlMockRecorder) GetSchedule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedule", reflect.TypeOf((*MockControl)(nil).GetSchedule), ctx, id)
+}
+
+// GetScheduleForUpdate mocks base method.
+func (m *MockControl) GetScheduleForUpdate(ctx context.Context, id int) (*models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduleForUpdate", ctx, id)
+	ret0, _ := ret[0].(*models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduleForUpdate indicates an expected call of GetScheduleForUpdate.
+func (mr *MockControlMockRecorder) GetScheduleForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduleForUpdate", reflect.TypeOf((*MockControl)(nil).GetScheduleForUpdate), ctx, id)
+}
+
+// GetScheduleRuns mocks base method.
+func (m *MockControl) GetScheduleRuns(ctx context.Context, scheduleId, limit int) ([]models.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduleRuns", ctx, scheduleId, limit)
+	ret0, _ := ret[0].([]models.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduleRuns indicates an expected call of GetScheduleRuns.
+func (mr *MockControlMockRecorder) GetScheduleRuns(ctx, scheduleId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduleRuns", reflect.TypeOf((*MockControl)(nil).GetScheduleRuns), ctx, scheduleId, limit)
+}
+
+// GetSchedules mocks base method.
+func (m *MockControl) GetSchedules(ctx context.Context, filter *models.ScheduleFilter) ([]models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedules", ctx, filter)
+	ret0, _ := ret[0].([]models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedules indicates an expected call of GetSchedules.
+func (mr *MockControlMockRecorder) GetSchedules(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedules", reflect.TypeOf((*MockControl)(nil).GetSchedules), ctx, filter)
 }
 
 // GetService mocks base method.
-func (m *MockControl) GetService(serviceId int) (string, error) {
+func (m *MockControl) GetService(ctx context.Context, serviceId int) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetService", serviceId)
+	ret := m.ctrl.Call(m, "GetService", ctx, serviceId)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetService indicates an expected call of GetService.
-func (mr *MockControlMockRecorder) GetService(serviceId interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) GetService(ctx, serviceId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetService", reflect.TypeOf((*MockControl)(nil).GetService), ctx, serviceId)
+}
+
+// GetSpendingLimit mocks base method.
+func (m *MockControl) GetSpendingLimit(ctx context.Context, userId int, operation string) (*models.SpendingLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSpendingLimit", ctx, userId, operation)
+	ret0, _ := ret[0].(*models.SpendingLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSpendingLimit indicates an expected call of GetSpendingLimit.
+func (mr *MockControlMockRecorder) GetSpendingLimit(ctx, userId, operation interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetService", reflect.TypeOf((*MockControl)(nil).GetService), serviceId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpendingLimit", reflect.TypeOf((*MockControl)(nil).GetSpendingLimit), ctx, userId, operation)
+}
+
+// GetSpendingLimits mocks base method.
+func (m *MockControl) GetSpendingLimits(ctx context.Context, userId int) ([]models.SpendingLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSpendingLimits", ctx, userId)
+	ret0, _ := ret[0].([]models.SpendingLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSpendingLimits indicates an expected call of GetSpendingLimits.
+func (mr *MockControlMockRecorder) GetSpendingLimits(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpendingLimits", reflect.TypeOf((*MockControl)(nil).GetSpendingLimits), ctx, userId)
+}
+
+// GetTotals mocks base method.
+func (m *MockControl) GetTotals(ctx context.Context) (*models.Totals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotals", ctx)
+	ret0, _ := ret[0].(*models.Totals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotals indicates an expected call of GetTotals.
+func (mr *MockControlMockRecorder) GetTotals(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotals", reflect.TypeOf((*MockControl)(nil).GetTotals), ctx)
 }
 
 // GetUser mocks base method.
-func (m *MockControl) GetUser(userId int) (*models.User, error) {
+func (m *MockControl) GetUser(ctx context.Context, userId int) (*models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", userId)
+	ret := m.ctrl.Call(m, "GetUser", ctx, userId)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUser indicates an expected call of GetUser.
-func (mr *MockControlMockRecorder) GetUser(userId interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) GetUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockControl)(nil).GetUser), ctx, userId)
+}
+
+// GetUserFirstLedgerDate mocks base method.
+func (m *MockControl) GetUserFirstLedgerDate(ctx context.Context, userId int) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserFirstLedgerDate", ctx, userId)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserFirstLedgerDate indicates an expected call of GetUserFirstLedgerDate.
+func (mr *MockControlMockRecorder) GetUserFirstLedgerDate(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockControl)(nil).GetUser), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserFirstLedgerDate", reflect.TypeOf((*MockControl)(nil).GetUserFirstLedgerDate), ctx, userId)
 }
 
 // GetUserForUpdate mocks base method.
-func (m *MockControl) GetUserForUpdate(tx *sql.Tx, userId int) (*models.User, error) {
+func (m *MockControl) GetUserForUpdate(ctx context.Context, userId int) (*models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserForUpdate", tx, userId)
+	ret := m.ctrl.Call(m, "GetUserForUpdate", ctx, userId)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserForUpdate indicates an expected call of GetUserForUpdate.
-func (mr *MockControlMockRecorder) GetUserForUpdate(tx, userId interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) GetUserForUpdate(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserForUpdate", reflect.TypeOf((*MockControl)(nil).GetUserForUpdate), tx, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserForUpdate", reflect.TypeOf((*MockControl)(nil).GetUserForUpdate), ctx, userId)
 }
 
-// InsertLogTx mocks base method.
-func (m *MockControl) InsertLogTx(tx *sql.Tx, userId int, date time.Time, amount int, description string) error {
+// InsertAPIKey mocks base method.
+func (m *MockControl) InsertAPIKey(ctx context.Context, key *models.APIKey, hash string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertLogTx", tx, userId, date, amount, description)
+	ret := m.ctrl.Call(m, "InsertAPIKey", ctx, key, hash)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// InsertLogTx indicates an expected call of InsertLogTx.
-func (mr *MockControlMockRecorder) InsertLogTx(tx, userId, date, amount, description interface{}) *gomock.Call {
+// InsertAPIKey indicates an expected call of InsertAPIKey.
+func (mr *MockControlMockRecorder) InsertAPIKey(ctx, key, hash interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertLogTx", reflect.TypeOf((*MockControl)(nil).InsertLogTx), tx, userId, date, amount, description)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertAPIKey", reflect.TypeOf((*MockControl)(nil).InsertAPIKey), ctx, key, hash)
 }
 
-// InsertMoneyReserveAccountsTx mocks base method.
-func (m *MockControl) InsertMoneyReserveAccountsTx(tx *sql.Tx, userId int) error {
+// InsertLedger mocks base method.
+func (m *MockControl) InsertLedger(ctx context.Context, entry *models.LedgerEntry) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertMoneyReserveAccountsTx", tx, userId)
+	ret := m.ctrl.Call(m, "InsertLedger", ctx, entry)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// InsertMoneyReserveAccountsTx indicates an expected call of InsertMoneyReserveAccountsTx.
-func (mr *MockControlMockRecorder) InsertMoneyReserveAccountsTx(tx, userId interface{}) *gomock.Call {
+// InsertLedger indicates an expected call of InsertLedger.
+func (mr *MockControlMockRecorder) InsertLedger(ctx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertLedger", reflect.TypeOf((*MockControl)(nil).InsertLedger), ctx, entry)
+}
+
+// InsertLog mocks base method.
+func (m *MockControl) InsertLog(ctx context.Context, userId int, date time.Time, amount int, description string, note models.Note) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertLog", ctx, userId, date, amount, description, note)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertLog indicates an expected call of InsertLog.
+func (mr *MockControlMockRecorder) InsertLog(ctx, userId, date, amount, description, note interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertLog", reflect.TypeOf((*MockControl)(nil).InsertLog), ctx, userId, date, amount, description, note)
+}
+
+// InsertMoneyReserveAccounts mocks base method.
+func (m *MockControl) InsertMoneyReserveAccounts(ctx context.Context, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertMoneyReserveAccounts", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertMoneyReserveAccounts indicates an expected call of InsertMoneyReserveAccounts.
+func (mr *MockControlMockRecorder) InsertMoneyReserveAccounts(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertMoneyReserveAccounts", reflect.TypeOf((*MockControl)(nil).InsertMoneyReserveAccounts), ctx, userId)
+}
+
+// InsertMoneyReserveDetails mocks base method.
+func (m *MockControl) InsertMoneyReserveDetails(ctx context.Context, userId, serviceId, orderId, amount int, date time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertMoneyReserveDetails", ctx, userId, serviceId, orderId, amount, date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertMoneyReserveDetails indicates an expected call of InsertMoneyReserveDetails.
+func (mr *MockControlMockRecorder) InsertMoneyReserveDetails(ctx, userId, serviceId, orderId, amount, date interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertMoneyReserveDetails", reflect.TypeOf((*MockControl)(nil).InsertMoneyReserveDetails), ctx, userId, serviceId, orderId, amount, date)
+}
+
+// InsertPendingTransfer mocks base method.
+func (m *MockControl) InsertPendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertPendingTransfer", ctx, transfer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertPendingTransfer indicates an expected call of InsertPendingTransfer.
+func (mr *MockControlMockRecorder) InsertPendingTransfer(ctx, transfer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertPendingTransfer", reflect.TypeOf((*MockControl)(nil).InsertPendingTransfer), ctx, transfer)
+}
+
+// InsertReport mocks base method.
+func (m *MockControl) InsertReport(ctx context.Context, userId, serviceId, amount int, date time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertReport", ctx, userId, serviceId, amount, date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertReport indicates an expected call of InsertReport.
+func (mr *MockControlMockRecorder) InsertReport(ctx, userId, serviceId, amount, date interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertReport", reflect.TypeOf((*MockControl)(nil).InsertReport), ctx, userId, serviceId, amount, date)
+}
+
+// InsertRiskDecision mocks base method.
+func (m *MockControl) InsertRiskDecision(ctx context.Context, decision *models.RiskDecision) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertRiskDecision", ctx, decision)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertRiskDecision indicates an expected call of InsertRiskDecision.
+func (mr *MockControlMockRecorder) InsertRiskDecision(ctx, decision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertRiskDecision", reflect.TypeOf((*MockControl)(nil).InsertRiskDecision), ctx, decision)
+}
+
+// InsertSchedule mocks base method.
+func (m *MockControl) InsertSchedule(ctx context.Context, schedule *models.Schedule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertSchedule", ctx, schedule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertSchedule indicates an expected call of InsertSchedule.
+func (mr *MockControlMockRecorder) InsertSchedule(ctx, schedule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertSchedule", reflect.TypeOf((*MockControl)(nil).InsertSchedule), ctx, schedule)
+}
+
+// InsertScheduleRun mocks base method.
+func (m *MockControl) InsertScheduleRun(ctx context.Context, run *models.ScheduleRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertScheduleRun", ctx, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertScheduleRun indicates an expected call of InsertScheduleRun.
+func (mr *MockControlMockRecorder) InsertScheduleRun(ctx, run interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertScheduleRun", reflect.TypeOf((*MockControl)(nil).InsertScheduleRun), ctx, run)
+}
+
+// InsertSnapshots mocks base method.
+func (m *MockControl) InsertSnapshots(ctx context.Context, takenAt time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertSnapshots", ctx, takenAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertSnapshots indicates an expected call of InsertSnapshots.
+func (mr *MockControlMockRecorder) InsertSnapshots(ctx, takenAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertSnapshots", reflect.TypeOf((*MockControl)(nil).InsertSnapshots), ctx, takenAt)
+}
+
+// InsertUser mocks base method.
+func (m *MockControl) InsertUser(ctx context.Context, userId, amount int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertUser", ctx, userId, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertUser indicates an expected call of InsertUser.
+func (mr *MockControlMockRecorder) InsertUser(ctx, userId, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUser", reflect.TypeOf((*MockControl)(nil).InsertUser), ctx, userId, amount)
+}
+
+// Ping mocks base method.
+func (m *MockControl) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockControlMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockControl)(nil).Ping), ctx)
+}
+
+// ResolvePendingTransfer mocks base method.
+func (m *MockControl) ResolvePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolvePendingTransfer", ctx, transfer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResolvePendingTransfer indicates an expected call of ResolvePendingTransfer.
+func (mr *MockControlMockRecorder) ResolvePendingTransfer(ctx, transfer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolvePendingTransfer", reflect.TypeOf((*MockControl)(nil).ResolvePendingTransfer), ctx, transfer)
+}
+
+// ResolveRiskDecision mocks base method.
+func (m *MockControl) ResolveRiskDecision(ctx context.Context, id int, status, resolvedBy string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveRiskDecision", ctx, id, status, resolvedBy)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveRiskDecision indicates an expected call of ResolveRiskDecision.
+func (mr *MockControlMockRecorder) ResolveRiskDecision(ctx, id, status, resolvedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveRiskDecision", reflect.TypeOf((*MockControl)(nil).ResolveRiskDecision), ctx, id, status, resolvedBy)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockControl) RevokeAPIKey(ctx context.Context, id int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockControlMockRecorder) RevokeAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockControl)(nil).RevokeAPIKey), ctx, id)
+}
+
+// SetAPIKeySigningSecret mocks base method.
+func (m *MockControl) SetAPIKeySigningSecret(ctx context.Context, id int, secret string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAPIKeySigningSecret", ctx, id, secret)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetAPIKeySigningSecret indicates an expected call of SetAPIKeySigningSecret.
+func (mr *MockControlMockRecorder) SetAPIKeySigningSecret(ctx, id, secret interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertMoneyReserveAccountsTx", reflect.TypeOf((*MockControl)(nil).InsertMoneyReserveAccountsTx), tx, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAPIKeySigningSecret", reflect.TypeOf((*MockControl)(nil).SetAPIKeySigningSecret), ctx, id, secret)
 }
 
-// InsertMoneyReserveDetailsTx mocks base method.
-func (m *MockControl) InsertMoneyReserveDetailsTx(tx *sql.Tx, userId, serviceId, orderId, amount int, date time.Time) error {
+// UpdateBalance mocks base method.
+func (m *MockControl) UpdateBalance(ctx context.Context, userId, amount int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertMoneyReserveDetailsTx", tx, userId, serviceId, orderId, amount, date)
+	ret := m.ctrl.Call(m, "UpdateBalance", ctx, userId, amount)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// InsertMoneyReserveDetailsTx indicates an expected call of InsertMoneyReserveDetailsTx.
-func (mr *MockControlMockRecorder) InsertMoneyReserveDetailsTx(tx, userId, serviceId, orderId, amount, date interface{}) *gomock.Call {
+// UpdateBalance indicates an expected call of UpdateBalance.
+func (mr *MockControlMockRecorder) UpdateBalance(ctx, userId, amount interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertMoneyReserveDetailsTx", reflect.TypeOf((*MockControl)(nil).InsertMoneyReserveDetailsTx), tx, userId, serviceId, orderId, amount, date)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBalance", reflect.TypeOf((*MockControl)(nil).UpdateBalance), ctx, userId, amount)
 }
 
-// InsertReportTx mocks base method.
-func (m *MockControl) InsertReportTx(tx *sql.Tx, userId, serviceId, amount int, date time.Time) error {
+// UpdateMoneyReserveAccounts mocks base method.
+func (m *MockControl) UpdateMoneyReserveAccounts(ctx context.Context, userId, amount int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertReportTx", tx, userId, serviceId, amount, date)
+	ret := m.ctrl.Call(m, "UpdateMoneyReserveAccounts", ctx, userId, amount)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// InsertReportTx indicates an expected call of InsertReportTx.
-func (mr *MockControlMockRecorder) InsertReportTx(tx, userId, serviceId, amount, date interface{}) *gomock.Call {
+// UpdateMoneyReserveAccounts indicates an expected call of UpdateMoneyReserveAccounts.
+func (mr *MockControlMockRecorder) UpdateMoneyReserveAccounts(ctx, userId, amount interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertReportTx", reflect.TypeOf((*MockControl)(nil).InsertReportTx), tx, userId, serviceId, amount, date)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMoneyReserveAccounts", reflect.TypeOf((*MockControl)(nil).UpdateMoneyReserveAccounts), ctx, userId, amount)
 }
 
-// InsertUserTx mocks base method.
-func (m *MockControl) InsertUserTx(tx *sql.Tx, userId, amount int) error {
+// UpdateSchedule mocks base method.
+func (m *MockControl) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertUserTx", tx, userId, amount)
+	ret := m.ctrl.Call(m, "UpdateSchedule", ctx, schedule)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// InsertUserTx indicates an expected call of InsertUserTx.
-func (mr *MockControlMockRecorder) InsertUserTx(tx, userId, amount interface{}) *gomock.Call {
+// UpdateSchedule indicates an expected call of UpdateSchedule.
+func (mr *MockControlMockRecorder) UpdateSchedule(ctx, schedule interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUserTx", reflect.TypeOf((*MockControl)(nil).InsertUserTx), tx, userId, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSchedule", reflect.TypeOf((*MockControl)(nil).UpdateSchedule), ctx, schedule)
 }
 
-// UpdateBalanceTx mocks base method.
-func (m *MockControl) UpdateBalanceTx(tx *sql.Tx, userId, amount int) error {
+// UpsertSpendingLimit mocks base method.
+func (m *MockControl) UpsertSpendingLimit(ctx context.Context, limit *models.SpendingLimit) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateBalanceTx", tx, userId, amount)
+	ret := m.ctrl.Call(m, "UpsertSpendingLimit", ctx, limit)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateBalanceTx indicates an expected call of UpdateBalanceTx.
-func (mr *MockControlMockRecorder) UpdateBalanceTx(tx, userId, amount interface{}) *gomock.Call {
+// UpsertSpendingLimit indicates an expected call of UpsertSpendingLimit.
+func (mr *MockControlMockRecorder) UpsertSpendingLimit(ctx, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBalanceTx", reflect.TypeOf((*MockControl)(nil).UpdateBalanceTx), tx, userId, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertSpendingLimit", reflect.TypeOf((*MockControl)(nil).UpsertSpendingLimit), ctx, limit)
 }
 
-// UpdateMoneyReserveAccountsTx mocks base method.
-func (m *MockControl) UpdateMoneyReserveAccountsTx(tx *sql.Tx, userId, amount int) error {
+// WalkLogs mocks base method.
+func (m *MockControl) WalkLogs(ctx context.Context, fn func(models.AuditRecord) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateMoneyReserveAccountsTx", tx, userId, amount)
+	ret := m.ctrl.Call(m, "WalkLogs", ctx, fn)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateMoneyReserveAccountsTx indicates an expected call of UpdateMoneyReserveAccountsTx.
-func (mr *MockControlMockRecorder) UpdateMoneyReserveAccountsTx(tx, userId, amount interface{}) *gomock.Call {
+// WalkLogs indicates an expected call of WalkLogs.
+func (mr *MockControlMockRecorder) WalkLogs(ctx, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMoneyReserveAccountsTx", reflect.TypeOf((*MockControl)(nil).UpdateMoneyReserveAccountsTx), tx, userId, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WalkLogs", reflect.TypeOf((*MockControl)(nil).WalkLogs), ctx, fn)
 }
@@ -1,26 +1,37 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 	"userbalance/internal/models"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 )
 
+// querier реализуется и *sql.DB, и *sql.Tx, поэтому один и тот же ControlPosgres
+// работает как с пулом соединений, так и внутри транзакции
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 type ControlPosgres struct {
-	DB *sql.DB
+	DB querier
 }
 
-func NewControlPostgres(db *sql.DB) *ControlPosgres {
+func NewControlPostgres(db querier) *ControlPosgres {
 	return &ControlPosgres{DB: db}
 }
 
-func (m *ControlPosgres) GetUser(userId int) (*models.User, error) {
+func (m *ControlPosgres) GetUser(ctx context.Context, userId int) (*models.User, error) {
 	var balance int
 	var id int
-	rows, err := m.DB.Query("SELECT id, balance FROM users WHERE id = $1", userId)
+	rows, err := m.DB.QueryContext(ctx, "SELECT id, balance FROM users WHERE id = $1", userId)
 	if err != nil {
 		return nil, err
 	}
@@ -38,17 +49,17 @@ func (m *ControlPosgres) GetUser(userId int) (*models.User, error) {
 	return &models.User{Id: id, Balance: balance}, err
 }
 
-func (m *ControlPosgres) GetUserForUpdate(tx *sql.Tx, userId int) (*models.User, error) {
+func (m *ControlPosgres) GetUserForUpdate(ctx context.Context, userId int) (*models.User, error) {
 	var balance int
 	var id int
 
-	stmt, err := tx.Prepare(`SELECT id, balance FROM users WHERE id = $1 FOR UPDATE;`)
+	stmt, err := m.DB.PrepareContext(ctx, `SELECT id, balance FROM users WHERE id = $1 FOR UPDATE;`)
 	if err != nil {
 		return nil, err
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(userId)
+	rows, err := stmt.QueryContext(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -67,10 +78,10 @@ func (m *ControlPosgres) GetUserForUpdate(tx *sql.Tx, userId int) (*models.User,
 	return &models.User{Id: id, Balance: balance}, err
 }
 
-func (m *ControlPosgres) GetReport(fromDate time.Time, toDate time.Time) (map[string]int, error) {
+func (m *ControlPosgres) GetReport(ctx context.Context, fromDate time.Time, toDate time.Time) (map[string]int, error) {
 	var report map[string]int = make(map[string]int)
 
-	rows, err := m.DB.Query(`
+	rows, err := m.DB.QueryContext(ctx, `
 		SELECT s.title, SUM(r.amount) AS sumAmount
 		FROM report r
 		JOIN services s ON r.service_id = s.id
@@ -95,12 +106,26 @@ func (m *ControlPosgres) GetReport(fromDate time.Time, toDate time.Time) (map[st
 	return report, err
 }
 
-func (m *ControlPosgres) GetHistory(requestHistory *models.RequestHistory) ([]models.History, error) {
+// GetHistory возвращает записи истории пользователя, с MetadataKey - только записи с этим ключом метаданных
+func (m *ControlPosgres) GetHistory(ctx context.Context, requestHistory *models.RequestHistory) ([]models.History, error) {
 	var history []models.History = make([]models.History, 0)
 
-	sql, args, err := sq.Select("date", "amount", "description").
+	query := sq.Select("date", "amount", "description", "comment", "metadata").
 		From("logs").
-		Where(sq.Eq{"user_id": requestHistory.UserID}).
+		Where(sq.Eq{"user_id": requestHistory.UserID})
+	switch {
+	case requestHistory.MetadataValue != "":
+		metadata, err := models.Metadata{requestHistory.MetadataKey: requestHistory.MetadataValue}.Value()
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("metadata @> ?::jsonb", metadata)
+	case requestHistory.MetadataKey != "":
+		// ?? - оператор jsonb ?, а не параметр запроса
+		query = query.Where("metadata ?? ?", requestHistory.MetadataKey)
+	}
+
+	sql, args, err := query.
 		OrderBy(fmt.Sprintf("%s %s", requestHistory.SortField, requestHistory.Direction)).
 		PlaceholderFormat(sq.Dollar).
 		ToSql()
@@ -108,7 +133,7 @@ func (m *ControlPosgres) GetHistory(requestHistory *models.RequestHistory) ([]mo
 		return nil, err
 	}
 
-	rows, err := m.DB.Query(sql, args...)
+	rows, err := m.DB.QueryContext(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -116,104 +141,109 @@ func (m *ControlPosgres) GetHistory(requestHistory *models.RequestHistory) ([]mo
 	defer rows.Close()
 
 	for rows.Next() {
-		var date time.Time
-		var description string
-		var amount int
-		err := rows.Scan(&date, &amount, &description)
+		var h models.History
+		err := rows.Scan(&h.Date, &h.Amount, &h.Description, &h.Comment, &h.Metadata)
 		if err != nil {
 			return history, err
 		}
-		h := models.History{
-			Date:        date,
-			Amount:      amount,
-			Description: description,
-		}
 		history = append(history, h)
 	}
 
 	return history, err
 }
 
-func (m *ControlPosgres) UpdateBalanceTx(tx *sql.Tx, userId int, amount int) error {
+func (m *ControlPosgres) UpdateBalance(ctx context.Context, userId int, amount int) error {
 
-	stmt, err := tx.Prepare(`UPDATE users SET balance = $1 WHERE id = $2;`)
+	stmt, err := m.DB.PrepareContext(ctx, `UPDATE users SET balance = $1 WHERE id = $2;`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.Exec(amount, userId); err != nil {
+	if _, err = stmt.ExecContext(ctx, amount, userId); err != nil {
 		return err
 	}
 	return err
 }
 
-func (m *ControlPosgres) InsertUserTx(tx *sql.Tx, userId int, amount int) error {
+func (m *ControlPosgres) InsertUser(ctx context.Context, userId int, amount int) error {
 
-	stmt, err := tx.Prepare(`INSERT INTO users (id, balance) VALUES ($1, $2);`)
+	stmt, err := m.DB.PrepareContext(ctx, `INSERT INTO users (id, balance) VALUES ($1, $2);`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(userId, amount); err != nil {
+	if _, err := stmt.ExecContext(ctx, userId, amount); err != nil {
 		return err
 	}
 	return err
 }
 
-func (m *ControlPosgres) InsertLogTx(tx *sql.Tx, userId int, date time.Time, amount int, description string) error {
+// InsertLog добавляет запись в цепочку пользователя: хэш записи включает хэш предыдущей, комментарий и метаданные.
+// Вызывается в транзакции, заблокировавшей строку пользователя, поэтому цепочка не ветвится
+func (m *ControlPosgres) InsertLog(ctx context.Context, userId int, date time.Time, amount int, description string, note models.Note) error {
+	var prevHash string
+
+	err := m.DB.QueryRowContext(ctx, `SELECT hash FROM logs WHERE user_id = $1 ORDER BY id DESC LIMIT 1`, userId).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
 
-	stmt, err := tx.Prepare(`INSERT INTO logs (user_id, date, amount, description) VALUES ($1, $2, $3, $4);`)
+	stmt, err := m.DB.PrepareContext(ctx, `INSERT INTO logs (user_id, date, amount, description, comment, metadata, prev_hash, hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(userId, date, amount, description); err != nil {
+	// дата передается строкой, чтобы в БД попал тот же день, от которого посчитан хэш
+	day := date.Format("2006-01-02")
+	hash := models.LogHash(userId, date, amount, description, note, prevHash)
+
+	if _, err := stmt.ExecContext(ctx, userId, day, amount, description, note.Comment, note.Metadata, prevHash, hash); err != nil {
 		return err
 	}
 	return err
 }
 
-func (m *ControlPosgres) InsertMoneyReserveAccountsTx(tx *sql.Tx, userId int) error {
+func (m *ControlPosgres) InsertMoneyReserveAccounts(ctx context.Context, userId int) error {
 
-	stmt, err := tx.Prepare(`INSERT INTO money_reserve_accounts (user_id) VALUES ($1);`)
+	stmt, err := m.DB.PrepareContext(ctx, `INSERT INTO money_reserve_accounts (user_id) VALUES ($1);`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(userId); err != nil {
+	if _, err := stmt.ExecContext(ctx, userId); err != nil {
 		return err
 	}
 	return err
 }
 
-func (m *ControlPosgres) UpdateMoneyReserveAccountsTx(tx *sql.Tx, userId int, amount int) error {
+func (m *ControlPosgres) UpdateMoneyReserveAccounts(ctx context.Context, userId int, amount int) error {
 
-	stmt, err := tx.Prepare(`UPDATE money_reserve_accounts SET balance = $1 WHERE user_id = $2`)
+	stmt, err := m.DB.PrepareContext(ctx, `UPDATE money_reserve_accounts SET balance = $1 WHERE user_id = $2`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(amount, userId); err != nil {
+	if _, err := stmt.ExecContext(ctx, amount, userId); err != nil {
 		return err
 	}
 	return err
 }
 
-func (m *ControlPosgres) GetBalanceReserveAccountsTx(tx *sql.Tx, userId int) (int, error) {
+func (m *ControlPosgres) GetBalanceReserveAccounts(ctx context.Context, userId int) (int, error) {
 	var balance int
 
-	stmt, err := tx.Prepare(`SELECT balance FROM money_reserve_accounts WHERE user_id = $1 FOR UPDATE;`)
+	stmt, err := m.DB.PrepareContext(ctx, `SELECT balance FROM money_reserve_accounts WHERE user_id = $1 FOR UPDATE;`)
 	if err != nil {
 		return 0, err
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(userId)
+	rows, err := stmt.QueryContext(ctx, userId)
 	if err != nil {
 		return 0, err
 	}
@@ -230,23 +260,23 @@ func (m *ControlPosgres) GetBalanceReserveAccountsTx(tx *sql.Tx, userId int) (in
 	return balance, err
 }
 
-func (m *ControlPosgres) InsertMoneyReserveDetailsTx(tx *sql.Tx, userId, serviceId, orderId, amount int, date time.Time) error {
+func (m *ControlPosgres) InsertMoneyReserveDetails(ctx context.Context, userId, serviceId, orderId, amount int, date time.Time) error {
 
-	stmt, err := tx.Prepare(`INSERT INTO money_reserve_details (user_id, service_id, order_id, amount, date) VALUES ($1, $2, $3, $4, $5);`)
+	stmt, err := m.DB.PrepareContext(ctx, `INSERT INTO money_reserve_details (user_id, service_id, order_id, amount, date) VALUES ($1, $2, $3, $4, $5);`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(userId, serviceId, orderId, amount, date); err != nil {
+	if _, err := stmt.ExecContext(ctx, userId, serviceId, orderId, amount, date); err != nil {
 		return err
 	}
 	return err
 }
 
-func (m *ControlPosgres) DeleteMoneyReserveDetailsTx(tx *sql.Tx, userId, serviceId, orderId, amount int, date time.Time) (int64, error) {
+func (m *ControlPosgres) DeleteMoneyReserveDetails(ctx context.Context, userId, serviceId, orderId, amount int, date time.Time) (int64, error) {
 
-	stmt, err := tx.Prepare(`
+	stmt, err := m.DB.PrepareContext(ctx, `
 			DELETE FROM money_reserve_details 
 			WHERE user_id = $1 
 			AND service_id = $2 
@@ -259,31 +289,31 @@ func (m *ControlPosgres) DeleteMoneyReserveDetailsTx(tx *sql.Tx, userId, service
 	defer stmt.Close()
 
 	var result sql.Result
-	if result, err = stmt.Exec(userId, serviceId, orderId, amount, date); err != nil {
+	if result, err = stmt.ExecContext(ctx, userId, serviceId, orderId, amount, date); err != nil {
 		return 0, err
 	}
 
 	return result.RowsAffected()
 }
 
-func (m *ControlPosgres) InsertReportTx(tx *sql.Tx, userId, serviceId, amount int, date time.Time) error {
+func (m *ControlPosgres) InsertReport(ctx context.Context, userId, serviceId, amount int, date time.Time) error {
 
-	stmt, err := tx.Prepare(`INSERT INTO report (user_id, service_id, amount, date) VALUES ($1, $2, $3, $4);`)
+	stmt, err := m.DB.PrepareContext(ctx, `INSERT INTO report (user_id, service_id, amount, date) VALUES ($1, $2, $3, $4);`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(userId, serviceId, amount, date); err != nil {
+	if _, err := stmt.ExecContext(ctx, userId, serviceId, amount, date); err != nil {
 		return err
 	}
 	return err
 }
 
-func (m *ControlPosgres) GetService(serviceId int) (string, error) {
+func (m *ControlPosgres) GetService(ctx context.Context, serviceId int) (string, error) {
 	var title string
 
-	rows, err := m.DB.Query("SELECT title FROM services WHERE id = $1", serviceId)
+	rows, err := m.DB.QueryContext(ctx, "SELECT title FROM services WHERE id = $1", serviceId)
 	if err != nil {
 		return title, err
 	}
@@ -298,3 +328,819 @@ func (m *ControlPosgres) GetService(serviceId int) (string, error) {
 
 	return title, err
 }
+
+func (m *ControlPosgres) InsertLedger(ctx context.Context, entry *models.LedgerEntry) error {
+
+	stmt, err := m.DB.PrepareContext(ctx, `INSERT INTO ledger (user_id, account, amount, created_at, description, operation, counterparty_id) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, 0));`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, entry.UserID, entry.Account, entry.Amount, entry.CreatedAt, entry.Description, entry.Operation, entry.CounterpartyID); err != nil {
+		return err
+	}
+	return err
+}
+
+func (m *ControlPosgres) GetLedgerSum(ctx context.Context, userId int, fromDate time.Time, toDate time.Time) (map[string]int, error) {
+	var sums map[string]int = make(map[string]int)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT account, SUM(amount) AS sumAmount
+		FROM ledger
+		WHERE user_id = $1 AND created_at >= $2 AND created_at <= $3
+		GROUP BY account
+	`, userId, fromDate, toDate)
+	if err != nil {
+		return sums, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var account string
+		var sum int
+		err := rows.Scan(&account, &sum)
+		if err != nil {
+			return sums, err
+		}
+		sums[account] = sum
+	}
+	return sums, err
+}
+
+// GetOperationSum возвращает сумму операций operation пользователя, записанных в журнал после from.
+// Пополнение увеличивает основной счет, перевод и резервирование уменьшают его, поэтому
+// входящие переводы в сумму переводов пользователя не входят. Возврат отклоненного или не принятого
+// в срок перевода записывается переводом с резервного счета на основной и вычитается из суммы
+func (m *ControlPosgres) GetOperationSum(ctx context.Context, userId int, operation string, from time.Time) (int, error) {
+	var sum int
+
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN account = 'main' THEN ABS(amount) ELSE -ABS(amount) END), 0)
+		FROM ledger
+		WHERE user_id = $1 AND operation = $2 AND created_at > $3 AND (amount > 0) = $4
+	`, userId, operation, from, operation == models.OperationTopup).Scan(&sum)
+
+	return sum, err
+}
+
+// GetOperationCount возвращает количество операций operation пользователя, записанных в журнал после from.
+// Как и в GetOperationSum, входящие переводы не учитываются
+func (m *ControlPosgres) GetOperationCount(ctx context.Context, userId int, operation string, from time.Time) (int, error) {
+	var count int
+
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM ledger
+		WHERE user_id = $1 AND operation = $2 AND account = 'main' AND created_at > $3 AND (amount > 0) = $4
+	`, userId, operation, from, operation == models.OperationTopup).Scan(&count)
+
+	return count, err
+}
+
+// GetRecipientCount возвращает количество разных получателей переводов пользователя после from, кроме toUserId
+func (m *ControlPosgres) GetRecipientCount(ctx context.Context, userId int, toUserId int, from time.Time) (int, error) {
+	var count int
+
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT counterparty_id)
+		FROM ledger
+		WHERE user_id = $1 AND operation = 'transfer' AND account = 'main' AND amount < 0
+			AND created_at > $3 AND counterparty_id <> $2
+	`, userId, toUserId, from).Scan(&count)
+
+	return count, err
+}
+
+// GetUserFirstLedgerDate возвращает дату первой записи журнала пользователя, если записей нет - нулевое время
+func (m *ControlPosgres) GetUserFirstLedgerDate(ctx context.Context, userId int) (time.Time, error) {
+	var date sql.NullTime
+
+	if err := m.DB.QueryRowContext(ctx, `SELECT MIN(created_at) FROM ledger WHERE user_id = $1`, userId).Scan(&date); err != nil {
+		return time.Time{}, err
+	}
+
+	return date.Time, nil
+}
+
+func (m *ControlPosgres) GetLastSnapshot(ctx context.Context, userId int, at time.Time) (*models.Snapshot, error) {
+	var snapshot models.Snapshot
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT user_id, taken_at, balance, reserve
+		FROM balance_snapshots
+		WHERE user_id = $1 AND taken_at <= $2
+		ORDER BY taken_at DESC
+		LIMIT 1
+	`, userId, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err := rows.Scan(&snapshot.UserID, &snapshot.TakenAt, &snapshot.Balance, &snapshot.Reserve)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, nil
+	}
+
+	return &snapshot, err
+}
+
+func (m *ControlPosgres) GetFirstLedgerDate(ctx context.Context) (time.Time, error) {
+	var date sql.NullTime
+
+	if err := m.DB.QueryRowContext(ctx, `SELECT MIN(created_at) FROM ledger`).Scan(&date); err != nil {
+		return time.Time{}, err
+	}
+
+	return date.Time, nil
+}
+
+func (m *ControlPosgres) InsertSnapshots(ctx context.Context, takenAt time.Time) (int64, error) {
+
+	result, err := m.DB.ExecContext(ctx, `
+		INSERT INTO balance_snapshots (user_id, taken_at, balance, reserve)
+		SELECT user_id, $1,
+			COALESCE(SUM(amount) FILTER (WHERE account = 'main'), 0),
+			COALESCE(SUM(amount) FILTER (WHERE account = 'reserve'), 0)
+		FROM ledger
+		WHERE created_at < $1
+		GROUP BY user_id
+		ON CONFLICT (user_id, taken_at) DO UPDATE
+		SET balance = EXCLUDED.balance, reserve = EXCLUDED.reserve;`, takenAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (m *ControlPosgres) DeleteSnapshots(ctx context.Context) error {
+
+	if _, err := m.DB.ExecContext(ctx, `DELETE FROM balance_snapshots;`); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetBalanceChecks восстанавливает остатки каждого пользователя по проводкам ledger:
+// основной счет - по сумме проводок по счету main, резерв - по проводкам по счету reserve,
+// кроме списаний (operation = 'confirm'), за вычетом списаний из report, а также по открытым
+// резервам money_reserve_details и средствам, удерживаемым по переводам, ожидающим подтверждения
+func (m *ControlPosgres) GetBalanceChecks(ctx context.Context) ([]models.BalanceCheck, error) {
+	var checks []models.BalanceCheck = make([]models.BalanceCheck, 0)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT u.id, u.balance, COALESCE(a.balance, 0),
+			COALESCE(l.main, 0),
+			COALESCE(l.reserved, 0) - COALESCE(r.confirmed, 0),
+			COALESCE(d.reserve, 0) + COALESCE(p.held, 0)
+		FROM users u
+		LEFT JOIN money_reserve_accounts a ON a.user_id = u.id
+		LEFT JOIN (
+			SELECT user_id,
+				SUM(CASE WHEN account = 'main' THEN amount ELSE 0 END) AS main,
+				SUM(CASE WHEN account = 'reserve' AND operation IS DISTINCT FROM 'confirm' THEN amount ELSE 0 END) AS reserved
+			FROM ledger
+			GROUP BY user_id
+		) l ON l.user_id = u.id
+		LEFT JOIN (
+			SELECT user_id, SUM(amount) AS confirmed FROM report GROUP BY user_id
+		) r ON r.user_id = u.id
+		LEFT JOIN (
+			SELECT user_id, SUM(amount) AS reserve FROM money_reserve_details GROUP BY user_id
+		) d ON d.user_id = u.id
+		LEFT JOIN (
+			SELECT from_user_id, SUM(amount) AS held FROM pending_transfers WHERE status = 'pending' GROUP BY from_user_id
+		) p ON p.from_user_id = u.id
+		ORDER BY u.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var check models.BalanceCheck
+		err := rows.Scan(
+			&check.UserID,
+			&check.Balance,
+			&check.Reserve,
+			&check.ExpectedBalance,
+			&check.ExpectedReserve,
+			&check.ReserveDetails)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, rows.Err()
+}
+
+// WalkLogs передает в fn записи logs по цепочкам пользователей в порядке их добавления
+func (m *ControlPosgres) WalkLogs(ctx context.Context, fn func(record models.AuditRecord) error) error {
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT id, user_id, date, amount, description, comment, metadata, prev_hash, hash
+		FROM logs
+		ORDER BY user_id, id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record models.AuditRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.UserID,
+			&record.Date,
+			&record.Amount,
+			&record.Description,
+			&record.Comment,
+			&record.Metadata,
+			&record.PrevHash,
+			&record.Hash)
+		if err != nil {
+			return err
+		}
+		if err = fn(record); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (m *ControlPosgres) GetChainHeads(ctx context.Context) ([]models.ChainHead, error) {
+	var heads []models.ChainHead = make([]models.ChainHead, 0)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT DISTINCT ON (user_id) user_id, id, hash
+		FROM logs
+		ORDER BY user_id, id DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var head models.ChainHead
+		if err := rows.Scan(&head.UserID, &head.LogID, &head.Hash); err != nil {
+			return nil, err
+		}
+		heads = append(heads, head)
+	}
+
+	return heads, rows.Err()
+}
+
+func (m *ControlPosgres) GetTotals(ctx context.Context) (*models.Totals, error) {
+	var totals models.Totals
+
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COALESCE(SUM(balance), 0) FROM users),
+			(SELECT COALESCE(SUM(balance), 0) FROM money_reserve_accounts),
+			(SELECT COUNT(*) FROM money_reserve_details)
+	`).Scan(&totals.Users, &totals.Balance, &totals.Reserve, &totals.Reservations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &totals, nil
+}
+
+func (m *ControlPosgres) Ping(ctx context.Context) error {
+	var one int
+	return m.DB.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+}
+
+// GetMigrationVersion возвращает версию примененных миграций и признак незавершенной миграции,
+// если миграции не применялись - нулевую версию
+func (m *ControlPosgres) GetMigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := m.DB.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return uint(version), dirty, nil
+}
+
+// InsertAPIKey сохраняет ключ клиента по хэшу и заполняет id и дату создания key
+func (m *ControlPosgres) InsertAPIKey(ctx context.Context, key *models.APIKey, hash string) error {
+	return m.DB.QueryRowContext(ctx, `
+		INSERT INTO api_keys (name, key_hash, scopes, signing_secret) VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING id, created_at`, key.Name, hash, pq.Array(key.Scopes), key.SigningSecret).Scan(&key.ID, &key.CreatedAt)
+}
+
+// GetAPIKeyByHash возвращает действующий (не отозванный) ключ по хэшу, если ключа нет - nil
+func (m *ControlPosgres) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT id, name, scopes, COALESCE(signing_secret, ''), created_at FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL`, hash).
+		Scan(&key.ID, &key.Name, pq.Array(&key.Scopes), &key.SigningSecret, &key.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.Signing = key.SigningSecret != ""
+
+	return &key, nil
+}
+
+// GetAPIKeyByName возвращает последний действующий ключ клиента с именем name, если ключа нет - nil
+func (m *ControlPosgres) GetAPIKeyByName(ctx context.Context, name string) (*models.APIKey, error) {
+	var key models.APIKey
+
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT id, name, scopes, COALESCE(signing_secret, ''), created_at FROM api_keys
+		WHERE name = $1 AND revoked_at IS NULL ORDER BY id DESC LIMIT 1`, name).
+		Scan(&key.ID, &key.Name, pq.Array(&key.Scopes), &key.SigningSecret, &key.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.Signing = key.SigningSecret != ""
+
+	return &key, nil
+}
+
+func (m *ControlPosgres) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys := make([]models.APIKey, 0)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT id, name, scopes, signing_secret IS NOT NULL, created_at, revoked_at
+		FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key models.APIKey
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Name, pq.Array(&key.Scopes), &key.Signing, &key.CreatedAt, &revokedAt); err != nil {
+			return nil, err
+		}
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey отзывает ключ и возвращает количество отозванных ключей: 0, если ключа нет или он уже отозван
+func (m *ControlPosgres) RevokeAPIKey(ctx context.Context, id int) (int64, error) {
+	result, err := m.DB.ExecContext(ctx, `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// SetAPIKeySigningSecret заменяет секрет подписи действующего ключа и возвращает количество измененных ключей
+func (m *ControlPosgres) SetAPIKeySigningSecret(ctx context.Context, id int, secret string) (int64, error) {
+	result, err := m.DB.ExecContext(ctx, `UPDATE api_keys SET signing_secret = $2 WHERE id = $1 AND revoked_at IS NULL`, id, secret)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetSpendingLimit возвращает лимиты операции пользователя, если они не заданы - nil
+func (m *ControlPosgres) GetSpendingLimit(ctx context.Context, userId int, operation string) (*models.SpendingLimit, error) {
+	limit := models.SpendingLimit{UserID: userId, Operation: operation}
+
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT per_operation, daily FROM spending_limits WHERE user_id = $1 AND operation = $2`, userId, operation).
+		Scan(&limit.PerOperation, &limit.Daily)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &limit, nil
+}
+
+func (m *ControlPosgres) GetSpendingLimits(ctx context.Context, userId int) ([]models.SpendingLimit, error) {
+	limits := make([]models.SpendingLimit, 0)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT user_id, operation, per_operation, daily FROM spending_limits
+		WHERE user_id = $1 ORDER BY operation`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var limit models.SpendingLimit
+		if err = rows.Scan(&limit.UserID, &limit.Operation, &limit.PerOperation, &limit.Daily); err != nil {
+			return nil, err
+		}
+		limits = append(limits, limit)
+	}
+
+	return limits, rows.Err()
+}
+
+// UpsertSpendingLimit задает лимиты операции пользователя, заменяя прежние
+func (m *ControlPosgres) UpsertSpendingLimit(ctx context.Context, limit *models.SpendingLimit) error {
+	_, err := m.DB.ExecContext(ctx, `
+		INSERT INTO spending_limits (user_id, operation, per_operation, daily) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, operation) DO UPDATE
+		SET per_operation = EXCLUDED.per_operation, daily = EXCLUDED.daily, updated_at = now()`,
+		limit.UserID, limit.Operation, limit.PerOperation, limit.Daily)
+	return err
+}
+
+// DeleteSpendingLimit удаляет лимиты операции пользователя и возвращает количество удаленных записей
+func (m *ControlPosgres) DeleteSpendingLimit(ctx context.Context, userId int, operation string) (int64, error) {
+	result, err := m.DB.ExecContext(ctx, `DELETE FROM spending_limits WHERE user_id = $1 AND operation = $2`, userId, operation)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// InsertRiskDecision сохраняет решение проверки операции и заполняет id и дату создания decision
+func (m *ControlPosgres) InsertRiskDecision(ctx context.Context, decision *models.RiskDecision) error {
+	operation := decision.Operation
+
+	return m.DB.QueryRowContext(ctx, `
+		INSERT INTO risk_decisions (operation, user_id, to_user_id, service_id, order_id, amount, date, decision, reasons, status, pending,
+			comment, metadata)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), NULLIF($5, 0), $6, NULLIF($7, ''), $8, $9, NULLIF($10, ''), $11, $12, $13)
+		RETURNING id, created_at`,
+		operation.Type, operation.UserID, operation.ToUserID, operation.ServiceID, operation.OrderID, operation.Amount,
+		operation.Date, decision.Decision, pq.Array(decision.Reasons), decision.Status, operation.Pending,
+		operation.Comment, operation.Metadata).
+		Scan(&decision.ID, &decision.CreatedAt)
+}
+
+// GetRiskDecisionForUpdate возвращает решение и блокирует его до конца транзакции, если решения нет - nil
+func (m *ControlPosgres) GetRiskDecisionForUpdate(ctx context.Context, id int) (*models.RiskDecision, error) {
+	row := m.DB.QueryRowContext(ctx, `
+		SELECT `+riskDecisionColumns+`
+		FROM risk_decisions WHERE id = $1 FOR UPDATE`, id)
+
+	decision, err := scanRiskDecision(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return decision, nil
+}
+
+// GetRiskDecisions возвращает решения по фильтру, начиная с последних
+func (m *ControlPosgres) GetRiskDecisions(ctx context.Context, filter *models.RiskFilter) ([]models.RiskDecision, error) {
+	decisions := make([]models.RiskDecision, 0)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT `+riskDecisionColumns+`
+		FROM risk_decisions
+		WHERE ($1 = '' OR decision = $1) AND ($2 = '' OR status = $2) AND ($3 = 0 OR user_id = $3)
+		ORDER BY id DESC
+		LIMIT $4`, filter.Decision, filter.Status, filter.UserID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		decision, err := scanRiskDecision(rows)
+		if err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, *decision)
+	}
+
+	return decisions, rows.Err()
+}
+
+// ResolveRiskDecision переводит операцию, ожидающую проверки, в состояние status и возвращает
+// количество измененных решений: 0, если решения нет или по нему уже принято решение
+func (m *ControlPosgres) ResolveRiskDecision(ctx context.Context, id int, status string, resolvedBy string) (int64, error) {
+	result, err := m.DB.ExecContext(ctx, `
+		UPDATE risk_decisions SET status = $2, resolved_at = now(), resolved_by = NULLIF($3, '')
+		WHERE id = $1 AND status = 'pending'`, id, status, resolvedBy)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+const riskDecisionColumns string = `id, operation, user_id, COALESCE(to_user_id, 0), COALESCE(service_id, 0), COALESCE(order_id, 0),
+		amount, COALESCE(date, ''), pending, comment, metadata, decision, reasons, COALESCE(status, ''), created_at, resolved_at, COALESCE(resolved_by, '')`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRiskDecision(row scanner) (*models.RiskDecision, error) {
+	var decision models.RiskDecision
+	var resolvedAt sql.NullTime
+
+	operation := &decision.Operation
+	err := row.Scan(&decision.ID, &operation.Type, &operation.UserID, &operation.ToUserID, &operation.ServiceID, &operation.OrderID,
+		&operation.Amount, &operation.Date, &operation.Pending, &operation.Comment, &operation.Metadata, &decision.Decision, pq.Array(&decision.Reasons), &decision.Status, &decision.CreatedAt,
+		&resolvedAt, &decision.ResolvedBy)
+	if err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		decision.ResolvedAt = &resolvedAt.Time
+	}
+
+	return &decision, nil
+}
+
+// InsertSchedule сохраняет операцию по расписанию и заполняет id и даты создания и изменения schedule
+func (m *ControlPosgres) InsertSchedule(ctx context.Context, schedule *models.Schedule) error {
+	return m.DB.QueryRowContext(ctx, `
+		INSERT INTO schedules (operation, user_id, to_user_id, service_id, order_id, amount, kind, run_at, repeat_interval, cron, status, next_run_at)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), NULLIF($5, 0), $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''), $11, $12)
+		RETURNING id, created_at, updated_at`,
+		schedule.Operation, schedule.UserID, schedule.ToUserID, schedule.ServiceID, schedule.OrderID, schedule.Amount,
+		schedule.Kind, schedule.RunAt, schedule.Interval, schedule.Cron, schedule.Status, schedule.NextRunAt).
+		Scan(&schedule.ID, &schedule.CreatedAt, &schedule.UpdatedAt)
+}
+
+// GetSchedule возвращает операцию по расписанию, если ее нет - nil
+func (m *ControlPosgres) GetSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	row := m.DB.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE id = $1`, id)
+	return getSchedule(row)
+}
+
+// GetScheduleForUpdate возвращает операцию по расписанию и блокирует ее до конца транзакции, если ее нет - nil
+func (m *ControlPosgres) GetScheduleForUpdate(ctx context.Context, id int) (*models.Schedule, error) {
+	row := m.DB.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE id = $1 FOR UPDATE`, id)
+	return getSchedule(row)
+}
+
+// GetDueScheduleForUpdate возвращает и блокирует активную операцию, время выполнения которой наступило
+// к now, начиная с самой давней, кроме операций с id из exclude. Операции, заблокированные другими
+// транзакциями, пропускаются, поэтому несколько экземпляров сервиса не выполняют одну операцию
+// одновременно. Если таких нет - nil
+func (m *ControlPosgres) GetDueScheduleForUpdate(ctx context.Context, now time.Time, exclude []int) (*models.Schedule, error) {
+	// пустой массив вместо NULL: условие id <> ALL(NULL) не выполняется ни для одной строки
+	if exclude == nil {
+		exclude = []int{}
+	}
+	row := m.DB.QueryRowContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM schedules
+		WHERE status = 'active' AND next_run_at <= $1 AND id <> ALL($2::int[])
+		ORDER BY next_run_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`, now, pq.Array(exclude))
+	return getSchedule(row)
+}
+
+// GetSchedules возвращает операции по расписанию по фильтру, начиная с последних созданных
+func (m *ControlPosgres) GetSchedules(ctx context.Context, filter *models.ScheduleFilter) ([]models.Schedule, error) {
+	schedules := make([]models.Schedule, 0)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM schedules
+		WHERE ($1 = 0 OR user_id = $1) AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3`, filter.UserID, filter.Status, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// UpdateSchedule сохраняет состояние, время следующего выполнения и номер попытки schedule
+func (m *ControlPosgres) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	return m.DB.QueryRowContext(ctx, `
+		UPDATE schedules SET status = $2, next_run_at = $3, attempt = $4, updated_at = now()
+		WHERE id = $1
+		RETURNING updated_at`, schedule.ID, schedule.Status, schedule.NextRunAt, schedule.Attempt).
+		Scan(&schedule.UpdatedAt)
+}
+
+// InsertScheduleRun сохраняет результат выполнения операции по расписанию и заполняет id и время выполнения run
+func (m *ControlPosgres) InsertScheduleRun(ctx context.Context, run *models.ScheduleRun) error {
+	return m.DB.QueryRowContext(ctx, `
+		INSERT INTO schedule_runs (schedule_id, attempt, status, error, due_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, executed_at`, run.ScheduleID, run.Attempt, run.Status, run.Error, run.DueAt).
+		Scan(&run.ID, &run.ExecutedAt)
+}
+
+// GetScheduleRuns возвращает последние limit выполнений операции по расписанию, начиная с последнего
+func (m *ControlPosgres) GetScheduleRuns(ctx context.Context, scheduleId int, limit int) ([]models.ScheduleRun, error) {
+	runs := make([]models.ScheduleRun, 0)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT id, schedule_id, attempt, status, COALESCE(error, ''), due_at, executed_at
+		FROM schedule_runs
+		WHERE schedule_id = $1
+		ORDER BY id DESC
+		LIMIT $2`, scheduleId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var run models.ScheduleRun
+		if err = rows.Scan(&run.ID, &run.ScheduleID, &run.Attempt, &run.Status, &run.Error, &run.DueAt, &run.ExecutedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+const scheduleColumns string = `id, operation, user_id, COALESCE(to_user_id, 0), COALESCE(service_id, 0), COALESCE(order_id, 0),
+		amount, kind, run_at, COALESCE(repeat_interval, ''), COALESCE(cron, ''), status, next_run_at, attempt, created_at, updated_at`
+
+func scanSchedule(row scanner) (*models.Schedule, error) {
+	var schedule models.Schedule
+	var runAt, nextRunAt sql.NullTime
+
+	err := row.Scan(&schedule.ID, &schedule.Operation, &schedule.UserID, &schedule.ToUserID, &schedule.ServiceID, &schedule.OrderID,
+		&schedule.Amount, &schedule.Kind, &runAt, &schedule.Interval, &schedule.Cron, &schedule.Status, &nextRunAt, &schedule.Attempt,
+		&schedule.CreatedAt, &schedule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if runAt.Valid {
+		schedule.RunAt = &runAt.Time
+	}
+	if nextRunAt.Valid {
+		schedule.NextRunAt = &nextRunAt.Time
+	}
+
+	return &schedule, nil
+}
+
+// getSchedule читает одну операцию по расписанию, если ее нет - nil
+func getSchedule(row scanner) (*models.Schedule, error) {
+	schedule, err := scanSchedule(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// InsertPendingTransfer сохраняет перевод, ожидающий подтверждения, и заполняет id и дату создания transfer
+func (m *ControlPosgres) InsertPendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	return m.DB.QueryRowContext(ctx, `
+		INSERT INTO pending_transfers (from_user_id, to_user_id, amount, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		transfer.FromUserID, transfer.ToUserID, transfer.Amount, transfer.Status, transfer.ExpiresAt).
+		Scan(&transfer.ID, &transfer.CreatedAt)
+}
+
+// GetPendingTransfer возвращает перевод, ожидающий подтверждения, если его нет - nil
+func (m *ControlPosgres) GetPendingTransfer(ctx context.Context, id int) (*models.PendingTransfer, error) {
+	row := m.DB.QueryRowContext(ctx, `SELECT `+pendingTransferColumns+` FROM pending_transfers WHERE id = $1`, id)
+	return getPendingTransfer(row)
+}
+
+// GetPendingTransferForUpdate возвращает перевод, ожидающий подтверждения, и блокирует его до конца транзакции,
+// если его нет - nil
+func (m *ControlPosgres) GetPendingTransferForUpdate(ctx context.Context, id int) (*models.PendingTransfer, error) {
+	row := m.DB.QueryRowContext(ctx, `SELECT `+pendingTransferColumns+` FROM pending_transfers WHERE id = $1 FOR UPDATE`, id)
+	return getPendingTransfer(row)
+}
+
+// GetExpiredPendingTransferForUpdate возвращает и блокирует неподтвержденный перевод, срок которого истек
+// к now, начиная с самого давнего. Переводы, заблокированные другими транзакциями, пропускаются. Если таких нет - nil
+func (m *ControlPosgres) GetExpiredPendingTransferForUpdate(ctx context.Context, now time.Time) (*models.PendingTransfer, error) {
+	row := m.DB.QueryRowContext(ctx, `
+		SELECT `+pendingTransferColumns+`
+		FROM pending_transfers
+		WHERE status = 'pending' AND expires_at <= $1
+		ORDER BY expires_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`, now)
+	return getPendingTransfer(row)
+}
+
+// GetPendingTransfers возвращает переводы, ожидающие подтверждения, по фильтру, начиная с последних
+func (m *ControlPosgres) GetPendingTransfers(ctx context.Context, filter *models.PendingTransferFilter) ([]models.PendingTransfer, error) {
+	transfers := make([]models.PendingTransfer, 0)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT `+pendingTransferColumns+`
+		FROM pending_transfers
+		WHERE ($1 = 0 OR from_user_id = $1 OR to_user_id = $1) AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3`, filter.UserID, filter.Status, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transfer, err := scanPendingTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *transfer)
+	}
+
+	return transfers, rows.Err()
+}
+
+// ResolvePendingTransfer сохраняет состояние завершенного перевода и заполняет время завершения transfer
+func (m *ControlPosgres) ResolvePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	var resolvedAt time.Time
+
+	err := m.DB.QueryRowContext(ctx, `
+		UPDATE pending_transfers SET status = $2, resolved_at = now()
+		WHERE id = $1
+		RETURNING resolved_at`, transfer.ID, transfer.Status).Scan(&resolvedAt)
+	if err != nil {
+		return err
+	}
+	transfer.ResolvedAt = &resolvedAt
+
+	return nil
+}
+
+const pendingTransferColumns string = `id, from_user_id, to_user_id, amount, status, expires_at, created_at, resolved_at`
+
+func scanPendingTransfer(row scanner) (*models.PendingTransfer, error) {
+	var transfer models.PendingTransfer
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(&transfer.ID, &transfer.FromUserID, &transfer.ToUserID, &transfer.Amount, &transfer.Status,
+		&transfer.ExpiresAt, &transfer.CreatedAt, &resolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		transfer.ResolvedAt = &resolvedAt.Time
+	}
+
+	return &transfer, nil
+}
+
+// getPendingTransfer читает один перевод, ожидающий подтверждения, если его нет - nil
+func getPendingTransfer(row scanner) (*models.PendingTransfer, error) {
+	transfer, err := scanPendingTransfer(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
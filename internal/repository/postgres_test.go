@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"log"
 	"testing"
@@ -8,6 +9,7 @@ import (
 	"userbalance/internal/models"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -66,6 +68,7 @@ func TestGetUser(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.id, testCase.balance)
 
 			got, err := r.GetUser(
+				context.Background(),
 				testCase.args.userid)
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -84,8 +87,6 @@ func TestGetUserForUpdate(t *testing.T) {
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid int
 	}
@@ -135,8 +136,9 @@ func TestGetUserForUpdate(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.id, testCase.balance)
 
 			tx, _ := db.Begin()
+			r := NewControlPostgres(tx)
 			got, err := r.GetUserForUpdate(
-				tx,
+				context.Background(),
 				testCase.args.userid)
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -202,6 +204,7 @@ func TestGetReport(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.title, testCase.sum)
 
 			got, err := r.GetReport(
+				context.Background(),
 				testCase.args.fromDate, testCase.args.toDate)
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -258,8 +261,56 @@ func TestGetHistory(t *testing.T) {
 				},
 			},
 			mockBehavior: func(args args, date time.Time, amount int, description string) {
-				rows := sqlmock.NewRows([]string{"date", "amount", "description"}).AddRow(date, amount, description)
-				mock.ExpectQuery("SELECT date, amount, description FROM logs").WithArgs(args.requestHistory.UserID).WillReturnRows(rows)
+				rows := sqlmock.NewRows([]string{"date", "amount", "description", "comment", "metadata"}).AddRow(date, amount, description, "", []byte("{}"))
+				mock.ExpectQuery("SELECT date, amount, description, comment, metadata FROM logs").WithArgs(args.requestHistory.UserID).WillReturnRows(rows)
+			},
+		},
+
+		{
+			name: "OK by metadata key",
+			args: args{
+				requestHistory: &models.RequestHistory{
+					UserID:      1,
+					SortField:   "date",
+					Direction:   "ASC",
+					MetadataKey: "invoice",
+				},
+			},
+			date:        time.Date(2022, 11, 01, 0, 0, 0, 0, time.Local),
+			amount:      100,
+			description: "Пополнение баланса",
+			want: []models.History{
+				{
+					Date:        time.Date(2022, 11, 01, 0, 0, 0, 0, time.Local),
+					Amount:      100,
+					Description: "Пополнение баланса",
+					Note:        models.Note{Comment: "оплата счета", Metadata: models.Metadata{"invoice": "42"}},
+				},
+			},
+			mockBehavior: func(args args, date time.Time, amount int, description string) {
+				rows := sqlmock.NewRows([]string{"date", "amount", "description", "comment", "metadata"}).
+					AddRow(date, amount, description, "оплата счета", []byte(`{"invoice": "42"}`))
+				mock.ExpectQuery(`FROM logs WHERE user_id = \$1 AND metadata \? \$2 ORDER BY date ASC`).
+					WithArgs(args.requestHistory.UserID, "invoice").WillReturnRows(rows)
+			},
+		},
+
+		{
+			name: "OK by metadata value",
+			args: args{
+				requestHistory: &models.RequestHistory{
+					UserID:        1,
+					SortField:     "date",
+					Direction:     "ASC",
+					MetadataKey:   "invoice",
+					MetadataValue: "42",
+				},
+			},
+			want: []models.History{},
+			mockBehavior: func(args args, date time.Time, amount int, description string) {
+				rows := sqlmock.NewRows([]string{"date", "amount", "description", "comment", "metadata"})
+				mock.ExpectQuery(`FROM logs WHERE user_id = \$1 AND metadata @> \$2::jsonb ORDER BY date ASC`).
+					WithArgs(args.requestHistory.UserID, `{"invoice":"42"}`).WillReturnRows(rows)
 			},
 		},
 
@@ -274,7 +325,7 @@ func TestGetHistory(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(args args, date time.Time, amount int, description string) {
-				mock.ExpectQuery("SELECT date, amount, description FROM logs").WithArgs(args.requestHistory.UserID).WillReturnError(errors.New("some error"))
+				mock.ExpectQuery("SELECT date, amount, description, comment, metadata FROM logs").WithArgs(args.requestHistory.UserID).WillReturnError(errors.New("some error"))
 			},
 		},
 	}
@@ -284,6 +335,7 @@ func TestGetHistory(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.date, testCase.amount, testCase.description)
 
 			got, err := r.GetHistory(
+				context.Background(),
 				testCase.args.requestHistory)
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -295,15 +347,13 @@ func TestGetHistory(t *testing.T) {
 	}
 }
 
-func TestUpdateBalanceTx(t *testing.T) {
+func TestUpdateBalance(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid int
 		amount int
@@ -349,8 +399,9 @@ func TestUpdateBalanceTx(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
 			tx, _ := db.Begin()
-			err := r.UpdateBalanceTx(
-				tx,
+			r := NewControlPostgres(tx)
+			err := r.UpdateBalance(
+				context.Background(),
 				testCase.args.userid,
 				testCase.args.amount)
 			if testCase.wantErr {
@@ -362,15 +413,13 @@ func TestUpdateBalanceTx(t *testing.T) {
 	}
 }
 
-func TestInsertUserTx(t *testing.T) {
+func TestInsertUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid int
 		amount int
@@ -416,8 +465,9 @@ func TestInsertUserTx(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
 			tx, _ := db.Begin()
-			err := r.InsertUserTx(
-				tx,
+			r := NewControlPostgres(tx)
+			err := r.InsertUser(
+				context.Background(),
 				testCase.args.userid,
 				testCase.args.amount)
 			if testCase.wantErr {
@@ -429,20 +479,19 @@ func TestInsertUserTx(t *testing.T) {
 	}
 }
 
-func TestInsertLogTx(t *testing.T) {
+func TestInsertLog(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid      int
 		date        time.Time
 		amount      int
 		description string
+		note        models.Note
 	}
 
 	type mockBehavior func(args args)
@@ -463,7 +512,29 @@ func TestInsertLogTx(t *testing.T) {
 			},
 			mockBehavior: func(args args) {
 				mock.ExpectBegin()
-				mock.ExpectPrepare("INSERT INTO logs").ExpectExec().WithArgs(args.userid, args.date, args.amount, args.description).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("SELECT hash FROM logs").WithArgs(args.userid).WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+				hash := models.LogHash(args.userid, args.date, args.amount, args.description, args.note, "")
+				mock.ExpectPrepare("INSERT INTO logs").ExpectExec().
+					WithArgs(args.userid, "2022-11-01", args.amount, args.description, "", "{}", "", hash).WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+
+		{
+			name: "OK chained with note",
+			args: args{
+				userid:      1,
+				date:        time.Date(2022, 11, 01, 0, 0, 0, 0, time.Local),
+				amount:      100,
+				description: "Пополнение баланса",
+				note:        models.Note{Comment: "оплата счета", Metadata: models.Metadata{"invoice": "42", "channel": "web"}},
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT hash FROM logs").WithArgs(args.userid).WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("prevhash"))
+				hash := models.LogHash(args.userid, args.date, args.amount, args.description, args.note, "prevhash")
+				mock.ExpectPrepare("INSERT INTO logs").ExpectExec().
+					WithArgs(args.userid, "2022-11-01", args.amount, args.description, "оплата счета", `{"channel":"web","invoice":"42"}`, "prevhash", hash).
+					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 		},
 
@@ -478,7 +549,8 @@ func TestInsertLogTx(t *testing.T) {
 			wantErr: true,
 			mockBehavior: func(args args) {
 				mock.ExpectBegin()
-				mock.ExpectPrepare("INSERT INTO logs").ExpectExec().WithArgs(args.userid, args.date, args.amount, args.description).WillReturnError(errors.New("error insert"))
+				mock.ExpectQuery("SELECT hash FROM logs").WithArgs(args.userid).WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+				mock.ExpectPrepare("INSERT INTO logs").ExpectExec().WillReturnError(errors.New("error insert"))
 				mock.ExpectRollback()
 			},
 		},
@@ -489,12 +561,14 @@ func TestInsertLogTx(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
 			tx, _ := db.Begin()
-			err := r.InsertLogTx(
-				tx,
+			r := NewControlPostgres(tx)
+			err := r.InsertLog(
+				context.Background(),
 				testCase.args.userid,
 				testCase.args.date,
 				testCase.args.amount,
-				testCase.args.description)
+				testCase.args.description,
+				testCase.args.note)
 			if testCase.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -504,15 +578,13 @@ func TestInsertLogTx(t *testing.T) {
 	}
 }
 
-func TestInsertMoneyReserveAccountsTx(t *testing.T) {
+func TestInsertMoneyReserveAccounts(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid int
 	}
@@ -555,8 +627,9 @@ func TestInsertMoneyReserveAccountsTx(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
 			tx, _ := db.Begin()
-			err := r.InsertMoneyReserveAccountsTx(
-				tx,
+			r := NewControlPostgres(tx)
+			err := r.InsertMoneyReserveAccounts(
+				context.Background(),
 				testCase.args.userid)
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -567,15 +640,13 @@ func TestInsertMoneyReserveAccountsTx(t *testing.T) {
 	}
 }
 
-func TestUpdateMoneyReserveAccountsTx(t *testing.T) {
+func TestUpdateMoneyReserveAccounts(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid int
 		amount int
@@ -621,8 +692,9 @@ func TestUpdateMoneyReserveAccountsTx(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
 			tx, _ := db.Begin()
-			err := r.UpdateMoneyReserveAccountsTx(
-				tx,
+			r := NewControlPostgres(tx)
+			err := r.UpdateMoneyReserveAccounts(
+				context.Background(),
 				testCase.args.userid,
 				testCase.args.amount)
 			if testCase.wantErr {
@@ -634,15 +706,13 @@ func TestUpdateMoneyReserveAccountsTx(t *testing.T) {
 	}
 }
 
-func TestGetBalanceReserveAccountsTx(t *testing.T) {
+func TestGetBalanceReserveAccounts(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid int
 	}
@@ -687,8 +757,9 @@ func TestGetBalanceReserveAccountsTx(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.balance)
 
 			tx, _ := db.Begin()
-			got, err := r.GetBalanceReserveAccountsTx(
-				tx,
+			r := NewControlPostgres(tx)
+			got, err := r.GetBalanceReserveAccounts(
+				context.Background(),
 				testCase.args.userid)
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -700,15 +771,13 @@ func TestGetBalanceReserveAccountsTx(t *testing.T) {
 	}
 }
 
-func TestInsertMoneyReserveDetailsTx(t *testing.T) {
+func TestInsertMoneyReserveDetails(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid    int
 		serviceId int
@@ -775,8 +844,9 @@ func TestInsertMoneyReserveDetailsTx(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
 			tx, _ := db.Begin()
-			err := r.InsertMoneyReserveDetailsTx(
-				tx,
+			r := NewControlPostgres(tx)
+			err := r.InsertMoneyReserveDetails(
+				context.Background(),
 				testCase.args.userid,
 				testCase.args.serviceId,
 				testCase.args.orderId,
@@ -791,15 +861,13 @@ func TestInsertMoneyReserveDetailsTx(t *testing.T) {
 	}
 }
 
-func TestDeleteMoneyReserveDetailsTx(t *testing.T) {
+func TestDeleteMoneyReserveDetails(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid    int
 		serviceId int
@@ -869,8 +937,9 @@ func TestDeleteMoneyReserveDetailsTx(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
 			tx, _ := db.Begin()
-			got, err := r.DeleteMoneyReserveDetailsTx(
-				tx,
+			r := NewControlPostgres(tx)
+			got, err := r.DeleteMoneyReserveDetails(
+				context.Background(),
 				testCase.args.userid,
 				testCase.args.serviceId,
 				testCase.args.orderId,
@@ -886,15 +955,13 @@ func TestDeleteMoneyReserveDetailsTx(t *testing.T) {
 	}
 }
 
-func TestInsertReportTx(t *testing.T) {
+func TestInsertReport(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	r := NewControlPostgres(db)
-
 	type args struct {
 		userid    int
 		serviceId int
@@ -954,8 +1021,9 @@ func TestInsertReportTx(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
 			tx, _ := db.Begin()
-			err := r.InsertReportTx(
-				tx,
+			r := NewControlPostgres(tx)
+			err := r.InsertReport(
+				context.Background(),
 				testCase.args.userid,
 				testCase.args.serviceId,
 				testCase.args.amount,
@@ -1022,6 +1090,7 @@ func TestGetService(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.title)
 
 			got, err := r.GetService(
+				context.Background(),
 				testCase.args.serviceid)
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -1032,3 +1101,1619 @@ func TestGetService(t *testing.T) {
 		})
 	}
 }
+
+func TestInsertLedger(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	type mockBehavior func(entry *models.LedgerEntry)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		entry        *models.LedgerEntry
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			entry: &models.LedgerEntry{
+				UserID:         1,
+				Account:        models.AccountMain,
+				Amount:         -100,
+				CreatedAt:      time.Date(2022, 11, 01, 10, 0, 0, 0, time.Local),
+				Description:    "Перевод средств пользователю 2",
+				Operation:      models.OperationTransfer,
+				CounterpartyID: 2,
+			},
+			mockBehavior: func(entry *models.LedgerEntry) {
+				mock.ExpectBegin()
+				mock.ExpectPrepare("INSERT INTO ledger").ExpectExec().WithArgs(
+					entry.UserID,
+					entry.Account,
+					entry.Amount,
+					entry.CreatedAt,
+					entry.Description,
+					entry.Operation,
+					entry.CounterpartyID).WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+
+		{
+			name: "error",
+			entry: &models.LedgerEntry{
+				UserID:  1,
+				Account: models.AccountMain,
+				Amount:  -100,
+			},
+			wantErr: true,
+			mockBehavior: func(entry *models.LedgerEntry) {
+				mock.ExpectBegin()
+				mock.ExpectPrepare("INSERT INTO ledger").ExpectExec().WillReturnError(errors.New("error insert"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.entry)
+
+			tx, _ := db.Begin()
+			r := NewControlPostgres(tx)
+			err := r.InsertLedger(
+				context.Background(),
+				testCase.entry)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetLedgerSum(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	type args struct {
+		userid   int
+		fromDate time.Time
+		toDate   time.Time
+	}
+
+	type mockBehavior func(args args)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		args         args
+		want         map[string]int
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				userid:   1,
+				fromDate: time.Date(2022, 3, 01, 0, 0, 0, 0, time.Local),
+				toDate:   time.Date(2022, 3, 31, 23, 59, 59, 0, time.Local),
+			},
+			want: map[string]int{models.AccountMain: 300, models.AccountReserve: -50},
+			mockBehavior: func(args args) {
+				rows := sqlmock.NewRows([]string{"account", "sumAmount"}).
+					AddRow(models.AccountMain, 300).
+					AddRow(models.AccountReserve, -50)
+				mock.ExpectQuery("SELECT account, SUM(.*) FROM ledger").WithArgs(args.userid, args.fromDate, args.toDate).WillReturnRows(rows)
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func(args args) {
+				mock.ExpectQuery("SELECT account, SUM(.*) FROM ledger").WithArgs(args.userid, args.fromDate, args.toDate).WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.GetLedgerSum(
+				context.Background(),
+				testCase.args.userid, testCase.args.fromDate, testCase.args.toDate)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestGetLastSnapshot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	at := time.Date(2022, 3, 31, 23, 59, 59, 0, time.Local)
+	takenAt := time.Date(2022, 3, 31, 0, 0, 0, 0, time.Local)
+
+	type mockBehavior func(userid int)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		userid       int
+		want         *models.Snapshot
+		wantErr      bool
+	}{
+		{
+			name:   "OK",
+			userid: 1,
+			want: &models.Snapshot{
+				UserID:  1,
+				TakenAt: takenAt,
+				Balance: 100,
+				Reserve: 10,
+			},
+			mockBehavior: func(userid int) {
+				rows := sqlmock.NewRows([]string{"user_id", "taken_at", "balance", "reserve"}).AddRow(userid, takenAt, 100, 10)
+				mock.ExpectQuery("SELECT (.*) FROM balance_snapshots").WithArgs(userid, at).WillReturnRows(rows)
+			},
+		},
+
+		{
+			name:   "OK no snapshot",
+			userid: 1,
+			mockBehavior: func(userid int) {
+				rows := sqlmock.NewRows([]string{"user_id", "taken_at", "balance", "reserve"})
+				mock.ExpectQuery("SELECT (.*) FROM balance_snapshots").WithArgs(userid, at).WillReturnRows(rows)
+			},
+		},
+
+		{
+			name:    "error",
+			userid:  1,
+			wantErr: true,
+			mockBehavior: func(userid int) {
+				mock.ExpectQuery("SELECT (.*) FROM balance_snapshots").WithArgs(userid, at).WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.userid)
+
+			got, err := r.GetLastSnapshot(context.Background(), testCase.userid, at)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestInsertSnapshots(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	takenAt := time.Date(2022, 4, 01, 0, 0, 0, 0, time.Local)
+
+	mock.ExpectExec("INSERT INTO balance_snapshots").WithArgs(takenAt).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	got, err := r.InsertSnapshots(context.Background(), takenAt)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBalanceChecks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         []models.BalanceCheck
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			want: []models.BalanceCheck{
+				{UserID: 1, Balance: 100, Reserve: 50, ExpectedBalance: 100, ExpectedReserve: 50, ReserveDetails: 50},
+				{UserID: 2, Balance: 120, Reserve: 0, ExpectedBalance: 100, ExpectedReserve: 0, ReserveDetails: 0},
+			},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "balance", "reserve", "main", "reserved", "details"}).
+					AddRow(1, 100, 50, 100, 50, 50).
+					AddRow(2, 120, 0, 100, 0, 0)
+				mock.ExpectQuery("SELECT (.*) FROM users (.*) FROM ledger").WillReturnRows(rows)
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM users").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetBalanceChecks(context.Background())
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestWalkLogs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	date := time.Date(2022, 11, 01, 0, 0, 0, 0, time.UTC)
+	want := []models.AuditRecord{
+		{ID: 1, UserID: 1, Date: date, Amount: 100, Description: "Пополнение баланса", PrevHash: "", Hash: "a"},
+		{ID: 5, UserID: 1, Date: date, Amount: 50, Description: "Перевод средств пользователю 2", PrevHash: "a", Hash: "b",
+			Note: models.Note{Comment: "за обед", Metadata: models.Metadata{"order": "15"}}},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "date", "amount", "description", "comment", "metadata", "prev_hash", "hash"})
+	for _, record := range want {
+		metadata, _ := record.Metadata.Value()
+		rows.AddRow(record.ID, record.UserID, record.Date, record.Amount, record.Description, record.Comment, metadata, record.PrevHash, record.Hash)
+	}
+	mock.ExpectQuery("SELECT (.*) FROM logs ORDER BY user_id, id").WillReturnRows(rows)
+
+	got := make([]models.AuditRecord, 0)
+	err = r.WalkLogs(context.Background(), func(record models.AuditRecord) error {
+		got = append(got, record)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetChainHeads(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	rows := sqlmock.NewRows([]string{"user_id", "id", "hash"}).
+		AddRow(1, 5, "b").
+		AddRow(2, 6, "c")
+	mock.ExpectQuery("SELECT DISTINCT ON (.*) FROM logs").WillReturnRows(rows)
+
+	got, err := r.GetChainHeads(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ChainHead{{UserID: 1, LogID: 5, Hash: "b"}, {UserID: 2, LogID: 6, Hash: "c"}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTotals(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         *models.Totals
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			want: &models.Totals{Users: 2, Balance: 300, Reserve: 50, Reservations: 1},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"users", "balance", "reserve", "reservations"}).AddRow(2, 300, 50, 1)
+				mock.ExpectQuery("SELECT (.*) FROM users").WillReturnRows(rows)
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM users").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetTotals(context.Background())
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(1))
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT 1").WillReturnError(errors.New("connection refused"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Ping(context.Background())
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetMigrationVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		wantVersion  uint
+		wantDirty    bool
+		wantErr      bool
+	}{
+		{
+			name:        "OK",
+			wantVersion: 11,
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"version", "dirty"}).AddRow(11, false)
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(rows)
+			},
+		},
+
+		{
+			name:        "OK dirty",
+			wantVersion: 9,
+			wantDirty:   true,
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"version", "dirty"}).AddRow(9, true)
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(rows)
+			},
+		},
+
+		{
+			name: "OK no migrations applied",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}))
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			version, dirty, err := r.GetMigrationVersion(context.Background())
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.wantVersion, version)
+				assert.Equal(t, testCase.wantDirty, dirty)
+			}
+		})
+	}
+}
+
+func TestInsertAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         *models.APIKey
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery("INSERT INTO api_keys").
+					WithArgs("billing", "hash", pq.Array([]string{"balance:read"}), "").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+			},
+			want: &models.APIKey{ID: 1, Name: "billing", Scopes: []string{"balance:read"}, CreatedAt: createdAt},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("INSERT INTO api_keys").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			key := &models.APIKey{Name: "billing", Scopes: []string{"balance:read"}}
+			err := r.InsertAPIKey(context.Background(), key, "hash")
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, key)
+			}
+		})
+	}
+}
+
+func TestGetAPIKeyByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         *models.APIKey
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "name", "scopes", "signing_secret", "created_at"}).
+					AddRow(1, "billing", "{balance:read,balance:topup}", "secret", createdAt)
+				mock.ExpectQuery("SELECT (.*) FROM api_keys").WithArgs("hash").WillReturnRows(rows)
+			},
+			want: &models.APIKey{
+				ID:            1,
+				Name:          "billing",
+				Scopes:        []string{"balance:read", "balance:topup"},
+				Signing:       true,
+				SigningSecret: "secret",
+				CreatedAt:     createdAt,
+			},
+		},
+
+		{
+			name: "OK not found",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM api_keys").WithArgs("hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "signing_secret", "created_at"}))
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM api_keys").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetAPIKeyByHash(context.Background(), "hash")
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestGetAPIKeyByName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         *models.APIKey
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "name", "scopes", "signing_secret", "created_at"}).
+					AddRow(2, "billing", "{balance:read}", "", createdAt)
+				mock.ExpectQuery("SELECT (.*) FROM api_keys WHERE name = (.*) ORDER BY id DESC LIMIT 1").
+					WithArgs("billing").WillReturnRows(rows)
+			},
+			want: &models.APIKey{
+				ID:        2,
+				Name:      "billing",
+				Scopes:    []string{"balance:read"},
+				CreatedAt: createdAt,
+			},
+		},
+
+		{
+			name: "OK not found",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM api_keys").WithArgs("billing").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "signing_secret", "created_at"}))
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM api_keys").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetAPIKeyByName(context.Background(), "billing")
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	revokedAt := time.Date(2022, 10, 2, 0, 0, 0, 0, time.UTC)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         []models.APIKey
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "name", "scopes", "signing", "created_at", "revoked_at"}).
+					AddRow(1, "billing", "{balance:read}", true, createdAt, revokedAt).
+					AddRow(2, "shop", "{reservations:write}", false, createdAt, nil)
+				mock.ExpectQuery("SELECT (.*) FROM api_keys").WillReturnRows(rows)
+			},
+			want: []models.APIKey{
+				{ID: 1, Name: "billing", Scopes: []string{"balance:read"}, Signing: true, CreatedAt: createdAt, RevokedAt: &revokedAt},
+				{ID: 2, Name: "shop", Scopes: []string{"reservations:write"}, CreatedAt: createdAt},
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM api_keys").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetAPIKeys(context.Background())
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         int64
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: 1,
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectExec("UPDATE api_keys SET revoked_at").WithArgs(1).WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.RevokeAPIKey(context.Background(), 1)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestSetAPIKeySigningSecret(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         int64
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectExec("UPDATE api_keys SET signing_secret").WithArgs(1, "secret").WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: 1,
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectExec("UPDATE api_keys SET signing_secret").WithArgs(1, "secret").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.SetAPIKeySigningSecret(context.Background(), 1, "secret")
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestGetOperationSum(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	from := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		operation    string
+		mockBehavior mockBehavior
+		want         int
+		wantErr      bool
+	}{
+		{
+			name:      "OK transfer",
+			operation: models.OperationTransfer,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT COALESCE(.*) FROM ledger").
+					WithArgs(1, models.OperationTransfer, from, false).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(300))
+			},
+			want: 300,
+		},
+
+		{
+			name:      "OK topup",
+			operation: models.OperationTopup,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT COALESCE(.*) FROM ledger").WithArgs(1, models.OperationTopup, from, true).
+					WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+			},
+		},
+
+		{
+			name:      "error",
+			operation: models.OperationTransfer,
+			wantErr:   true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT COALESCE(.*) FROM ledger").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetOperationSum(context.Background(), 1, testCase.operation, from)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestGetSpendingLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         *models.SpendingLimit
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT per_operation, daily FROM spending_limits").WithArgs(1, models.OperationTransfer).
+					WillReturnRows(sqlmock.NewRows([]string{"per_operation", "daily"}).AddRow(500, 1000))
+			},
+			want: &models.SpendingLimit{UserID: 1, Operation: models.OperationTransfer, PerOperation: 500, Daily: 1000},
+		},
+
+		{
+			name: "OK not set",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT per_operation, daily FROM spending_limits").WithArgs(1, models.OperationTransfer).
+					WillReturnRows(sqlmock.NewRows([]string{"per_operation", "daily"}))
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT per_operation, daily FROM spending_limits").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetSpendingLimit(context.Background(), 1, models.OperationTransfer)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestGetSpendingLimits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	rows := sqlmock.NewRows([]string{"user_id", "operation", "per_operation", "daily"}).
+		AddRow(1, models.OperationReserve, 0, 2000).
+		AddRow(1, models.OperationTransfer, 500, 1000)
+	mock.ExpectQuery("SELECT (.*) FROM spending_limits").WithArgs(1).WillReturnRows(rows)
+
+	got, err := r.GetSpendingLimits(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.SpendingLimit{
+		{UserID: 1, Operation: models.OperationReserve, Daily: 2000},
+		{UserID: 1, Operation: models.OperationTransfer, PerOperation: 500, Daily: 1000},
+	}, got)
+
+	mock.ExpectQuery("SELECT (.*) FROM spending_limits").WillReturnError(errors.New("some error"))
+	_, err = r.GetSpendingLimits(context.Background(), 1)
+	assert.Error(t, err)
+}
+
+func TestUpsertSpendingLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	limit := &models.SpendingLimit{UserID: 1, Operation: models.OperationTransfer, PerOperation: 500, Daily: 1000}
+
+	mock.ExpectExec("INSERT INTO spending_limits (.*) ON CONFLICT").WithArgs(1, models.OperationTransfer, 500, 1000).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.UpsertSpendingLimit(context.Background(), limit))
+
+	mock.ExpectExec("INSERT INTO spending_limits").WillReturnError(errors.New("some error"))
+	assert.Error(t, r.UpsertSpendingLimit(context.Background(), limit))
+}
+
+func TestDeleteSpendingLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	mock.ExpectExec("DELETE FROM spending_limits").WithArgs(1, models.OperationTransfer).WillReturnResult(sqlmock.NewResult(0, 1))
+	got, err := r.DeleteSpendingLimit(context.Background(), 1, models.OperationTransfer)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+
+	mock.ExpectExec("DELETE FROM spending_limits").WillReturnError(errors.New("some error"))
+	_, err = r.DeleteSpendingLimit(context.Background(), 1, models.OperationTransfer)
+	assert.Error(t, err)
+}
+
+func TestGetOperationCount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	from := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT COUNT(.*) FROM ledger").WithArgs(1, models.OperationTransfer, from, false).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	got, err := r.GetOperationCount(context.Background(), 1, models.OperationTransfer, from)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, got)
+
+	mock.ExpectQuery("SELECT COUNT(.*) FROM ledger").WillReturnError(errors.New("some error"))
+	_, err = r.GetOperationCount(context.Background(), 1, models.OperationTransfer, from)
+	assert.Error(t, err)
+}
+
+func TestGetRecipientCount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	from := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT COUNT(.*DISTINCT counterparty_id.*) FROM ledger").WithArgs(1, 2, from).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	got, err := r.GetRecipientCount(context.Background(), 1, 2, from)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, got)
+
+	mock.ExpectQuery("SELECT COUNT(.*) FROM ledger").WillReturnError(errors.New("some error"))
+	_, err = r.GetRecipientCount(context.Background(), 1, 2, from)
+	assert.Error(t, err)
+}
+
+func TestGetUserFirstLedgerDate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	date := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT MIN(.*) FROM ledger WHERE user_id").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(date))
+	got, err := r.GetUserFirstLedgerDate(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, date, got)
+
+	mock.ExpectQuery("SELECT MIN(.*) FROM ledger WHERE user_id").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+	got, err = r.GetUserFirstLedgerDate(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.True(t, got.IsZero())
+}
+
+func TestInsertRiskDecision(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	decision := &models.RiskDecision{
+		Decision: models.RiskReview,
+		Reasons:  []string{"large-transfer"},
+		Status:   models.RiskStatusPending,
+		Operation: models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100,
+			Note: models.Note{Comment: "за обед", Metadata: models.Metadata{"order": "15"}}},
+	}
+
+	mock.ExpectQuery("INSERT INTO risk_decisions").
+		WithArgs(models.OperationTransfer, 1, 2, 0, 0, 100, "", models.RiskReview, sqlmock.AnyArg(), models.RiskStatusPending, false,
+			"за обед", `{"order":"15"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, createdAt))
+	assert.NoError(t, r.InsertRiskDecision(context.Background(), decision))
+	assert.Equal(t, 7, decision.ID)
+	assert.Equal(t, createdAt, decision.CreatedAt)
+
+	mock.ExpectQuery("INSERT INTO risk_decisions").WillReturnError(errors.New("some error"))
+	assert.Error(t, r.InsertRiskDecision(context.Background(), decision))
+}
+
+func TestGetRiskDecisionForUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "operation", "user_id", "to_user_id", "service_id", "order_id", "amount", "date",
+		"pending", "comment", "metadata", "decision", "reasons", "status", "created_at", "resolved_at", "resolved_by"}
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         *models.RiskDecision
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM risk_decisions WHERE id = (.*) FOR UPDATE").WithArgs(7).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(7, models.OperationReserve, 1, 0, 2, 3, 100, "2022-10-01", false, "", []byte("{}"),
+						models.RiskReview, "{large-reserve,new-account}", models.RiskStatusPending, createdAt, nil, ""))
+			},
+			want: &models.RiskDecision{
+				ID:        7,
+				Decision:  models.RiskReview,
+				Reasons:   []string{"large-reserve", "new-account"},
+				Status:    models.RiskStatusPending,
+				Operation: models.RiskOperation{Type: models.OperationReserve, UserID: 1, ServiceID: 2, OrderID: 3, Amount: 100, Date: "2022-10-01"},
+				CreatedAt: createdAt,
+			},
+		},
+
+		{
+			name: "OK not found",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM risk_decisions").WithArgs(7).WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM risk_decisions").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetRiskDecisionForUpdate(context.Background(), 7)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestGetRiskDecisions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	resolvedAt := createdAt.Add(time.Hour)
+	filter := &models.RiskFilter{Decision: models.RiskReview, Limit: 100}
+
+	rows := sqlmock.NewRows([]string{"id", "operation", "user_id", "to_user_id", "service_id", "order_id", "amount", "date",
+		"pending", "comment", "metadata", "decision", "reasons", "status", "created_at", "resolved_at", "resolved_by"}).
+		AddRow(8, models.OperationTransfer, 1, 2, 0, 0, 100, "", true, "за обед", []byte(`{"order": "15"}`), models.RiskReview, "{velocity}", models.RiskStatusApproved, createdAt, resolvedAt, "support")
+	mock.ExpectQuery("SELECT (.*) FROM risk_decisions").WithArgs(models.RiskReview, "", 0, 100).WillReturnRows(rows)
+
+	got, err := r.GetRiskDecisions(context.Background(), filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.RiskDecision{{
+		ID:       8,
+		Decision: models.RiskReview,
+		Reasons:  []string{"velocity"},
+		Status:   models.RiskStatusApproved,
+		Operation: models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100, Pending: true,
+			Note: models.Note{Comment: "за обед", Metadata: models.Metadata{"order": "15"}}},
+		CreatedAt:  createdAt,
+		ResolvedAt: &resolvedAt,
+		ResolvedBy: "support",
+	}}, got)
+
+	mock.ExpectQuery("SELECT (.*) FROM risk_decisions").WillReturnError(errors.New("some error"))
+	_, err = r.GetRiskDecisions(context.Background(), filter)
+	assert.Error(t, err)
+}
+
+func TestResolveRiskDecision(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+
+	mock.ExpectExec("UPDATE risk_decisions SET status").WithArgs(7, models.RiskStatusApproved, "support").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	got, err := r.ResolveRiskDecision(context.Background(), 7, models.RiskStatusApproved, "support")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), got)
+
+	mock.ExpectExec("UPDATE risk_decisions SET status").WillReturnError(errors.New("some error"))
+	_, err = r.ResolveRiskDecision(context.Background(), 7, models.RiskStatusApproved, "support")
+	assert.Error(t, err)
+}
+
+func TestInsertSchedule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	schedule := &models.Schedule{
+		Operation: models.OperationReserve,
+		UserID:    1,
+		ServiceID: 2,
+		OrderID:   3,
+		Amount:    100,
+		Kind:      models.ScheduleCron,
+		Cron:      "@monthly",
+		Status:    models.ScheduleActive,
+		NextRunAt: &createdAt,
+	}
+
+	mock.ExpectQuery("INSERT INTO schedules").
+		WithArgs(models.OperationReserve, 1, 0, 2, 3, 100, models.ScheduleCron, nil, "", "@monthly", models.ScheduleActive, &createdAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(7, createdAt, createdAt))
+	assert.NoError(t, r.InsertSchedule(context.Background(), schedule))
+	assert.Equal(t, 7, schedule.ID)
+	assert.Equal(t, createdAt, schedule.CreatedAt)
+
+	mock.ExpectQuery("INSERT INTO schedules").WillReturnError(errors.New("some error"))
+	assert.Error(t, r.InsertSchedule(context.Background(), schedule))
+}
+
+func TestGetDueScheduleForUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	runAt := now.Add(-time.Hour)
+	columns := []string{"id", "operation", "user_id", "to_user_id", "service_id", "order_id", "amount", "kind", "run_at",
+		"repeat_interval", "cron", "status", "next_run_at", "attempt", "created_at", "updated_at"}
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		exclude      []int
+		mockBehavior mockBehavior
+		want         *models.Schedule
+		wantErr      bool
+	}{
+		{
+			name:    "OK",
+			exclude: []int{3},
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM schedules WHERE status = 'active' AND next_run_at <= (.*) AND id <> ALL(.*) FOR UPDATE SKIP LOCKED").WithArgs(now, pq.Array([]int{3})).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(7, models.OperationTransfer, 1, 2, 0, 0, 100, models.ScheduleInterval, runAt,
+						"24h", "", models.ScheduleActive, runAt, 1, runAt, runAt))
+			},
+			want: &models.Schedule{
+				ID:        7,
+				Operation: models.OperationTransfer,
+				UserID:    1,
+				ToUserID:  2,
+				Amount:    100,
+				Kind:      models.ScheduleInterval,
+				RunAt:     &runAt,
+				Interval:  "24h",
+				Status:    models.ScheduleActive,
+				NextRunAt: &runAt,
+				Attempt:   1,
+				CreatedAt: runAt,
+				UpdatedAt: runAt,
+			},
+		},
+
+		{
+			name: "OK nothing is due",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM schedules").WithArgs(now, pq.Array([]int{})).WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM schedules").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetDueScheduleForUpdate(context.Background(), now, testCase.exclude)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestGetSchedules(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	filter := &models.ScheduleFilter{UserID: 1, Limit: 100}
+
+	rows := sqlmock.NewRows([]string{"id", "operation", "user_id", "to_user_id", "service_id", "order_id", "amount", "kind", "run_at",
+		"repeat_interval", "cron", "status", "next_run_at", "attempt", "created_at", "updated_at"}).
+		AddRow(8, models.OperationTransfer, 1, 2, 0, 0, 100, models.ScheduleOnce, createdAt, "", "", models.ScheduleCompleted, nil, 0, createdAt, createdAt)
+	mock.ExpectQuery("SELECT (.*) FROM schedules").WithArgs(1, "", 100).WillReturnRows(rows)
+
+	got, err := r.GetSchedules(context.Background(), filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Schedule{{
+		ID:        8,
+		Operation: models.OperationTransfer,
+		UserID:    1,
+		ToUserID:  2,
+		Amount:    100,
+		Kind:      models.ScheduleOnce,
+		RunAt:     &createdAt,
+		Status:    models.ScheduleCompleted,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}}, got)
+
+	mock.ExpectQuery("SELECT (.*) FROM schedules").WillReturnError(errors.New("some error"))
+	_, err = r.GetSchedules(context.Background(), filter)
+	assert.Error(t, err)
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	updatedAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	schedule := &models.Schedule{ID: 7, Status: models.SchedulePaused, NextRunAt: &updatedAt, Attempt: 2}
+
+	mock.ExpectQuery("UPDATE schedules SET status").WithArgs(7, models.SchedulePaused, &updatedAt, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(updatedAt))
+	assert.NoError(t, r.UpdateSchedule(context.Background(), schedule))
+	assert.Equal(t, updatedAt, schedule.UpdatedAt)
+
+	mock.ExpectQuery("UPDATE schedules SET status").WillReturnError(errors.New("some error"))
+	assert.Error(t, r.UpdateSchedule(context.Background(), schedule))
+}
+
+func TestInsertScheduleRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	dueAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	run := &models.ScheduleRun{ScheduleID: 7, Attempt: 1, Status: models.RunRetry, Error: "недостаточно средств", DueAt: dueAt}
+
+	mock.ExpectQuery("INSERT INTO schedule_runs").WithArgs(7, 1, models.RunRetry, "недостаточно средств", dueAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "executed_at"}).AddRow(9, dueAt))
+	assert.NoError(t, r.InsertScheduleRun(context.Background(), run))
+	assert.Equal(t, 9, run.ID)
+
+	mock.ExpectQuery("INSERT INTO schedule_runs").WillReturnError(errors.New("some error"))
+	assert.Error(t, r.InsertScheduleRun(context.Background(), run))
+}
+
+func TestGetScheduleRuns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	dueAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	rows := sqlmock.NewRows([]string{"id", "schedule_id", "attempt", "status", "error", "due_at", "executed_at"}).
+		AddRow(9, 7, 2, models.RunSucceeded, "", dueAt, dueAt.Add(time.Hour)).
+		AddRow(8, 7, 1, models.RunRetry, "недостаточно средств", dueAt, dueAt)
+	mock.ExpectQuery("SELECT (.*) FROM schedule_runs").WithArgs(7, 100).WillReturnRows(rows)
+
+	got, err := r.GetScheduleRuns(context.Background(), 7, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ScheduleRun{
+		{ID: 9, ScheduleID: 7, Attempt: 2, Status: models.RunSucceeded, DueAt: dueAt, ExecutedAt: dueAt.Add(time.Hour)},
+		{ID: 8, ScheduleID: 7, Attempt: 1, Status: models.RunRetry, Error: "недостаточно средств", DueAt: dueAt, ExecutedAt: dueAt},
+	}, got)
+
+	mock.ExpectQuery("SELECT (.*) FROM schedule_runs").WillReturnError(errors.New("some error"))
+	_, err = r.GetScheduleRuns(context.Background(), 7, 100)
+	assert.Error(t, err)
+}
+
+func TestInsertPendingTransfer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(72 * time.Hour)
+	transfer := &models.PendingTransfer{
+		FromUserID: 1,
+		ToUserID:   2,
+		Amount:     100,
+		Status:     models.PendingTransferPending,
+		ExpiresAt:  expiresAt,
+	}
+
+	mock.ExpectQuery("INSERT INTO pending_transfers").
+		WithArgs(1, 2, 100, models.PendingTransferPending, expiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, createdAt))
+	assert.NoError(t, r.InsertPendingTransfer(context.Background(), transfer))
+	assert.Equal(t, 7, transfer.ID)
+	assert.Equal(t, createdAt, transfer.CreatedAt)
+
+	mock.ExpectQuery("INSERT INTO pending_transfers").WillReturnError(errors.New("some error"))
+	assert.Error(t, r.InsertPendingTransfer(context.Background(), transfer))
+}
+
+func TestGetExpiredPendingTransferForUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	now := time.Date(2022, 10, 4, 12, 0, 0, 0, time.UTC)
+	createdAt := now.Add(-72 * time.Hour)
+	expiresAt := now.Add(-time.Minute)
+	columns := []string{"id", "from_user_id", "to_user_id", "amount", "status", "expires_at", "created_at", "resolved_at"}
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		want         *models.PendingTransfer
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM pending_transfers WHERE status = 'pending' AND expires_at <= (.*) FOR UPDATE SKIP LOCKED").
+					WithArgs(now).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(7, 1, 2, 100, models.PendingTransferPending, expiresAt, createdAt, nil))
+			},
+			want: &models.PendingTransfer{
+				ID:         7,
+				FromUserID: 1,
+				ToUserID:   2,
+				Amount:     100,
+				Status:     models.PendingTransferPending,
+				ExpiresAt:  expiresAt,
+				CreatedAt:  createdAt,
+			},
+		},
+
+		{
+			name: "OK nothing expired",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM pending_transfers").WithArgs(now).WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+
+		{
+			name:    "error",
+			wantErr: true,
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.*) FROM pending_transfers").WillReturnError(errors.New("some error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetExpiredPendingTransferForUpdate(context.Background(), now)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestGetPendingTransfers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	createdAt := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	resolvedAt := createdAt.Add(time.Hour)
+	expiresAt := createdAt.Add(72 * time.Hour)
+	filter := &models.PendingTransferFilter{UserID: 2, Limit: 100}
+
+	rows := sqlmock.NewRows([]string{"id", "from_user_id", "to_user_id", "amount", "status", "expires_at", "created_at", "resolved_at"}).
+		AddRow(7, 1, 2, 100, models.PendingTransferAccepted, expiresAt, createdAt, resolvedAt)
+	mock.ExpectQuery("SELECT (.*) FROM pending_transfers WHERE (.*) from_user_id = (.*) OR to_user_id = ").
+		WithArgs(2, "", 100).WillReturnRows(rows)
+
+	got, err := r.GetPendingTransfers(context.Background(), filter)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.PendingTransfer{{
+		ID:         7,
+		FromUserID: 1,
+		ToUserID:   2,
+		Amount:     100,
+		Status:     models.PendingTransferAccepted,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  createdAt,
+		ResolvedAt: &resolvedAt,
+	}}, got)
+
+	mock.ExpectQuery("SELECT (.*) FROM pending_transfers").WillReturnError(errors.New("some error"))
+	_, err = r.GetPendingTransfers(context.Background(), filter)
+	assert.Error(t, err)
+}
+
+func TestResolvePendingTransfer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewControlPostgres(db)
+	resolvedAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	transfer := &models.PendingTransfer{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 100, Status: models.PendingTransferDeclined}
+
+	mock.ExpectQuery("UPDATE pending_transfers SET status").WithArgs(7, models.PendingTransferDeclined).
+		WillReturnRows(sqlmock.NewRows([]string{"resolved_at"}).AddRow(resolvedAt))
+	assert.NoError(t, r.ResolvePendingTransfer(context.Background(), transfer))
+	assert.Equal(t, &resolvedAt, transfer.ResolvedAt)
+
+	mock.ExpectQuery("UPDATE pending_transfers SET status").WillReturnError(errors.New("some error"))
+	assert.Error(t, r.ResolvePendingTransfer(context.Background(), transfer))
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
+	c "userbalance/internal/config"
 	"userbalance/internal/models"
 )
 
@@ -10,27 +12,87 @@ import (
 
 type Repository struct {
 	Control
+	UnitOfWork
+}
+
+// UnitOfWork выполняет fn в транзакции: repo, переданный в fn, работает в рамках этой транзакции.
+// Если fn возвращает ошибку или паникует, транзакция откатывается, иначе фиксируется
+type UnitOfWork interface {
+	WithinTx(ctx context.Context, fn func(repo Control) error) error
 }
 
 type Control interface {
-	UpdateBalanceTx(tx *sql.Tx, userId int, amount int) error
-	GetUser(userId int) (*models.User, error)
-	GetUserForUpdate(tx *sql.Tx, userId int) (*models.User, error)
-	InsertUserTx(tx *sql.Tx, userId int, amount int) error
-	InsertLogTx(tx *sql.Tx, userId int, date time.Time, amount int, description string) error
-	InsertMoneyReserveAccountsTx(tx *sql.Tx, userId int) error
-	UpdateMoneyReserveAccountsTx(tx *sql.Tx, userId int, amount int) error
-	GetBalanceReserveAccountsTx(tx *sql.Tx, userId int) (int, error)
-	InsertMoneyReserveDetailsTx(tx *sql.Tx, userId, serviceId, orderId, amount int, date time.Time) error
-	DeleteMoneyReserveDetailsTx(tx *sql.Tx, userId, serviceId, orderId, amount int, date time.Time) (int64, error)
-	InsertReportTx(tx *sql.Tx, userId, serviceId, amount int, date time.Time) error
-	GetService(serviceId int) (string, error)
-	GetReport(fromDate time.Time, toDate time.Time) (map[string]int, error)
-	GetHistory(requestHistory *models.RequestHistory) ([]models.History, error)
+	UpdateBalance(ctx context.Context, userId int, amount int) error
+	GetUser(ctx context.Context, userId int) (*models.User, error)
+	GetUserForUpdate(ctx context.Context, userId int) (*models.User, error)
+	InsertUser(ctx context.Context, userId int, amount int) error
+	InsertLog(ctx context.Context, userId int, date time.Time, amount int, description string, note models.Note) error
+	InsertMoneyReserveAccounts(ctx context.Context, userId int) error
+	UpdateMoneyReserveAccounts(ctx context.Context, userId int, amount int) error
+	GetBalanceReserveAccounts(ctx context.Context, userId int) (int, error)
+	InsertMoneyReserveDetails(ctx context.Context, userId, serviceId, orderId, amount int, date time.Time) error
+	DeleteMoneyReserveDetails(ctx context.Context, userId, serviceId, orderId, amount int, date time.Time) (int64, error)
+	InsertReport(ctx context.Context, userId, serviceId, amount int, date time.Time) error
+	GetService(ctx context.Context, serviceId int) (string, error)
+	GetReport(ctx context.Context, fromDate time.Time, toDate time.Time) (map[string]int, error)
+	GetHistory(ctx context.Context, requestHistory *models.RequestHistory) ([]models.History, error)
+	InsertLedger(ctx context.Context, entry *models.LedgerEntry) error
+	GetLedgerSum(ctx context.Context, userId int, fromDate time.Time, toDate time.Time) (map[string]int, error)
+	GetOperationSum(ctx context.Context, userId int, operation string, from time.Time) (int, error)
+	GetOperationCount(ctx context.Context, userId int, operation string, from time.Time) (int, error)
+	GetRecipientCount(ctx context.Context, userId int, toUserId int, from time.Time) (int, error)
+	GetUserFirstLedgerDate(ctx context.Context, userId int) (time.Time, error)
+	GetLastSnapshot(ctx context.Context, userId int, at time.Time) (*models.Snapshot, error)
+	GetFirstLedgerDate(ctx context.Context) (time.Time, error)
+	InsertSnapshots(ctx context.Context, takenAt time.Time) (int64, error)
+	DeleteSnapshots(ctx context.Context) error
+	GetBalanceChecks(ctx context.Context) ([]models.BalanceCheck, error)
+	WalkLogs(ctx context.Context, fn func(record models.AuditRecord) error) error
+	GetChainHeads(ctx context.Context) ([]models.ChainHead, error)
+	GetTotals(ctx context.Context) (*models.Totals, error)
+	Ping(ctx context.Context) error
+	GetMigrationVersion(ctx context.Context) (uint, bool, error)
+	InsertAPIKey(ctx context.Context, key *models.APIKey, hash string) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	GetAPIKeyByName(ctx context.Context, name string) (*models.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) (int64, error)
+	SetAPIKeySigningSecret(ctx context.Context, id int, secret string) (int64, error)
+	GetSpendingLimit(ctx context.Context, userId int, operation string) (*models.SpendingLimit, error)
+	GetSpendingLimits(ctx context.Context, userId int) ([]models.SpendingLimit, error)
+	UpsertSpendingLimit(ctx context.Context, limit *models.SpendingLimit) error
+	DeleteSpendingLimit(ctx context.Context, userId int, operation string) (int64, error)
+	InsertRiskDecision(ctx context.Context, decision *models.RiskDecision) error
+	GetRiskDecisionForUpdate(ctx context.Context, id int) (*models.RiskDecision, error)
+	GetRiskDecisions(ctx context.Context, filter *models.RiskFilter) ([]models.RiskDecision, error)
+	ResolveRiskDecision(ctx context.Context, id int, status string, resolvedBy string) (int64, error)
+	InsertSchedule(ctx context.Context, schedule *models.Schedule) error
+	GetSchedule(ctx context.Context, id int) (*models.Schedule, error)
+	GetScheduleForUpdate(ctx context.Context, id int) (*models.Schedule, error)
+	GetDueScheduleForUpdate(ctx context.Context, now time.Time, exclude []int) (*models.Schedule, error)
+	GetSchedules(ctx context.Context, filter *models.ScheduleFilter) ([]models.Schedule, error)
+	UpdateSchedule(ctx context.Context, schedule *models.Schedule) error
+	InsertScheduleRun(ctx context.Context, run *models.ScheduleRun) error
+	GetScheduleRuns(ctx context.Context, scheduleId int, limit int) ([]models.ScheduleRun, error)
+	InsertPendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error
+	GetPendingTransfer(ctx context.Context, id int) (*models.PendingTransfer, error)
+	GetPendingTransferForUpdate(ctx context.Context, id int) (*models.PendingTransfer, error)
+	GetExpiredPendingTransferForUpdate(ctx context.Context, now time.Time) (*models.PendingTransfer, error)
+	GetPendingTransfers(ctx context.Context, filter *models.PendingTransferFilter) ([]models.PendingTransfer, error)
+	ResolvePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error
 }
 
-func NewRepository(db *sql.DB) *Repository {
+func NewRepository(db *sql.DB, conf *c.Config) *Repository {
+	var attempts int
+	var backoff time.Duration
+
+	if conf != nil {
+		attempts = conf.TxMaxAttempts
+		backoff = time.Duration(conf.TxRetryBackoff) * time.Millisecond
+	}
+
 	return &Repository{
-		Control: NewControlPostgres(db),
+		Control:    NewTracedControl(NewControlPostgres(db)),
+		UnitOfWork: NewUnitOfWork(db, attempts, backoff),
 	}
 }
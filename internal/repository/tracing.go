@@ -0,0 +1,448 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"userbalance/internal/models"
+	"userbalance/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedControl записывает span для каждого обращения к БД через next. Span'ы запросов,
+// сделанных в транзакции, вложены в span транзакции UnitOfWork, поэтому по ним видно,
+// сколько времени запрос ждал блокировки FOR UPDATE
+type TracedControl struct {
+	next Control
+	tx   trace.Span
+}
+
+func NewTracedControl(next Control) *TracedControl {
+	return &TracedControl{next: next}
+}
+
+// newTxTracedControl вкладывает span'ы запросов в span транзакции tx независимо от контекста,
+// переданного в метод: функции транзакции получают контекст, созданный до ее начала
+func newTxTracedControl(next Control, tx trace.Span) *TracedControl {
+	return &TracedControl{next: next, tx: tx}
+}
+
+func (t *TracedControl) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if t.tx != nil {
+		ctx = trace.ContextWithSpan(ctx, t.tx)
+	}
+	attrs = append(attrs, semconv.DBSystemPostgreSQL)
+	return tracing.Start(ctx, "ControlPosgres."+method, attrs...)
+}
+
+func userID(userId int) attribute.KeyValue {
+	return attribute.Int("user.id", userId)
+}
+
+func (t *TracedControl) UpdateBalance(ctx context.Context, userId int, amount int) error {
+	ctx, span := t.start(ctx, "UpdateBalance", userID(userId))
+	err := t.next.UpdateBalance(ctx, userId, amount)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetUser(ctx context.Context, userId int) (*models.User, error) {
+	ctx, span := t.start(ctx, "GetUser", userID(userId))
+	user, err := t.next.GetUser(ctx, userId)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *TracedControl) GetUserForUpdate(ctx context.Context, userId int) (*models.User, error) {
+	ctx, span := t.start(ctx, "GetUserForUpdate", userID(userId))
+	user, err := t.next.GetUserForUpdate(ctx, userId)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *TracedControl) InsertUser(ctx context.Context, userId int, amount int) error {
+	ctx, span := t.start(ctx, "InsertUser", userID(userId))
+	err := t.next.InsertUser(ctx, userId, amount)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) InsertLog(ctx context.Context, userId int, date time.Time, amount int, description string, note models.Note) error {
+	ctx, span := t.start(ctx, "InsertLog", userID(userId))
+	err := t.next.InsertLog(ctx, userId, date, amount, description, note)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) InsertMoneyReserveAccounts(ctx context.Context, userId int) error {
+	ctx, span := t.start(ctx, "InsertMoneyReserveAccounts", userID(userId))
+	err := t.next.InsertMoneyReserveAccounts(ctx, userId)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) UpdateMoneyReserveAccounts(ctx context.Context, userId int, amount int) error {
+	ctx, span := t.start(ctx, "UpdateMoneyReserveAccounts", userID(userId))
+	err := t.next.UpdateMoneyReserveAccounts(ctx, userId, amount)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetBalanceReserveAccounts(ctx context.Context, userId int) (int, error) {
+	ctx, span := t.start(ctx, "GetBalanceReserveAccounts", userID(userId))
+	balance, err := t.next.GetBalanceReserveAccounts(ctx, userId)
+	tracing.End(span, err)
+	return balance, err
+}
+
+func (t *TracedControl) InsertMoneyReserveDetails(ctx context.Context, userId, serviceId, orderId, amount int, date time.Time) error {
+	ctx, span := t.start(ctx, "InsertMoneyReserveDetails", userID(userId))
+	err := t.next.InsertMoneyReserveDetails(ctx, userId, serviceId, orderId, amount, date)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) DeleteMoneyReserveDetails(ctx context.Context, userId, serviceId, orderId, amount int, date time.Time) (int64, error) {
+	ctx, span := t.start(ctx, "DeleteMoneyReserveDetails", userID(userId))
+	n, err := t.next.DeleteMoneyReserveDetails(ctx, userId, serviceId, orderId, amount, date)
+	tracing.End(span, err)
+	return n, err
+}
+
+func (t *TracedControl) InsertReport(ctx context.Context, userId, serviceId, amount int, date time.Time) error {
+	ctx, span := t.start(ctx, "InsertReport", userID(userId))
+	err := t.next.InsertReport(ctx, userId, serviceId, amount, date)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetService(ctx context.Context, serviceId int) (string, error) {
+	ctx, span := t.start(ctx, "GetService", attribute.Int("service.id", serviceId))
+	service, err := t.next.GetService(ctx, serviceId)
+	tracing.End(span, err)
+	return service, err
+}
+
+func (t *TracedControl) GetReport(ctx context.Context, fromDate time.Time, toDate time.Time) (map[string]int, error) {
+	ctx, span := t.start(ctx, "GetReport")
+	report, err := t.next.GetReport(ctx, fromDate, toDate)
+	tracing.End(span, err)
+	return report, err
+}
+
+func (t *TracedControl) GetHistory(ctx context.Context, requestHistory *models.RequestHistory) ([]models.History, error) {
+	ctx, span := t.start(ctx, "GetHistory", userID(requestHistory.UserID))
+	history, err := t.next.GetHistory(ctx, requestHistory)
+	tracing.End(span, err)
+	return history, err
+}
+
+func (t *TracedControl) InsertLedger(ctx context.Context, entry *models.LedgerEntry) error {
+	ctx, span := t.start(ctx, "InsertLedger", userID(entry.UserID))
+	err := t.next.InsertLedger(ctx, entry)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetLedgerSum(ctx context.Context, userId int, fromDate time.Time, toDate time.Time) (map[string]int, error) {
+	ctx, span := t.start(ctx, "GetLedgerSum", userID(userId))
+	sums, err := t.next.GetLedgerSum(ctx, userId, fromDate, toDate)
+	tracing.End(span, err)
+	return sums, err
+}
+
+func (t *TracedControl) GetOperationSum(ctx context.Context, userId int, operation string, from time.Time) (int, error) {
+	ctx, span := t.start(ctx, "GetOperationSum", userID(userId))
+	sum, err := t.next.GetOperationSum(ctx, userId, operation, from)
+	tracing.End(span, err)
+	return sum, err
+}
+
+func (t *TracedControl) GetOperationCount(ctx context.Context, userId int, operation string, from time.Time) (int, error) {
+	ctx, span := t.start(ctx, "GetOperationCount", userID(userId))
+	count, err := t.next.GetOperationCount(ctx, userId, operation, from)
+	tracing.End(span, err)
+	return count, err
+}
+
+func (t *TracedControl) GetRecipientCount(ctx context.Context, userId int, toUserId int, from time.Time) (int, error) {
+	ctx, span := t.start(ctx, "GetRecipientCount", userID(userId))
+	count, err := t.next.GetRecipientCount(ctx, userId, toUserId, from)
+	tracing.End(span, err)
+	return count, err
+}
+
+func (t *TracedControl) GetUserFirstLedgerDate(ctx context.Context, userId int) (time.Time, error) {
+	ctx, span := t.start(ctx, "GetUserFirstLedgerDate", userID(userId))
+	date, err := t.next.GetUserFirstLedgerDate(ctx, userId)
+	tracing.End(span, err)
+	return date, err
+}
+
+func (t *TracedControl) GetLastSnapshot(ctx context.Context, userId int, at time.Time) (*models.Snapshot, error) {
+	ctx, span := t.start(ctx, "GetLastSnapshot", userID(userId))
+	snapshot, err := t.next.GetLastSnapshot(ctx, userId, at)
+	tracing.End(span, err)
+	return snapshot, err
+}
+
+func (t *TracedControl) GetFirstLedgerDate(ctx context.Context) (time.Time, error) {
+	ctx, span := t.start(ctx, "GetFirstLedgerDate")
+	date, err := t.next.GetFirstLedgerDate(ctx)
+	tracing.End(span, err)
+	return date, err
+}
+
+func (t *TracedControl) InsertSnapshots(ctx context.Context, takenAt time.Time) (int64, error) {
+	ctx, span := t.start(ctx, "InsertSnapshots")
+	n, err := t.next.InsertSnapshots(ctx, takenAt)
+	tracing.End(span, err)
+	return n, err
+}
+
+func (t *TracedControl) DeleteSnapshots(ctx context.Context) error {
+	ctx, span := t.start(ctx, "DeleteSnapshots")
+	err := t.next.DeleteSnapshots(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetBalanceChecks(ctx context.Context) ([]models.BalanceCheck, error) {
+	ctx, span := t.start(ctx, "GetBalanceChecks")
+	checks, err := t.next.GetBalanceChecks(ctx)
+	tracing.End(span, err)
+	return checks, err
+}
+
+func (t *TracedControl) WalkLogs(ctx context.Context, fn func(record models.AuditRecord) error) error {
+	ctx, span := t.start(ctx, "WalkLogs")
+	err := t.next.WalkLogs(ctx, fn)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetChainHeads(ctx context.Context) ([]models.ChainHead, error) {
+	ctx, span := t.start(ctx, "GetChainHeads")
+	heads, err := t.next.GetChainHeads(ctx)
+	tracing.End(span, err)
+	return heads, err
+}
+
+func (t *TracedControl) GetTotals(ctx context.Context) (*models.Totals, error) {
+	ctx, span := t.start(ctx, "GetTotals")
+	totals, err := t.next.GetTotals(ctx)
+	tracing.End(span, err)
+	return totals, err
+}
+
+func (t *TracedControl) Ping(ctx context.Context) error {
+	ctx, span := t.start(ctx, "Ping")
+	err := t.next.Ping(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetMigrationVersion(ctx context.Context) (uint, bool, error) {
+	ctx, span := t.start(ctx, "GetMigrationVersion")
+	version, dirty, err := t.next.GetMigrationVersion(ctx)
+	tracing.End(span, err)
+	return version, dirty, err
+}
+
+func (t *TracedControl) InsertAPIKey(ctx context.Context, key *models.APIKey, hash string) error {
+	ctx, span := t.start(ctx, "InsertAPIKey")
+	err := t.next.InsertAPIKey(ctx, key, hash)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	ctx, span := t.start(ctx, "GetAPIKeyByHash")
+	key, err := t.next.GetAPIKeyByHash(ctx, hash)
+	tracing.End(span, err)
+	return key, err
+}
+
+func (t *TracedControl) GetAPIKeyByName(ctx context.Context, name string) (*models.APIKey, error) {
+	ctx, span := t.start(ctx, "GetAPIKeyByName")
+	key, err := t.next.GetAPIKeyByName(ctx, name)
+	tracing.End(span, err)
+	return key, err
+}
+
+func (t *TracedControl) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ctx, span := t.start(ctx, "GetAPIKeys")
+	keys, err := t.next.GetAPIKeys(ctx)
+	tracing.End(span, err)
+	return keys, err
+}
+
+func (t *TracedControl) RevokeAPIKey(ctx context.Context, id int) (int64, error) {
+	ctx, span := t.start(ctx, "RevokeAPIKey")
+	affected, err := t.next.RevokeAPIKey(ctx, id)
+	tracing.End(span, err)
+	return affected, err
+}
+
+func (t *TracedControl) SetAPIKeySigningSecret(ctx context.Context, id int, secret string) (int64, error) {
+	ctx, span := t.start(ctx, "SetAPIKeySigningSecret")
+	affected, err := t.next.SetAPIKeySigningSecret(ctx, id, secret)
+	tracing.End(span, err)
+	return affected, err
+}
+
+func (t *TracedControl) GetSpendingLimit(ctx context.Context, userId int, operation string) (*models.SpendingLimit, error) {
+	ctx, span := t.start(ctx, "GetSpendingLimit", userID(userId))
+	limit, err := t.next.GetSpendingLimit(ctx, userId, operation)
+	tracing.End(span, err)
+	return limit, err
+}
+
+func (t *TracedControl) GetSpendingLimits(ctx context.Context, userId int) ([]models.SpendingLimit, error) {
+	ctx, span := t.start(ctx, "GetSpendingLimits", userID(userId))
+	limits, err := t.next.GetSpendingLimits(ctx, userId)
+	tracing.End(span, err)
+	return limits, err
+}
+
+func (t *TracedControl) UpsertSpendingLimit(ctx context.Context, limit *models.SpendingLimit) error {
+	ctx, span := t.start(ctx, "UpsertSpendingLimit", userID(limit.UserID))
+	err := t.next.UpsertSpendingLimit(ctx, limit)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) DeleteSpendingLimit(ctx context.Context, userId int, operation string) (int64, error) {
+	ctx, span := t.start(ctx, "DeleteSpendingLimit", userID(userId))
+	affected, err := t.next.DeleteSpendingLimit(ctx, userId, operation)
+	tracing.End(span, err)
+	return affected, err
+}
+
+func (t *TracedControl) InsertRiskDecision(ctx context.Context, decision *models.RiskDecision) error {
+	ctx, span := t.start(ctx, "InsertRiskDecision", userID(decision.Operation.UserID))
+	err := t.next.InsertRiskDecision(ctx, decision)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetRiskDecisionForUpdate(ctx context.Context, id int) (*models.RiskDecision, error) {
+	ctx, span := t.start(ctx, "GetRiskDecisionForUpdate")
+	decision, err := t.next.GetRiskDecisionForUpdate(ctx, id)
+	tracing.End(span, err)
+	return decision, err
+}
+
+func (t *TracedControl) GetRiskDecisions(ctx context.Context, filter *models.RiskFilter) ([]models.RiskDecision, error) {
+	ctx, span := t.start(ctx, "GetRiskDecisions")
+	decisions, err := t.next.GetRiskDecisions(ctx, filter)
+	tracing.End(span, err)
+	return decisions, err
+}
+
+func (t *TracedControl) ResolveRiskDecision(ctx context.Context, id int, status string, resolvedBy string) (int64, error) {
+	ctx, span := t.start(ctx, "ResolveRiskDecision")
+	affected, err := t.next.ResolveRiskDecision(ctx, id, status, resolvedBy)
+	tracing.End(span, err)
+	return affected, err
+}
+
+func (t *TracedControl) InsertSchedule(ctx context.Context, schedule *models.Schedule) error {
+	ctx, span := t.start(ctx, "InsertSchedule", userID(schedule.UserID))
+	err := t.next.InsertSchedule(ctx, schedule)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	ctx, span := t.start(ctx, "GetSchedule")
+	schedule, err := t.next.GetSchedule(ctx, id)
+	tracing.End(span, err)
+	return schedule, err
+}
+
+func (t *TracedControl) GetScheduleForUpdate(ctx context.Context, id int) (*models.Schedule, error) {
+	ctx, span := t.start(ctx, "GetScheduleForUpdate")
+	schedule, err := t.next.GetScheduleForUpdate(ctx, id)
+	tracing.End(span, err)
+	return schedule, err
+}
+
+func (t *TracedControl) GetDueScheduleForUpdate(ctx context.Context, now time.Time, exclude []int) (*models.Schedule, error) {
+	ctx, span := t.start(ctx, "GetDueScheduleForUpdate")
+	schedule, err := t.next.GetDueScheduleForUpdate(ctx, now, exclude)
+	tracing.End(span, err)
+	return schedule, err
+}
+
+func (t *TracedControl) GetSchedules(ctx context.Context, filter *models.ScheduleFilter) ([]models.Schedule, error) {
+	ctx, span := t.start(ctx, "GetSchedules")
+	schedules, err := t.next.GetSchedules(ctx, filter)
+	tracing.End(span, err)
+	return schedules, err
+}
+
+func (t *TracedControl) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	ctx, span := t.start(ctx, "UpdateSchedule", userID(schedule.UserID))
+	err := t.next.UpdateSchedule(ctx, schedule)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) InsertScheduleRun(ctx context.Context, run *models.ScheduleRun) error {
+	ctx, span := t.start(ctx, "InsertScheduleRun")
+	err := t.next.InsertScheduleRun(ctx, run)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetScheduleRuns(ctx context.Context, scheduleId int, limit int) ([]models.ScheduleRun, error) {
+	ctx, span := t.start(ctx, "GetScheduleRuns")
+	runs, err := t.next.GetScheduleRuns(ctx, scheduleId, limit)
+	tracing.End(span, err)
+	return runs, err
+}
+
+func (t *TracedControl) InsertPendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	ctx, span := t.start(ctx, "InsertPendingTransfer", userID(transfer.FromUserID))
+	err := t.next.InsertPendingTransfer(ctx, transfer)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetPendingTransfer(ctx context.Context, id int) (*models.PendingTransfer, error) {
+	ctx, span := t.start(ctx, "GetPendingTransfer")
+	transfer, err := t.next.GetPendingTransfer(ctx, id)
+	tracing.End(span, err)
+	return transfer, err
+}
+
+func (t *TracedControl) GetPendingTransferForUpdate(ctx context.Context, id int) (*models.PendingTransfer, error) {
+	ctx, span := t.start(ctx, "GetPendingTransferForUpdate")
+	transfer, err := t.next.GetPendingTransferForUpdate(ctx, id)
+	tracing.End(span, err)
+	return transfer, err
+}
+
+func (t *TracedControl) GetExpiredPendingTransferForUpdate(ctx context.Context, now time.Time) (*models.PendingTransfer, error) {
+	ctx, span := t.start(ctx, "GetExpiredPendingTransferForUpdate")
+	transfer, err := t.next.GetExpiredPendingTransferForUpdate(ctx, now)
+	tracing.End(span, err)
+	return transfer, err
+}
+
+func (t *TracedControl) GetPendingTransfers(ctx context.Context, filter *models.PendingTransferFilter) ([]models.PendingTransfer, error) {
+	ctx, span := t.start(ctx, "GetPendingTransfers")
+	transfers, err := t.next.GetPendingTransfers(ctx, filter)
+	tracing.End(span, err)
+	return transfers, err
+}
+
+func (t *TracedControl) ResolvePendingTransfer(ctx context.Context, transfer *models.PendingTransfer) error {
+	ctx, span := t.start(ctx, "ResolvePendingTransfer", userID(transfer.FromUserID))
+	err := t.next.ResolvePendingTransfer(ctx, transfer)
+	tracing.End(span, err)
+	return err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+	"userbalance/internal/tracing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithinTxTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSyncer(exporter), 1))
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("SELECT id, balance FROM users").
+		ExpectQuery().WithArgs(1).WillReturnError(&pq.Error{Code: "40P01"})
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectPrepare("SELECT id, balance FROM users").
+		ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "balance"}).AddRow(1, 100))
+	mock.ExpectCommit()
+
+	uow := NewUnitOfWork(db, 3, time.Millisecond)
+	err = uow.WithinTx(context.Background(), func(repo Control) error {
+		_, err := repo.GetUserForUpdate(context.Background(), 1)
+		return err
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	failed, query, tx := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "ControlPosgres.GetUserForUpdate", failed.Name)
+	assert.Equal(t, codes.Error, failed.Status.Code)
+	assert.Equal(t, "ControlPosgres.GetUserForUpdate", query.Name)
+	assert.Equal(t, codes.Unset, query.Status.Code)
+	assert.Contains(t, query.Attributes, attribute.Int("user.id", 1))
+
+	assert.Equal(t, "UnitOfWork.WithinTx", tx.Name)
+	assert.Contains(t, tx.Attributes, attribute.Int("db.tx.attempts", 2))
+	require.Len(t, tx.Events, 1)
+	assert.Equal(t, "retry", tx.Events[0].Name)
+	assert.Equal(t, tx.SpanContext.SpanID(), failed.Parent.SpanID())
+	assert.Equal(t, tx.SpanContext.SpanID(), query.Parent.SpanID())
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+	"userbalance/internal/logger"
+	"userbalance/internal/tracing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultTxMaxAttempts int           = 3
+	defaultTxBackoff     time.Duration = 20 * time.Millisecond
+)
+
+// UnitOfWorkPostgres выполняет функцию в транзакции и повторяет ее,
+// если БД прервала транзакцию из-за конфликта сериализации или взаимной блокировки
+type UnitOfWorkPostgres struct {
+	db          *sql.DB
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func NewUnitOfWork(db *sql.DB, maxAttempts int, backoff time.Duration) *UnitOfWorkPostgres {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultTxMaxAttempts
+	}
+	if backoff <= 0 {
+		backoff = defaultTxBackoff
+	}
+	return &UnitOfWorkPostgres{
+		db:          db,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+func (u *UnitOfWorkPostgres) WithinTx(ctx context.Context, fn func(repo Control) error) (err error) {
+	ctx, span := tracing.Start(ctx, "UnitOfWork.WithinTx", semconv.DBSystemPostgreSQL)
+	defer func() { tracing.End(span, err) }()
+
+	for attempt := 1; ; attempt++ {
+		span.SetAttributes(attribute.Int("db.tx.attempts", attempt))
+		if err = u.run(ctx, fn); err == nil || !IsRetryable(err) || attempt >= u.maxAttempts {
+			return err
+		}
+		logger.WarnContext(ctx, "транзакция прервана БД и будет повторена", "attempt", attempt, "error", err)
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt), attribute.String("error", err.Error())))
+
+		timer := time.NewTimer(u.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (u *UnitOfWorkPostgres) run(ctx context.Context, fn func(repo Control) error) (err error) {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			err = fmt.Errorf("паника в транзакции: %v", p)
+		}
+	}()
+
+	if err = fn(newTxTracedControl(NewControlPostgres(tx), trace.SpanFromContext(ctx))); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// delay возвращает экспоненциально растущую паузу со случайной добавкой,
+// чтобы повторы конкурирующих транзакций не совпадали по времени
+func (u *UnitOfWorkPostgres) delay(attempt int) time.Duration {
+	d := u.backoff << (attempt - 1)
+	return d + time.Duration(rand.Int63n(int64(d)))
+}
+
+// IsRetryable сообщает, можно ли повторить транзакцию, завершившуюся ошибкой err
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// serialization_failure, deadlock_detected
+		return pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// ER_LOCK_DEADLOCK
+		return mysqlErr.Number == 1213
+	}
+
+	return false
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithinTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	deadlock := &pq.Error{Code: "40P01"}
+	serialization := &pq.Error{Code: "40001"}
+
+	type mockBehavior func()
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		errs         []error
+		wantCalls    int
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+
+		{
+			name: "OK retry after deadlock",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+			errs:      []error{deadlock, serialization, nil},
+			wantCalls: 3,
+		},
+
+		{
+			name: "OK retry after serialization failure on commit",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectCommit().WillReturnError(serialization)
+				mock.ExpectBegin()
+				mock.ExpectCommit()
+			},
+			errs:      []error{nil, nil},
+			wantCalls: 2,
+		},
+
+		{
+			name: "error not retryable",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			errs:      []error{errors.New("недостаточно средств")},
+			wantCalls: 1,
+			wantErr:   errors.New("недостаточно средств"),
+		},
+
+		{
+			name: "error attempts exhausted",
+			mockBehavior: func() {
+				for i := 0; i < 3; i++ {
+					mock.ExpectBegin()
+					mock.ExpectRollback()
+				}
+			},
+			errs:      []error{deadlock, deadlock, deadlock},
+			wantCalls: 3,
+			wantErr:   deadlock,
+		},
+
+		{
+			name: "error begin",
+			mockBehavior: func() {
+				mock.ExpectBegin().WillReturnError(errors.New("db error"))
+			},
+			wantErr: errors.New("db error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			calls := 0
+			u := NewUnitOfWork(db, 3, time.Millisecond)
+			err := u.WithinTx(context.Background(), func(repo Control) error {
+				calls++
+				return testCase.errs[calls-1]
+			})
+
+			assert.Equal(t, testCase.wantErr, err)
+			assert.Equal(t, testCase.wantCalls, calls)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&pq.Error{Code: "40001"}))
+	assert.True(t, IsRetryable(&pq.Error{Code: "40P01"}))
+	assert.False(t, IsRetryable(&pq.Error{Code: "23505"}))
+	assert.False(t, IsRetryable(errors.New("some error")))
+	assert.False(t, IsRetryable(nil))
+}
+
+func TestWithinTxCanceledContext(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	u := NewUnitOfWork(db, 3, time.Millisecond)
+	err = u.WithinTx(ctx, func(repo Control) error {
+		calls++
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithinTxPanic(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	u := NewUnitOfWork(db, 3, time.Millisecond)
+	err = u.WithinTx(context.Background(), func(repo Control) error {
+		panic("some panic")
+	})
+
+	assert.EqualError(t, err, "паника в транзакции: some panic")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithinTxUsesTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("UPDATE users SET balance").ExpectExec().WithArgs(100, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	u := NewUnitOfWork(db, 3, time.Millisecond)
+	err = u.WithinTx(context.Background(), func(repo Control) error {
+		return repo.UpdateBalance(context.Background(), 1, 100)
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,67 @@
+// Package risk оценивает операции по правилам из YAML-файла перед их выполнением
+package risk
+
+import (
+	"context"
+	"time"
+	"userbalance/internal/models"
+	"userbalance/internal/rulefile"
+)
+
+// History - история операций пользователя, по которой считаются правила velocity,
+// newaccount и recipients. Ее реализует repository.Control, в том числе в транзакции
+type History interface {
+	GetOperationCount(ctx context.Context, userId int, operation string, from time.Time) (int, error)
+	GetRecipientCount(ctx context.Context, userId int, toUserId int, from time.Time) (int, error)
+	GetUserFirstLedgerDate(ctx context.Context, userId int) (time.Time, error)
+}
+
+// severity - строгость решений: из решений сработавших правил выбирается самое строгое
+var severity = map[string]int{
+	models.RiskAllow:  0,
+	models.RiskReview: 1,
+	models.RiskDeny:   2,
+}
+
+// Engine проверяет операции по правилам из файла, перечитываемого методом Reload
+type Engine struct {
+	*rulefile.File[Rules]
+}
+
+// NewEngine загружает правила из файла path
+func NewEngine(path string) (*Engine, error) {
+	rules, err := rulefile.New(path, Load)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{rules}, nil
+}
+
+// Check проверяет операцию всеми правилами, которые к ней относятся. Решение - самое строгое
+// из решений сработавших правил, если ни одно не сработало - allow
+func (e *Engine) Check(ctx context.Context, history History, operation *models.RiskOperation) (*models.RiskDecision, error) {
+	decision := &models.RiskDecision{
+		Decision: models.RiskAllow,
+		Reasons:  make([]string, 0),
+	}
+	now := time.Now()
+
+	for _, rule := range e.Rules().Rules {
+		if !rule.applies(operation.Type) {
+			continue
+		}
+		reason, err := rule.match(ctx, history, operation, now)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" {
+			continue
+		}
+		decision.Reasons = append(decision.Reasons, reason)
+		if severity[rule.Decision] > severity[decision.Decision] {
+			decision.Decision = rule.Decision
+		}
+	}
+
+	return decision, nil
+}
@@ -0,0 +1,223 @@
+package risk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"userbalance/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// history - история пользователя с заданными значениями
+type history struct {
+	operations int
+	recipients int
+	first      time.Time
+}
+
+func (h history) GetOperationCount(ctx context.Context, userId int, operation string, from time.Time) (int, error) {
+	return h.operations, nil
+}
+
+func (h history) GetRecipientCount(ctx context.Context, userId int, toUserId int, from time.Time) (int, error) {
+	return h.recipients, nil
+}
+
+func (h history) GetUserFirstLedgerDate(ctx context.Context, userId int) (time.Time, error) {
+	return h.first, nil
+}
+
+func writeRules(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "risk.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+const rules = `
+rules:
+  - name: large-transfer
+    type: amount
+    operations: [transfer]
+    amount: 1000
+    decision: review
+  - name: velocity
+    type: velocity
+    window: 1h
+    count: 3
+    decision: review
+  - name: new-account
+    type: newaccount
+    age: 72h
+    amount: 500
+    decision: review
+  - name: many-recipients
+    type: recipients
+    window: 24h
+    count: 2
+    decision: deny
+`
+
+func TestEngine_Check(t *testing.T) {
+	engine, err := NewEngine(writeRules(t, rules))
+	require.NoError(t, err)
+
+	old := history{first: time.Now().Add(-30 * 24 * time.Hour)}
+
+	testTable := []struct {
+		name      string
+		history   history
+		operation models.RiskOperation
+		want      string
+		reasons   int
+	}{
+		{
+			name:      "allow",
+			history:   old,
+			operation: models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100},
+			want:      models.RiskAllow,
+		},
+
+		{
+			name:      "review large transfer",
+			history:   old,
+			operation: models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 1000},
+			want:      models.RiskReview,
+			reasons:   1,
+		},
+
+		{
+			name:      "allow large reserve",
+			history:   old,
+			operation: models.RiskOperation{Type: models.OperationReserve, UserID: 1, ServiceID: 1, OrderID: 1, Amount: 1000},
+			want:      models.RiskAllow,
+		},
+
+		{
+			name:      "review velocity",
+			history:   history{operations: 3, first: old.first},
+			operation: models.RiskOperation{Type: models.OperationReserve, UserID: 1, ServiceID: 1, OrderID: 1, Amount: 100},
+			want:      models.RiskReview,
+			reasons:   1,
+		},
+
+		{
+			name:      "review new account",
+			history:   history{first: time.Now().Add(-time.Hour)},
+			operation: models.RiskOperation{Type: models.OperationReserve, UserID: 1, ServiceID: 1, OrderID: 1, Amount: 500},
+			want:      models.RiskReview,
+			reasons:   1,
+		},
+
+		{
+			name:      "review account without history",
+			operation: models.RiskOperation{Type: models.OperationReserve, UserID: 1, ServiceID: 1, OrderID: 1, Amount: 500},
+			want:      models.RiskReview,
+			reasons:   1,
+		},
+
+		{
+			name:      "deny is stricter than review",
+			history:   history{recipients: 2, first: old.first},
+			operation: models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 1000},
+			want:      models.RiskDeny,
+			reasons:   2,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := engine.Check(context.Background(), testCase.history, &testCase.operation)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.want, got.Decision)
+			assert.Len(t, got.Reasons, testCase.reasons)
+		})
+	}
+}
+
+func TestEngine_Reload(t *testing.T) {
+	path := writeRules(t, rules)
+	engine, err := NewEngine(path)
+	require.NoError(t, err)
+
+	operation := &models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 1000}
+	old := history{first: time.Now().Add(-30 * 24 * time.Hour)}
+
+	require.NoError(t, os.WriteFile(path, []byte("rules: []\n"), 0600))
+	require.NoError(t, engine.Reload())
+	got, err := engine.Check(context.Background(), old, operation)
+	require.NoError(t, err)
+	assert.Equal(t, models.RiskAllow, got.Decision)
+
+	// файл с ошибкой не заменяет действующие правила
+	require.NoError(t, os.WriteFile(path, []byte("rules:\n  - name: broken\n"), 0600))
+	assert.Error(t, engine.Reload())
+	got, err = engine.Check(context.Background(), old, operation)
+	require.NoError(t, err)
+	assert.Equal(t, models.RiskAllow, got.Decision)
+}
+
+func TestLoad(t *testing.T) {
+	testTable := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "OK",
+			content: rules,
+		},
+
+		{
+			name:    "error unknown type",
+			content: "rules:\n  - {name: a, type: country, decision: deny}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error allow decision",
+			content: "rules:\n  - {name: a, type: amount, amount: 10, decision: allow}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error velocity without window",
+			content: "rules:\n  - {name: a, type: velocity, count: 10, decision: review}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error recipients for reserve",
+			content: "rules:\n  - {name: a, type: recipients, operations: [reserve], window: 1h, count: 10, decision: deny}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error duplicate name",
+			content: "rules:\n  - {name: a, type: amount, amount: 10, decision: deny}\n  - {name: a, type: amount, amount: 20, decision: deny}\n",
+			wantErr: true,
+		},
+
+		{
+			name:    "error wrong duration",
+			content: "rules:\n  - {name: a, type: newaccount, age: week, decision: review}\n",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := Load(writeRules(t, testCase.content))
+			if testCase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, got.Rules, 4)
+			assert.Equal(t, time.Hour, got.Rules[1].Window)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"userbalance/internal/models"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"gopkg.in/yaml.v3"
+)
+
+// Типы правил
+const (
+	// RuleAmount срабатывает на операцию с суммой не меньше Amount
+	RuleAmount string = "amount"
+	// RuleVelocity срабатывает, если с операцией за Window их будет больше Count
+	RuleVelocity string = "velocity"
+	// RuleNewAccount срабатывает на операцию с суммой не меньше Amount, если первая
+	// запись журнала пользователя сделана меньше Age назад
+	RuleNewAccount string = "newaccount"
+	// RuleRecipients срабатывает на перевод, если с его получателем за Window у пользователя
+	// будет больше Count разных получателей
+	RuleRecipients string = "recipients"
+)
+
+// Rule - правило проверки операций Operations, при срабатывании выносит решение Decision
+type Rule struct {
+	Name       string        `yaml:"name"`
+	Type       string        `yaml:"type"`
+	Operations []string      `yaml:"operations"`
+	Decision   string        `yaml:"decision"`
+	Amount     int           `yaml:"amount"`
+	Count      int           `yaml:"count"`
+	Window     time.Duration `yaml:"window"`
+	Age        time.Duration `yaml:"age"`
+}
+
+// Rules - содержимое файла правил
+type Rules struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Load читает и проверяет файл правил
+func Load(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules Rules
+	if err = yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе файла правил %s: %w", path, err)
+	}
+
+	names := make(map[string]bool, len(rules.Rules))
+	for i, rule := range rules.Rules {
+		if err = rule.Validate(); err != nil {
+			return nil, fmt.Errorf("правило %d (%s): %w", i+1, rule.Name, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("правило %s задано несколько раз", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+
+	return &rules, nil
+}
+
+func (r Rule) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Name, validation.Required.Error("имя правила не может быть не указано")),
+		validation.Field(&r.Type,
+			validation.Required.Error("тип правила не может быть не указан"),
+			validation.In(RuleAmount, RuleVelocity, RuleNewAccount, RuleRecipients).
+				Error("тип правила может быть amount, velocity, newaccount либо recipients")),
+		validation.Field(&r.Operations, validation.By(r.validateOperations)),
+		validation.Field(&r.Decision,
+			validation.Required.Error("решение правила не может быть не указано"),
+			validation.In(models.RiskReview, models.RiskDeny).Error("решение правила может быть review либо deny")),
+		validation.Field(&r.Amount,
+			validation.Min(0).Error("сумма не может быть < 0"),
+			validation.By(required(r.Type == RuleAmount, "для правила amount сумма не может быть не указана"))),
+		validation.Field(&r.Count,
+			validation.Min(0).Error("количество не может быть < 0"),
+			validation.By(required(r.Type == RuleVelocity || r.Type == RuleRecipients, "количество не может быть не указано"))),
+		validation.Field(&r.Window,
+			validation.Min(time.Duration(0)).Error("окно не может быть < 0"),
+			validation.By(required(r.Type == RuleVelocity || r.Type == RuleRecipients, "окно не может быть не указано"))),
+		validation.Field(&r.Age,
+			validation.Min(time.Duration(0)).Error("возраст не может быть < 0"),
+			validation.By(required(r.Type == RuleNewAccount, "для правила newaccount возраст не может быть не указан"))))
+}
+
+// validateOperations проверяет, что правило проверяет только операции из models.RiskOperations,
+// а правило recipients - только переводы
+func (r Rule) validateOperations(value interface{}) error {
+	for _, operation := range r.Operations {
+		switch {
+		case operation != models.OperationTransfer && operation != models.OperationReserve:
+			return errors.New("проверяются только операции transfer и reserve")
+		case r.Type == RuleRecipients && operation != models.OperationTransfer:
+			return errors.New("правило recipients проверяет только переводы")
+		}
+	}
+	return nil
+}
+
+// required возвращает правило, требующее ненулевого значения, если cond истинно
+func required(cond bool, msg string) validation.RuleFunc {
+	return func(value interface{}) error {
+		if !cond {
+			return nil
+		}
+		return validation.Required.Error(msg).Validate(value)
+	}
+}
+
+// applies сообщает, что правило проверяет операцию operation: без списка операций -
+// все проверяемые операции, правило recipients - только переводы
+func (r Rule) applies(operation string) bool {
+	if r.Type == RuleRecipients && operation != models.OperationTransfer {
+		return false
+	}
+	if len(r.Operations) == 0 {
+		return true
+	}
+	for _, o := range r.Operations {
+		if o == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// match проверяет операцию и, если правило сработало, возвращает причину решения
+func (r Rule) match(ctx context.Context, history History, operation *models.RiskOperation, now time.Time) (string, error) {
+	switch r.Type {
+	case RuleAmount:
+		if operation.Amount >= r.Amount {
+			return fmt.Sprintf("%s: сумма %d не меньше %d", r.Name, operation.Amount, r.Amount), nil
+		}
+	case RuleVelocity:
+		count, err := history.GetOperationCount(ctx, operation.UserID, operation.Type, now.Add(-r.Window))
+		if err != nil {
+			return "", err
+		}
+		if count+1 > r.Count {
+			return fmt.Sprintf("%s: %d-я операция %s за %s, допустимо %d", r.Name, count+1, operation.Type, r.Window, r.Count), nil
+		}
+	case RuleNewAccount:
+		if operation.Amount < r.Amount {
+			return "", nil
+		}
+		first, err := history.GetUserFirstLedgerDate(ctx, operation.UserID)
+		if err != nil {
+			return "", err
+		}
+		if first.IsZero() || now.Sub(first) < r.Age {
+			return fmt.Sprintf("%s: пользователь совершает операции меньше %s", r.Name, r.Age), nil
+		}
+	case RuleRecipients:
+		count, err := history.GetRecipientCount(ctx, operation.UserID, operation.ToUserID, now.Add(-r.Window))
+		if err != nil {
+			return "", err
+		}
+		if count+1 > r.Count {
+			return fmt.Sprintf("%s: %d-й получатель переводов за %s, допустимо %d", r.Name, count+1, r.Window, r.Count), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,36 @@
+// Package rulefile хранит правила, загруженные из файла, и перечитывает их без остановки сервера
+package rulefile
+
+import "sync/atomic"
+
+// File - правила T из файла. Правила перечитываются методом Reload, обращения, начатые
+// до этого, заканчиваются с прежними правилами
+type File[T any] struct {
+	path  string
+	load  func(path string) (*T, error)
+	rules atomic.Value
+}
+
+// New загружает правила из файла path функцией load, которая их разбирает и проверяет
+func New[T any](path string, load func(path string) (*T, error)) (*File[T], error) {
+	f := &File[T]{path: path, load: load}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reload перечитывает файл. Если файл содержит ошибку, действуют прежние правила
+func (f *File[T]) Reload() error {
+	rules, err := f.load(f.path)
+	if err != nil {
+		return err
+	}
+	f.rules.Store(rules)
+	return nil
+}
+
+// Rules возвращает действующие правила
+func (f *File[T]) Rules() *T {
+	return f.rules.Load().(*T)
+}
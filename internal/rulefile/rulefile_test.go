@@ -0,0 +1,51 @@
+package rulefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// load читает из файла число, отрицательное считается ошибкой
+func load(path string) (*int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, errors.New("число не может быть < 0")
+	}
+	return &n, nil
+}
+
+func TestFile_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules")
+	require.NoError(t, os.WriteFile(path, []byte("1"), 0600))
+
+	f, err := New(path, load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, *f.Rules())
+
+	require.NoError(t, os.WriteFile(path, []byte("2"), 0600))
+	require.NoError(t, f.Reload())
+	assert.Equal(t, 2, *f.Rules())
+
+	// файл с ошибкой не заменяет действующие правила
+	require.NoError(t, os.WriteFile(path, []byte("-1"), 0600))
+	assert.Error(t, f.Reload())
+	assert.Equal(t, 2, *f.Rules())
+}
+
+func TestNew_Error(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing"), load)
+	assert.Error(t, err)
+}
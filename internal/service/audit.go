@@ -0,0 +1,237 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+	c "userbalance/internal/config"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+
+	"github.com/mailru/easyjson"
+)
+
+// errChainBroken останавливает обход цепочки на первом нарушении
+var errChainBroken = errors.New("цепочка записей нарушена")
+
+type AuditService struct {
+	repo repository.Control
+	conf *c.Config
+}
+
+func NewAuditService(repo repository.Control, conf *c.Config) *AuditService {
+	return &AuditService{
+		repo: repo,
+		conf: conf,
+	}
+}
+
+// Verify проходит цепочки записей logs всех пользователей, сверяет их с подписанными
+// контрольными точками и сообщает о первом найденном нарушении
+func (s *AuditService) Verify(ctx context.Context) (*models.AuditReport, error) {
+	report := &models.AuditReport{CheckedAt: time.Now()}
+
+	checkpoints, err := s.readCheckpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	// записи, зафиксированные контрольными точками, должны существовать и не меняться
+	expected := make(map[int]models.ChainHead)
+	for _, checkpoint := range checkpoints {
+		var valid bool
+		if valid, err = s.verifySignature(checkpoint); err != nil {
+			return nil, err
+		}
+		if !valid {
+			report.Broken = &models.AuditBreak{
+				Reason: fmt.Sprintf("подпись контрольной точки от %s неверна", checkpoint.TakenAt.Format(time.RFC3339)),
+			}
+			return report, nil
+		}
+		for _, head := range checkpoint.Heads {
+			expected[head.LogID] = head
+		}
+	}
+	report.Checkpoints = len(checkpoints)
+
+	var userId int
+	var prevHash string
+
+	err = s.repo.WalkLogs(ctx, func(record models.AuditRecord) error {
+		if record.UserID != userId {
+			userId = record.UserID
+			prevHash = ""
+		}
+		report.Records++
+
+		var reason string
+		switch {
+		case record.PrevHash != prevHash:
+			reason = "ссылка на предыдущую запись нарушена"
+		case record.ComputeHash() != record.Hash:
+			reason = "содержимое записи не совпадает с ее хэшем"
+		}
+		if head, ok := expected[record.ID]; ok {
+			if reason == "" && (head.UserID != record.UserID || head.Hash != record.Hash) {
+				reason = "запись не совпадает с контрольной точкой"
+			}
+			delete(expected, record.ID)
+		}
+
+		if reason != "" {
+			report.Broken = &models.AuditBreak{LogID: record.ID, UserID: record.UserID, Reason: reason}
+			return errChainBroken
+		}
+
+		prevHash = record.Hash
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return nil, err
+	}
+
+	if report.Broken == nil {
+		for _, head := range expected {
+			if report.Broken == nil || head.LogID < report.Broken.LogID {
+				report.Broken = &models.AuditBreak{LogID: head.LogID, UserID: head.UserID, Reason: "запись из контрольной точки отсутствует"}
+			}
+		}
+	}
+
+	report.Valid = report.Broken == nil
+	return report, nil
+}
+
+// Checkpoint подписывает последние записи всех цепочек и дописывает контрольную точку в файл
+func (s *AuditService) Checkpoint(ctx context.Context) (*models.Checkpoint, error) {
+	if s.conf == nil || s.conf.AuditCheckpointFile == "" {
+		return nil, errors.New("не задан файл контрольных точек")
+	}
+
+	heads, err := s.repo.GetChainHeads(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := &models.Checkpoint{
+		TakenAt: time.Now().UTC(),
+		Heads:   heads,
+	}
+	if checkpoint.Signature, err = s.sign(checkpoint); err != nil {
+		return nil, err
+	}
+
+	line, err := easyjson.Marshal(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(s.conf.AuditCheckpointFile), 0700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(s.conf.AuditCheckpointFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+
+	return checkpoint, file.Sync()
+}
+
+// Run сохраняет контрольные точки с заданным интервалом, пока не будет отменен ctx
+func (s *AuditService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if checkpoint, err := s.Checkpoint(ctx); err != nil {
+			logger.Error("ошибка при сохранении контрольной точки", "error", err)
+		} else {
+			logger.Info("контрольная точка сохранена", "chains", len(checkpoint.Heads))
+		}
+	}
+}
+
+func (s *AuditService) readCheckpoints() ([]models.Checkpoint, error) {
+	checkpoints := make([]models.Checkpoint, 0)
+
+	if s.conf == nil || s.conf.AuditCheckpointFile == "" {
+		return checkpoints, nil
+	}
+
+	file, err := os.Open(s.conf.AuditCheckpointFile)
+	if os.IsNotExist(err) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// строка с контрольной точкой растет вместе с числом пользователей, поэтому читается целиком
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var checkpoint models.Checkpoint
+			if err := easyjson.Unmarshal(line, &checkpoint); err != nil {
+				return nil, fmt.Errorf("ошибка чтения контрольной точки: %w", err)
+			}
+			checkpoints = append(checkpoints, checkpoint)
+		}
+		if err == io.EOF {
+			return checkpoints, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *AuditService) sign(checkpoint *models.Checkpoint) (string, error) {
+	if s.conf == nil || s.conf.AuditKey == "" {
+		return "", errors.New("не задан ключ подписи контрольных точек")
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.conf.AuditKey))
+	fmt.Fprintf(mac, "%s\n", checkpoint.TakenAt.UTC().Format(time.RFC3339Nano))
+	for _, head := range checkpoint.Heads {
+		fmt.Fprintf(mac, "%d|%d|%s\n", head.UserID, head.LogID, head.Hash)
+	}
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (s *AuditService) verifySignature(checkpoint models.Checkpoint) (bool, error) {
+	signature, err := s.sign(&checkpoint)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal([]byte(signature), []byte(checkpoint.Signature)), nil
+}
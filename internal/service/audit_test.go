@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	c "userbalance/internal/config"
+	"userbalance/internal/models"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chain связывает записи в цепочки по пользователям так же, как это делает InsertLog
+func chain(records ...models.AuditRecord) []models.AuditRecord {
+	prev := make(map[int]string)
+	for i := range records {
+		records[i].PrevHash = prev[records[i].UserID]
+		records[i].Hash = records[i].ComputeHash()
+		prev[records[i].UserID] = records[i].Hash
+	}
+	return records
+}
+
+func walk(records []models.AuditRecord) func(ctx context.Context, fn func(models.AuditRecord) error) error {
+	return func(ctx context.Context, fn func(models.AuditRecord) error) error {
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestVerify(t *testing.T) {
+	date := time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC)
+	records := func() []models.AuditRecord {
+		return chain(
+			models.AuditRecord{ID: 1, UserID: 1, Date: date, Amount: 100, Description: "Пополнение баланса"},
+			models.AuditRecord{ID: 3, UserID: 1, Date: date, Amount: 50, Description: "Перевод средств пользователю 2",
+				Note: models.Note{Comment: "за обед", Metadata: models.Metadata{"order": "15"}}},
+			models.AuditRecord{ID: 2, UserID: 2, Date: date, Amount: 10, Description: "Пополнение баланса"},
+			models.AuditRecord{ID: 4, UserID: 2, Date: date, Amount: 50, Description: "Перевод средств от пользователя 1",
+				Note: models.Note{Comment: "за обед", Metadata: models.Metadata{"order": "15"}}},
+		)
+	}
+
+	testTable := []struct {
+		name    string
+		records func() []models.AuditRecord
+		want    *models.AuditBreak
+	}{
+		{
+			name:    "OK",
+			records: records,
+		},
+
+		{
+			name: "changed amount",
+			records: func() []models.AuditRecord {
+				r := records()
+				r[1].Amount = 5
+				return r
+			},
+			want: &models.AuditBreak{LogID: 3, UserID: 1, Reason: "содержимое записи не совпадает с ее хэшем"},
+		},
+
+		{
+			name: "changed metadata",
+			records: func() []models.AuditRecord {
+				r := records()
+				r[3].Metadata = models.Metadata{"order": "16"}
+				return r
+			},
+			want: &models.AuditBreak{LogID: 4, UserID: 2, Reason: "содержимое записи не совпадает с ее хэшем"},
+		},
+
+		{
+			name: "deleted record",
+			records: func() []models.AuditRecord {
+				r := records()
+				return append(r[:2], r[3])
+			},
+			want: &models.AuditBreak{LogID: 4, UserID: 2, Reason: "ссылка на предыдущую запись нарушена"},
+		},
+
+		{
+			name: "rehashed record",
+			records: func() []models.AuditRecord {
+				r := records()
+				r[0].Amount = 1000
+				r[0].Hash = r[0].ComputeHash()
+				return r
+			},
+			want: &models.AuditBreak{LogID: 3, UserID: 1, Reason: "ссылка на предыдущую запись нарушена"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			control := mock_repository.NewMockControl(c)
+			control.EXPECT().WalkLogs(gomock.Any(), gomock.Any()).DoAndReturn(walk(testCase.records()))
+
+			s := NewAuditService(control, nil)
+
+			got, err := s.Verify(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.want, got.Broken)
+			assert.Equal(t, testCase.want == nil, got.Valid)
+		})
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	date := time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC)
+	records := chain(
+		models.AuditRecord{ID: 1, UserID: 1, Date: date, Amount: 100, Description: "Пополнение баланса"},
+		models.AuditRecord{ID: 2, UserID: 1, Date: date, Amount: 50, Description: "Перевод средств пользователю 2"},
+	)
+	heads := []models.ChainHead{{UserID: 1, LogID: 2, Hash: records[1].Hash}}
+
+	conf := &c.Config{
+		AuditKey:            "secret",
+		AuditCheckpointFile: filepath.Join(t.TempDir(), "audit", "checkpoints.jsonl"),
+	}
+
+	gc := gomock.NewController(t)
+	defer gc.Finish()
+
+	control := mock_repository.NewMockControl(gc)
+	control.EXPECT().GetChainHeads(gomock.Any()).Return(heads, nil)
+
+	s := NewAuditService(control, conf)
+
+	checkpoint, err := s.Checkpoint(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, heads, checkpoint.Heads)
+	assert.NotEmpty(t, checkpoint.Signature)
+
+	t.Run("OK", func(t *testing.T) {
+		control.EXPECT().WalkLogs(gomock.Any(), gomock.Any()).DoAndReturn(walk(records))
+
+		got, err := s.Verify(context.Background())
+
+		assert.NoError(t, err)
+		assert.True(t, got.Valid)
+		assert.Equal(t, 1, got.Checkpoints)
+		assert.Equal(t, 2, got.Records)
+	})
+
+	t.Run("truncated chain", func(t *testing.T) {
+		control.EXPECT().WalkLogs(gomock.Any(), gomock.Any()).DoAndReturn(walk(records[:1]))
+
+		got, err := s.Verify(context.Background())
+
+		assert.NoError(t, err)
+		assert.False(t, got.Valid)
+		assert.Equal(t, &models.AuditBreak{LogID: 2, UserID: 1, Reason: "запись из контрольной точки отсутствует"}, got.Broken)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		s := NewAuditService(control, &c.Config{AuditKey: "other", AuditCheckpointFile: conf.AuditCheckpointFile})
+
+		got, err := s.Verify(context.Background())
+
+		assert.NoError(t, err)
+		assert.False(t, got.Valid)
+		assert.Contains(t, got.Broken.Reason, "подпись контрольной точки")
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		_, err := NewAuditService(control, &c.Config{AuditCheckpointFile: conf.AuditCheckpointFile}).Verify(context.Background())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		_, err := os.Stat(conf.AuditCheckpointFile)
+		require.NoError(t, err)
+
+		_, err = NewAuditService(control, &c.Config{AuditKey: "secret"}).Checkpoint(context.Background())
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	c "userbalance/internal/config"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrUnauthenticated = errors.New("требуется аутентификация")
+	ErrAPIKeyNotFound  = errors.New("ключ API не найден или уже отозван")
+)
+
+// apiKeyPrefix отличает ключи сервиса от других секретов в конфигурации клиентов и в логах
+const apiKeyPrefix string = "ubk_"
+
+// tokenClaims - утверждения JWT: права передаются строкой через пробел, как в OAuth 2.0
+type tokenClaims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+type AuthService struct {
+	repo repository.Control
+	conf c.Source
+}
+
+func NewAuthService(repo repository.Control, conf c.Source) *AuthService {
+	return &AuthService{
+		repo: repo,
+		conf: conf,
+	}
+}
+
+// Required сообщает, что запросы к API должны быть аутентифицированы
+func (s *AuthService) Required() bool {
+	return s.conf.Get().AuthRequired
+}
+
+// CreateAPIKey создает ключ клиента с правами key.Scopes, а если key.Signing - и секрет подписи запросов.
+// Значение ключа возвращается только здесь, в БД сохраняется его хэш
+func (s *AuthService) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.IssuedAPIKey, error) {
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	value, err := randomHex()
+	if err != nil {
+		return nil, err
+	}
+	value = apiKeyPrefix + value
+
+	key.SigningSecret = ""
+	if key.Signing {
+		if key.SigningSecret, err = randomHex(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = s.repo.InsertAPIKey(ctx, key, hashAPIKey(value)); err != nil {
+		return nil, err
+	}
+
+	return &models.IssuedAPIKey{APIKey: *key, Key: value, SigningSecret: key.SigningSecret}, nil
+}
+
+// RotateSigningSecret выдает клиенту новый секрет подписи запросов, прежний перестает действовать сразу
+func (s *AuthService) RotateSigningSecret(ctx context.Context, id int) (string, error) {
+	secret, err := randomHex()
+	if err != nil {
+		return "", err
+	}
+
+	affected, err := s.repo.SetAPIKeySigningSecret(ctx, id, secret)
+	if err != nil {
+		return "", err
+	}
+	if affected == 0 {
+		return "", ErrAPIKeyNotFound
+	}
+	return secret, nil
+}
+
+func (s *AuthService) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.repo.GetAPIKeys(ctx)
+}
+
+func (s *AuthService) RevokeAPIKey(ctx context.Context, id int) error {
+	affected, err := s.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey находит действующий ключ клиента по его хэшу
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, value string) (*models.Principal, error) {
+	if !strings.HasPrefix(value, apiKeyPrefix) {
+		return nil, fmt.Errorf("%w: неверный формат ключа API", ErrUnauthenticated)
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(value))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, ErrAPIKeyNotFound)
+	}
+
+	return &models.Principal{
+		Client:        key.Name,
+		Method:        models.AuthMethodAPIKey,
+		Scopes:        key.Scopes,
+		SigningSecret: key.SigningSecret,
+	}, nil
+}
+
+// AuthenticateCertificate сопоставляет клиента, предъявившего проверенный сертификат, ключу API
+// с именем, равным CN сертификата: права и секрет подписи берутся из ключа, а отзыв ключа
+// запрещает и доступ по сертификату
+func (s *AuthService) AuthenticateCertificate(ctx context.Context, commonName string) (*models.Principal, error) {
+	if commonName == "" {
+		return nil, fmt.Errorf("%w: в сертификате клиента не указан CN", ErrUnauthenticated)
+	}
+
+	key, err := s.repo.GetAPIKeyByName(ctx, commonName)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%w: для клиента %s нет действующего ключа API", ErrUnauthenticated, commonName)
+	}
+
+	return &models.Principal{
+		Client:        key.Name,
+		Method:        models.AuthMethodCertificate,
+		Scopes:        key.Scopes,
+		SigningSecret: key.SigningSecret,
+	}, nil
+}
+
+// AuthenticateToken проверяет подпись и срок действия JWT, выпущенного с общим секретом jwtsecret,
+// а также издателя и получателя, если они заданы в конфигурации
+func (s *AuthService) AuthenticateToken(ctx context.Context, token string) (*models.Principal, error) {
+	conf := s.conf.Get()
+	if conf.JWTSecret == "" {
+		return nil, fmt.Errorf("%w: аутентификация по JWT не настроена", ErrUnauthenticated)
+	}
+
+	var claims tokenClaims
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}))
+	_, err := parser.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(conf.JWTSecret), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	switch {
+	case !claims.VerifyExpiresAt(time.Now(), true):
+		return nil, fmt.Errorf("%w: у токена не указан срок действия", ErrUnauthenticated)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: в токене не указан клиент", ErrUnauthenticated)
+	case conf.JWTIssuer != "" && !claims.VerifyIssuer(conf.JWTIssuer, true):
+		return nil, fmt.Errorf("%w: токен выпущен другим издателем", ErrUnauthenticated)
+	case conf.JWTAudience != "" && !claims.VerifyAudience(conf.JWTAudience, true):
+		return nil, fmt.Errorf("%w: токен выпущен для другого получателя", ErrUnauthenticated)
+	}
+
+	return &models.Principal{Client: claims.Subject, Method: models.AuthMethodJWT, Scopes: strings.Fields(claims.Scope)}, nil
+}
+
+// randomHex возвращает 32 случайных байта в hex: значение ключа либо секрет подписи
+func randomHex() (string, error) {
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(value), nil
+}
+
+// hashAPIKey - ключи случайные и длинные, поэтому для хранения достаточно SHA-256 без соли
+func hashAPIKey(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"userbalance/internal/config"
+	"userbalance/internal/models"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_CreateAPIKey(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	s := NewAuthService(repo, &config.Config{})
+
+	var storedHash string
+	repo.EXPECT().InsertAPIKey(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, key *models.APIKey, hash string) error {
+			storedHash = hash
+			key.ID = 1
+			return nil
+		})
+
+	issued, err := s.CreateAPIKey(context.Background(), &models.APIKey{Name: "billing", Scopes: []string{models.ScopeBalanceRead}, Signing: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, issued.ID)
+	assert.True(t, strings.HasPrefix(issued.Key, apiKeyPrefix))
+	assert.Equal(t, hashAPIKey(issued.Key), storedHash)
+	assert.Len(t, issued.SigningSecret, 64)
+
+	_, err = s.CreateAPIKey(context.Background(), &models.APIKey{Name: "billing", Scopes: []string{"balance:write"}})
+	assert.Error(t, err)
+}
+
+func TestAuthService_AuthenticateAPIKey(t *testing.T) {
+	const key = apiKeyPrefix + "0123456789abcdef"
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		key          string
+		mockBehavior mockBehavior
+		want         *models.Principal
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			key:  key,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetAPIKeyByHash(gomock.Any(), hashAPIKey(key)).Return(
+					&models.APIKey{ID: 1, Name: "billing", Scopes: []string{models.ScopeBalanceRead}}, nil)
+			},
+			want: &models.Principal{Client: "billing", Method: models.AuthMethodAPIKey, Scopes: []string{models.ScopeBalanceRead}},
+		},
+
+		{
+			name: "error revoked",
+			key:  key,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetAPIKeyByHash(gomock.Any(), hashAPIKey(key)).Return(nil, nil)
+			},
+			wantErr: ErrUnauthenticated,
+		},
+
+		{
+			name:         "error wrong format",
+			key:          "0123456789abcdef",
+			mockBehavior: func(r *mock_repository.MockControl) {},
+			wantErr:      ErrUnauthenticated,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			got, err := NewAuthService(repo, &config.Config{}).AuthenticateAPIKey(context.Background(), testCase.key)
+			if testCase.wantErr != nil {
+				assert.True(t, errors.Is(err, testCase.wantErr))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestAuthService_AuthenticateCertificate(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		commonName   string
+		mockBehavior mockBehavior
+		want         *models.Principal
+		wantErr      error
+	}{
+		{
+			name:       "OK",
+			commonName: "billing",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetAPIKeyByName(gomock.Any(), "billing").Return(
+					&models.APIKey{ID: 1, Name: "billing", Scopes: []string{models.ScopeBalanceTopup}, SigningSecret: "secret"}, nil)
+			},
+			want: &models.Principal{
+				Client:        "billing",
+				Method:        models.AuthMethodCertificate,
+				Scopes:        []string{models.ScopeBalanceTopup},
+				SigningSecret: "secret",
+			},
+		},
+
+		{
+			name:       "error no key",
+			commonName: "billing",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetAPIKeyByName(gomock.Any(), "billing").Return(nil, nil)
+			},
+			wantErr: ErrUnauthenticated,
+		},
+
+		{
+			name:         "error empty common name",
+			mockBehavior: func(r *mock_repository.MockControl) {},
+			wantErr:      ErrUnauthenticated,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			got, err := NewAuthService(repo, &config.Config{}).AuthenticateCertificate(context.Background(), testCase.commonName)
+			if testCase.wantErr != nil {
+				assert.True(t, errors.Is(err, testCase.wantErr))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestAuthService_AuthenticateToken(t *testing.T) {
+	const secret = "jwt-secret"
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims tokenClaims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+	valid := func() tokenClaims {
+		return tokenClaims{
+			Scope: "balance:read balance:topup",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "billing",
+				Issuer:    "auth.example.com",
+				Audience:  jwt.ClaimStrings{"userbalance"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+	}
+
+	conf := &config.Config{JWTSecret: secret, JWTIssuer: "auth.example.com", JWTAudience: "userbalance"}
+
+	testTable := []struct {
+		name    string
+		conf    *config.Config
+		token   func() string
+		want    *models.Principal
+		wantErr bool
+	}{
+		{
+			name:  "OK",
+			conf:  conf,
+			token: func() string { return sign(jwt.SigningMethodHS256, []byte(secret), valid()) },
+			want: &models.Principal{
+				Client: "billing",
+				Method: models.AuthMethodJWT,
+				Scopes: []string{models.ScopeBalanceRead, models.ScopeBalanceTopup},
+			},
+		},
+
+		{
+			name: "error expired",
+			conf: conf,
+			token: func() string {
+				claims := valid()
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return sign(jwt.SigningMethodHS256, []byte(secret), claims)
+			},
+			wantErr: true,
+		},
+
+		{
+			name: "error without expiration",
+			conf: conf,
+			token: func() string {
+				claims := valid()
+				claims.ExpiresAt = nil
+				return sign(jwt.SigningMethodHS256, []byte(secret), claims)
+			},
+			wantErr: true,
+		},
+
+		{
+			name:    "error wrong secret",
+			conf:    conf,
+			token:   func() string { return sign(jwt.SigningMethodHS256, []byte("other"), valid()) },
+			wantErr: true,
+		},
+
+		{
+			name:    "error none algorithm",
+			conf:    conf,
+			token:   func() string { return sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid()) },
+			wantErr: true,
+		},
+
+		{
+			name: "error wrong audience",
+			conf: conf,
+			token: func() string {
+				claims := valid()
+				claims.Audience = jwt.ClaimStrings{"other"}
+				return sign(jwt.SigningMethodHS256, []byte(secret), claims)
+			},
+			wantErr: true,
+		},
+
+		{
+			name:    "error jwt disabled",
+			conf:    &config.Config{},
+			token:   func() string { return sign(jwt.SigningMethodHS256, []byte(secret), valid()) },
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := NewAuthService(nil, testCase.conf).AuthenticateToken(context.Background(), testCase.token())
+			if testCase.wantErr {
+				assert.True(t, errors.Is(err, ErrUnauthenticated))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestAuthService_RevokeAPIKey(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	repo.EXPECT().RevokeAPIKey(gomock.Any(), 1).Return(int64(1), nil)
+	repo.EXPECT().RevokeAPIKey(gomock.Any(), 2).Return(int64(0), nil)
+
+	s := NewAuthService(repo, &config.Config{})
+	assert.NoError(t, s.RevokeAPIKey(context.Background(), 1))
+	assert.True(t, errors.Is(s.RevokeAPIKey(context.Background(), 2), ErrAPIKeyNotFound))
+}
+
+func TestAuthService_RotateSigningSecret(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+
+	var stored string
+	repo.EXPECT().SetAPIKeySigningSecret(gomock.Any(), 1, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, id int, secret string) (int64, error) {
+			stored = secret
+			return 1, nil
+		})
+	repo.EXPECT().SetAPIKeySigningSecret(gomock.Any(), 2, gomock.Any()).Return(int64(0), nil)
+
+	s := NewAuthService(repo, &config.Config{})
+
+	secret, err := s.RotateSigningSecret(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, secret, 64)
+	assert.Equal(t, stored, secret)
+
+	_, err = s.RotateSigningSecret(context.Background(), 2)
+	assert.True(t, errors.Is(err, ErrAPIKeyNotFound))
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+)
+
+// ErrBatchInterrupted - пакет остановлен таймаутом или сбоем БД, а не ошибкой в операции.
+// Вместе с ней возвращаются результаты пакета: в независимом режиме операции до сбоя выполнены
+var ErrBatchInterrupted = errors.New("выполнение пакета прервано")
+
+// Batch выполняет операции пакета. В независимом режиме каждая операция выполняется в своей
+// транзакции со своим таймаутом dbtimeout, в атомарном - весь пакет в одной транзакции
+// с таймаутом batchtimeout
+func (c *ControlService) Batch(ctx context.Context, requestBatch *models.RequestBatch) (*models.BatchResults, error) {
+	results := &models.BatchResults{
+		Mode:   requestBatch.Mode,
+		Entity: make([]models.BatchResult, len(requestBatch.Operations)),
+	}
+	for i, operation := range requestBatch.Operations {
+		results.Entity[i] = models.BatchResult{
+			Index:  i,
+			Type:   operation.Type,
+			Status: models.BatchStatusOK,
+		}
+	}
+
+	if requestBatch.Mode == models.BatchModeIndependent {
+		for i, operation := range requestBatch.Operations {
+			err := operation.Validate()
+			if err == nil {
+				if err = c.execute(ctx, operation); err != nil && !domainError(err) {
+					// следующие операции, скорее всего, завершатся той же ошибкой
+					for j := i + 1; j < len(results.Entity); j++ {
+						results.Entity[j].Status = models.BatchStatusSkipped
+					}
+					results.Entity[i].Status = models.BatchStatusError
+					results.Entity[i].Message = err.Error()
+					return results, fmt.Errorf("%w: %v", ErrBatchInterrupted, err)
+				}
+			}
+			if err != nil {
+				results.Entity[i].Status = models.BatchStatusError
+				results.Entity[i].Message = err.Error()
+				continue
+			}
+			results.Committed = true
+		}
+		return results, nil
+	}
+
+	ctx, cancel := c.withBatchTimeout(ctx)
+	defer cancel()
+
+	for i, operation := range requestBatch.Operations {
+		if err := operation.Validate(); err != nil {
+			failBatch(results, i, err)
+			return results, nil
+		}
+	}
+
+	services := make(map[int]string)
+	for i, operation := range requestBatch.Operations {
+		if operation.Type != models.OperationReserve && operation.Type != models.OperationCancel {
+			continue
+		}
+		if _, ok := services[operation.ServiceID]; ok {
+			continue
+		}
+		service, err := c.getService(ctx, operation.ServiceID)
+		if err != nil {
+			failBatch(results, i, err)
+			observe(ctx, operation.Type, operation.Amount, err)
+			return results, interrupted(err)
+		}
+		services[operation.ServiceID] = service
+	}
+
+	userIds := make([]int, 0, len(requestBatch.Operations)+1)
+	for _, operation := range requestBatch.Operations {
+		userIds = append(userIds, operation.UserIDs()...)
+	}
+	// счет комиссий блокируется вместе с пользователями пакета, а не при первом зачислении комиссии
+	if feeUserId := c.feeUserID(); feeUserId != 0 {
+		userIds = append(userIds, feeUserId)
+	}
+
+	failed := -1
+	err := c.withinTx(ctx, func(repo repository.Control) error {
+		failed = -1
+		if err := c.lockUsersTx(ctx, repo, userIds...); err != nil {
+			return err
+		}
+		for i, operation := range requestBatch.Operations {
+			if err := c.executeTx(ctx, repo, operation, services[operation.ServiceID]); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if failed >= 0 {
+		failBatch(results, failed, err)
+		observe(ctx, requestBatch.Operations[failed].Type, requestBatch.Operations[failed].Amount, err)
+		return results, interrupted(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	results.Committed = true
+
+	for _, operation := range requestBatch.Operations {
+		observe(ctx, operation.Type, operation.Amount, nil)
+	}
+
+	return results, nil
+}
+
+// execute выполняет проверенную операцию пакета в собственной транзакции
+func (c *ControlService) execute(ctx context.Context, operation models.BatchOperation) error {
+	switch operation.Type {
+	case models.OperationTopup:
+		return c.ReplenishmentBalance(ctx, operation.Replenishment())
+	case models.OperationTransfer:
+		return c.Transfer(ctx, operation.Money())
+	case models.OperationReserve:
+		return c.Reservation(ctx, operation.Transaction())
+	case models.OperationConfirm:
+		return c.Confirmation(ctx, operation.Transaction())
+	case models.OperationCancel:
+		return c.CancelReservation(ctx, operation.Transaction())
+	}
+	return errors.New("неизвестный тип операции")
+}
+
+// executeTx выполняет операцию пакета в рамках общей транзакции
+func (c *ControlService) executeTx(ctx context.Context, repo repository.Control, operation models.BatchOperation, service string) error {
+	switch operation.Type {
+	case models.OperationTopup:
+		return c.replenishmentBalanceTx(ctx, repo, operation.Replenishment())
+	case models.OperationTransfer:
+		return c.transferTx(ctx, repo, operation.Money())
+	case models.OperationReserve:
+		return c.reservationTx(ctx, repo, operation.Transaction(), service)
+	case models.OperationConfirm:
+		return c.confirmationTx(ctx, repo, operation.Transaction())
+	case models.OperationCancel:
+		return c.cancelReservationTx(ctx, repo, operation.Transaction(), service)
+	}
+	return errors.New("неизвестный тип операции")
+}
+
+// lockUsersTx блокирует строки пользователей в порядке возрастания id,
+// чтобы параллельные транзакции не могли взаимно заблокировать друг друга
+func (c *ControlService) lockUsersTx(ctx context.Context, repo repository.Control, userIds ...int) error {
+	for _, id := range ascending(userIds...) {
+		if _, err := repo.GetUserForUpdate(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// domainError сообщает, что операция отклонена из-за ее данных или состояния счетов, а не из-за
+// таймаута, отмены запроса или сбоя БД (в том числе исчерпанных повторов транзакции)
+func domainError(err error) bool {
+	switch errorType(err) {
+	case "timeout", "canceled", "internal":
+		return false
+	}
+	return true
+}
+
+// interrupted возвращает ErrBatchInterrupted, если операция пакета не выполнена не по своей вине
+func interrupted(err error) error {
+	if domainError(err) {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrBatchInterrupted, err)
+}
+
+func failBatch(results *models.BatchResults, index int, err error) {
+	for i := range results.Entity {
+		results.Entity[i].Status = models.BatchStatusSkipped
+	}
+	results.Entity[index].Status = models.BatchStatusError
+	results.Entity[index].Message = err.Error()
+}
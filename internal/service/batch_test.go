@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+	date := time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC)
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		requestBatch *models.RequestBatch
+		want         *models.BatchResults
+		wantErr      bool
+		// пакет остановлен сбоем, результаты возвращаются вместе с ErrBatchInterrupted
+		wantInterrupted bool
+	}{
+		{
+			name: "OK atomic",
+			requestBatch: &models.RequestBatch{
+				Mode: models.BatchModeAtomic,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 3, Amount: 100, Date: "2022-10-01"},
+					{Type: models.OperationTransfer, FromUserID: 3, ToUserID: 1, Amount: 50, Date: "2022-10-01"},
+				},
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				gomock.InOrder(
+					r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 10}, nil),
+					r.EXPECT().GetUserForUpdate(gomock.Any(), 3).Return(&models.User{Id: 3, Balance: 0}, nil),
+					r.EXPECT().GetUserForUpdate(gomock.Any(), 3).Return(&models.User{Id: 3, Balance: 0}, nil),
+					r.EXPECT().UpdateBalance(gomock.Any(), 3, 100).Return(nil),
+					r.EXPECT().InsertLog(gomock.Any(), 3, date, 100, "Пополнение баланса", models.Note{}).Return(nil),
+					r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(3, models.AccountMain, 100)).Return(nil),
+					r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 10}, nil),
+					r.EXPECT().GetUserForUpdate(gomock.Any(), 3).Return(&models.User{Id: 3, Balance: 100}, nil),
+					r.EXPECT().UpdateBalance(gomock.Any(), 3, 50).Return(nil),
+					r.EXPECT().InsertLog(gomock.Any(), 3, date, 50, fmt.Sprintf("Перевод средств пользователю %d", 1), models.Note{}).Return(nil),
+					r.EXPECT().UpdateBalance(gomock.Any(), 1, 60).Return(nil),
+					r.EXPECT().InsertLog(gomock.Any(), 1, date, 50, fmt.Sprintf("Перевод средств от пользователя %d", 3), models.Note{}).Return(nil),
+					r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(3, models.AccountMain, -50)).Return(nil),
+					r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, 50)).Return(nil),
+				)
+			},
+			want: &models.BatchResults{
+				Mode:      models.BatchModeAtomic,
+				Committed: true,
+				Entity: []models.BatchResult{
+					{Index: 0, Type: models.OperationTopup, Status: models.BatchStatusOK},
+					{Index: 1, Type: models.OperationTransfer, Status: models.BatchStatusOK},
+				},
+			},
+		},
+
+		{
+			name: "atomic rollback on insufficient funds",
+			requestBatch: &models.RequestBatch{
+				Mode: models.BatchModeAtomic,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 1, Amount: 100, Date: "2022-10-01"},
+					{Type: models.OperationTransfer, FromUserID: 2, ToUserID: 1, Amount: 50, Date: "2022-10-01"},
+				},
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 0}, nil).Times(3)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil).Times(2)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 100).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, date, 100, "Пополнение баланса", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, 100)).Return(nil)
+			},
+			want: &models.BatchResults{
+				Mode: models.BatchModeAtomic,
+				Entity: []models.BatchResult{
+					{Index: 0, Type: models.OperationTopup, Status: models.BatchStatusSkipped},
+					{Index: 1, Type: models.OperationTransfer, Status: models.BatchStatusError, Message: "недостаточно средств"},
+				},
+			},
+		},
+
+		{
+			name: "atomic invalid operation",
+			requestBatch: &models.RequestBatch{
+				Mode: models.BatchModeAtomic,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 1, Amount: 100},
+					{Type: "withdraw", UserID: 1, Amount: 100},
+				},
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {},
+			want: &models.BatchResults{
+				Mode: models.BatchModeAtomic,
+				Entity: []models.BatchResult{
+					{Index: 0, Type: models.OperationTopup, Status: models.BatchStatusSkipped},
+					{Index: 1, Type: "withdraw", Status: models.BatchStatusError, Message: "неизвестный тип операции"},
+				},
+			},
+		},
+
+		{
+			name: "atomic error lock",
+			requestBatch: &models.RequestBatch{
+				Mode: models.BatchModeAtomic,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 1, Amount: 100},
+				},
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(nil, errors.New("db error"))
+			},
+			wantErr: true,
+		},
+
+		{
+			name: "atomic interrupted by db error",
+			requestBatch: &models.RequestBatch{
+				Mode: models.BatchModeAtomic,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 1, Amount: 100},
+					{Type: models.OperationTopup, UserID: 1, Amount: 50},
+				},
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				gomock.InOrder(
+					r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 0}, nil),
+					r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(nil, errors.New("db error")),
+				)
+			},
+			want: &models.BatchResults{
+				Mode: models.BatchModeAtomic,
+				Entity: []models.BatchResult{
+					{Index: 0, Type: models.OperationTopup, Status: models.BatchStatusError, Message: "db error"},
+					{Index: 1, Type: models.OperationTopup, Status: models.BatchStatusSkipped},
+				},
+			},
+			wantInterrupted: true,
+		},
+
+		{
+			name: "OK independent",
+			requestBatch: &models.RequestBatch{
+				Mode: models.BatchModeIndependent,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 1, Amount: 100, Date: "2022-10-01"},
+					{Type: models.OperationTransfer, FromUserID: 2, ToUserID: 1, Amount: 50, Date: "2022-10-01"},
+				},
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 0}, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 100).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, date, 100, "Пополнение баланса", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, 100)).Return(nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 100}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(nil, nil)
+			},
+			want: &models.BatchResults{
+				Mode:      models.BatchModeIndependent,
+				Committed: true,
+				Entity: []models.BatchResult{
+					{Index: 0, Type: models.OperationTopup, Status: models.BatchStatusOK},
+					{Index: 1, Type: models.OperationTransfer, Status: models.BatchStatusError, Message: "пользователь не найден"},
+				},
+			},
+		},
+
+		{
+			name: "independent interrupted by db error",
+			requestBatch: &models.RequestBatch{
+				Mode: models.BatchModeIndependent,
+				Operations: []models.BatchOperation{
+					{Type: models.OperationTopup, UserID: 1, Amount: 100, Date: "2022-10-01"},
+					{Type: "withdraw", UserID: 1, Amount: 100},
+					{Type: models.OperationTopup, UserID: 2, Amount: 100, Date: "2022-10-01"},
+					{Type: models.OperationTopup, UserID: 3, Amount: 100, Date: "2022-10-01"},
+				},
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 0}, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 100).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, date, 100, "Пополнение баланса", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, 100)).Return(nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(nil, errors.New("db error"))
+			},
+			want: &models.BatchResults{
+				Mode:      models.BatchModeIndependent,
+				Committed: true,
+				Entity: []models.BatchResult{
+					{Index: 0, Type: models.OperationTopup, Status: models.BatchStatusOK},
+					{Index: 1, Type: "withdraw", Status: models.BatchStatusError, Message: "неизвестный тип операции"},
+					{Index: 2, Type: models.OperationTopup, Status: models.BatchStatusError, Message: "db error"},
+					{Index: 3, Type: models.OperationTopup, Status: models.BatchStatusSkipped},
+				},
+			},
+			wantInterrupted: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			control := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(control)
+
+			repository := &repository.Repository{Control: control}
+			s := NewControlService(repository, unitOfWork{repository}, nil, nil, nil)
+
+			got, err := s.Batch(context.Background(), testCase.requestBatch)
+
+			switch {
+			case testCase.wantErr:
+				assert.Error(t, err)
+			case testCase.wantInterrupted:
+				assert.ErrorIs(t, err, ErrBatchInterrupted)
+				assert.Equal(t, testCase.want, got)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
@@ -1,48 +1,122 @@
 package service
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 	c "userbalance/internal/config"
+	"userbalance/internal/logger"
+	"userbalance/internal/metrics"
 	"userbalance/internal/models"
 	"userbalance/internal/repository"
 )
 
 const layout string = "2006-01-02"
 
+// ошибки предметной области, по ним считаются метрики ошибок
+var (
+	ErrUserNotFound      = errors.New("пользователь не найден")
+	ErrInsufficientFunds = errors.New("недостаточно средств")
+	ErrServiceNotFound   = errors.New("услуга не найдена")
+	ErrReserveNotFound   = errors.New("по указанным критериям не было резерва")
+	ErrRecordsNotFound   = errors.New("записи не найдены")
+)
+
 type ControlService struct {
-	repo repository.Control
-	conf *c.Config
-	db   *sql.DB
+	repo    repository.Control
+	uow     repository.UnitOfWork
+	conf    c.Source
+	checker RiskChecker
+	fees    FeeCalculator
 }
 
-func NewControlService(repo repository.Control, conf *c.Config, db *sql.DB) *ControlService {
+// NewControlService создает сервис операций, conf читается при каждом обращении,
+// поэтому перечитанная во время работы конфигурация применяется к следующим запросам.
+// Если checker не nil, переводы и резервирования перед выполнением проверяются им,
+// если fees не nil - за них взимается комиссия
+func NewControlService(repo repository.Control, uow repository.UnitOfWork, conf c.Source, checker RiskChecker, fees FeeCalculator) *ControlService {
 	return &ControlService{
-		repo: repo,
-		conf: conf,
-		db:   db,
+		repo:    repo,
+		uow:     uow,
+		conf:    conf,
+		checker: checker,
+		fees:    fees,
 	}
 }
 
-func (c *ControlService) GetBalance(userId int) (*models.User, error) {
+func (c *ControlService) GetBalance(ctx context.Context, userId int) (*models.User, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	var user *models.User
 	var err error
 
-	if user, err = c.repo.GetUser(userId); err != nil {
+	if user, err = c.repo.GetUser(ctx, userId); err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("пользователь не найден")
+		return nil, ErrUserNotFound
 	}
 	return user, err
 }
 
-func (c *ControlService) ReplenishmentBalance(replenishment *models.Replenishment) error {
-	var tx *sql.Tx
+func (c *ControlService) GetBalanceAt(ctx context.Context, userId int, at time.Time) (*models.BalanceAt, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	var user *models.User
+	var snapshot *models.Snapshot
+	var sums map[string]int
+	var from time.Time
+	var err error
+
+	if user, err = c.repo.GetUser(ctx, userId); err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	balance := &models.BalanceAt{
+		UserID: userId,
+		At:     at,
+	}
+
+	// остаток берется из последнего снимка до at и дополняется записями журнала после него
+	if snapshot, err = c.repo.GetLastSnapshot(ctx, userId, at); err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		from = snapshot.TakenAt
+		balance.Balance = snapshot.Balance
+		balance.Reserve = snapshot.Reserve
+	}
+
+	if sums, err = c.repo.GetLedgerSum(ctx, userId, from, at); err != nil {
+		return nil, err
+	}
+	balance.Balance += sums[models.AccountMain]
+	balance.Reserve += sums[models.AccountReserve]
+
+	return balance, err
+}
+
+func (c *ControlService) ReplenishmentBalance(ctx context.Context, replenishment *models.Replenishment) (err error) {
+	defer func() { observe(ctx, models.OperationTopup, replenishment.Amount, err) }()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	return c.uow.WithinTx(ctx, func(repo repository.Control) error {
+		return c.replenishmentBalanceTx(ctx, repo, replenishment)
+	})
+}
+
+func (c *ControlService) replenishmentBalanceTx(ctx context.Context, repo repository.Control, replenishment *models.Replenishment) error {
 	var err error
 	var user *models.User
 
@@ -51,46 +125,46 @@ func (c *ControlService) ReplenishmentBalance(replenishment *models.Replenishmen
 		date = time.Now()
 	}
 
-	tx, err = c.db.Begin()
-	if err != nil {
+	if user, err = repo.GetUserForUpdate(ctx, replenishment.UserID); err != nil {
 		return err
 	}
-
-	user, err = c.repo.GetUserForUpdate(tx, replenishment.UserID)
-	if err != nil {
-		tx.Rollback()
+	if err = c.checkLimitTx(ctx, repo, replenishment.UserID, models.OperationTopup, replenishment.Amount); err != nil {
 		return err
 	}
 
 	if user != nil {
-		if err = c.repo.UpdateBalanceTx(tx, replenishment.UserID, user.Balance+replenishment.Amount); err != nil {
-			tx.Rollback()
-			return err
-		}
-		if err = c.repo.InsertLogTx(tx, replenishment.UserID, date, replenishment.Amount, "Пополнение баланса"); err != nil {
-			tx.Rollback()
+		if err = repo.UpdateBalance(ctx, replenishment.UserID, user.Balance+replenishment.Amount); err != nil {
 			return err
 		}
 	} else {
-		if err = c.repo.InsertUserTx(tx, replenishment.UserID, replenishment.Amount); err != nil {
-			tx.Rollback()
-			return err
-		}
-		if err = c.repo.InsertMoneyReserveAccountsTx(tx, replenishment.UserID); err != nil {
-			tx.Rollback()
+		if err = repo.InsertUser(ctx, replenishment.UserID, replenishment.Amount); err != nil {
 			return err
 		}
-		if err = c.repo.InsertLogTx(tx, replenishment.UserID, date, replenishment.Amount, "Пополнение баланса"); err != nil {
-			tx.Rollback()
+		if err = repo.InsertMoneyReserveAccounts(ctx, replenishment.UserID); err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	if err = repo.InsertLog(ctx, replenishment.UserID, date, replenishment.Amount, "Пополнение баланса", replenishment.Note); err != nil {
+		return err
+	}
+
+	return journalTx(ctx, repo, models.OperationTopup,
+		models.LedgerEntry{UserID: replenishment.UserID, Account: models.AccountMain, Amount: replenishment.Amount, Description: "Пополнение баланса"})
 }
 
-func (c *ControlService) Transfer(money *models.Money) error {
-	var tx *sql.Tx
+func (c *ControlService) Transfer(ctx context.Context, money *models.Money) (err error) {
+	defer func() { observe(ctx, models.OperationTransfer, money.Amount, err) }()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	return c.withinTx(ctx, func(repo repository.Control) error {
+		return c.transferTx(ctx, repo, money)
+	})
+}
+
+func (c *ControlService) transferTx(ctx context.Context, repo repository.Control, money *models.Money) error {
 	var err error
 	var fromUser, toUser *models.User
 
@@ -99,58 +173,75 @@ func (c *ControlService) Transfer(money *models.Money) error {
 		date = time.Now()
 	}
 
-	tx, err = c.db.Begin()
+	quote := c.quoteFee(money.FromUserID, &models.FeeRequest{Operation: models.OperationTransfer, Amount: money.Amount})
+
+	// строки блокируются в порядке возрастания id независимо от направления перевода,
+	// иначе встречные переводы между одной парой пользователей взаимно блокируют друг друга
+	users, err := c.lockTx(ctx, repo, quote, money.FromUserID, money.ToUserID)
 	if err != nil {
 		return err
 	}
+	fromUser, toUser = users[money.FromUserID], users[money.ToUserID]
 
-	if fromUser, err = c.repo.GetUserForUpdate(tx, money.FromUserID); err != nil {
-		tx.Rollback()
-		return err
+	if fromUser.Balance-quote.Total < 0 {
+		return ErrInsufficientFunds
 	}
-	if fromUser == nil {
-		tx.Rollback()
-		return errors.New("пользователь не найден")
-	}
-	if toUser, err = c.repo.GetUserForUpdate(tx, money.ToUserID); err != nil {
-		tx.Rollback()
+	if err = c.checkLimitTx(ctx, repo, money.FromUserID, models.OperationTransfer, money.Amount); err != nil {
 		return err
 	}
-	if toUser == nil {
-		tx.Rollback()
-		return errors.New("пользователь не найден")
+	if err = c.screenTx(ctx, repo, &models.RiskOperation{
+		Type:     models.OperationTransfer,
+		UserID:   money.FromUserID,
+		ToUserID: money.ToUserID,
+		Amount:   money.Amount,
+		Date:     money.Date,
+		Note:     money.Note,
+	}); err != nil {
+		return err
 	}
 
-	if fromUser.Balance-money.Amount < 0 {
-		tx.Rollback()
-		return errors.New("недостаточно средств")
+	if err = repo.UpdateBalance(ctx, fromUser.Id, fromUser.Balance-quote.Total); err != nil {
+		return err
+	}
+	if err = repo.InsertLog(ctx, money.FromUserID, date, money.Amount, fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID), money.Note); err != nil {
+		return err
 	}
 
-	if err = c.repo.UpdateBalanceTx(tx, fromUser.Id, fromUser.Balance-money.Amount); err != nil {
-		tx.Rollback()
+	if err = repo.UpdateBalance(ctx, toUser.Id, toUser.Balance+money.Amount); err != nil {
 		return err
 	}
-	if err = c.repo.InsertLogTx(tx, money.FromUserID, date, money.Amount, fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID)); err != nil {
-		tx.Rollback()
+
+	if err = repo.InsertLog(ctx, money.ToUserID, date, money.Amount, fmt.Sprintf("Перевод средств от пользователя %d", money.FromUserID), money.Note); err != nil {
 		return err
 	}
 
-	if err = c.repo.UpdateBalanceTx(tx, toUser.Id, toUser.Balance+money.Amount); err != nil {
-		tx.Rollback()
+	if err = journalTx(ctx, repo, models.OperationTransfer,
+		models.LedgerEntry{UserID: money.FromUserID, Account: models.AccountMain, Amount: -money.Amount, Description: fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID), CounterpartyID: money.ToUserID},
+		models.LedgerEntry{UserID: money.ToUserID, Account: models.AccountMain, Amount: money.Amount, Description: fmt.Sprintf("Перевод средств от пользователя %d", money.FromUserID), CounterpartyID: money.FromUserID}); err != nil {
 		return err
 	}
-	if err = c.repo.InsertLogTx(tx, money.ToUserID, date, money.Amount, fmt.Sprintf("Перевод средств от пользователя %d", money.FromUserID)); err != nil {
-		tx.Rollback()
+
+	return c.chargeFeeTx(ctx, repo, money.FromUserID, quote, date, fmt.Sprintf("Комиссия за перевод пользователю %d", money.ToUserID))
+}
+
+func (c *ControlService) Reservation(ctx context.Context, transaction *models.Transaction) (err error) {
+	defer func() { observe(ctx, models.OperationReserve, transaction.Amount, err) }()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	var service string
+	if service, err = c.getService(ctx, transaction.ServiceID); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	return c.withinTx(ctx, func(repo repository.Control) error {
+		return c.reservationTx(ctx, repo, transaction, service)
+	})
 }
 
-func (c *ControlService) Reservation(transaction *models.Transaction) error {
-	var tx *sql.Tx
+func (c *ControlService) reservationTx(ctx context.Context, repo repository.Control, transaction *models.Transaction, service string) error {
 	var user *models.User
-	var service string
 	var err error
 	var reservBalance int
 
@@ -159,64 +250,81 @@ func (c *ControlService) Reservation(transaction *models.Transaction) error {
 		date = time.Now()
 	}
 
-	if service, err = c.repo.GetService(transaction.ServiceID); err != nil {
+	quote := c.quoteFee(transaction.UserID, &models.FeeRequest{Operation: models.OperationReserve, ServiceID: transaction.ServiceID, Amount: transaction.Amount})
+
+	users, err := c.lockTx(ctx, repo, quote, transaction.UserID)
+	if err != nil {
 		return err
 	}
+	user = users[transaction.UserID]
 
-	if service == "" {
-		return errors.New("услуга не найдена")
+	if user.Balance-quote.Total < 0 {
+		return ErrInsufficientFunds
 	}
-
-	tx, err = c.db.Begin()
-	if err != nil {
+	if err = c.checkLimitTx(ctx, repo, transaction.UserID, models.OperationReserve, transaction.Amount); err != nil {
 		return err
 	}
-
-	if user, err = c.repo.GetUserForUpdate(tx, transaction.UserID); err != nil {
-		tx.Rollback()
+	if err = c.screenTx(ctx, repo, &models.RiskOperation{
+		Type:      models.OperationReserve,
+		UserID:    transaction.UserID,
+		ServiceID: transaction.ServiceID,
+		OrderID:   transaction.OrderID,
+		Amount:    transaction.Amount,
+		Date:      transaction.Date,
+		Note:      transaction.Note,
+	}); err != nil {
 		return err
 	}
-	if user == nil {
-		tx.Rollback()
-		return errors.New("пользователь не найден")
+
+	if reservBalance, err = repo.GetBalanceReserveAccounts(ctx, transaction.UserID); err != nil {
+		return err
 	}
-	if user.Balance-transaction.Amount < 0 {
-		tx.Rollback()
-		return errors.New("недостаточно средств")
+
+	if err = repo.UpdateBalance(ctx, transaction.UserID, user.Balance-quote.Total); err != nil {
+		return err
 	}
 
-	if reservBalance, err = c.repo.GetBalanceReserveAccountsTx(tx, transaction.UserID); err != nil {
-		tx.Rollback()
+	if err = repo.UpdateMoneyReserveAccounts(ctx, transaction.UserID, reservBalance+transaction.Amount); err != nil {
 		return err
 	}
 
-	if err = c.repo.UpdateBalanceTx(tx, transaction.UserID, user.Balance-transaction.Amount); err != nil {
-		tx.Rollback()
+	if err = repo.InsertMoneyReserveDetails(ctx, transaction.UserID, transaction.ServiceID, transaction.OrderID, transaction.Amount, date); err != nil {
 		return err
 	}
 
-	if err = c.repo.UpdateMoneyReserveAccountsTx(tx, transaction.UserID, reservBalance+transaction.Amount); err != nil {
-		tx.Rollback()
+	description := fmt.Sprintf("Заказ №%d, услуга \"%s\"", transaction.OrderID, service)
+	if err = repo.InsertLog(ctx, transaction.UserID, date, transaction.Amount, description, transaction.Note); err != nil {
 		return err
 	}
 
-	if err = c.repo.InsertMoneyReserveDetailsTx(tx, transaction.UserID, transaction.ServiceID, transaction.OrderID, transaction.Amount, date); err != nil {
-		tx.Rollback()
+	if err = journalTx(ctx, repo, models.OperationReserve,
+		models.LedgerEntry{UserID: transaction.UserID, Account: models.AccountMain, Amount: -transaction.Amount, Description: description},
+		models.LedgerEntry{UserID: transaction.UserID, Account: models.AccountReserve, Amount: transaction.Amount, Description: description}); err != nil {
 		return err
 	}
 
-	if err = c.repo.InsertLogTx(tx, transaction.UserID, date, transaction.Amount, fmt.Sprintf("Заказ №%d, услуга \"%s\"", transaction.OrderID, service)); err != nil {
-		tx.Rollback()
+	// комиссия за резервирование не возвращается при отмене заказа
+	return c.chargeFeeTx(ctx, repo, transaction.UserID, quote, date, fmt.Sprintf("Комиссия за заказ №%d, услуга \"%s\"", transaction.OrderID, service))
+}
+
+func (c *ControlService) CancelReservation(ctx context.Context, transaction *models.Transaction) (err error) {
+	defer func() { observe(ctx, models.OperationCancel, transaction.Amount, err) }()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	var service string
+	if service, err = c.getService(ctx, transaction.ServiceID); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	return c.uow.WithinTx(ctx, func(repo repository.Control) error {
+		return c.cancelReservationTx(ctx, repo, transaction, service)
+	})
 }
 
-func (c *ControlService) CancelReservation(transaction *models.Transaction) error {
-	var tx *sql.Tx
+func (c *ControlService) cancelReservationTx(ctx context.Context, repo repository.Control, transaction *models.Transaction, service string) error {
 	var user *models.User
-	var service string
 	var err error
 	var reservBalance int
 
@@ -225,63 +333,55 @@ func (c *ControlService) CancelReservation(transaction *models.Transaction) erro
 		date = time.Now()
 	}
 
-	if service, err = c.repo.GetService(transaction.ServiceID); err != nil {
-		return err
-	}
-
-	if service == "" {
-		return errors.New("услуга не найдена")
-	}
-
-	tx, err = c.db.Begin()
-	if err != nil {
-		return err
-	}
-
-	if user, err = c.repo.GetUserForUpdate(tx, transaction.UserID); err != nil {
-		tx.Rollback()
+	if user, err = repo.GetUserForUpdate(ctx, transaction.UserID); err != nil {
 		return err
 	}
 	if user == nil {
-		tx.Rollback()
-		return errors.New("пользователь не найден")
+		return ErrUserNotFound
 	}
 
-	if reservBalance, err = c.repo.GetBalanceReserveAccountsTx(tx, transaction.UserID); err != nil {
-		tx.Rollback()
+	if reservBalance, err = repo.GetBalanceReserveAccounts(ctx, transaction.UserID); err != nil {
 		return err
 	}
 
-	r, err := c.repo.DeleteMoneyReserveDetailsTx(tx, transaction.UserID, transaction.ServiceID, transaction.OrderID, transaction.Amount, date)
+	r, err := repo.DeleteMoneyReserveDetails(ctx, transaction.UserID, transaction.ServiceID, transaction.OrderID, transaction.Amount, date)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 	if r == 0 {
-		tx.Rollback()
-		return errors.New("по указанным критериям не было резерва")
+		return ErrReserveNotFound
 	}
 
-	if err = c.repo.InsertLogTx(tx, transaction.UserID, date, transaction.Amount, fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service)); err != nil {
-		tx.Rollback()
+	description := fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service)
+	if err = repo.InsertLog(ctx, transaction.UserID, date, transaction.Amount, description, transaction.Note); err != nil {
 		return err
 	}
 
-	if err = c.repo.UpdateBalanceTx(tx, transaction.UserID, user.Balance+transaction.Amount); err != nil {
-		tx.Rollback()
+	if err = repo.UpdateBalance(ctx, transaction.UserID, user.Balance+transaction.Amount); err != nil {
 		return err
 	}
 
-	if err = c.repo.UpdateMoneyReserveAccountsTx(tx, transaction.UserID, reservBalance-transaction.Amount); err != nil {
-		tx.Rollback()
+	if err = repo.UpdateMoneyReserveAccounts(ctx, transaction.UserID, reservBalance-transaction.Amount); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	return journalTx(ctx, repo, models.OperationCancel,
+		models.LedgerEntry{UserID: transaction.UserID, Account: models.AccountReserve, Amount: -transaction.Amount, Description: description},
+		models.LedgerEntry{UserID: transaction.UserID, Account: models.AccountMain, Amount: transaction.Amount, Description: description})
+}
+
+func (c *ControlService) Confirmation(ctx context.Context, transaction *models.Transaction) (err error) {
+	defer func() { observe(ctx, models.OperationConfirm, transaction.Amount, err) }()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	return c.uow.WithinTx(ctx, func(repo repository.Control) error {
+		return c.confirmationTx(ctx, repo, transaction)
+	})
 }
 
-func (c *ControlService) Confirmation(transaction *models.Transaction) error {
-	var tx *sql.Tx
+func (c *ControlService) confirmationTx(ctx context.Context, repo repository.Control, transaction *models.Transaction) error {
 	var err error
 	var reservBalance int
 
@@ -290,40 +390,170 @@ func (c *ControlService) Confirmation(transaction *models.Transaction) error {
 		date = time.Now()
 	}
 
-	tx, err = c.db.Begin()
-	if err != nil {
+	if reservBalance, err = repo.GetBalanceReserveAccounts(ctx, transaction.UserID); err != nil {
 		return err
 	}
 
-	if reservBalance, err = c.repo.GetBalanceReserveAccountsTx(tx, transaction.UserID); err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	r, err := c.repo.DeleteMoneyReserveDetailsTx(tx, transaction.UserID, transaction.ServiceID, transaction.OrderID, transaction.Amount, date)
+	r, err := repo.DeleteMoneyReserveDetails(ctx, transaction.UserID, transaction.ServiceID, transaction.OrderID, transaction.Amount, date)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 	if r == 0 {
-		tx.Rollback()
-		return errors.New("по указанным критериям не было резерва")
+		return ErrReserveNotFound
 	}
 
-	if err = c.repo.UpdateMoneyReserveAccountsTx(tx, transaction.UserID, reservBalance-transaction.Amount); err != nil {
-		tx.Rollback()
+	if err = repo.UpdateMoneyReserveAccounts(ctx, transaction.UserID, reservBalance-transaction.Amount); err != nil {
 		return err
 	}
 
-	if err = c.repo.InsertReportTx(tx, transaction.UserID, transaction.ServiceID, transaction.Amount, date); err != nil {
-		tx.Rollback()
+	if err = repo.InsertReport(ctx, transaction.UserID, transaction.ServiceID, transaction.Amount, date); err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	return journalTx(ctx, repo, models.OperationConfirm,
+		models.LedgerEntry{UserID: transaction.UserID, Account: models.AccountReserve, Amount: -transaction.Amount, Description: fmt.Sprintf("Списание по заказу №%d", transaction.OrderID)})
+}
+
+// journalTx записывает в журнал изменения счетов, сделанные операцией operation
+func journalTx(ctx context.Context, repo repository.Control, operation string, entries ...models.LedgerEntry) error {
+	now := time.Now()
+	for i := range entries {
+		entries[i].CreatedAt = now
+		entries[i].Operation = operation
+		if err := repo.InsertLedger(ctx, &entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observe учитывает результат операции в метриках и журнале запроса. Ошибки в лог
+// пишет обработчик запроса, здесь они записываются только на уровне debug
+func observe(ctx context.Context, operation string, amount int, err error) {
+	if err != nil {
+		metrics.ObserveError(operation, errorType(err))
+		logger.DebugContext(ctx, "операция не выполнена", "operation", operation, "amount", amount, "error", err)
+		return
+	}
+	metrics.ObserveOperation(operation, amount)
+	logger.DebugContext(ctx, "операция выполнена", "operation", operation, "amount", amount)
+}
+
+// errorType возвращает тип ошибки для метрик: ошибку предметной области либо internal
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		return "user_not_found"
+	case errors.Is(err, ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, ErrServiceNotFound):
+		return "service_not_found"
+	case errors.Is(err, ErrReserveNotFound):
+		return "reserve_not_found"
+	case errors.Is(err, ErrLimitExceeded):
+		return "limit_exceeded"
+	case errors.Is(err, ErrRiskReview):
+		return "risk_review"
+	case errors.Is(err, ErrRiskDenied):
+		return "risk_denied"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "internal"
+}
+
+// withTimeout ограничивает время работы с БД в рамках одного запроса
+func (c *ControlService) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	conf := c.config()
+	if conf == nil || conf.DBTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(conf.DBTimeout)*time.Second)
+}
+
+// withBatchTimeout ограничивает время выполнения атомарного пакета, который занимает одну транзакцию
+// и может быть намного дольше отдельной операции
+func (c *ControlService) withBatchTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	conf := c.config()
+	if conf == nil || conf.BatchTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(conf.BatchTimeout)*time.Second)
+}
+
+// config возвращает действующую конфигурацию, nil - если сервис создан без нее
+func (c *ControlService) config() *c.Config {
+	if c.conf == nil {
+		return nil
+	}
+	return c.conf.Get()
+}
+
+// ascending возвращает id без повторов в порядке возрастания
+func ascending(ids ...int) []int {
+	sorted := make([]int, len(ids))
+	copy(sorted, ids)
+	sort.Ints(sorted)
+
+	unique := sorted[:0]
+	for i, id := range sorted {
+		if i > 0 && sorted[i-1] == id {
+			continue
+		}
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// lockTx блокирует строки пользователей операции userIds, а если за операцию взимается комиссия quote -
+// и строку счета комиссий, все в порядке возрастания id, иначе операции, одна из которых зачисляет
+// комиссию, а другая списывает средства со счета комиссий, взаимно блокируют друг друга.
+// Возвращает пользователей userIds, отсутствующий счет комиссий создается при зачислении комиссии
+func (c *ControlService) lockTx(ctx context.Context, repo repository.Control, quote *models.FeeQuote, userIds ...int) (map[int]*models.User, error) {
+	ids := userIds
+	if quote.Fee > 0 {
+		ids = append(append(make([]int, 0, len(userIds)+1), userIds...), c.feeUserID())
+	}
+
+	users := make(map[int]*models.User, len(ids))
+	for _, id := range userIds {
+		users[id] = nil
+	}
+	for _, id := range ascending(ids...) {
+		user, err := repo.GetUserForUpdate(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if _, participant := users[id]; participant && user == nil {
+			return nil, ErrUserNotFound
+		}
+		users[id] = user
+	}
+	return users, nil
+}
+
+func (c *ControlService) getService(ctx context.Context, serviceId int) (string, error) {
+	return getServiceTx(ctx, c.repo, serviceId)
 }
 
-func (c *ControlService) CreateReport(requestReport *models.RequestReport) (string, error) {
+// getServiceTx возвращает название услуги через repo, в том числе в транзакции
+func getServiceTx(ctx context.Context, repo repository.Control, serviceId int) (string, error) {
+	service, err := repo.GetService(ctx, serviceId)
+	if err != nil {
+		return service, err
+	}
+	if service == "" {
+		return service, ErrServiceNotFound
+	}
+	return service, err
+}
+
+func (c *ControlService) CreateReport(ctx context.Context, requestReport *models.RequestReport) (string, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	var report map[string]int
 	var err error
 	var file *os.File
@@ -333,7 +563,7 @@ func (c *ControlService) CreateReport(requestReport *models.RequestReport) (stri
 	from := time.Date(requestReport.Year, time.Month(requestReport.Month), 1, 0, 0, 0, 0, time.Local)
 	to := from.AddDate(0, 1, 0).Add(-time.Nanosecond)
 
-	if report, err = c.repo.GetReport(from, to); err != nil {
+	if report, err = c.repo.GetReport(ctx, from, to); err != nil {
 		return path, err
 	}
 
@@ -358,14 +588,16 @@ func (c *ControlService) CreateReport(requestReport *models.RequestReport) (stri
 
 	var host string
 
-	if c.conf.Host == "" {
+	conf := c.config()
+
+	if conf.Host == "" {
 		host = "localhost"
 	} else {
-		host = c.conf.Host
+		host = conf.Host
 	}
 
-	if c.conf.Port != "" {
-		host += c.conf.Port
+	if conf.Port != "" {
+		host += conf.Port
 	}
 
 	path = fmt.Sprintf("%s/%s", host, file.Name())
@@ -373,7 +605,10 @@ func (c *ControlService) CreateReport(requestReport *models.RequestReport) (stri
 	return path, err
 }
 
-func (c *ControlService) GetHistory(requestHistory *models.RequestHistory) ([]models.History, error) {
+func (c *ControlService) GetHistory(ctx context.Context, requestHistory *models.RequestHistory) ([]models.History, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	direction := strings.ToUpper(requestHistory.Direction)
 	sortField := strings.ToLower(requestHistory.SortField)
 
@@ -389,12 +624,12 @@ func (c *ControlService) GetHistory(requestHistory *models.RequestHistory) ([]mo
 		requestHistory.SortField = "amount"
 	}
 
-	history, err := c.repo.GetHistory(requestHistory)
+	history, err := c.repo.GetHistory(ctx, requestHistory)
 	if err != nil {
 		return nil, err
 	}
 	if len(history) == 0 {
-		return history, errors.New("записи не найдены")
+		return history, ErrRecordsNotFound
 	}
 	return history, err
 }
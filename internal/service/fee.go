@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+)
+
+// FeeCalculator рассчитывает комиссию за операцию по действующим правилам
+type FeeCalculator interface {
+	Quote(request *models.FeeRequest) *models.FeeQuote
+}
+
+// FeeService рассчитывает комиссию за операцию до ее выполнения
+type FeeService struct {
+	control *ControlService
+}
+
+func NewFeeService(control *ControlService) *FeeService {
+	return &FeeService{control: control}
+}
+
+// QuoteFee рассчитывает комиссию так же, как она будет рассчитана при выполнении операции
+func (s *FeeService) QuoteFee(ctx context.Context, request *models.FeeRequest) (*models.FeeQuote, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if request.Operation == models.OperationReserve {
+		ctx, cancel := s.control.withTimeout(ctx)
+		defer cancel()
+
+		if _, err := s.control.getService(ctx, request.ServiceID); err != nil {
+			return nil, err
+		}
+	}
+	return s.control.quoteFee(0, request), nil
+}
+
+// quoteFee рассчитывает комиссию, которую заплатит пользователь userId. Без правил комиссий
+// и для самого счета комиссий операция выполняется без комиссии
+func (c *ControlService) quoteFee(userId int, request *models.FeeRequest) *models.FeeQuote {
+	feeUserId := c.feeUserID()
+	if feeUserId == 0 || userId == feeUserId {
+		return &models.FeeQuote{
+			Operation: request.Operation,
+			ServiceID: request.ServiceID,
+			Amount:    request.Amount,
+			Total:     request.Amount,
+		}
+	}
+	return c.fees.Quote(request)
+}
+
+// feeUserID возвращает id счета комиссий, 0 - если комиссия не взимается
+func (c *ControlService) feeUserID() int {
+	conf := c.config()
+	if c.fees == nil || conf == nil {
+		return 0
+	}
+	return conf.FeeUserID
+}
+
+// chargeFeeTx зачисляет комиссию quote, уже списанную с основного счета пользователя userId,
+// на счет комиссий и записывает ее в историю отдельной строкой с описанием description.
+// Строка счета комиссий к этому времени заблокирована lockTx, здесь читается ее текущий остаток.
+// Счет комиссий создается при первом зачислении
+func (c *ControlService) chargeFeeTx(ctx context.Context, repo repository.Control, userId int, quote *models.FeeQuote, date time.Time, description string) error {
+	if quote.Fee == 0 {
+		return nil
+	}
+	feeUserId := c.feeUserID()
+
+	account, err := repo.GetUserForUpdate(ctx, feeUserId)
+	if err != nil {
+		return err
+	}
+	if account != nil {
+		if err = repo.UpdateBalance(ctx, feeUserId, account.Balance+quote.Fee); err != nil {
+			return err
+		}
+	} else {
+		if err = repo.InsertUser(ctx, feeUserId, quote.Fee); err != nil {
+			return err
+		}
+		if err = repo.InsertMoneyReserveAccounts(ctx, feeUserId); err != nil {
+			return err
+		}
+	}
+
+	// имя правила ограничено fee.MaxNameLength, поэтому описание помещается в logs и ledger
+	accountDescription := fmt.Sprintf("Комиссия от пользователя %d по правилу %s", userId, quote.Rule)
+	if err = repo.InsertLog(ctx, userId, date, quote.Fee, description, models.Note{}); err != nil {
+		return err
+	}
+	if err = repo.InsertLog(ctx, feeUserId, date, quote.Fee, accountDescription, models.Note{}); err != nil {
+		return err
+	}
+
+	return journalTx(ctx, repo, models.OperationFee,
+		models.LedgerEntry{UserID: userId, Account: models.AccountMain, Amount: -quote.Fee, Description: description, CounterpartyID: feeUserId},
+		models.LedgerEntry{UserID: feeUserId, Account: models.AccountMain, Amount: quote.Fee, Description: accountDescription, CounterpartyID: userId})
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"userbalance/internal/config"
+	"userbalance/internal/models"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fees берет комиссию fee с любой операции
+type fees struct {
+	fee int
+}
+
+func (f fees) Quote(request *models.FeeRequest) *models.FeeQuote {
+	return &models.FeeQuote{
+		Operation: request.Operation,
+		ServiceID: request.ServiceID,
+		Amount:    request.Amount,
+		Fee:       f.fee,
+		Total:     request.Amount + f.fee,
+		Rule:      "test",
+	}
+}
+
+func TestFeeService_QuoteFee(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		fees         FeeCalculator
+		request      models.FeeRequest
+		mockBehavior mockBehavior
+		want         *models.FeeQuote
+		wantErr      error
+	}{
+		{
+			name:         "OK transfer",
+			fees:         fees{fee: 10},
+			request:      models.FeeRequest{Operation: models.OperationTransfer, Amount: 1000},
+			mockBehavior: func(r *mock_repository.MockControl) {},
+			want:         &models.FeeQuote{Operation: models.OperationTransfer, Amount: 1000, Fee: 10, Total: 1010, Rule: "test"},
+		},
+
+		{
+			name:    "OK reserve",
+			fees:    fees{fee: 50},
+			request: models.FeeRequest{Operation: models.OperationReserve, ServiceID: 1, Amount: 1000},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetService(gomock.Any(), 1).Return("доставка", nil)
+			},
+			want: &models.FeeQuote{Operation: models.OperationReserve, ServiceID: 1, Amount: 1000, Fee: 50, Total: 1050, Rule: "test"},
+		},
+
+		{
+			name:         "OK without fee rules",
+			request:      models.FeeRequest{Operation: models.OperationTransfer, Amount: 1000},
+			mockBehavior: func(r *mock_repository.MockControl) {},
+			want:         &models.FeeQuote{Operation: models.OperationTransfer, Amount: 1000, Total: 1000},
+		},
+
+		{
+			name:    "error service not found",
+			fees:    fees{fee: 50},
+			request: models.FeeRequest{Operation: models.OperationReserve, ServiceID: 9, Amount: 1000},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetService(gomock.Any(), 9).Return("", nil)
+			},
+			wantErr: ErrServiceNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			s := NewFeeService(NewControlService(repo, unitOfWork{repo}, &config.Config{FeeUserID: 100}, nil, testCase.fees))
+			got, err := s.QuoteFee(context.Background(), &testCase.request)
+
+			if testCase.wantErr != nil {
+				assert.True(t, errors.Is(err, testCase.wantErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.want, got)
+		})
+	}
+}
+
+func TestTransfer_Fee(t *testing.T) {
+	date := time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC)
+	money := &models.Money{FromUserID: 1, ToUserID: 2, Amount: 1000, Date: "2022-10-01"}
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		money        *models.Money
+		mockBehavior mockBehavior
+		wantErr      error
+	}{
+		{
+			name:  "OK fee credited to fee account",
+			money: money,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1010}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 0).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, date, 1000, "Перевод средств пользователю 2", models.Note{}).Return(nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 2, 1000).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 2, date, 1000, "Перевод средств от пользователя 1", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -1000)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(2, models.AccountMain, 1000)).Return(nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 100).Return(&models.User{Id: 100, Balance: 500}, nil).Times(2)
+				r.EXPECT().UpdateBalance(gomock.Any(), 100, 510).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, date, 10, "Комиссия за перевод пользователю 2", models.Note{}).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 100, date, 10, "Комиссия от пользователя 1 по правилу test", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -10)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(100, models.AccountMain, 10)).Return(nil)
+			},
+		},
+
+		{
+			name:  "OK fee account created",
+			money: money,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 2000}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 990).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, date, 1000, "Перевод средств пользователю 2", models.Note{}).Return(nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 2, 1000).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 2, date, 1000, "Перевод средств от пользователя 1", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 100).Return(nil, nil).Times(2)
+				r.EXPECT().InsertUser(gomock.Any(), 100, 10).Return(nil)
+				r.EXPECT().InsertMoneyReserveAccounts(gomock.Any(), 100).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, date, 10, "Комиссия за перевод пользователю 2", models.Note{}).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 100, date, 10, "Комиссия от пользователя 1 по правилу test", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -10)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(100, models.AccountMain, 10)).Return(nil)
+			},
+		},
+
+		{
+			name:  "OK fee account pays no fee",
+			money: &models.Money{FromUserID: 100, ToUserID: 2, Amount: 1000, Date: "2022-10-01"},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 100).Return(&models.User{Id: 100, Balance: 1000}, nil)
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 100, models.OperationTransfer).Return(nil, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 100, 0).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 100, date, 1000, "Перевод средств пользователю 2", models.Note{}).Return(nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 2, 1000).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 2, date, 1000, "Перевод средств от пользователя 100", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+			},
+		},
+
+		{
+			name:  "error balance does not cover fee",
+			money: money,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1005}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 100).Return(&models.User{Id: 100, Balance: 0}, nil)
+			},
+			wantErr: ErrInsufficientFunds,
+		},
+
+		{
+			name:  "error lock fee account",
+			money: money,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 2000}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 100).Return(nil, errors.New("db error"))
+			},
+			wantErr: errors.New("db error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			s := NewControlService(repo, unitOfWork{repo}, &config.Config{FeeUserID: 100}, nil, fees{fee: 10})
+			err := s.Transfer(context.Background(), testCase.money)
+
+			if testCase.wantErr != nil {
+				assert.EqualError(t, err, testCase.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReservation_Fee(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	date := time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC)
+	transaction := &models.Transaction{UserID: 1, ServiceID: 1, OrderID: 5, Amount: 1000, Date: "2022-10-01"}
+
+	repo := mock_repository.NewMockControl(c)
+	repo.EXPECT().GetService(gomock.Any(), 1).Return("доставка", nil)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 2000}, nil)
+	repo.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationReserve).Return(nil, nil)
+	repo.EXPECT().GetBalanceReserveAccounts(gomock.Any(), 1).Return(0, nil)
+	repo.EXPECT().UpdateBalance(gomock.Any(), 1, 950).Return(nil)
+	repo.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), 1, 1000).Return(nil)
+	repo.EXPECT().InsertMoneyReserveDetails(gomock.Any(), 1, 1, 5, 1000, date).Return(nil)
+	repo.EXPECT().InsertLog(gomock.Any(), 1, date, 1000, "Заказ №5, услуга \"доставка\"", models.Note{}).Return(nil)
+	repo.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -1000)).Return(nil)
+	repo.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountReserve, 1000)).Return(nil)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 100).Return(&models.User{Id: 100, Balance: 0}, nil).Times(2)
+	repo.EXPECT().UpdateBalance(gomock.Any(), 100, 50).Return(nil)
+	repo.EXPECT().InsertLog(gomock.Any(), 1, date, 50, "Комиссия за заказ №5, услуга \"доставка\"", models.Note{}).Return(nil)
+	repo.EXPECT().InsertLog(gomock.Any(), 100, date, 50, "Комиссия от пользователя 1 по правилу test", models.Note{}).Return(nil)
+	repo.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -50)).Return(nil)
+	repo.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(100, models.AccountMain, 50)).Return(nil)
+
+	s := NewControlService(repo, unitOfWork{repo}, &config.Config{FeeUserID: 100}, nil, fees{fee: 50})
+	assert.NoError(t, s.Reservation(context.Background(), transaction))
+}
+
+func TestTransfer_FeeAccountLockOrder(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	// счет комиссий блокируется в общем порядке возрастания id, а не после пользователей перевода
+	repo := mock_repository.NewMockControl(c)
+	gomock.InOrder(
+		repo.EXPECT().GetUserForUpdate(gomock.Any(), 100).Return(&models.User{Id: 100, Balance: 0}, nil),
+		repo.EXPECT().GetUserForUpdate(gomock.Any(), 150).Return(&models.User{Id: 150, Balance: 0}, nil),
+		repo.EXPECT().GetUserForUpdate(gomock.Any(), 200).Return(&models.User{Id: 200, Balance: 5}, nil),
+	)
+
+	s := NewControlService(repo, unitOfWork{repo}, &config.Config{FeeUserID: 100}, nil, fees{fee: 10})
+	err := s.Transfer(context.Background(), &models.Money{FromUserID: 200, ToUserID: 150, Amount: 1})
+
+	assert.True(t, errors.Is(err, ErrInsufficientFunds))
+}
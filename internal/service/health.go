@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+)
+
+type HealthService struct {
+	repo    repository.Control
+	timeout time.Duration
+
+	// draining выставляется при получении сигнала остановки, после чего сервер перестает быть готовым
+	draining int32
+
+	mu      sync.Mutex
+	workers map[string]bool
+}
+
+func NewHealthService(repo repository.Control, timeout time.Duration) *HealthService {
+	return &HealthService{
+		repo:    repo,
+		timeout: timeout,
+		workers: make(map[string]bool),
+	}
+}
+
+// Go запускает фоновый процесс и учитывает его при проверке готовности:
+// пока fn не вернула управление, процесс считается работающим
+func (s *HealthService) Go(name string, fn func()) {
+	s.mu.Lock()
+	s.workers[name] = true
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			s.workers[name] = false
+			s.mu.Unlock()
+
+			if !s.Draining() {
+				logger.Error("фоновый процесс остановлен", "worker", name)
+			}
+		}()
+		fn()
+	}()
+}
+
+// Drain переводит сервер в режим остановки: проверка готовности начинает завершаться неудачей,
+// чтобы балансировщик перестал направлять новые запросы до выключения http сервера
+func (s *HealthService) Drain() {
+	atomic.StoreInt32(&s.draining, 1)
+}
+
+func (s *HealthService) Draining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+// Ready проверяет доступность БД, версию примененных миграций и работу фоновых процессов
+func (s *HealthService) Ready(ctx context.Context) *models.Readiness {
+	if s.Draining() {
+		return &models.Readiness{Status: models.HealthStatusDraining, Checks: []models.HealthCheck{}}
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	readiness := &models.Readiness{Status: models.HealthStatusOK}
+	readiness.Checks = append(readiness.Checks,
+		healthCheck("database", s.repo.Ping(ctx)),
+		healthCheck("migrations", s.checkMigrations(ctx)),
+	)
+
+	s.mu.Lock()
+	names := make([]string, 0, len(s.workers))
+	for name := range s.workers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var err error
+		if !s.workers[name] {
+			err = fmt.Errorf("фоновый процесс %s не работает", name)
+		}
+		readiness.Checks = append(readiness.Checks, healthCheck("worker."+name, err))
+	}
+	s.mu.Unlock()
+
+	for _, result := range readiness.Checks {
+		if result.Status != models.HealthStatusOK {
+			readiness.Status = models.HealthStatusFail
+		}
+	}
+
+	return readiness
+}
+
+// checkMigrations сверяет версию миграций БД с последней миграцией, встроенной в бинарный файл
+func (s *HealthService) checkMigrations(ctx context.Context) error {
+	expected, err := repository.LatestMigration()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := s.repo.GetMigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("миграция %d применена не полностью", version)
+	}
+	if version != expected {
+		return fmt.Errorf("версия миграций БД %d, ожидается %d", version, expected)
+	}
+
+	return nil
+}
+
+func healthCheck(name string, err error) models.HealthCheck {
+	if err != nil {
+		return models.HealthCheck{Name: name, Status: models.HealthStatusFail, Error: err.Error()}
+	}
+	return models.HealthCheck{Name: name, Status: models.HealthStatusOK}
+}
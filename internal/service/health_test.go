@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthService_Ready(t *testing.T) {
+	latest, err := repository.LatestMigration()
+	require.NoError(t, err)
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		stopped      bool
+		draining     bool
+		wantStatus   string
+		wantFailed   []string
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().Ping(gomock.Any()).Return(nil)
+				r.EXPECT().GetMigrationVersion(gomock.Any()).Return(latest, false, nil)
+			},
+			wantStatus: models.HealthStatusOK,
+		},
+
+		{
+			name: "error database unavailable",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().Ping(gomock.Any()).Return(errors.New("connection refused"))
+				r.EXPECT().GetMigrationVersion(gomock.Any()).Return(uint(0), false, errors.New("connection refused"))
+			},
+			wantStatus: models.HealthStatusFail,
+			wantFailed: []string{"database", "migrations"},
+		},
+
+		{
+			name: "error migrations behind",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().Ping(gomock.Any()).Return(nil)
+				r.EXPECT().GetMigrationVersion(gomock.Any()).Return(latest-1, false, nil)
+			},
+			wantStatus: models.HealthStatusFail,
+			wantFailed: []string{"migrations"},
+		},
+
+		{
+			name: "error migration dirty",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().Ping(gomock.Any()).Return(nil)
+				r.EXPECT().GetMigrationVersion(gomock.Any()).Return(latest, true, nil)
+			},
+			wantStatus: models.HealthStatusFail,
+			wantFailed: []string{"migrations"},
+		},
+
+		{
+			name: "error worker stopped",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().Ping(gomock.Any()).Return(nil)
+				r.EXPECT().GetMigrationVersion(gomock.Any()).Return(latest, false, nil)
+			},
+			stopped:    true,
+			wantStatus: models.HealthStatusFail,
+			wantFailed: []string{"worker.snapshot"},
+		},
+
+		{
+			name:         "draining",
+			mockBehavior: func(r *mock_repository.MockControl) {},
+			draining:     true,
+			wantStatus:   models.HealthStatusDraining,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			s := NewHealthService(repo, time.Second)
+
+			stop := make(chan struct{})
+			defer close(stop)
+			worker := func() { <-stop }
+			if testCase.stopped {
+				worker = func() {}
+			}
+			s.Go("snapshot", worker)
+			assert.Eventually(t, func() bool {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				return s.workers["snapshot"] != testCase.stopped
+			}, time.Second, time.Millisecond)
+
+			if testCase.draining {
+				s.Drain()
+			}
+
+			got := s.Ready(context.Background())
+			assert.Equal(t, testCase.wantStatus, got.Status)
+
+			failed := make([]string, 0)
+			for _, check := range got.Checks {
+				if check.Status != models.HealthStatusOK {
+					assert.NotEmpty(t, check.Error)
+					failed = append(failed, check.Name)
+				}
+			}
+			assert.ElementsMatch(t, testCase.wantFailed, failed)
+		})
+	}
+}
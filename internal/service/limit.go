@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	c "userbalance/internal/config"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+)
+
+var (
+	ErrLimitExceeded = errors.New("превышен лимит операций")
+	ErrLimitNotFound = errors.New("лимиты пользователя для операции не заданы")
+)
+
+// limitWindow - окно суточного лимита, отсчитывается назад от момента операции
+const limitWindow = 24 * time.Hour
+
+// LimitExceededError - операция превысила лимит вида Kind со значением Limit,
+// Remaining - сумма, которую пользователь может провести этой операцией сейчас
+type LimitExceededError struct {
+	Operation string
+	Kind      string
+	Limit     int
+	Remaining int
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Kind == models.LimitPerOperation {
+		return fmt.Sprintf("%v: сумма операции %s не может превышать %d", ErrLimitExceeded, e.Operation, e.Limit)
+	}
+	return fmt.Sprintf("%v: сумма операций %s за сутки не может превышать %d, доступно %d", ErrLimitExceeded, e.Operation, e.Limit, e.Remaining)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// Response возвращает тело ответа на операцию, превысившую лимит
+func (e *LimitExceededError) Response() *models.LimitExceeded {
+	return &models.LimitExceeded{
+		Message:   e.Error(),
+		Code:      models.ErrorCodeLimitExceeded,
+		Operation: e.Operation,
+		Kind:      e.Kind,
+		Limit:     e.Limit,
+		Remaining: e.Remaining,
+	}
+}
+
+// checkLimitTx проверяет лимиты операции пользователя. Вызывается после блокировки строки
+// пользователя, поэтому сумма за сутки учитывает завершенные параллельные операции
+func (c *ControlService) checkLimitTx(ctx context.Context, repo repository.Control, userId int, operation string, amount int) error {
+	conf := c.config()
+	if conf == nil {
+		return nil
+	}
+
+	limit, err := repo.GetSpendingLimit(ctx, userId, operation)
+	if err != nil {
+		return err
+	}
+	if limit == nil {
+		limit = defaultLimit(conf, userId, operation)
+	}
+	if limit.PerOperation == 0 && limit.Daily == 0 {
+		return nil
+	}
+
+	kind, max, remaining := models.LimitPerOperation, limit.PerOperation, limit.PerOperation
+	if limit.Daily > 0 {
+		spent, err := repo.GetOperationSum(ctx, userId, operation, time.Now().Add(-limitWindow))
+		if err != nil {
+			return err
+		}
+
+		left := limit.Daily - spent
+		if left < 0 {
+			left = 0
+		}
+		if limit.PerOperation == 0 || left < remaining {
+			kind, max, remaining = models.LimitDaily, limit.Daily, left
+		}
+	}
+
+	if amount > remaining {
+		return &LimitExceededError{Operation: operation, Kind: kind, Limit: max, Remaining: remaining}
+	}
+	return nil
+}
+
+// defaultLimit возвращает лимиты операции из конфигурации, действующие для пользователей без своих лимитов
+func defaultLimit(conf *c.Config, userId int, operation string) *models.SpendingLimit {
+	limit := &models.SpendingLimit{UserID: userId, Operation: operation}
+
+	switch operation {
+	case models.OperationTopup:
+		limit.PerOperation, limit.Daily = conf.LimitTopupOperation, conf.LimitTopupDaily
+	case models.OperationTransfer:
+		limit.PerOperation, limit.Daily = conf.LimitTransferOperation, conf.LimitTransferDaily
+	case models.OperationReserve:
+		limit.PerOperation, limit.Daily = conf.LimitReserveOperation, conf.LimitReserveDaily
+	}
+
+	return limit
+}
+
+// LimitService управляет лимитами отдельных пользователей
+type LimitService struct {
+	repo repository.Control
+	conf c.Source
+}
+
+func NewLimitService(repo repository.Control, conf c.Source) *LimitService {
+	return &LimitService{
+		repo: repo,
+		conf: conf,
+	}
+}
+
+// GetSpendingLimits возвращает действующие лимиты пользователя по всем операциям:
+// заданные для пользователя (Override) либо значения по умолчанию из конфигурации
+func (s *LimitService) GetSpendingLimits(ctx context.Context, userId int) ([]models.SpendingLimit, error) {
+	overrides, err := s.repo.GetSpendingLimits(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	byOperation := make(map[string]models.SpendingLimit, len(overrides))
+	for _, limit := range overrides {
+		limit.Override = true
+		byOperation[limit.Operation] = limit
+	}
+
+	conf := s.conf.Get()
+	limits := make([]models.SpendingLimit, 0, len(models.LimitedOperations))
+	for _, operation := range models.LimitedOperations {
+		limit, ok := byOperation[operation]
+		if !ok {
+			limit = *defaultLimit(conf, userId, operation)
+		}
+		limits = append(limits, limit)
+	}
+
+	return limits, nil
+}
+
+// SetSpendingLimit задает лимиты операции пользователя вместо значений по умолчанию
+func (s *LimitService) SetSpendingLimit(ctx context.Context, limit *models.SpendingLimit) error {
+	if err := limit.Validate(); err != nil {
+		return err
+	}
+	return s.repo.UpsertSpendingLimit(ctx, limit)
+}
+
+// DeleteSpendingLimit удаляет лимиты операции пользователя, после чего действуют значения по умолчанию
+func (s *LimitService) DeleteSpendingLimit(ctx context.Context, userId int, operation string) error {
+	affected, err := s.repo.DeleteSpendingLimit(ctx, userId, operation)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLimitNotFound
+	}
+	return nil
+}
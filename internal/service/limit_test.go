@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"userbalance/internal/config"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlService_checkLimitTx(t *testing.T) {
+	conf := &config.Config{LimitTransferOperation: 500, LimitTransferDaily: 1000}
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		conf         *config.Config
+		amount       int
+		mockBehavior mockBehavior
+		want         *LimitExceededError
+	}{
+		{
+			name:   "OK within limits",
+			conf:   conf,
+			amount: 500,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+				r.EXPECT().GetOperationSum(gomock.Any(), 1, models.OperationTransfer, gomock.Any()).Return(500, nil)
+			},
+		},
+
+		{
+			name:   "OK without limits",
+			conf:   &config.Config{},
+			amount: 100000,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+			},
+		},
+
+		{
+			name:   "OK user override removes limits",
+			conf:   conf,
+			amount: 100000,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(
+					&models.SpendingLimit{UserID: 1, Operation: models.OperationTransfer}, nil)
+			},
+		},
+
+		{
+			name:   "per operation exceeded",
+			conf:   conf,
+			amount: 600,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+				r.EXPECT().GetOperationSum(gomock.Any(), 1, models.OperationTransfer, gomock.Any()).Return(0, nil)
+			},
+			want: &LimitExceededError{Operation: models.OperationTransfer, Kind: models.LimitPerOperation, Limit: 500, Remaining: 500},
+		},
+
+		{
+			name:   "daily exceeded",
+			conf:   conf,
+			amount: 400,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+				r.EXPECT().GetOperationSum(gomock.Any(), 1, models.OperationTransfer, gomock.Any()).Return(700, nil)
+			},
+			want: &LimitExceededError{Operation: models.OperationTransfer, Kind: models.LimitDaily, Limit: 1000, Remaining: 300},
+		},
+
+		{
+			name:   "user override daily exceeded",
+			conf:   conf,
+			amount: 100,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(
+					&models.SpendingLimit{UserID: 1, Operation: models.OperationTransfer, Daily: 200}, nil)
+				r.EXPECT().GetOperationSum(gomock.Any(), 1, models.OperationTransfer, gomock.Any()).Return(250, nil)
+			},
+			want: &LimitExceededError{Operation: models.OperationTransfer, Kind: models.LimitDaily, Limit: 200, Remaining: 0},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			s := NewControlService(repo, unitOfWork{repo}, testCase.conf, nil, nil)
+			err := s.checkLimitTx(context.Background(), repo, 1, models.OperationTransfer, testCase.amount)
+
+			if testCase.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var limitErr *LimitExceededError
+			require.True(t, errors.As(err, &limitErr))
+			assert.Equal(t, testCase.want, limitErr)
+			assert.True(t, errors.Is(err, ErrLimitExceeded))
+		})
+	}
+}
+
+func TestTransfer_LimitExceeded(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1000}, nil)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+	repo.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+
+	s := NewControlService(&repository.Repository{Control: repo}, unitOfWork{repo}, &config.Config{LimitTransferOperation: 100}, nil, nil)
+	err := s.Transfer(context.Background(), &models.Money{FromUserID: 1, ToUserID: 2, Amount: 500})
+
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+}
+
+func TestLimitService_GetSpendingLimits(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	repo.EXPECT().GetSpendingLimits(gomock.Any(), 1).Return([]models.SpendingLimit{
+		{UserID: 1, Operation: models.OperationTransfer, PerOperation: 50},
+	}, nil)
+
+	s := NewLimitService(repo, &config.Config{LimitTopupDaily: 1000, LimitTransferOperation: 500, LimitReserveOperation: 300})
+	got, err := s.GetSpendingLimits(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.SpendingLimit{
+		{UserID: 1, Operation: models.OperationTopup, Daily: 1000},
+		{UserID: 1, Operation: models.OperationTransfer, PerOperation: 50, Override: true},
+		{UserID: 1, Operation: models.OperationReserve, PerOperation: 300},
+	}, got)
+}
+
+func TestLimitService_SetSpendingLimit(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	limit := &models.SpendingLimit{UserID: 1, Operation: models.OperationReserve, Daily: 1000}
+	repo.EXPECT().UpsertSpendingLimit(gomock.Any(), limit).Return(nil)
+
+	s := NewLimitService(repo, &config.Config{})
+	assert.NoError(t, s.SetSpendingLimit(context.Background(), limit))
+
+	err := s.SetSpendingLimit(context.Background(), &models.SpendingLimit{UserID: 1, Operation: models.OperationConfirm})
+	assert.Error(t, err, "для списания лимиты не действуют")
+
+	err = s.SetSpendingLimit(context.Background(), &models.SpendingLimit{UserID: 1, Operation: models.OperationTopup, Daily: -1})
+	assert.Error(t, err)
+}
+
+func TestLimitService_DeleteSpendingLimit(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	repo.EXPECT().DeleteSpendingLimit(gomock.Any(), 1, models.OperationTopup).Return(int64(1), nil)
+	repo.EXPECT().DeleteSpendingLimit(gomock.Any(), 2, models.OperationTopup).Return(int64(0), nil)
+
+	s := NewLimitService(repo, &config.Config{})
+	assert.NoError(t, s.DeleteSpendingLimit(context.Background(), 1, models.OperationTopup))
+	assert.True(t, errors.Is(s.DeleteSpendingLimit(context.Background(), 2, models.OperationTopup), ErrLimitNotFound))
+}
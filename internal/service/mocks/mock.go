@@ -5,7 +5,9 @@
 package mock_service
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 	models "userbalance/internal/models"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,117 +36,1111 @@ func (m *MockControl) EXPECT() *MockControlMockRecorder {
 	return m.recorder
 }
 
+// Batch mocks base method.
+func (m *MockControl) Batch(ctx context.Context, requestBatch *models.RequestBatch) (*models.BatchResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Batch", ctx, requestBatch)
+	ret0, _ := ret[0].(*models.BatchResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Batch indicates an expected call of Batch.
+func (mr *MockControlMockRecorder) Batch(ctx, requestBatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*MockControl)(nil).Batch), ctx, requestBatch)
+}
+
 // CancelReservation mocks base method.
-func (m *MockControl) CancelReservation(transaction *models.Transaction) error {
+func (m *MockControl) CancelReservation(ctx context.Context, transaction *models.Transaction) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CancelReservation", transaction)
+	ret := m.ctrl.Call(m, "CancelReservation", ctx, transaction)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CancelReservation indicates an expected call of CancelReservation.
-func (mr *MockControlMockRecorder) CancelReservation(transaction interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) CancelReservation(ctx, transaction interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelReservation", reflect.TypeOf((*MockControl)(nil).CancelReservation), transaction)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelReservation", reflect.TypeOf((*MockControl)(nil).CancelReservation), ctx, transaction)
 }
 
 // Confirmation mocks base method.
-func (m *MockControl) Confirmation(transaction *models.Transaction) error {
+func (m *MockControl) Confirmation(ctx context.Context, transaction *models.Transaction) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Confirmation", transaction)
+	ret := m.ctrl.Call(m, "Confirmation", ctx, transaction)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Confirmation indicates an expected call of Confirmation.
-func (mr *MockControlMockRecorder) Confirmation(transaction interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) Confirmation(ctx, transaction interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirmation", reflect.TypeOf((*MockControl)(nil).Confirmation), transaction)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirmation", reflect.TypeOf((*MockControl)(nil).Confirmation), ctx, transaction)
 }
 
 // CreateReport mocks base method.
-func (m *MockControl) CreateReport(requestReport *models.RequestReport) (string, error) {
+func (m *MockControl) CreateReport(ctx context.Context, requestReport *models.RequestReport) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateReport", requestReport)
+	ret := m.ctrl.Call(m, "CreateReport", ctx, requestReport)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateReport indicates an expected call of CreateReport.
-func (mr *MockControlMockRecorder) CreateReport(requestReport interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) CreateReport(ctx, requestReport interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReport", reflect.TypeOf((*MockControl)(nil).CreateReport), requestReport)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReport", reflect.TypeOf((*MockControl)(nil).CreateReport), ctx, requestReport)
 }
 
 // GetBalance mocks base method.
-func (m *MockControl) GetBalance(userId int) (*models.User, error) {
+func (m *MockControl) GetBalance(ctx context.Context, userId int) (*models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBalance", userId)
+	ret := m.ctrl.Call(m, "GetBalance", ctx, userId)
 	ret0, _ := ret[0].(*models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetBalance indicates an expected call of GetBalance.
-func (mr *MockControlMockRecorder) GetBalance(userId interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) GetBalance(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockControl)(nil).GetBalance), ctx, userId)
+}
+
+// GetBalanceAt mocks base method.
+func (m *MockControl) GetBalanceAt(ctx context.Context, userId int, at time.Time) (*models.BalanceAt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceAt", ctx, userId, at)
+	ret0, _ := ret[0].(*models.BalanceAt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceAt indicates an expected call of GetBalanceAt.
+func (mr *MockControlMockRecorder) GetBalanceAt(ctx, userId, at interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockControl)(nil).GetBalance), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceAt", reflect.TypeOf((*MockControl)(nil).GetBalanceAt), ctx, userId, at)
 }
 
 // GetHistory mocks base method.
-func (m *MockControl) GetHistory(requestHistory *models.RequestHistory) ([]models.History, error) {
+func (m *MockControl) GetHistory(ctx context.Context, requestHistory *models.RequestHistory) ([]models.History, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetHistory", requestHistory)
+	ret := m.ctrl.Call(m, "GetHistory", ctx, requestHistory)
 	ret0, _ := ret[0].([]models.History)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetHistory indicates an expected call of GetHistory.
-func (mr *MockControlMockRecorder) GetHistory(requestHistory interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) GetHistory(ctx, requestHistory interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockControl)(nil).GetHistory), requestHistory)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockControl)(nil).GetHistory), ctx, requestHistory)
 }
 
 // ReplenishmentBalance mocks base method.
-func (m *MockControl) ReplenishmentBalance(replenishment *models.Replenishment) error {
+func (m *MockControl) ReplenishmentBalance(ctx context.Context, replenishment *models.Replenishment) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReplenishmentBalance", replenishment)
+	ret := m.ctrl.Call(m, "ReplenishmentBalance", ctx, replenishment)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ReplenishmentBalance indicates an expected call of ReplenishmentBalance.
-func (mr *MockControlMockRecorder) ReplenishmentBalance(replenishment interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) ReplenishmentBalance(ctx, replenishment interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplenishmentBalance", reflect.TypeOf((*MockControl)(nil).ReplenishmentBalance), replenishment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplenishmentBalance", reflect.TypeOf((*MockControl)(nil).ReplenishmentBalance), ctx, replenishment)
 }
 
 // Reservation mocks base method.
-func (m *MockControl) Reservation(transaction *models.Transaction) error {
+func (m *MockControl) Reservation(ctx context.Context, transaction *models.Transaction) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Reservation", transaction)
+	ret := m.ctrl.Call(m, "Reservation", ctx, transaction)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Reservation indicates an expected call of Reservation.
-func (mr *MockControlMockRecorder) Reservation(transaction interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) Reservation(ctx, transaction interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reservation", reflect.TypeOf((*MockControl)(nil).Reservation), transaction)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reservation", reflect.TypeOf((*MockControl)(nil).Reservation), ctx, transaction)
 }
 
 // Transfer mocks base method.
-func (m *MockControl) Transfer(money *models.Money) error {
+func (m *MockControl) Transfer(ctx context.Context, money *models.Money) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Transfer", money)
+	ret := m.ctrl.Call(m, "Transfer", ctx, money)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Transfer indicates an expected call of Transfer.
-func (mr *MockControlMockRecorder) Transfer(money interface{}) *gomock.Call {
+func (mr *MockControlMockRecorder) Transfer(ctx, money interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockControl)(nil).Transfer), ctx, money)
+}
+
+// MockSnapshot is a mock of Snapshot interface.
+type MockSnapshot struct {
+	ctrl     *gomock.Controller
+	recorder *MockSnapshotMockRecorder
+}
+
+// MockSnapshotMockRecorder is the mock recorder for MockSnapshot.
+type MockSnapshotMockRecorder struct {
+	mock *MockSnapshot
+}
+
+// NewMockSnapshot creates a new mock instance.
+func NewMockSnapshot(ctrl *gomock.Controller) *MockSnapshot {
+	mock := &MockSnapshot{ctrl: ctrl}
+	mock.recorder = &MockSnapshotMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSnapshot) EXPECT() *MockSnapshotMockRecorder {
+	return m.recorder
+}
+
+// CreateSnapshot mocks base method.
+func (m *MockSnapshot) CreateSnapshot(ctx context.Context, takenAt time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSnapshot", ctx, takenAt)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSnapshot indicates an expected call of CreateSnapshot.
+func (mr *MockSnapshotMockRecorder) CreateSnapshot(ctx, takenAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSnapshot", reflect.TypeOf((*MockSnapshot)(nil).CreateSnapshot), ctx, takenAt)
+}
+
+// RebuildSnapshots mocks base method.
+func (m *MockSnapshot) RebuildSnapshots(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildSnapshots", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RebuildSnapshots indicates an expected call of RebuildSnapshots.
+func (mr *MockSnapshotMockRecorder) RebuildSnapshots(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildSnapshots", reflect.TypeOf((*MockSnapshot)(nil).RebuildSnapshots), ctx)
+}
+
+// Run mocks base method.
+func (m *MockSnapshot) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockSnapshotMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockSnapshot)(nil).Run), ctx)
+}
+
+// MockReconciliation is a mock of Reconciliation interface.
+type MockReconciliation struct {
+	ctrl     *gomock.Controller
+	recorder *MockReconciliationMockRecorder
+}
+
+// MockReconciliationMockRecorder is the mock recorder for MockReconciliation.
+type MockReconciliationMockRecorder struct {
+	mock *MockReconciliation
+}
+
+// NewMockReconciliation creates a new mock instance.
+func NewMockReconciliation(ctrl *gomock.Controller) *MockReconciliation {
+	mock := &MockReconciliation{ctrl: ctrl}
+	mock.recorder = &MockReconciliationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReconciliation) EXPECT() *MockReconciliationMockRecorder {
+	return m.recorder
+}
+
+// Reconcile mocks base method.
+func (m *MockReconciliation) Reconcile(ctx context.Context) (*models.ReconciliationReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile", ctx)
+	ret0, _ := ret[0].(*models.ReconciliationReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockReconciliationMockRecorder) Reconcile(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockReconciliation)(nil).Reconcile), ctx)
+}
+
+// Run mocks base method.
+func (m *MockReconciliation) Run(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx, interval)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockReconciliationMockRecorder) Run(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockReconciliation)(nil).Run), ctx, interval)
+}
+
+// MockAudit is a mock of Audit interface.
+type MockAudit struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditMockRecorder
+}
+
+// MockAuditMockRecorder is the mock recorder for MockAudit.
+type MockAuditMockRecorder struct {
+	mock *MockAudit
+}
+
+// NewMockAudit creates a new mock instance.
+func NewMockAudit(ctrl *gomock.Controller) *MockAudit {
+	mock := &MockAudit{ctrl: ctrl}
+	mock.recorder = &MockAuditMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAudit) EXPECT() *MockAuditMockRecorder {
+	return m.recorder
+}
+
+// Checkpoint mocks base method.
+func (m *MockAudit) Checkpoint(ctx context.Context) (*models.Checkpoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkpoint", ctx)
+	ret0, _ := ret[0].(*models.Checkpoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Checkpoint indicates an expected call of Checkpoint.
+func (mr *MockAuditMockRecorder) Checkpoint(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkpoint", reflect.TypeOf((*MockAudit)(nil).Checkpoint), ctx)
+}
+
+// Run mocks base method.
+func (m *MockAudit) Run(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx, interval)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockAuditMockRecorder) Run(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockAudit)(nil).Run), ctx, interval)
+}
+
+// Verify mocks base method.
+func (m *MockAudit) Verify(ctx context.Context) (*models.AuditReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx)
+	ret0, _ := ret[0].(*models.AuditReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockAuditMockRecorder) Verify(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockAudit)(nil).Verify), ctx)
+}
+
+// MockHealth is a mock of Health interface.
+type MockHealth struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthMockRecorder
+}
+
+// MockHealthMockRecorder is the mock recorder for MockHealth.
+type MockHealthMockRecorder struct {
+	mock *MockHealth
+}
+
+// NewMockHealth creates a new mock instance.
+func NewMockHealth(ctrl *gomock.Controller) *MockHealth {
+	mock := &MockHealth{ctrl: ctrl}
+	mock.recorder = &MockHealthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealth) EXPECT() *MockHealthMockRecorder {
+	return m.recorder
+}
+
+// Drain mocks base method.
+func (m *MockHealth) Drain() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Drain")
+}
+
+// Drain indicates an expected call of Drain.
+func (mr *MockHealthMockRecorder) Drain() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Drain", reflect.TypeOf((*MockHealth)(nil).Drain))
+}
+
+// Go mocks base method.
+func (m *MockHealth) Go(name string, fn func()) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Go", name, fn)
+}
+
+// Go indicates an expected call of Go.
+func (mr *MockHealthMockRecorder) Go(name, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Go", reflect.TypeOf((*MockHealth)(nil).Go), name, fn)
+}
+
+// Ready mocks base method.
+func (m *MockHealth) Ready(ctx context.Context) *models.Readiness {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ready", ctx)
+	ret0, _ := ret[0].(*models.Readiness)
+	return ret0
+}
+
+// Ready indicates an expected call of Ready.
+func (mr *MockHealthMockRecorder) Ready(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ready", reflect.TypeOf((*MockHealth)(nil).Ready), ctx)
+}
+
+// MockAuth is a mock of Auth interface.
+type MockAuth struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthMockRecorder
+}
+
+// MockAuthMockRecorder is the mock recorder for MockAuth.
+type MockAuthMockRecorder struct {
+	mock *MockAuth
+}
+
+// NewMockAuth creates a new mock instance.
+func NewMockAuth(ctrl *gomock.Controller) *MockAuth {
+	mock := &MockAuth{ctrl: ctrl}
+	mock.recorder = &MockAuthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuth) EXPECT() *MockAuthMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateAPIKey mocks base method.
+func (m *MockAuth) AuthenticateAPIKey(ctx context.Context, value string) (*models.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateAPIKey", ctx, value)
+	ret0, _ := ret[0].(*models.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateAPIKey indicates an expected call of AuthenticateAPIKey.
+func (mr *MockAuthMockRecorder) AuthenticateAPIKey(ctx, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateAPIKey", reflect.TypeOf((*MockAuth)(nil).AuthenticateAPIKey), ctx, value)
+}
+
+// AuthenticateCertificate mocks base method.
+func (m *MockAuth) AuthenticateCertificate(ctx context.Context, commonName string) (*models.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateCertificate", ctx, commonName)
+	ret0, _ := ret[0].(*models.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateCertificate indicates an expected call of AuthenticateCertificate.
+func (mr *MockAuthMockRecorder) AuthenticateCertificate(ctx, commonName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateCertificate", reflect.TypeOf((*MockAuth)(nil).AuthenticateCertificate), ctx, commonName)
+}
+
+// AuthenticateToken mocks base method.
+func (m *MockAuth) AuthenticateToken(ctx context.Context, token string) (*models.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateToken", ctx, token)
+	ret0, _ := ret[0].(*models.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateToken indicates an expected call of AuthenticateToken.
+func (mr *MockAuthMockRecorder) AuthenticateToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateToken", reflect.TypeOf((*MockAuth)(nil).AuthenticateToken), ctx, token)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAuth) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.IssuedAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, key)
+	ret0, _ := ret[0].(*models.IssuedAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAuthMockRecorder) CreateAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAuth)(nil).CreateAPIKey), ctx, key)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockAuth) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockAuthMockRecorder) GetAPIKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockAuth)(nil).GetAPIKeys), ctx)
+}
+
+// Required mocks base method.
+func (m *MockAuth) Required() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Required")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Required indicates an expected call of Required.
+func (mr *MockAuthMockRecorder) Required() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Required", reflect.TypeOf((*MockAuth)(nil).Required))
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAuth) RevokeAPIKey(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAuthMockRecorder) RevokeAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAuth)(nil).RevokeAPIKey), ctx, id)
+}
+
+// RotateSigningSecret mocks base method.
+func (m *MockAuth) RotateSigningSecret(ctx context.Context, id int) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSigningSecret", ctx, id)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSigningSecret indicates an expected call of RotateSigningSecret.
+func (mr *MockAuthMockRecorder) RotateSigningSecret(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSigningSecret", reflect.TypeOf((*MockAuth)(nil).RotateSigningSecret), ctx, id)
+}
+
+// MockRateLimit is a mock of RateLimit interface.
+type MockRateLimit struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateLimitMockRecorder
+}
+
+// MockRateLimitMockRecorder is the mock recorder for MockRateLimit.
+type MockRateLimitMockRecorder struct {
+	mock *MockRateLimit
+}
+
+// NewMockRateLimit creates a new mock instance.
+func NewMockRateLimit(ctrl *gomock.Controller) *MockRateLimit {
+	mock := &MockRateLimit{ctrl: ctrl}
+	mock.recorder = &MockRateLimitMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRateLimit) EXPECT() *MockRateLimitMockRecorder {
+	return m.recorder
+}
+
+// AllowClient mocks base method.
+func (m *MockRateLimit) AllowClient(ctx context.Context, client string) (bool, time.Duration) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllowClient", ctx, client)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(time.Duration)
+	return ret0, ret1
+}
+
+// AllowClient indicates an expected call of AllowClient.
+func (mr *MockRateLimitMockRecorder) AllowClient(ctx, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowClient", reflect.TypeOf((*MockRateLimit)(nil).AllowClient), ctx, client)
+}
+
+// AllowUser mocks base method.
+func (m *MockRateLimit) AllowUser(ctx context.Context, userId int) (bool, time.Duration) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllowUser", ctx, userId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(time.Duration)
+	return ret0, ret1
+}
+
+// AllowUser indicates an expected call of AllowUser.
+func (mr *MockRateLimitMockRecorder) AllowUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowUser", reflect.TypeOf((*MockRateLimit)(nil).AllowUser), ctx, userId)
+}
+
+// MaxRequestBody mocks base method.
+func (m *MockRateLimit) MaxRequestBody() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxRequestBody")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// MaxRequestBody indicates an expected call of MaxRequestBody.
+func (mr *MockRateLimitMockRecorder) MaxRequestBody() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxRequestBody", reflect.TypeOf((*MockRateLimit)(nil).MaxRequestBody))
+}
+
+// MockSignature is a mock of Signature interface.
+type MockSignature struct {
+	ctrl     *gomock.Controller
+	recorder *MockSignatureMockRecorder
+}
+
+// MockSignatureMockRecorder is the mock recorder for MockSignature.
+type MockSignatureMockRecorder struct {
+	mock *MockSignature
+}
+
+// NewMockSignature creates a new mock instance.
+func NewMockSignature(ctrl *gomock.Controller) *MockSignature {
+	mock := &MockSignature{ctrl: ctrl}
+	mock.recorder = &MockSignatureMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSignature) EXPECT() *MockSignatureMockRecorder {
+	return m.recorder
+}
+
+// MaxRequestBody mocks base method.
+func (m *MockSignature) MaxRequestBody() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxRequestBody")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// MaxRequestBody indicates an expected call of MaxRequestBody.
+func (mr *MockSignatureMockRecorder) MaxRequestBody() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxRequestBody", reflect.TypeOf((*MockSignature)(nil).MaxRequestBody))
+}
+
+// SignatureRequired mocks base method.
+func (m *MockSignature) SignatureRequired(route string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignatureRequired", route)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// SignatureRequired indicates an expected call of SignatureRequired.
+func (mr *MockSignatureMockRecorder) SignatureRequired(route interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignatureRequired", reflect.TypeOf((*MockSignature)(nil).SignatureRequired), route)
+}
+
+// VerifySignature mocks base method.
+func (m *MockSignature) VerifySignature(ctx context.Context, principal *models.Principal, request *models.SignedRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySignature", ctx, principal, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifySignature indicates an expected call of VerifySignature.
+func (mr *MockSignatureMockRecorder) VerifySignature(ctx, principal, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySignature", reflect.TypeOf((*MockSignature)(nil).VerifySignature), ctx, principal, request)
+}
+
+// MockLimits is a mock of Limits interface.
+type MockLimits struct {
+	ctrl     *gomock.Controller
+	recorder *MockLimitsMockRecorder
+}
+
+// MockLimitsMockRecorder is the mock recorder for MockLimits.
+type MockLimitsMockRecorder struct {
+	mock *MockLimits
+}
+
+// NewMockLimits creates a new mock instance.
+func NewMockLimits(ctrl *gomock.Controller) *MockLimits {
+	mock := &MockLimits{ctrl: ctrl}
+	mock.recorder = &MockLimitsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLimits) EXPECT() *MockLimitsMockRecorder {
+	return m.recorder
+}
+
+// DeleteSpendingLimit mocks base method.
+func (m *MockLimits) DeleteSpendingLimit(ctx context.Context, userId int, operation string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSpendingLimit", ctx, userId, operation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSpendingLimit indicates an expected call of DeleteSpendingLimit.
+func (mr *MockLimitsMockRecorder) DeleteSpendingLimit(ctx, userId, operation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSpendingLimit", reflect.TypeOf((*MockLimits)(nil).DeleteSpendingLimit), ctx, userId, operation)
+}
+
+// GetSpendingLimits mocks base method.
+func (m *MockLimits) GetSpendingLimits(ctx context.Context, userId int) ([]models.SpendingLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSpendingLimits", ctx, userId)
+	ret0, _ := ret[0].([]models.SpendingLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSpendingLimits indicates an expected call of GetSpendingLimits.
+func (mr *MockLimitsMockRecorder) GetSpendingLimits(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpendingLimits", reflect.TypeOf((*MockLimits)(nil).GetSpendingLimits), ctx, userId)
+}
+
+// SetSpendingLimit mocks base method.
+func (m *MockLimits) SetSpendingLimit(ctx context.Context, limit *models.SpendingLimit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSpendingLimit", ctx, limit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSpendingLimit indicates an expected call of SetSpendingLimit.
+func (mr *MockLimitsMockRecorder) SetSpendingLimit(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSpendingLimit", reflect.TypeOf((*MockLimits)(nil).SetSpendingLimit), ctx, limit)
+}
+
+// MockRisk is a mock of Risk interface.
+type MockRisk struct {
+	ctrl     *gomock.Controller
+	recorder *MockRiskMockRecorder
+}
+
+// MockRiskMockRecorder is the mock recorder for MockRisk.
+type MockRiskMockRecorder struct {
+	mock *MockRisk
+}
+
+// NewMockRisk creates a new mock instance.
+func NewMockRisk(ctrl *gomock.Controller) *MockRisk {
+	mock := &MockRisk{ctrl: ctrl}
+	mock.recorder = &MockRiskMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRisk) EXPECT() *MockRiskMockRecorder {
+	return m.recorder
+}
+
+// ApproveRiskReview mocks base method.
+func (m *MockRisk) ApproveRiskReview(ctx context.Context, id int, resolvedBy string) (*models.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveRiskReview", ctx, id, resolvedBy)
+	ret0, _ := ret[0].(*models.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveRiskReview indicates an expected call of ApproveRiskReview.
+func (mr *MockRiskMockRecorder) ApproveRiskReview(ctx, id, resolvedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveRiskReview", reflect.TypeOf((*MockRisk)(nil).ApproveRiskReview), ctx, id, resolvedBy)
+}
+
+// GetRiskDecisions mocks base method.
+func (m *MockRisk) GetRiskDecisions(ctx context.Context, filter *models.RiskFilter) ([]models.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRiskDecisions", ctx, filter)
+	ret0, _ := ret[0].([]models.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRiskDecisions indicates an expected call of GetRiskDecisions.
+func (mr *MockRiskMockRecorder) GetRiskDecisions(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRiskDecisions", reflect.TypeOf((*MockRisk)(nil).GetRiskDecisions), ctx, filter)
+}
+
+// RejectRiskReview mocks base method.
+func (m *MockRisk) RejectRiskReview(ctx context.Context, id int, resolvedBy string) (*models.RiskDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectRiskReview", ctx, id, resolvedBy)
+	ret0, _ := ret[0].(*models.RiskDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectRiskReview indicates an expected call of RejectRiskReview.
+func (mr *MockRiskMockRecorder) RejectRiskReview(ctx, id, resolvedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectRiskReview", reflect.TypeOf((*MockRisk)(nil).RejectRiskReview), ctx, id, resolvedBy)
+}
+
+// MockScheduler is a mock of Scheduler interface.
+type MockScheduler struct {
+	ctrl     *gomock.Controller
+	recorder *MockSchedulerMockRecorder
+}
+
+// MockSchedulerMockRecorder is the mock recorder for MockScheduler.
+type MockSchedulerMockRecorder struct {
+	mock *MockScheduler
+}
+
+// NewMockScheduler creates a new mock instance.
+func NewMockScheduler(ctrl *gomock.Controller) *MockScheduler {
+	mock := &MockScheduler{ctrl: ctrl}
+	mock.recorder = &MockSchedulerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduler) EXPECT() *MockSchedulerMockRecorder {
+	return m.recorder
+}
+
+// CancelSchedule mocks base method.
+func (m *MockScheduler) CancelSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSchedule", ctx, id)
+	ret0, _ := ret[0].(*models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelSchedule indicates an expected call of CancelSchedule.
+func (mr *MockSchedulerMockRecorder) CancelSchedule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSchedule", reflect.TypeOf((*MockScheduler)(nil).CancelSchedule), ctx, id)
+}
+
+// CreateSchedule mocks base method.
+func (m *MockScheduler) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSchedule", ctx, schedule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSchedule indicates an expected call of CreateSchedule.
+func (mr *MockSchedulerMockRecorder) CreateSchedule(ctx, schedule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSchedule", reflect.TypeOf((*MockScheduler)(nil).CreateSchedule), ctx, schedule)
+}
+
+// GetSchedule mocks base method.
+func (m *MockScheduler) GetSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedule", ctx, id)
+	ret0, _ := ret[0].(*models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedule indicates an expected call of GetSchedule.
+func (mr *MockSchedulerMockRecorder) GetSchedule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedule", reflect.TypeOf((*MockScheduler)(nil).GetSchedule), ctx, id)
+}
+
+// GetScheduleRuns mocks base method.
+func (m *MockScheduler) GetScheduleRuns(ctx context.Context, id, limit int) ([]models.ScheduleRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduleRuns", ctx, id, limit)
+	ret0, _ := ret[0].([]models.ScheduleRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduleRuns indicates an expected call of GetScheduleRuns.
+func (mr *MockSchedulerMockRecorder) GetScheduleRuns(ctx, id, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduleRuns", reflect.TypeOf((*MockScheduler)(nil).GetScheduleRuns), ctx, id, limit)
+}
+
+// GetSchedules mocks base method.
+func (m *MockScheduler) GetSchedules(ctx context.Context, filter *models.ScheduleFilter) ([]models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedules", ctx, filter)
+	ret0, _ := ret[0].([]models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedules indicates an expected call of GetSchedules.
+func (mr *MockSchedulerMockRecorder) GetSchedules(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedules", reflect.TypeOf((*MockScheduler)(nil).GetSchedules), ctx, filter)
+}
+
+// PauseSchedule mocks base method.
+func (m *MockScheduler) PauseSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseSchedule", ctx, id)
+	ret0, _ := ret[0].(*models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PauseSchedule indicates an expected call of PauseSchedule.
+func (mr *MockSchedulerMockRecorder) PauseSchedule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseSchedule", reflect.TypeOf((*MockScheduler)(nil).PauseSchedule), ctx, id)
+}
+
+// ResumeSchedule mocks base method.
+func (m *MockScheduler) ResumeSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResumeSchedule", ctx, id)
+	ret0, _ := ret[0].(*models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResumeSchedule indicates an expected call of ResumeSchedule.
+func (mr *MockSchedulerMockRecorder) ResumeSchedule(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResumeSchedule", reflect.TypeOf((*MockScheduler)(nil).ResumeSchedule), ctx, id)
+}
+
+// Run mocks base method.
+func (m *MockScheduler) Run(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx, interval)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockSchedulerMockRecorder) Run(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockScheduler)(nil).Run), ctx, interval)
+}
+
+// RunDueSchedules mocks base method.
+func (m *MockScheduler) RunDueSchedules(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunDueSchedules", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunDueSchedules indicates an expected call of RunDueSchedules.
+func (mr *MockSchedulerMockRecorder) RunDueSchedules(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunDueSchedules", reflect.TypeOf((*MockScheduler)(nil).RunDueSchedules), ctx)
+}
+
+// MockPendingTransfers is a mock of PendingTransfers interface.
+type MockPendingTransfers struct {
+	ctrl     *gomock.Controller
+	recorder *MockPendingTransfersMockRecorder
+}
+
+// MockPendingTransfersMockRecorder is the mock recorder for MockPendingTransfers.
+type MockPendingTransfersMockRecorder struct {
+	mock *MockPendingTransfers
+}
+
+// NewMockPendingTransfers creates a new mock instance.
+func NewMockPendingTransfers(ctrl *gomock.Controller) *MockPendingTransfers {
+	mock := &MockPendingTransfers{ctrl: ctrl}
+	mock.recorder = &MockPendingTransfersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPendingTransfers) EXPECT() *MockPendingTransfersMockRecorder {
+	return m.recorder
+}
+
+// AcceptPendingTransfer mocks base method.
+func (m *MockPendingTransfers) AcceptPendingTransfer(ctx context.Context, id, userId int) (*models.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptPendingTransfer", ctx, id, userId)
+	ret0, _ := ret[0].(*models.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptPendingTransfer indicates an expected call of AcceptPendingTransfer.
+func (mr *MockPendingTransfersMockRecorder) AcceptPendingTransfer(ctx, id, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptPendingTransfer", reflect.TypeOf((*MockPendingTransfers)(nil).AcceptPendingTransfer), ctx, id, userId)
+}
+
+// CreatePendingTransfer mocks base method.
+func (m *MockPendingTransfers) CreatePendingTransfer(ctx context.Context, money *models.Money) (*models.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePendingTransfer", ctx, money)
+	ret0, _ := ret[0].(*models.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePendingTransfer indicates an expected call of CreatePendingTransfer.
+func (mr *MockPendingTransfersMockRecorder) CreatePendingTransfer(ctx, money interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePendingTransfer", reflect.TypeOf((*MockPendingTransfers)(nil).CreatePendingTransfer), ctx, money)
+}
+
+// DeclinePendingTransfer mocks base method.
+func (m *MockPendingTransfers) DeclinePendingTransfer(ctx context.Context, id, userId int) (*models.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeclinePendingTransfer", ctx, id, userId)
+	ret0, _ := ret[0].(*models.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeclinePendingTransfer indicates an expected call of DeclinePendingTransfer.
+func (mr *MockPendingTransfersMockRecorder) DeclinePendingTransfer(ctx, id, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeclinePendingTransfer", reflect.TypeOf((*MockPendingTransfers)(nil).DeclinePendingTransfer), ctx, id, userId)
+}
+
+// ExpirePendingTransfers mocks base method.
+func (m *MockPendingTransfers) ExpirePendingTransfers(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpirePendingTransfers", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpirePendingTransfers indicates an expected call of ExpirePendingTransfers.
+func (mr *MockPendingTransfersMockRecorder) ExpirePendingTransfers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpirePendingTransfers", reflect.TypeOf((*MockPendingTransfers)(nil).ExpirePendingTransfers), ctx)
+}
+
+// GetPendingTransfer mocks base method.
+func (m *MockPendingTransfers) GetPendingTransfer(ctx context.Context, id int) (*models.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingTransfer", ctx, id)
+	ret0, _ := ret[0].(*models.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingTransfer indicates an expected call of GetPendingTransfer.
+func (mr *MockPendingTransfersMockRecorder) GetPendingTransfer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingTransfer", reflect.TypeOf((*MockPendingTransfers)(nil).GetPendingTransfer), ctx, id)
+}
+
+// GetPendingTransfers mocks base method.
+func (m *MockPendingTransfers) GetPendingTransfers(ctx context.Context, filter *models.PendingTransferFilter) ([]models.PendingTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingTransfers", ctx, filter)
+	ret0, _ := ret[0].([]models.PendingTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingTransfers indicates an expected call of GetPendingTransfers.
+func (mr *MockPendingTransfersMockRecorder) GetPendingTransfers(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingTransfers", reflect.TypeOf((*MockPendingTransfers)(nil).GetPendingTransfers), ctx, filter)
+}
+
+// Run mocks base method.
+func (m *MockPendingTransfers) Run(ctx context.Context, interval time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx, interval)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockPendingTransfersMockRecorder) Run(ctx, interval interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockPendingTransfers)(nil).Run), ctx, interval)
+}
+
+// MockFees is a mock of Fees interface.
+type MockFees struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeesMockRecorder
+}
+
+// MockFeesMockRecorder is the mock recorder for MockFees.
+type MockFeesMockRecorder struct {
+	mock *MockFees
+}
+
+// NewMockFees creates a new mock instance.
+func NewMockFees(ctrl *gomock.Controller) *MockFees {
+	mock := &MockFees{ctrl: ctrl}
+	mock.recorder = &MockFeesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFees) EXPECT() *MockFeesMockRecorder {
+	return m.recorder
+}
+
+// QuoteFee mocks base method.
+func (m *MockFees) QuoteFee(ctx context.Context, request *models.FeeRequest) (*models.FeeQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuoteFee", ctx, request)
+	ret0, _ := ret[0].(*models.FeeQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuoteFee indicates an expected call of QuoteFee.
+func (mr *MockFeesMockRecorder) QuoteFee(ctx, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockControl)(nil).Transfer), money)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuoteFee", reflect.TypeOf((*MockFees)(nil).QuoteFee), ctx, request)
 }
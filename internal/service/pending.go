@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"userbalance/internal/config"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+)
+
+var (
+	ErrPendingTransferNotFound = errors.New("перевод, ожидающий подтверждения, не найден")
+	ErrPendingTransferResolved = errors.New("перевод не ожидает подтверждения")
+	ErrNotTransferRecipient    = errors.New("перевод адресован другому пользователю")
+)
+
+// pendingTransfersLimit - количество переводов, возвращаемых по умолчанию
+const pendingTransfersLimit = 100
+
+// PendingTransferService выполняет переводы в два этапа: при создании сумма удерживается на резервном
+// счете отправителя, после подтверждения получателем зачисляется ему, после отклонения либо по истечении
+// срока pendingtransferttl возвращается отправителю. Каждый этап записывается в историю обоих пользователей
+type PendingTransferService struct {
+	control *ControlService
+}
+
+func NewPendingTransferService(control *ControlService) *PendingTransferService {
+	return &PendingTransferService{control: control}
+}
+
+// CreatePendingTransfer удерживает сумму перевода у отправителя с теми же проверками остатка,
+// лимитов и на мошенничество, что и Transfer
+func (s *PendingTransferService) CreatePendingTransfer(ctx context.Context, money *models.Money) (transfer *models.PendingTransfer, err error) {
+	defer func() { observe(ctx, models.OperationTransfer, money.Amount, err) }()
+
+	ctx, cancel := s.control.withTimeout(ctx)
+	defer cancel()
+
+	err = s.control.withinTx(ctx, func(repo repository.Control) error {
+		var err error
+		transfer, err = s.control.holdTransferTx(ctx, repo, money)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "перевод ожидает подтверждения", "id", transfer.ID, "fromuserid", transfer.FromUserID,
+		"touserid", transfer.ToUserID, "expiresat", transfer.ExpiresAt)
+	return transfer, nil
+}
+
+func (s *PendingTransferService) GetPendingTransfer(ctx context.Context, id int) (*models.PendingTransfer, error) {
+	transfer, err := s.control.repo.GetPendingTransfer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfer == nil {
+		return nil, ErrPendingTransferNotFound
+	}
+	return transfer, nil
+}
+
+func (s *PendingTransferService) GetPendingTransfers(ctx context.Context, filter *models.PendingTransferFilter) ([]models.PendingTransfer, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = pendingTransfersLimit
+	}
+	return s.control.repo.GetPendingTransfers(ctx, filter)
+}
+
+// AcceptPendingTransfer зачисляет удержанную сумму получателю userId. Перевод с истекшим сроком
+// подтвердить нельзя: сумма возвращается отправителю
+func (s *PendingTransferService) AcceptPendingTransfer(ctx context.Context, id int, userId int) (*models.PendingTransfer, error) {
+	return s.resolve(ctx, id, userId, models.PendingTransferAccepted)
+}
+
+// DeclinePendingTransfer возвращает удержанную сумму отправителю по решению получателя userId
+func (s *PendingTransferService) DeclinePendingTransfer(ctx context.Context, id int, userId int) (*models.PendingTransfer, error) {
+	return s.resolve(ctx, id, userId, models.PendingTransferDeclined)
+}
+
+// resolve завершает перевод id в состоянии status по решению получателя userId
+func (s *PendingTransferService) resolve(ctx context.Context, id int, userId int, status string) (*models.PendingTransfer, error) {
+	ctx, cancel := s.control.withTimeout(ctx)
+	defer cancel()
+
+	var transfer *models.PendingTransfer
+	var executed bool
+
+	err := s.control.uow.WithinTx(ctx, func(repo repository.Control) error {
+		var err error
+		executed = false
+		if transfer, err = repo.GetPendingTransferForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if transfer == nil {
+			return ErrPendingTransferNotFound
+		}
+		if transfer.ToUserID != userId {
+			return ErrNotTransferRecipient
+		}
+		if transfer.Status != models.PendingTransferPending {
+			return fmt.Errorf("%w: %s", ErrPendingTransferResolved, transfer.Status)
+		}
+		if status == models.PendingTransferAccepted && !time.Now().Before(transfer.ExpiresAt) {
+			return fmt.Errorf("%w: срок подтверждения истек", ErrPendingTransferResolved)
+		}
+
+		executed = true
+		return resolveTransferTx(ctx, repo, transfer, status)
+	})
+	if executed {
+		observe(ctx, resolveOperations[status], transfer.Amount, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "перевод, ожидающий подтверждения, завершен", "id", id, "status", status)
+	return transfer, nil
+}
+
+// ExpirePendingTransfers возвращает отправителям суммы переводов, не подтвержденных в срок,
+// и возвращает количество таких переводов
+func (s *PendingTransferService) ExpirePendingTransfers(ctx context.Context) (int, error) {
+	var expired int
+	for {
+		done, err := s.expireNext(ctx)
+		if err != nil || !done {
+			return expired, err
+		}
+		expired++
+	}
+}
+
+// expireNext возвращает сумму одного перевода с истекшим сроком и возвращает false, если таких нет
+func (s *PendingTransferService) expireNext(ctx context.Context) (bool, error) {
+	var transfer *models.PendingTransfer
+
+	txCtx, cancel := s.control.withTimeout(ctx)
+	defer cancel()
+
+	err := s.control.uow.WithinTx(txCtx, func(repo repository.Control) error {
+		var err error
+		if transfer, err = repo.GetExpiredPendingTransferForUpdate(txCtx, time.Now()); err != nil || transfer == nil {
+			return err
+		}
+		return resolveTransferTx(txCtx, repo, transfer, models.PendingTransferExpired)
+	})
+	if transfer == nil {
+		return false, err
+	}
+
+	observe(ctx, models.OperationTransferExpire, transfer.Amount, err)
+	if err != nil {
+		return false, err
+	}
+
+	logger.Info("перевод не подтвержден в срок, средства возвращены", "id", transfer.ID,
+		"fromuserid", transfer.FromUserID, "amount", transfer.Amount)
+	return true, nil
+}
+
+// Run с интервалом interval возвращает суммы переводов, не подтвержденных в срок, пока не будет отменен ctx
+func (s *PendingTransferService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if expired, err := s.ExpirePendingTransfers(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("ошибка при возврате неподтвержденных переводов", "error", err, "expired", expired)
+		}
+	}
+}
+
+// resolveOperations - операции, которыми завершается перевод в каждом из состояний
+var resolveOperations = map[string]string{
+	models.PendingTransferAccepted: models.OperationTransferAccept,
+	models.PendingTransferDeclined: models.OperationTransferDecline,
+	models.PendingTransferExpired:  models.OperationTransferExpire,
+}
+
+// holdTransferTx удерживает сумму перевода на резервном счете отправителя и сохраняет перевод,
+// ожидающий подтверждения. Удержание записывается в журнал как перевод и учитывается в лимитах переводов,
+// пока перевод не отклонен и не возвращен по сроку: возврат записывается обратным переводом
+func (c *ControlService) holdTransferTx(ctx context.Context, repo repository.Control, money *models.Money) (*models.PendingTransfer, error) {
+	var err error
+	var fromUser *models.User
+	var reservBalance int
+
+	date, _ := time.Parse(layout, money.Date)
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	quote := c.quoteFee(money.FromUserID, &models.FeeRequest{Operation: models.OperationTransfer, Amount: money.Amount})
+
+	// строка получателя тоже блокируется: в его цепочку истории добавляется запись, и без
+	// блокировки параллельные переводы ему ссылались бы на одну и ту же предыдущую запись.
+	// Строки блокируются в порядке возрастания id, как и при переводе
+	users, err := c.lockTx(ctx, repo, quote, money.FromUserID, money.ToUserID)
+	if err != nil {
+		return nil, err
+	}
+	fromUser = users[money.FromUserID]
+
+	if fromUser.Balance-quote.Total < 0 {
+		return nil, ErrInsufficientFunds
+	}
+	if err = c.checkLimitTx(ctx, repo, money.FromUserID, models.OperationTransfer, money.Amount); err != nil {
+		return nil, err
+	}
+	if err = c.screenTx(ctx, repo, &models.RiskOperation{
+		Type:     models.OperationTransfer,
+		UserID:   money.FromUserID,
+		ToUserID: money.ToUserID,
+		Amount:   money.Amount,
+		Date:     money.Date,
+		Pending:  true,
+		Note:     money.Note,
+	}); err != nil {
+		return nil, err
+	}
+
+	if reservBalance, err = repo.GetBalanceReserveAccounts(ctx, money.FromUserID); err != nil {
+		return nil, err
+	}
+	if err = repo.UpdateBalance(ctx, money.FromUserID, fromUser.Balance-quote.Total); err != nil {
+		return nil, err
+	}
+	if err = repo.UpdateMoneyReserveAccounts(ctx, money.FromUserID, reservBalance+money.Amount); err != nil {
+		return nil, err
+	}
+
+	transfer := &models.PendingTransfer{
+		FromUserID: money.FromUserID,
+		ToUserID:   money.ToUserID,
+		Amount:     money.Amount,
+		Status:     models.PendingTransferPending,
+		ExpiresAt:  time.Now().Add(c.pendingTransferTTL()),
+	}
+	if err = repo.InsertPendingTransfer(ctx, transfer); err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Перевод №%d пользователю %d ожидает подтверждения", transfer.ID, money.ToUserID)
+	if err = repo.InsertLog(ctx, money.FromUserID, date, money.Amount, description, money.Note); err != nil {
+		return nil, err
+	}
+	if err = repo.InsertLog(ctx, money.ToUserID, date, money.Amount,
+		fmt.Sprintf("Перевод №%d от пользователя %d ожидает подтверждения", transfer.ID, money.FromUserID), money.Note); err != nil {
+		return nil, err
+	}
+
+	if err = journalTx(ctx, repo, models.OperationTransfer,
+		models.LedgerEntry{UserID: money.FromUserID, Account: models.AccountMain, Amount: -money.Amount, Description: description, CounterpartyID: money.ToUserID},
+		models.LedgerEntry{UserID: money.FromUserID, Account: models.AccountReserve, Amount: money.Amount, Description: description, CounterpartyID: money.ToUserID}); err != nil {
+		return nil, err
+	}
+
+	// комиссия взимается при создании перевода и не возвращается при его отклонении или возврате
+	return transfer, c.chargeFeeTx(ctx, repo, money.FromUserID, quote, date, fmt.Sprintf("Комиссия за перевод №%d пользователю %d", transfer.ID, money.ToUserID))
+}
+
+// resolveTransferTx снимает удержание перевода transfer и переводит его в состояние status:
+// accepted - сумма зачисляется получателю, declined и expired - возвращается отправителю
+func resolveTransferTx(ctx context.Context, repo repository.Control, transfer *models.PendingTransfer, status string) error {
+	var err error
+	var fromUser, toUser *models.User
+	var reservBalance int
+
+	// строки блокируются в порядке возрастания id, как и при переводе
+	users := make(map[int]*models.User, 2)
+	for _, id := range ascending(transfer.FromUserID, transfer.ToUserID) {
+		var user *models.User
+		if user, err = repo.GetUserForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if user == nil {
+			return ErrUserNotFound
+		}
+		users[id] = user
+	}
+	fromUser, toUser = users[transfer.FromUserID], users[transfer.ToUserID]
+
+	if reservBalance, err = repo.GetBalanceReserveAccounts(ctx, transfer.FromUserID); err != nil {
+		return err
+	}
+	if err = repo.UpdateMoneyReserveAccounts(ctx, transfer.FromUserID, reservBalance-transfer.Amount); err != nil {
+		return err
+	}
+
+	transfer.Status = status
+	if err = repo.ResolvePendingTransfer(ctx, transfer); err != nil {
+		return err
+	}
+
+	date := time.Now()
+	operation := resolveOperations[status]
+	var fromDescription, toDescription string
+	var entries []models.LedgerEntry
+
+	switch status {
+	case models.PendingTransferAccepted:
+		if err = repo.UpdateBalance(ctx, toUser.Id, toUser.Balance+transfer.Amount); err != nil {
+			return err
+		}
+		fromDescription = fmt.Sprintf("Перевод №%d принят пользователем %d", transfer.ID, transfer.ToUserID)
+		toDescription = fmt.Sprintf("Перевод средств от пользователя %d, перевод №%d принят", transfer.FromUserID, transfer.ID)
+		entries = []models.LedgerEntry{
+			{UserID: transfer.FromUserID, Account: models.AccountReserve, Amount: -transfer.Amount, Description: fromDescription, CounterpartyID: transfer.ToUserID},
+			{UserID: transfer.ToUserID, Account: models.AccountMain, Amount: transfer.Amount, Description: toDescription, CounterpartyID: transfer.FromUserID},
+		}
+	default:
+		if err = repo.UpdateBalance(ctx, fromUser.Id, fromUser.Balance+transfer.Amount); err != nil {
+			return err
+		}
+		if status == models.PendingTransferDeclined {
+			fromDescription = fmt.Sprintf("Перевод №%d отклонен пользователем %d, средства возвращены", transfer.ID, transfer.ToUserID)
+			toDescription = fmt.Sprintf("Перевод №%d от пользователя %d отклонен", transfer.ID, transfer.FromUserID)
+		} else {
+			fromDescription = fmt.Sprintf("Перевод №%d не принят пользователем %d в срок, средства возвращены", transfer.ID, transfer.ToUserID)
+			toDescription = fmt.Sprintf("Перевод №%d от пользователя %d не принят в срок", transfer.ID, transfer.FromUserID)
+		}
+		// возврат записывается как перевод, обратный удержанию, поэтому в лимите переводов
+		// отправителя удержание и возврат взаимно погашаются
+		operation = models.OperationTransfer
+		entries = []models.LedgerEntry{
+			{UserID: transfer.FromUserID, Account: models.AccountReserve, Amount: -transfer.Amount, Description: fromDescription, CounterpartyID: transfer.ToUserID},
+			{UserID: transfer.FromUserID, Account: models.AccountMain, Amount: transfer.Amount, Description: fromDescription, CounterpartyID: transfer.ToUserID},
+		}
+	}
+
+	if err = repo.InsertLog(ctx, transfer.FromUserID, date, transfer.Amount, fromDescription, models.Note{}); err != nil {
+		return err
+	}
+	if err = repo.InsertLog(ctx, transfer.ToUserID, date, transfer.Amount, toDescription, models.Note{}); err != nil {
+		return err
+	}
+
+	return journalTx(ctx, repo, operation, entries...)
+}
+
+// pendingTransferTTL возвращает срок, в течение которого получатель может подтвердить перевод.
+// Без конфигурации действует значение по умолчанию
+func (c *ControlService) pendingTransferTTL() time.Duration {
+	conf := c.config()
+	if conf == nil {
+		conf = config.Default()
+	}
+	return time.Duration(conf.PendingTransferTTL) * time.Minute
+}
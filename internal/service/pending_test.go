@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"userbalance/internal/config"
+	"userbalance/internal/models"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingTransferService_CreatePendingTransfer(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		money        models.Money
+		fees         FeeCalculator
+		mockBehavior mockBehavior
+		wantErr      error
+	}{
+		{
+			name:  "OK",
+			money: models.Money{FromUserID: 1, ToUserID: 2, Amount: 100},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1000}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), 1).Return(50, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 900).Return(nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), 1, 150).Return(nil)
+				r.EXPECT().InsertPendingTransfer(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, transfer *models.PendingTransfer) error {
+						transfer.ID = 7
+						return nil
+					})
+				r.EXPECT().InsertLog(gomock.Any(), 1, gomock.Any(), 100, "Перевод №7 пользователю 2 ожидает подтверждения", models.Note{}).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 2, gomock.Any(), 100, "Перевод №7 от пользователя 1 ожидает подтверждения", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -100)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountReserve, 100)).Return(nil)
+			},
+		},
+
+		{
+			name:  "OK fee charged on hold",
+			money: models.Money{FromUserID: 1, ToUserID: 2, Amount: 100},
+			fees:  fees{fee: 10},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 110}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), 1).Return(0, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 0).Return(nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), 1, 100).Return(nil)
+				r.EXPECT().InsertPendingTransfer(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, transfer *models.PendingTransfer) error {
+						transfer.ID = 7
+						return nil
+					})
+				r.EXPECT().InsertLog(gomock.Any(), gomock.Any(), gomock.Any(), 100, gomock.Any(), models.Note{}).Return(nil).Times(2)
+				r.EXPECT().InsertLedger(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 100).Return(&models.User{Id: 100, Balance: 0}, nil).Times(2)
+				r.EXPECT().UpdateBalance(gomock.Any(), 100, 10).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, gomock.Any(), 10, "Комиссия за перевод №7 пользователю 2", models.Note{}).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 100, gomock.Any(), 10, "Комиссия от пользователя 1 по правилу test", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -10)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(100, models.AccountMain, 10)).Return(nil)
+			},
+		},
+
+		{
+			name:  "error recipient not found",
+			money: models.Money{FromUserID: 1, ToUserID: 2, Amount: 100},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1000}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(nil, nil)
+			},
+			wantErr: ErrUserNotFound,
+		},
+
+		{
+			name:  "error insufficient funds",
+			money: models.Money{FromUserID: 1, ToUserID: 2, Amount: 100},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 10}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+			},
+			wantErr: ErrInsufficientFunds,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			conf := &config.Config{PendingTransferTTL: 60, FeeUserID: 100}
+			s := NewPendingTransferService(NewControlService(repo, unitOfWork{repo}, conf, nil, testCase.fees))
+			got, err := s.CreatePendingTransfer(context.Background(), &testCase.money)
+
+			if testCase.wantErr != nil {
+				assert.True(t, errors.Is(err, testCase.wantErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 7, got.ID)
+			assert.Equal(t, models.PendingTransferPending, got.Status)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), got.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestPendingTransferService_resolve(t *testing.T) {
+	pending := func() *models.PendingTransfer {
+		return &models.PendingTransfer{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 100,
+			Status: models.PendingTransferPending, ExpiresAt: time.Now().Add(time.Hour)}
+	}
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		action       func(s *PendingTransferService) (*models.PendingTransfer, error)
+		mockBehavior mockBehavior
+		wantStatus   string
+		wantErr      error
+	}{
+		{
+			name: "OK accept",
+			action: func(s *PendingTransferService) (*models.PendingTransfer, error) {
+				return s.AcceptPendingTransfer(context.Background(), 7, 2)
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetPendingTransferForUpdate(gomock.Any(), 7).Return(pending(), nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 900}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 10}, nil)
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), 1).Return(100, nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), 1, 0).Return(nil)
+				r.EXPECT().ResolvePendingTransfer(gomock.Any(), gomock.Any()).Return(nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 2, 110).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, gomock.Any(), 100, "Перевод №7 принят пользователем 2", models.Note{}).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 2, gomock.Any(), 100, "Перевод средств от пользователя 1, перевод №7 принят", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), gomock.All(ledgerEntry(1, models.AccountReserve, -100), ledgerOperation(models.OperationTransferAccept))).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), gomock.All(ledgerEntry(2, models.AccountMain, 100), ledgerOperation(models.OperationTransferAccept))).Return(nil)
+			},
+			wantStatus: models.PendingTransferAccepted,
+		},
+
+		{
+			name: "OK decline",
+			action: func(s *PendingTransferService) (*models.PendingTransfer, error) {
+				return s.DeclinePendingTransfer(context.Background(), 7, 2)
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetPendingTransferForUpdate(gomock.Any(), 7).Return(pending(), nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 900}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 10}, nil)
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), 1).Return(100, nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), 1, 0).Return(nil)
+				r.EXPECT().ResolvePendingTransfer(gomock.Any(), gomock.Any()).Return(nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 1000).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, gomock.Any(), 100, "Перевод №7 отклонен пользователем 2, средства возвращены", models.Note{}).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 2, gomock.Any(), 100, "Перевод №7 от пользователя 1 отклонен", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), gomock.All(ledgerEntry(1, models.AccountReserve, -100), ledgerOperation(models.OperationTransfer))).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), gomock.All(ledgerEntry(1, models.AccountMain, 100), ledgerOperation(models.OperationTransfer))).Return(nil)
+			},
+			wantStatus: models.PendingTransferDeclined,
+		},
+
+		{
+			name: "error not found",
+			action: func(s *PendingTransferService) (*models.PendingTransfer, error) {
+				return s.AcceptPendingTransfer(context.Background(), 7, 2)
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetPendingTransferForUpdate(gomock.Any(), 7).Return(nil, nil)
+			},
+			wantErr: ErrPendingTransferNotFound,
+		},
+
+		{
+			name: "error not a recipient",
+			action: func(s *PendingTransferService) (*models.PendingTransfer, error) {
+				return s.AcceptPendingTransfer(context.Background(), 7, 1)
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetPendingTransferForUpdate(gomock.Any(), 7).Return(pending(), nil)
+			},
+			wantErr: ErrNotTransferRecipient,
+		},
+
+		{
+			name: "error already resolved",
+			action: func(s *PendingTransferService) (*models.PendingTransfer, error) {
+				return s.DeclinePendingTransfer(context.Background(), 7, 2)
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				transfer := pending()
+				transfer.Status = models.PendingTransferAccepted
+				r.EXPECT().GetPendingTransferForUpdate(gomock.Any(), 7).Return(transfer, nil)
+			},
+			wantErr: ErrPendingTransferResolved,
+		},
+
+		{
+			name: "error accept expired",
+			action: func(s *PendingTransferService) (*models.PendingTransfer, error) {
+				return s.AcceptPendingTransfer(context.Background(), 7, 2)
+			},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				transfer := pending()
+				transfer.ExpiresAt = time.Now().Add(-time.Minute)
+				r.EXPECT().GetPendingTransferForUpdate(gomock.Any(), 7).Return(transfer, nil)
+			},
+			wantErr: ErrPendingTransferResolved,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			got, err := testCase.action(NewPendingTransferService(NewControlService(repo, unitOfWork{repo}, nil, nil, nil)))
+
+			if testCase.wantErr != nil {
+				assert.True(t, errors.Is(err, testCase.wantErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.wantStatus, got.Status)
+		})
+	}
+}
+
+func TestPendingTransferService_ExpirePendingTransfers(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	gomock.InOrder(
+		repo.EXPECT().GetExpiredPendingTransferForUpdate(gomock.Any(), gomock.Any()).Return(&models.PendingTransfer{
+			ID: 7, FromUserID: 1, ToUserID: 2, Amount: 100, Status: models.PendingTransferPending,
+			ExpiresAt: time.Now().Add(-time.Minute)}, nil),
+		repo.EXPECT().GetExpiredPendingTransferForUpdate(gomock.Any(), gomock.Any()).Return(nil, nil),
+	)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 900}, nil)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 10}, nil)
+	repo.EXPECT().GetBalanceReserveAccounts(gomock.Any(), 1).Return(100, nil)
+	repo.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), 1, 0).Return(nil)
+	repo.EXPECT().ResolvePendingTransfer(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, transfer *models.PendingTransfer) error {
+			assert.Equal(t, models.PendingTransferExpired, transfer.Status)
+			return nil
+		})
+	repo.EXPECT().UpdateBalance(gomock.Any(), 1, 1000).Return(nil)
+	repo.EXPECT().InsertLog(gomock.Any(), 1, gomock.Any(), 100, "Перевод №7 не принят пользователем 2 в срок, средства возвращены", models.Note{}).Return(nil)
+	repo.EXPECT().InsertLog(gomock.Any(), 2, gomock.Any(), 100, "Перевод №7 от пользователя 1 не принят в срок", models.Note{}).Return(nil)
+	repo.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountReserve, -100)).Return(nil)
+	repo.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, 100)).Return(nil)
+
+	s := NewPendingTransferService(NewControlService(repo, unitOfWork{repo}, nil, nil, nil))
+	expired, err := s.ExpirePendingTransfers(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+}
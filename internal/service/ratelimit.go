@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+	c "userbalance/internal/config"
+	"userbalance/internal/logger"
+	"userbalance/internal/metrics"
+	"userbalance/internal/ratelimit"
+)
+
+// Виды ограничений частоты запросов
+const (
+	rateLimitClient string = "client"
+	rateLimitUser   string = "user"
+)
+
+type RateLimitService struct {
+	store ratelimit.Store
+	conf  c.Source
+}
+
+func NewRateLimitService(store ratelimit.Store, conf c.Source) *RateLimitService {
+	return &RateLimitService{
+		store: store,
+		conf:  conf,
+	}
+}
+
+// AllowClient учитывает запрос клиента API и сообщает, разрешен ли он, а если нет - через сколько повторить
+func (s *RateLimitService) AllowClient(ctx context.Context, client string) (bool, time.Duration) {
+	conf := s.conf.Get()
+	return s.allow(ctx, rateLimitClient, client, ratelimit.Limit{Rate: conf.RateLimitClientRate, Burst: conf.RateLimitClientBurst})
+}
+
+// AllowUser учитывает запрос, изменяющий баланс пользователя userId: частые операции с одним
+// пользователем ждут блокировки его строки и задерживают запросы других клиентов
+func (s *RateLimitService) AllowUser(ctx context.Context, userId int) (bool, time.Duration) {
+	conf := s.conf.Get()
+	return s.allow(ctx, rateLimitUser, strconv.Itoa(userId), ratelimit.Limit{Rate: conf.RateLimitUserRate, Burst: conf.RateLimitUserBurst})
+}
+
+// MaxRequestBody возвращает наибольший размер тела запроса в байтах, которое читается,
+// чтобы определить пользователей операции
+func (s *RateLimitService) MaxRequestBody() int64 {
+	return int64(s.conf.Get().MaxRequestBody)
+}
+
+// allow при недоступности хранилища пропускает запрос: ограничение частоты не должно останавливать сервис
+func (s *RateLimitService) allow(ctx context.Context, kind, id string, limit ratelimit.Limit) (bool, time.Duration) {
+	if !limit.Enabled() {
+		return true, 0
+	}
+
+	allowed, retryAfter, err := s.store.Allow(ctx, kind+":"+id, limit)
+	if err != nil {
+		logger.WarnContext(ctx, "ограничение частоты запросов не проверено", "limit", kind, "error", err)
+		return true, 0
+	}
+	if !allowed {
+		metrics.ObserveRateLimited(kind)
+	}
+
+	return allowed, retryAfter
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"userbalance/internal/config"
+	"userbalance/internal/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type storeFunc func(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error)
+
+func (f storeFunc) Allow(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	return f(ctx, key, limit)
+}
+
+func TestRateLimitService(t *testing.T) {
+	conf := &config.Config{
+		RateLimitClientRate:  10,
+		RateLimitClientBurst: 20,
+		RateLimitUserRate:    1,
+		RateLimitUserBurst:   2,
+	}
+
+	testTable := []struct {
+		name        string
+		conf        *config.Config
+		store       storeFunc
+		allow       func(s *RateLimitService) (bool, time.Duration)
+		wantAllowed bool
+		wantRetry   time.Duration
+	}{
+		{
+			name: "OK client",
+			conf: conf,
+			store: func(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+				assert.Equal(t, "client:billing", key)
+				assert.Equal(t, ratelimit.Limit{Rate: 10, Burst: 20}, limit)
+				return true, 0, nil
+			},
+			allow: func(s *RateLimitService) (bool, time.Duration) {
+				return s.AllowClient(context.Background(), "billing")
+			},
+			wantAllowed: true,
+		},
+
+		{
+			name: "user limited",
+			conf: conf,
+			store: func(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+				assert.Equal(t, "user:15", key)
+				assert.Equal(t, ratelimit.Limit{Rate: 1, Burst: 2}, limit)
+				return false, 300 * time.Millisecond, nil
+			},
+			allow: func(s *RateLimitService) (bool, time.Duration) {
+				return s.AllowUser(context.Background(), 15)
+			},
+			wantRetry: 300 * time.Millisecond,
+		},
+
+		{
+			name: "OK disabled",
+			conf: &config.Config{},
+			store: func(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+				t.Error("хранилище не должно вызываться без ограничения")
+				return false, 0, nil
+			},
+			allow: func(s *RateLimitService) (bool, time.Duration) {
+				return s.AllowUser(context.Background(), 15)
+			},
+			wantAllowed: true,
+		},
+
+		{
+			name: "OK store unavailable",
+			conf: conf,
+			store: func(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+				return false, 0, errors.New("connection refused")
+			},
+			allow: func(s *RateLimitService) (bool, time.Duration) {
+				return s.AllowClient(context.Background(), "billing")
+			},
+			wantAllowed: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			allowed, retry := testCase.allow(NewRateLimitService(testCase.store, testCase.conf))
+
+			assert.Equal(t, testCase.wantAllowed, allowed)
+			assert.Equal(t, testCase.wantRetry, retry)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+	"userbalance/internal/logger"
+	"userbalance/internal/metrics"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+)
+
+type ReconciliationService struct {
+	repo repository.Control
+}
+
+func NewReconciliationService(repo repository.Control) *ReconciliationService {
+	return &ReconciliationService{
+		repo: repo,
+	}
+}
+
+// Reconcile сверяет остатки счетов пользователей с остатками, восстановленными по журналам,
+// и возвращает только расхождения
+func (s *ReconciliationService) Reconcile(ctx context.Context) (*models.ReconciliationReport, error) {
+	checks, err := s.repo.GetBalanceChecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ReconciliationReport{
+		CheckedAt: time.Now(),
+		Users:     len(checks),
+		Entity:    make([]models.BalanceCheck, 0),
+	}
+	for _, check := range checks {
+		if !check.Matches() {
+			report.Entity = append(report.Entity, check)
+		}
+	}
+	report.Mismatches = len(report.Entity)
+
+	metrics.ObserveReconciliation(report.Mismatches, report.CheckedAt)
+
+	return report, nil
+}
+
+// Run выполняет сверку с заданным интервалом, пока не будет отменен ctx
+func (s *ReconciliationService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := s.Reconcile(ctx)
+		if err != nil {
+			logger.Error("ошибка при сверке остатков", "error", err)
+			continue
+		}
+		if report.Mismatches > 0 {
+			logger.Warn("сверка остатков: найдены расхождения", "mismatches", report.Mismatches, "users", report.Users)
+		}
+	}
+}
+
+// WriteReconciliationCSV записывает расхождения в формате CSV с разделителем ";"
+func WriteReconciliationCSV(w io.Writer, report *models.ReconciliationReport) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+
+	if err := writer.Write([]string{"userid", "balance", "expectedbalance", "reserve", "expectedreserve", "reservedetails"}); err != nil {
+		return err
+	}
+	for _, check := range report.Entity {
+		record := []string{
+			strconv.Itoa(check.UserID),
+			strconv.Itoa(check.Balance),
+			strconv.Itoa(check.ExpectedBalance),
+			strconv.Itoa(check.Reserve),
+			strconv.Itoa(check.ExpectedReserve),
+			strconv.Itoa(check.ReserveDetails),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"userbalance/internal/models"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcile(t *testing.T) {
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		wantUsers    int
+		want         []models.BalanceCheck
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetBalanceChecks(gomock.Any()).Return([]models.BalanceCheck{
+					{UserID: 1, Balance: 100, ExpectedBalance: 100, Reserve: 50, ExpectedReserve: 50, ReserveDetails: 50},
+					{UserID: 2, Balance: 120, ExpectedBalance: 100},
+					{UserID: 3, Reserve: 50, ExpectedReserve: 50, ReserveDetails: 0},
+				}, nil)
+			},
+			wantUsers: 3,
+			want: []models.BalanceCheck{
+				{UserID: 2, Balance: 120, ExpectedBalance: 100},
+				{UserID: 3, Reserve: 50, ExpectedReserve: 50, ReserveDetails: 0},
+			},
+		},
+
+		{
+			name: "OK no mismatches",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetBalanceChecks(gomock.Any()).Return([]models.BalanceCheck{
+					{UserID: 1, Balance: 100, ExpectedBalance: 100},
+				}, nil)
+			},
+			wantUsers: 1,
+			want:      []models.BalanceCheck{},
+		},
+
+		{
+			name: "error",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetBalanceChecks(gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			control := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(control)
+
+			s := NewReconciliationService(control)
+
+			got, err := s.Reconcile(context.Background())
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.wantUsers, got.Users)
+				assert.Equal(t, len(testCase.want), got.Mismatches)
+				assert.Equal(t, testCase.want, got.Entity)
+			}
+		})
+	}
+}
+
+func TestWriteReconciliationCSV(t *testing.T) {
+	var buf bytes.Buffer
+
+	report := &models.ReconciliationReport{
+		Entity: []models.BalanceCheck{
+			{UserID: 2, Balance: 120, ExpectedBalance: 100, Reserve: 10, ExpectedReserve: 10, ReserveDetails: 0},
+		},
+	}
+
+	err := WriteReconciliationCSV(&buf, report)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "userid;balance;expectedbalance;reserve;expectedreserve;reservedetails\n2;120;100;10;10;0\n", buf.String())
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+	"userbalance/internal/risk"
+)
+
+var (
+	ErrRiskReview         = errors.New("операция отправлена на проверку")
+	ErrRiskDenied         = errors.New("операция отклонена проверкой на мошенничество")
+	ErrRiskReviewNotFound = errors.New("операция, ожидающая проверки, не найдена")
+	ErrRiskReviewResolved = errors.New("по операции уже принято решение")
+)
+
+// riskDecisionsLimit - количество решений, возвращаемых по умолчанию
+const riskDecisionsLimit = 100
+
+// RiskChecker оценивает операцию перед выполнением. Вызывается в транзакции операции
+// после блокировки строк пользователей, history читает данные в этой же транзакции
+type RiskChecker interface {
+	Check(ctx context.Context, history risk.History, operation *models.RiskOperation) (*models.RiskDecision, error)
+}
+
+// RiskError - операция не выполнена по решению проверки: отклонена (deny) либо
+// ожидает решения администратора (review)
+type RiskError struct {
+	Decision *models.RiskDecision
+}
+
+func (e *RiskError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Unwrap(), strings.Join(e.Decision.Reasons, "; "))
+}
+
+func (e *RiskError) Unwrap() error {
+	if e.Decision.Decision == models.RiskReview {
+		return ErrRiskReview
+	}
+	return ErrRiskDenied
+}
+
+// Response возвращает тело ответа на операцию, остановленную проверкой
+func (e *RiskError) Response() *models.RiskResponse {
+	code := models.ErrorCodeRiskDenied
+	if e.Decision.Decision == models.RiskReview {
+		code = models.ErrorCodeRiskReview
+	}
+	return &models.RiskResponse{
+		Message:  e.Error(),
+		Code:     code,
+		ID:       e.Decision.ID,
+		Decision: e.Decision.Decision,
+		Reasons:  e.Decision.Reasons,
+	}
+}
+
+// riskApprovedKey - ключ контекста операции, одобренной администратором: повторно она не проверяется
+type riskApprovedKey struct{}
+
+// screenTx проверяет операцию после проверок остатка и лимитов. Решение allow записывается
+// в транзакции операции, review и deny возвращаются ошибкой *RiskError и записываются
+// в withinTx после отката транзакции. Резервирования меньше riskreservemin не проверяются
+func (c *ControlService) screenTx(ctx context.Context, repo repository.Control, operation *models.RiskOperation) error {
+	if c.checker == nil || ctx.Value(riskApprovedKey{}) != nil {
+		return nil
+	}
+	if conf := c.config(); conf != nil && operation.Type == models.OperationReserve && operation.Amount < conf.RiskReserveMin {
+		return nil
+	}
+
+	decision, err := c.checker.Check(ctx, repo, operation)
+	if err != nil {
+		return err
+	}
+	decision.Operation = *operation
+
+	switch decision.Decision {
+	case models.RiskReview:
+		decision.Status = models.RiskStatusPending
+		return &RiskError{Decision: decision}
+	case models.RiskDeny:
+		return &RiskError{Decision: decision}
+	}
+	return repo.InsertRiskDecision(ctx, decision)
+}
+
+// withinTx выполняет fn в транзакции. Если операцию остановила проверка, ее решение
+// записывается после отката транзакции, чтобы отклоненные и ожидающие проверки операции сохранились
+func (c *ControlService) withinTx(ctx context.Context, fn func(repo repository.Control) error) error {
+	err := c.uow.WithinTx(ctx, fn)
+
+	var riskErr *RiskError
+	if !errors.As(err, &riskErr) {
+		return err
+	}
+	if recordErr := c.repo.InsertRiskDecision(ctx, riskErr.Decision); recordErr != nil {
+		return recordErr
+	}
+	logger.WarnContext(ctx, "операция остановлена проверкой на мошенничество",
+		"id", riskErr.Decision.ID, "decision", riskErr.Decision.Decision, "reasons", riskErr.Decision.Reasons)
+
+	return err
+}
+
+// RiskService показывает решения проверки и выполняет либо отклоняет операции, ожидающие решения администратора
+type RiskService struct {
+	control *ControlService
+}
+
+func NewRiskService(control *ControlService) *RiskService {
+	return &RiskService{control: control}
+}
+
+func (s *RiskService) GetRiskDecisions(ctx context.Context, filter *models.RiskFilter) ([]models.RiskDecision, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = riskDecisionsLimit
+	}
+	return s.control.repo.GetRiskDecisions(ctx, filter)
+}
+
+// ApproveRiskReview выполняет операцию, ожидающую проверки, без повторной оценки риска. Если операция
+// не может быть выполнена (например, не хватает средств), она остается ожидающей решения
+func (s *RiskService) ApproveRiskReview(ctx context.Context, id int, resolvedBy string) (*models.RiskDecision, error) {
+	ctx, cancel := s.control.withTimeout(ctx)
+	defer cancel()
+
+	var decision *models.RiskDecision
+	var executed bool
+
+	err := s.control.uow.WithinTx(ctx, func(repo repository.Control) error {
+		var err error
+		executed = false
+		if decision, err = pendingTx(ctx, repo, id); err != nil {
+			return err
+		}
+
+		executed = true
+		if err = s.executeTx(context.WithValue(ctx, riskApprovedKey{}, true), repo, &decision.Operation); err != nil {
+			return err
+		}
+		return resolveTx(ctx, repo, decision, models.RiskStatusApproved, resolvedBy)
+	})
+	if executed {
+		observe(ctx, decision.Operation.Type, decision.Operation.Amount, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "операция одобрена после проверки", "id", id, "resolvedby", resolvedBy)
+	return decision, nil
+}
+
+// RejectRiskReview отклоняет операцию, ожидающую проверки
+func (s *RiskService) RejectRiskReview(ctx context.Context, id int, resolvedBy string) (*models.RiskDecision, error) {
+	ctx, cancel := s.control.withTimeout(ctx)
+	defer cancel()
+
+	var decision *models.RiskDecision
+
+	err := s.control.uow.WithinTx(ctx, func(repo repository.Control) error {
+		var err error
+		if decision, err = pendingTx(ctx, repo, id); err != nil {
+			return err
+		}
+		return resolveTx(ctx, repo, decision, models.RiskStatusRejected, resolvedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "операция отклонена после проверки", "id", id, "resolvedby", resolvedBy)
+	return decision, nil
+}
+
+// executeTx выполняет операцию, сохраненную при отправке на проверку. Перевод, ожидающий
+// подтверждения, создается заново: сумма удерживается до решения получателя
+func (s *RiskService) executeTx(ctx context.Context, repo repository.Control, operation *models.RiskOperation) error {
+	switch operation.Type {
+	case models.OperationTransfer:
+		if operation.Pending {
+			_, err := s.control.holdTransferTx(ctx, repo, operation.Money())
+			return err
+		}
+		return s.control.transferTx(ctx, repo, operation.Money())
+	case models.OperationReserve:
+		service, err := getServiceTx(ctx, repo, operation.ServiceID)
+		if err != nil {
+			return err
+		}
+		return s.control.reservationTx(ctx, repo, operation.Transaction(), service)
+	}
+	return fmt.Errorf("операция %s не проверяется", operation.Type)
+}
+
+// pendingTx возвращает и блокирует решение review, по которому администратор еще не принял решение
+func pendingTx(ctx context.Context, repo repository.Control, id int) (*models.RiskDecision, error) {
+	decision, err := repo.GetRiskDecisionForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if decision == nil || decision.Decision != models.RiskReview {
+		return nil, ErrRiskReviewNotFound
+	}
+	if decision.Status != models.RiskStatusPending {
+		return nil, fmt.Errorf("%w: %s", ErrRiskReviewResolved, decision.Status)
+	}
+	return decision, nil
+}
+
+func resolveTx(ctx context.Context, repo repository.Control, decision *models.RiskDecision, status string, resolvedBy string) error {
+	affected, err := repo.ResolveRiskDecision(ctx, decision.ID, status, resolvedBy)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRiskReviewResolved
+	}
+
+	now := time.Now()
+	decision.Status = status
+	decision.ResolvedAt = &now
+	decision.ResolvedBy = resolvedBy
+	return nil
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"userbalance/internal/config"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+	mock_repository "userbalance/internal/repository/mocks"
+	"userbalance/internal/risk"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checker выносит решение decision по любой операции
+type checker struct {
+	decision string
+	reasons  []string
+}
+
+func (c checker) Check(ctx context.Context, history risk.History, operation *models.RiskOperation) (*models.RiskDecision, error) {
+	return &models.RiskDecision{Decision: c.decision, Reasons: c.reasons}, nil
+}
+
+func TestControlService_screenTx(t *testing.T) {
+	transfer := &models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100}
+	reserve := &models.RiskOperation{Type: models.OperationReserve, UserID: 1, ServiceID: 1, OrderID: 1, Amount: 100}
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		checker      RiskChecker
+		ctx          context.Context
+		operation    *models.RiskOperation
+		mockBehavior mockBehavior
+		want         *models.RiskDecision
+	}{
+		{
+			name:         "OK without checker",
+			ctx:          context.Background(),
+			operation:    transfer,
+			mockBehavior: func(r *mock_repository.MockControl) {},
+		},
+
+		{
+			name:      "OK allow is recorded",
+			checker:   checker{decision: models.RiskAllow},
+			ctx:       context.Background(),
+			operation: transfer,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().InsertRiskDecision(gomock.Any(), &models.RiskDecision{Decision: models.RiskAllow, Operation: *transfer}).Return(nil)
+			},
+		},
+
+		{
+			name:         "OK small reservation is not screened",
+			checker:      checker{decision: models.RiskDeny},
+			ctx:          context.Background(),
+			operation:    reserve,
+			mockBehavior: func(r *mock_repository.MockControl) {},
+		},
+
+		{
+			name:         "OK approved operation is not screened",
+			checker:      checker{decision: models.RiskDeny},
+			ctx:          context.WithValue(context.Background(), riskApprovedKey{}, true),
+			operation:    transfer,
+			mockBehavior: func(r *mock_repository.MockControl) {},
+		},
+
+		{
+			name:         "review",
+			checker:      checker{decision: models.RiskReview, reasons: []string{"large-transfer"}},
+			ctx:          context.Background(),
+			operation:    transfer,
+			mockBehavior: func(r *mock_repository.MockControl) {},
+			want: &models.RiskDecision{
+				Decision:  models.RiskReview,
+				Reasons:   []string{"large-transfer"},
+				Status:    models.RiskStatusPending,
+				Operation: *transfer,
+			},
+		},
+
+		{
+			name:         "deny",
+			checker:      checker{decision: models.RiskDeny, reasons: []string{"many-recipients"}},
+			ctx:          context.Background(),
+			operation:    transfer,
+			mockBehavior: func(r *mock_repository.MockControl) {},
+			want: &models.RiskDecision{
+				Decision:  models.RiskDeny,
+				Reasons:   []string{"many-recipients"},
+				Operation: *transfer,
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			s := NewControlService(repo, unitOfWork{repo}, &config.Config{RiskReserveMin: 1000}, testCase.checker, nil)
+			err := s.screenTx(testCase.ctx, repo, testCase.operation)
+
+			if testCase.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var riskErr *RiskError
+			require.True(t, errors.As(err, &riskErr))
+			assert.Equal(t, testCase.want, riskErr.Decision)
+		})
+	}
+}
+
+func TestTransfer_RiskReview(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1000}, nil)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+	repo.EXPECT().InsertRiskDecision(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, decision *models.RiskDecision) error {
+			decision.ID = 7
+			return nil
+		})
+
+	s := NewControlService(&repository.Repository{Control: repo}, unitOfWork{repo}, nil,
+		checker{decision: models.RiskReview, reasons: []string{"large-transfer"}}, nil)
+	err := s.Transfer(context.Background(), &models.Money{FromUserID: 1, ToUserID: 2, Amount: 500})
+
+	assert.True(t, errors.Is(err, ErrRiskReview))
+	var riskErr *RiskError
+	require.True(t, errors.As(err, &riskErr))
+	assert.Equal(t, &models.RiskResponse{
+		Message:  err.Error(),
+		Code:     models.ErrorCodeRiskReview,
+		ID:       7,
+		Decision: models.RiskReview,
+		Reasons:  []string{"large-transfer"},
+	}, riskErr.Response())
+}
+
+func TestRiskService_ApproveRiskReview(t *testing.T) {
+	pending := func() *models.RiskDecision {
+		return &models.RiskDecision{
+			ID:        7,
+			Decision:  models.RiskReview,
+			Status:    models.RiskStatusPending,
+			Operation: models.RiskOperation{Type: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100},
+		}
+	}
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetRiskDecisionForUpdate(gomock.Any(), 7).Return(pending(), nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1000}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 900).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, gomock.Any(), 100, gomock.Any(), models.Note{}).Return(nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 2, 100).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 2, gomock.Any(), 100, gomock.Any(), models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -100)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(2, models.AccountMain, 100)).Return(nil)
+				r.EXPECT().ResolveRiskDecision(gomock.Any(), 7, models.RiskStatusApproved, "support").Return(int64(1), nil)
+			},
+		},
+
+		{
+			name: "OK pending transfer is held",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				decision := pending()
+				decision.Operation.Pending = true
+				r.EXPECT().GetRiskDecisionForUpdate(gomock.Any(), 7).Return(decision, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1000}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), 1).Return(0, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 900).Return(nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), 1, 100).Return(nil)
+				r.EXPECT().InsertPendingTransfer(gomock.Any(), gomock.Any()).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, gomock.Any(), 100, gomock.Any(), models.Note{}).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 2, gomock.Any(), 100, gomock.Any(), models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -100)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountReserve, 100)).Return(nil)
+				r.EXPECT().ResolveRiskDecision(gomock.Any(), 7, models.RiskStatusApproved, "support").Return(int64(1), nil)
+			},
+		},
+
+		{
+			name: "error insufficient funds",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetRiskDecisionForUpdate(gomock.Any(), 7).Return(pending(), nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 10}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+			},
+			wantErr: ErrInsufficientFunds,
+		},
+
+		{
+			name: "error not found",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetRiskDecisionForUpdate(gomock.Any(), 7).Return(nil, nil)
+			},
+			wantErr: ErrRiskReviewNotFound,
+		},
+
+		{
+			name: "error not a review",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetRiskDecisionForUpdate(gomock.Any(), 7).Return(&models.RiskDecision{ID: 7, Decision: models.RiskDeny}, nil)
+			},
+			wantErr: ErrRiskReviewNotFound,
+		},
+
+		{
+			name: "error already resolved",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				decision := pending()
+				decision.Status = models.RiskStatusRejected
+				r.EXPECT().GetRiskDecisionForUpdate(gomock.Any(), 7).Return(decision, nil)
+			},
+			wantErr: ErrRiskReviewResolved,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			// одобренная операция не проверяется повторно, хотя checker отклонил бы ее
+			control := NewControlService(repo, unitOfWork{repo}, nil, checker{decision: models.RiskDeny}, nil)
+			got, err := NewRiskService(control).ApproveRiskReview(context.Background(), 7, "support")
+
+			if testCase.wantErr != nil {
+				assert.True(t, errors.Is(err, testCase.wantErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, models.RiskStatusApproved, got.Status)
+			assert.Equal(t, "support", got.ResolvedBy)
+			assert.NotNil(t, got.ResolvedAt)
+		})
+	}
+}
+
+func TestRiskService_RejectRiskReview(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	repo.EXPECT().GetRiskDecisionForUpdate(gomock.Any(), 7).Return(&models.RiskDecision{
+		ID:        7,
+		Decision:  models.RiskReview,
+		Status:    models.RiskStatusPending,
+		Operation: models.RiskOperation{Type: models.OperationReserve, UserID: 1, ServiceID: 1, OrderID: 1, Amount: 100},
+	}, nil)
+	repo.EXPECT().ResolveRiskDecision(gomock.Any(), 7, models.RiskStatusRejected, "support").Return(int64(1), nil)
+
+	got, err := NewRiskService(NewControlService(repo, unitOfWork{repo}, nil, nil, nil)).RejectRiskReview(context.Background(), 7, "support")
+
+	require.NoError(t, err)
+	assert.Equal(t, models.RiskStatusRejected, got.Status)
+}
+
+func TestRiskService_GetRiskDecisions(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	repo := mock_repository.NewMockControl(c)
+	repo.EXPECT().GetRiskDecisions(gomock.Any(), &models.RiskFilter{Status: models.RiskStatusPending, Limit: riskDecisionsLimit}).
+		Return([]models.RiskDecision{{ID: 7}}, nil)
+
+	s := NewRiskService(NewControlService(repo, unitOfWork{repo}, nil, nil, nil))
+
+	got, err := s.GetRiskDecisions(context.Background(), &models.RiskFilter{Status: models.RiskStatusPending})
+	require.NoError(t, err)
+	assert.Equal(t, []models.RiskDecision{{ID: 7}}, got)
+
+	_, err = s.GetRiskDecisions(context.Background(), &models.RiskFilter{Decision: "block"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"userbalance/internal/cron"
+	"userbalance/internal/logger"
+	"userbalance/internal/models"
+	"userbalance/internal/repository"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("операция по расписанию не найдена")
+	ErrScheduleState    = errors.New("действие недоступно для операции в состоянии")
+)
+
+// schedulesLimit - количество операций по расписанию и их выполнений, возвращаемых по умолчанию
+const schedulesLimit = 100
+
+// SchedulerService хранит операции по расписанию и выполняет наступившие тем же кодом, что и
+// запросы /transfer и /reserv: с проверками остатка, лимитов и на мошенничество
+type SchedulerService struct {
+	control *ControlService
+}
+
+func NewSchedulerService(control *ControlService) *SchedulerService {
+	return &SchedulerService{control: control}
+}
+
+// CreateSchedule сохраняет операцию с временем первого выполнения: для once - RunAt, для interval -
+// RunAt либо сразу, если RunAt не указано, для cron - ближайшее время по расписанию не раньше RunAt
+func (s *SchedulerService) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	ctx, cancel := s.control.withTimeout(ctx)
+	defer cancel()
+
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkParties(ctx, schedule); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	switch schedule.Kind {
+	case models.ScheduleOnce:
+		schedule.NextRunAt = schedule.RunAt
+	case models.ScheduleInterval:
+		if schedule.RunAt == nil {
+			schedule.RunAt = &now
+		}
+		schedule.NextRunAt = nextRun(schedule, now.Add(-time.Nanosecond))
+	case models.ScheduleCron:
+		from := now
+		if schedule.RunAt != nil && schedule.RunAt.After(now) {
+			from = *schedule.RunAt
+		}
+		schedule.NextRunAt = nextRun(schedule, from.Add(-time.Nanosecond))
+	}
+	schedule.Status = models.ScheduleActive
+	schedule.Attempt = 0
+
+	if err := s.control.repo.InsertSchedule(ctx, schedule); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "операция по расписанию создана", "id", schedule.ID, "operation", schedule.Operation,
+		"userid", schedule.UserID, "nextrunat", schedule.NextRunAt)
+	return nil
+}
+
+// checkParties проверяет, что пользователи и услуга операции существуют
+func (s *SchedulerService) checkParties(ctx context.Context, schedule *models.Schedule) error {
+	users := []int{schedule.UserID}
+	if schedule.ToUserID != 0 {
+		users = append(users, schedule.ToUserID)
+	}
+	for _, id := range users {
+		user, err := s.control.repo.GetUser(ctx, id)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return ErrUserNotFound
+		}
+	}
+
+	if schedule.Operation == models.OperationReserve {
+		if _, err := s.control.getService(ctx, schedule.ServiceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SchedulerService) GetSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	schedule, err := s.control.repo.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+func (s *SchedulerService) GetSchedules(ctx context.Context, filter *models.ScheduleFilter) ([]models.Schedule, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = schedulesLimit
+	}
+	return s.control.repo.GetSchedules(ctx, filter)
+}
+
+// GetScheduleRuns возвращает последние limit попыток выполнения операции, 0 - количество по умолчанию
+func (s *SchedulerService) GetScheduleRuns(ctx context.Context, id int, limit int) ([]models.ScheduleRun, error) {
+	if _, err := s.GetSchedule(ctx, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = schedulesLimit
+	}
+	return s.control.repo.GetScheduleRuns(ctx, id, limit)
+}
+
+// PauseSchedule приостанавливает операцию: пока она приостановлена, выполнения по расписанию пропускаются
+func (s *SchedulerService) PauseSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	return s.transition(ctx, id, func(schedule *models.Schedule, now time.Time) error {
+		if schedule.Status != models.ScheduleActive {
+			return fmt.Errorf("%w %s", ErrScheduleState, schedule.Status)
+		}
+		schedule.Status = models.SchedulePaused
+		return nil
+	})
+}
+
+// ResumeSchedule возобновляет приостановленную операцию. Пропущенные за время паузы выполнения
+// повторяющейся операции не выполняются, однократная операция с прошедшим временем выполняется сразу
+func (s *SchedulerService) ResumeSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	return s.transition(ctx, id, func(schedule *models.Schedule, now time.Time) error {
+		if schedule.Status != models.SchedulePaused {
+			return fmt.Errorf("%w %s", ErrScheduleState, schedule.Status)
+		}
+		schedule.Status = models.ScheduleActive
+		if schedule.Kind != models.ScheduleOnce && schedule.NextRunAt.Before(now) {
+			schedule.NextRunAt = nextRun(schedule, now)
+			schedule.Attempt = 0
+		}
+		return nil
+	})
+}
+
+// CancelSchedule отменяет операцию, отмененная операция больше не выполняется
+func (s *SchedulerService) CancelSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	return s.transition(ctx, id, func(schedule *models.Schedule, now time.Time) error {
+		if schedule.Status != models.ScheduleActive && schedule.Status != models.SchedulePaused {
+			return fmt.Errorf("%w %s", ErrScheduleState, schedule.Status)
+		}
+		schedule.Status = models.ScheduleCanceled
+		schedule.NextRunAt = nil
+		return nil
+	})
+}
+
+// transition меняет операцию функцией change в транзакции, заблокировав ее от выполнения
+func (s *SchedulerService) transition(ctx context.Context, id int, change func(schedule *models.Schedule, now time.Time) error) (*models.Schedule, error) {
+	ctx, cancel := s.control.withTimeout(ctx)
+	defer cancel()
+
+	var schedule *models.Schedule
+
+	err := s.control.uow.WithinTx(ctx, func(repo repository.Control) error {
+		var err error
+		if schedule, err = repo.GetScheduleForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if schedule == nil {
+			return ErrScheduleNotFound
+		}
+		if err = change(schedule, time.Now()); err != nil {
+			return err
+		}
+		return repo.UpdateSchedule(ctx, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "состояние операции по расписанию изменено", "id", id, "status", schedule.Status)
+	return schedule, nil
+}
+
+// RunDueSchedules выполняет все операции, время выполнения которых наступило, и возвращает
+// количество попыток выполнения. Операция, которая снова оказалась наступившей (например, попытку
+// не удалось записать), выполняется только при следующем запуске, чтобы не повторять ее без паузы,
+// а остальные наступившие операции выполняются в этом же запуске
+func (s *SchedulerService) RunDueSchedules(ctx context.Context) (int, error) {
+	var runs int
+	var seen []int
+	for {
+		id, err := s.runNext(ctx, seen)
+		if err != nil || id == 0 {
+			return runs, err
+		}
+		seen = append(seen, id)
+		runs++
+	}
+}
+
+// runNext выполняет одну наступившую операцию, кроме уже выполнявшихся в этом запуске (из seen),
+// и возвращает ее id, 0 - если таких нет. Операция, запись о ее выполнении и следующее время
+// выполнения сохраняются в одной транзакции, поэтому операция не выполняется дважды. Если
+// операция не выполнена, неудачная попытка записывается после отката
+func (s *SchedulerService) runNext(ctx context.Context, seen []int) (int, error) {
+	var schedule *models.Schedule
+	var nextRunAt *time.Time
+	now := time.Now()
+
+	txCtx, cancel := s.control.withTimeout(ctx)
+	err := s.control.withinTx(txCtx, func(repo repository.Control) error {
+		var err error
+		if schedule, err = repo.GetDueScheduleForUpdate(txCtx, now, seen); err != nil || schedule == nil {
+			return err
+		}
+		if err = s.executeTx(txCtx, repo, schedule); err != nil {
+			return err
+		}
+		// записывается копия: если транзакция не завершится, fail сравнивает операцию в БД
+		// с прочитанной до попытки
+		recorded := *schedule
+		if err = s.recordTx(txCtx, repo, &recorded, now, nil); err != nil {
+			return err
+		}
+		nextRunAt = recorded.NextRunAt
+		return nil
+	})
+	cancel()
+	if schedule == nil {
+		return 0, err
+	}
+
+	observe(ctx, schedule.Operation, schedule.Amount, err)
+	if err == nil {
+		logger.Info("операция по расписанию выполнена", "id", schedule.ID, "operation", schedule.Operation,
+			"amount", schedule.Amount, "nextrunat", nextRunAt)
+		return schedule.ID, nil
+	}
+	// сервер останавливается: попытка не учитывается и будет сделана после запуска
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	logger.Warn("операция по расписанию не выполнена", "id", schedule.ID, "operation", schedule.Operation,
+		"attempt", schedule.Attempt+1, "error", err)
+	return schedule.ID, s.fail(ctx, schedule, now, err)
+}
+
+// executeTx выполняет операцию по расписанию в транзакции repo
+func (s *SchedulerService) executeTx(ctx context.Context, repo repository.Control, schedule *models.Schedule) error {
+	switch schedule.Operation {
+	case models.OperationTransfer:
+		return s.control.transferTx(ctx, repo, schedule.Money())
+	case models.OperationReserve:
+		service, err := getServiceTx(ctx, repo, schedule.ServiceID)
+		if err != nil {
+			return err
+		}
+		return s.control.reservationTx(ctx, repo, schedule.Transaction(), service)
+	}
+	return fmt.Errorf("операция %s не выполняется по расписанию", schedule.Operation)
+}
+
+// fail записывает неудачную попытку выполнения операции failed, если операцию не изменили,
+// пока она выполнялась: не приостановили, не отменили и не выполнили в другом экземпляре сервиса
+func (s *SchedulerService) fail(ctx context.Context, failed *models.Schedule, now time.Time, runErr error) error {
+	ctx, cancel := s.control.withTimeout(ctx)
+	defer cancel()
+
+	return s.control.uow.WithinTx(ctx, func(repo repository.Control) error {
+		schedule, err := repo.GetScheduleForUpdate(ctx, failed.ID)
+		if err != nil {
+			return err
+		}
+		if schedule == nil || schedule.Status != models.ScheduleActive || schedule.Attempt != failed.Attempt ||
+			schedule.NextRunAt == nil || !schedule.NextRunAt.Equal(*failed.NextRunAt) {
+			return nil
+		}
+		return s.recordTx(ctx, repo, schedule, now, runErr)
+	})
+}
+
+// recordTx записывает попытку выполнения и назначает следующую: повторную через scheduleretrydelay,
+// если операция не выполнена из-за временной ошибки и не исчерпаны scheduleretries попыток, иначе
+// по расписанию. Однократная операция после этого завершается
+func (s *SchedulerService) recordTx(ctx context.Context, repo repository.Control, schedule *models.Schedule, now time.Time, runErr error) error {
+	run := &models.ScheduleRun{
+		ScheduleID: schedule.ID,
+		Attempt:    schedule.Attempt + 1,
+		Status:     models.RunSucceeded,
+		DueAt:      *schedule.NextRunAt,
+	}
+	if runErr != nil {
+		run.Status, run.Error = models.RunFailed, runErr.Error()
+	}
+
+	retries, delay := s.retryPolicy()
+	if runErr != nil && retryable(runErr) && schedule.Attempt < retries {
+		retryAt := now.Add(delay)
+		run.Status = models.RunRetry
+		schedule.Attempt++
+		schedule.NextRunAt = &retryAt
+	} else {
+		schedule.Attempt = 0
+		if schedule.NextRunAt = nextRun(schedule, now); schedule.NextRunAt == nil {
+			schedule.Status = models.ScheduleCompleted
+			if runErr != nil {
+				schedule.Status = models.ScheduleFailed
+			}
+		}
+	}
+
+	if err := repo.UpdateSchedule(ctx, schedule); err != nil {
+		return err
+	}
+	return repo.InsertScheduleRun(ctx, run)
+}
+
+// retryPolicy возвращает количество повторных попыток и задержку перед каждой из них
+func (s *SchedulerService) retryPolicy() (int, time.Duration) {
+	conf := s.control.config()
+	if conf == nil {
+		return 0, 0
+	}
+	return conf.ScheduleRetries, time.Duration(conf.ScheduleRetryDelay) * time.Minute
+}
+
+// retryable - ошибки, после которых операция может выполниться через некоторое время: нехватка
+// средств и сбои БД. После остальных, например превышения лимита или проверки на мошенничество,
+// операция выполняется только в следующий раз по расписанию
+func retryable(err error) bool {
+	switch errorType(err) {
+	case "insufficient_funds", "timeout", "internal":
+		return true
+	}
+	return false
+}
+
+// nextRun возвращает время выполнения повторяющейся операции позже after,
+// nil - если операция однократная либо больше не выполнится по расписанию
+func nextRun(schedule *models.Schedule, after time.Time) *time.Time {
+	var next time.Time
+
+	switch schedule.Kind {
+	case models.ScheduleInterval:
+		// время выполнения отсчитывается от RunAt, поэтому повторные попытки не сдвигают расписание
+		every := schedule.Every()
+		if every <= 0 {
+			return nil
+		}
+		next = *schedule.RunAt
+		if !after.Before(next) {
+			next = next.Add(every * (after.Sub(next)/every + 1))
+		}
+	case models.ScheduleCron:
+		spec, err := cron.Parse(schedule.Cron)
+		if err != nil {
+			return nil
+		}
+		if next = spec.Next(after); next.IsZero() {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	return &next
+}
+
+// Run с интервалом interval выполняет наступившие операции, пока не будет отменен ctx
+func (s *SchedulerService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if runs, err := s.RunDueSchedules(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("ошибка при выполнении операций по расписанию", "error", err, "runs", runs)
+		}
+	}
+}
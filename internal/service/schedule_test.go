@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"userbalance/internal/config"
+	"userbalance/internal/models"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerService_CreateSchedule(t *testing.T) {
+	runAt := time.Now().Add(time.Hour).Truncate(time.Minute)
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		schedule     models.Schedule
+		mockBehavior mockBehavior
+		wantNext     func(schedule *models.Schedule) time.Time
+		wantErr      error
+	}{
+		{
+			name:     "OK once",
+			schedule: models.Schedule{Operation: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100, Kind: models.ScheduleOnce, RunAt: &runAt},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUser(gomock.Any(), 1).Return(&models.User{Id: 1}, nil)
+				r.EXPECT().GetUser(gomock.Any(), 2).Return(&models.User{Id: 2}, nil)
+				r.EXPECT().InsertSchedule(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantNext: func(schedule *models.Schedule) time.Time { return runAt },
+		},
+
+		{
+			name:     "OK interval starts at once",
+			schedule: models.Schedule{Operation: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100, Kind: models.ScheduleInterval, Interval: "720h"},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUser(gomock.Any(), 1).Return(&models.User{Id: 1}, nil)
+				r.EXPECT().GetUser(gomock.Any(), 2).Return(&models.User{Id: 2}, nil)
+				r.EXPECT().InsertSchedule(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantNext: func(schedule *models.Schedule) time.Time { return *schedule.RunAt },
+		},
+
+		{
+			name:     "OK cron",
+			schedule: models.Schedule{Operation: models.OperationReserve, UserID: 1, ServiceID: 1, OrderID: 1, Amount: 100, Kind: models.ScheduleCron, Cron: "@hourly"},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUser(gomock.Any(), 1).Return(&models.User{Id: 1}, nil)
+				r.EXPECT().GetService(gomock.Any(), 1).Return("Услуга", nil)
+				r.EXPECT().InsertSchedule(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantNext: func(schedule *models.Schedule) time.Time { return time.Now().Truncate(time.Hour).Add(time.Hour) },
+		},
+
+		{
+			name:     "OK cron not earlier than runat",
+			schedule: models.Schedule{Operation: models.OperationReserve, UserID: 1, ServiceID: 1, OrderID: 1, Amount: 100, Kind: models.ScheduleCron, Cron: "* * * * *", RunAt: &runAt},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUser(gomock.Any(), 1).Return(&models.User{Id: 1}, nil)
+				r.EXPECT().GetService(gomock.Any(), 1).Return("Услуга", nil)
+				r.EXPECT().InsertSchedule(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantNext: func(schedule *models.Schedule) time.Time { return runAt },
+		},
+
+		{
+			name:         "error validation",
+			schedule:     models.Schedule{Operation: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100, Kind: models.ScheduleInterval, Interval: "30s"},
+			mockBehavior: func(r *mock_repository.MockControl) {},
+		},
+
+		{
+			name:     "error recipient not found",
+			schedule: models.Schedule{Operation: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100, Kind: models.ScheduleOnce, RunAt: &runAt},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUser(gomock.Any(), 1).Return(&models.User{Id: 1}, nil)
+				r.EXPECT().GetUser(gomock.Any(), 2).Return(nil, nil)
+			},
+			wantErr: ErrUserNotFound,
+		},
+
+		{
+			name:     "error service not found",
+			schedule: models.Schedule{Operation: models.OperationReserve, UserID: 1, ServiceID: 9, OrderID: 1, Amount: 100, Kind: models.ScheduleOnce, RunAt: &runAt},
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUser(gomock.Any(), 1).Return(&models.User{Id: 1}, nil)
+				r.EXPECT().GetService(gomock.Any(), 9).Return("", nil)
+			},
+			wantErr: ErrServiceNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			s := NewSchedulerService(NewControlService(repo, unitOfWork{repo}, nil, nil, nil))
+			err := s.CreateSchedule(context.Background(), &testCase.schedule)
+
+			if testCase.wantNext == nil {
+				assert.Error(t, err)
+				if testCase.wantErr != nil {
+					assert.True(t, errors.Is(err, testCase.wantErr))
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, models.ScheduleActive, testCase.schedule.Status)
+			require.NotNil(t, testCase.schedule.NextRunAt)
+			assert.Equal(t, testCase.wantNext(&testCase.schedule), *testCase.schedule.NextRunAt)
+		})
+	}
+}
+
+func TestSchedulerService_RunDueSchedules(t *testing.T) {
+	dueAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	start := dueAt.Add(-30 * 24 * time.Hour)
+
+	monthly := func() *models.Schedule {
+		return &models.Schedule{ID: 7, Operation: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100,
+			Kind: models.ScheduleInterval, Interval: "720h", RunAt: &start, Status: models.ScheduleActive, NextRunAt: &dueAt}
+	}
+	once := func(attempt int) *models.Schedule {
+		return &models.Schedule{ID: 8, Operation: models.OperationReserve, UserID: 1, ServiceID: 1, OrderID: 1, Amount: 100,
+			Kind: models.ScheduleOnce, RunAt: &dueAt, Status: models.ScheduleActive, NextRunAt: &dueAt, Attempt: attempt}
+	}
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		wantRun      models.ScheduleRun
+		wantSchedule func(t *testing.T, schedule *models.Schedule)
+	}{
+		{
+			name: "OK next run is counted from runat",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetDueScheduleForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(monthly(), nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1000}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 1, 900).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 1, gomock.Any(), 100, gomock.Any(), models.Note{}).Return(nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), 2, 100).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), 2, gomock.Any(), 100, gomock.Any(), models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(1, models.AccountMain, -100)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(2, models.AccountMain, 100)).Return(nil)
+			},
+			wantRun: models.ScheduleRun{ScheduleID: 7, Attempt: 1, Status: models.RunSucceeded, DueAt: dueAt},
+			wantSchedule: func(t *testing.T, schedule *models.Schedule) {
+				assert.Equal(t, models.ScheduleActive, schedule.Status)
+				assert.Equal(t, 0, schedule.Attempt)
+				assert.Equal(t, start.Add(2*720*time.Hour), *schedule.NextRunAt)
+			},
+		},
+
+		{
+			name: "OK insufficient funds is retried",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetDueScheduleForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(monthly(), nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 10}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+				r.EXPECT().GetScheduleForUpdate(gomock.Any(), 7).Return(monthly(), nil)
+			},
+			wantRun: models.ScheduleRun{ScheduleID: 7, Attempt: 1, Status: models.RunRetry, Error: ErrInsufficientFunds.Error(), DueAt: dueAt},
+			wantSchedule: func(t *testing.T, schedule *models.Schedule) {
+				assert.Equal(t, models.ScheduleActive, schedule.Status)
+				assert.Equal(t, 1, schedule.Attempt)
+				assert.WithinDuration(t, time.Now().Add(time.Hour), *schedule.NextRunAt, time.Minute)
+			},
+		},
+
+		{
+			name: "OK once fails when retries are exhausted",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetDueScheduleForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(once(1), nil)
+				r.EXPECT().GetService(gomock.Any(), 1).Return("Услуга", nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 10}, nil)
+				r.EXPECT().GetScheduleForUpdate(gomock.Any(), 8).Return(once(1), nil)
+			},
+			wantRun: models.ScheduleRun{ScheduleID: 8, Attempt: 2, Status: models.RunFailed, Error: ErrInsufficientFunds.Error(), DueAt: dueAt},
+			wantSchedule: func(t *testing.T, schedule *models.Schedule) {
+				assert.Equal(t, models.ScheduleFailed, schedule.Status)
+				assert.Equal(t, 0, schedule.Attempt)
+				assert.Nil(t, schedule.NextRunAt)
+			},
+		},
+
+		{
+			name: "OK user not found is not retried",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetDueScheduleForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(monthly(), nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1000}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(nil, nil)
+				r.EXPECT().GetScheduleForUpdate(gomock.Any(), 7).Return(monthly(), nil)
+			},
+			wantRun: models.ScheduleRun{ScheduleID: 7, Attempt: 1, Status: models.RunFailed, Error: ErrUserNotFound.Error(), DueAt: dueAt},
+			wantSchedule: func(t *testing.T, schedule *models.Schedule) {
+				assert.Equal(t, models.ScheduleActive, schedule.Status)
+				assert.Equal(t, start.Add(2*720*time.Hour), *schedule.NextRunAt)
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			var updated *models.Schedule
+			var run *models.ScheduleRun
+			repo.EXPECT().UpdateSchedule(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, schedule *models.Schedule) error {
+					updated = schedule
+					return nil
+				})
+			repo.EXPECT().InsertScheduleRun(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, r *models.ScheduleRun) error {
+					run = r
+					return nil
+				})
+			repo.EXPECT().GetDueScheduleForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+
+			conf := &config.Config{ScheduleRetries: 1, ScheduleRetryDelay: 60}
+			s := NewSchedulerService(NewControlService(repo, unitOfWork{repo}, conf, nil, nil))
+
+			runs, err := s.RunDueSchedules(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, runs)
+			assert.Equal(t, testCase.wantRun, *run)
+			testCase.wantSchedule(t, updated)
+		})
+	}
+}
+
+func TestSchedulerService_RunDueSchedules_Changed(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	dueAt := time.Now().Add(-time.Minute)
+	due := &models.Schedule{ID: 7, Operation: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100,
+		Kind: models.ScheduleOnce, RunAt: &dueAt, Status: models.ScheduleActive, NextRunAt: &dueAt}
+	paused := *due
+	paused.Status = models.SchedulePaused
+
+	repo := mock_repository.NewMockControl(c)
+	gomock.InOrder(
+		repo.EXPECT().GetDueScheduleForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(due, nil),
+		repo.EXPECT().GetDueScheduleForUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil),
+	)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 10}, nil)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+	// операцию приостановили, пока она выполнялась: неудачная попытка не записывается
+	repo.EXPECT().GetScheduleForUpdate(gomock.Any(), 7).Return(&paused, nil)
+
+	s := NewSchedulerService(NewControlService(repo, unitOfWork{repo}, nil, nil, nil))
+	runs, err := s.RunDueSchedules(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, runs)
+}
+
+func TestSchedulerService_RunDueSchedules_RecordFailed(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	dueAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	start := dueAt.Add(-30 * 24 * time.Hour)
+	monthly := func() *models.Schedule {
+		return &models.Schedule{ID: 7, Operation: models.OperationTransfer, UserID: 1, ToUserID: 2, Amount: 100,
+			Kind: models.ScheduleInterval, Interval: "720h", RunAt: &start, Status: models.ScheduleActive, NextRunAt: &dueAt}
+	}
+
+	repo := mock_repository.NewMockControl(c)
+	// запись о выполнении не сохранилась, и операция снова наступившая: в этом запуске она
+	// исключается из выборки, а остальные наступившие операции выполняются
+	gomock.InOrder(
+		repo.EXPECT().GetDueScheduleForUpdate(gomock.Any(), gomock.Any(), gomock.Nil()).Return(monthly(), nil),
+		repo.EXPECT().GetDueScheduleForUpdate(gomock.Any(), gomock.Any(), []int{7}).Return(nil, nil),
+	)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 1000}, nil)
+	repo.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+	repo.EXPECT().GetSpendingLimit(gomock.Any(), 1, models.OperationTransfer).Return(nil, nil)
+	repo.EXPECT().UpdateBalance(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	repo.EXPECT().InsertLog(gomock.Any(), gomock.Any(), gomock.Any(), 100, gomock.Any(), models.Note{}).Return(nil).Times(2)
+	repo.EXPECT().InsertLedger(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	gomock.InOrder(
+		repo.EXPECT().UpdateSchedule(gomock.Any(), gomock.Any()).Return(errors.New("some error")),
+		// неудачная попытка записывается, так как операция в БД не изменилась
+		repo.EXPECT().GetScheduleForUpdate(gomock.Any(), 7).Return(monthly(), nil),
+		repo.EXPECT().UpdateSchedule(gomock.Any(), gomock.Any()).Return(nil),
+	)
+	var run *models.ScheduleRun
+	repo.EXPECT().InsertScheduleRun(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, r *models.ScheduleRun) error {
+			run = r
+			return nil
+		})
+
+	conf := &config.Config{ScheduleRetries: 1, ScheduleRetryDelay: 60}
+	s := NewSchedulerService(NewControlService(repo, unitOfWork{repo}, conf, nil, nil))
+	runs, err := s.RunDueSchedules(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, runs)
+	require.NotNil(t, run)
+	assert.Equal(t, models.RunRetry, run.Status)
+	assert.Equal(t, 1, run.Attempt)
+}
+
+func TestSchedulerService_transitions(t *testing.T) {
+	past := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	start := past.Add(-time.Hour)
+
+	schedule := func(kind string, status string) *models.Schedule {
+		s := &models.Schedule{ID: 7, Kind: kind, RunAt: &start, Status: status, NextRunAt: &past, Attempt: 1}
+		if kind == models.ScheduleInterval {
+			s.Interval = "24h"
+		}
+		return s
+	}
+
+	testTable := []struct {
+		name       string
+		action     func(s *SchedulerService) (*models.Schedule, error)
+		current    *models.Schedule
+		wantStatus string
+		wantNext   *time.Time
+		wantErr    error
+	}{
+		{
+			name:       "OK pause",
+			action:     func(s *SchedulerService) (*models.Schedule, error) { return s.PauseSchedule(context.Background(), 7) },
+			current:    schedule(models.ScheduleInterval, models.ScheduleActive),
+			wantStatus: models.SchedulePaused,
+			wantNext:   &past,
+		},
+
+		{
+			name:       "OK resume skips missed runs",
+			action:     func(s *SchedulerService) (*models.Schedule, error) { return s.ResumeSchedule(context.Background(), 7) },
+			current:    schedule(models.ScheduleInterval, models.SchedulePaused),
+			wantStatus: models.ScheduleActive,
+			wantNext:   timePtr(start.Add(3 * 24 * time.Hour)),
+		},
+
+		{
+			name:       "OK resume once runs at once",
+			action:     func(s *SchedulerService) (*models.Schedule, error) { return s.ResumeSchedule(context.Background(), 7) },
+			current:    schedule(models.ScheduleOnce, models.SchedulePaused),
+			wantStatus: models.ScheduleActive,
+			wantNext:   &past,
+		},
+
+		{
+			name:       "OK cancel paused",
+			action:     func(s *SchedulerService) (*models.Schedule, error) { return s.CancelSchedule(context.Background(), 7) },
+			current:    schedule(models.ScheduleCron, models.SchedulePaused),
+			wantStatus: models.ScheduleCanceled,
+		},
+
+		{
+			name:    "error resume active",
+			action:  func(s *SchedulerService) (*models.Schedule, error) { return s.ResumeSchedule(context.Background(), 7) },
+			current: schedule(models.ScheduleInterval, models.ScheduleActive),
+			wantErr: ErrScheduleState,
+		},
+
+		{
+			name:    "error cancel completed",
+			action:  func(s *SchedulerService) (*models.Schedule, error) { return s.CancelSchedule(context.Background(), 7) },
+			current: schedule(models.ScheduleOnce, models.ScheduleCompleted),
+			wantErr: ErrScheduleState,
+		},
+
+		{
+			name:    "error not found",
+			action:  func(s *SchedulerService) (*models.Schedule, error) { return s.PauseSchedule(context.Background(), 7) },
+			wantErr: ErrScheduleNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_repository.NewMockControl(c)
+			repo.EXPECT().GetScheduleForUpdate(gomock.Any(), 7).Return(testCase.current, nil)
+			if testCase.wantErr == nil {
+				repo.EXPECT().UpdateSchedule(gomock.Any(), testCase.current).Return(nil)
+			}
+
+			got, err := testCase.action(NewSchedulerService(NewControlService(repo, unitOfWork{repo}, nil, nil, nil)))
+
+			if testCase.wantErr != nil {
+				assert.True(t, errors.Is(err, testCase.wantErr))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.wantStatus, got.Status)
+			assert.Equal(t, testCase.wantNext, got.NextRunAt)
+		})
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	start := time.Date(2022, 10, 01, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name     string
+		schedule models.Schedule
+		after    time.Time
+		want     *time.Time
+	}{
+		{
+			name:     "once",
+			schedule: models.Schedule{Kind: models.ScheduleOnce, RunAt: &start},
+			after:    start,
+		},
+
+		{
+			name:     "interval before start",
+			schedule: models.Schedule{Kind: models.ScheduleInterval, Interval: "24h", RunAt: &start},
+			after:    start.Add(-time.Hour),
+			want:     &start,
+		},
+
+		{
+			name:     "interval at run time",
+			schedule: models.Schedule{Kind: models.ScheduleInterval, Interval: "24h", RunAt: &start},
+			after:    start.Add(24 * time.Hour),
+			want:     timePtr(start.Add(48 * time.Hour)),
+		},
+
+		{
+			name:     "interval after retries",
+			schedule: models.Schedule{Kind: models.ScheduleInterval, Interval: "24h", RunAt: &start},
+			after:    start.Add(27 * time.Hour),
+			want:     timePtr(start.Add(48 * time.Hour)),
+		},
+
+		{
+			name:     "cron",
+			schedule: models.Schedule{Kind: models.ScheduleCron, Cron: "@monthly", RunAt: &start},
+			after:    start,
+			want:     timePtr(time.Date(2022, 11, 01, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, nextRun(&testCase.schedule, testCase.after))
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
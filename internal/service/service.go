@@ -1,31 +1,148 @@
 package service
 
 import (
-	"database/sql"
+	"context"
+	"time"
 	c "userbalance/internal/config"
 	"userbalance/internal/models"
+	"userbalance/internal/ratelimit"
 	"userbalance/internal/repository"
+	"userbalance/internal/signature"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
 
 type Control interface {
-	ReplenishmentBalance(replenishment *models.Replenishment) error
-	Transfer(money *models.Money) error
-	Reservation(transaction *models.Transaction) error
-	CancelReservation(transaction *models.Transaction) error
-	Confirmation(transaction *models.Transaction) error
-	GetBalance(userId int) (*models.User, error)
-	CreateReport(requestReport *models.RequestReport) (string, error)
-	GetHistory(requestHistory *models.RequestHistory) ([]models.History, error)
+	ReplenishmentBalance(ctx context.Context, replenishment *models.Replenishment) error
+	Transfer(ctx context.Context, money *models.Money) error
+	Reservation(ctx context.Context, transaction *models.Transaction) error
+	CancelReservation(ctx context.Context, transaction *models.Transaction) error
+	Confirmation(ctx context.Context, transaction *models.Transaction) error
+	GetBalance(ctx context.Context, userId int) (*models.User, error)
+	GetBalanceAt(ctx context.Context, userId int, at time.Time) (*models.BalanceAt, error)
+	CreateReport(ctx context.Context, requestReport *models.RequestReport) (string, error)
+	GetHistory(ctx context.Context, requestHistory *models.RequestHistory) ([]models.History, error)
+	Batch(ctx context.Context, requestBatch *models.RequestBatch) (*models.BatchResults, error)
+}
+
+type Snapshot interface {
+	CreateSnapshot(ctx context.Context, takenAt time.Time) (int64, error)
+	RebuildSnapshots(ctx context.Context) error
+	Run(ctx context.Context)
+}
+
+type Reconciliation interface {
+	Reconcile(ctx context.Context) (*models.ReconciliationReport, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type Audit interface {
+	Verify(ctx context.Context) (*models.AuditReport, error)
+	Checkpoint(ctx context.Context) (*models.Checkpoint, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type Health interface {
+	Go(name string, fn func())
+	Drain()
+	Ready(ctx context.Context) *models.Readiness
+}
+
+type Auth interface {
+	Required() bool
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.IssuedAPIKey, error)
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	AuthenticateAPIKey(ctx context.Context, value string) (*models.Principal, error)
+	AuthenticateToken(ctx context.Context, token string) (*models.Principal, error)
+	AuthenticateCertificate(ctx context.Context, commonName string) (*models.Principal, error)
+	RotateSigningSecret(ctx context.Context, id int) (string, error)
+}
+
+type RateLimit interface {
+	AllowClient(ctx context.Context, client string) (bool, time.Duration)
+	AllowUser(ctx context.Context, userId int) (bool, time.Duration)
+	MaxRequestBody() int64
+}
+
+type Signature interface {
+	SignatureRequired(route string) bool
+	MaxRequestBody() int64
+	VerifySignature(ctx context.Context, principal *models.Principal, request *models.SignedRequest) error
+}
+
+type Limits interface {
+	GetSpendingLimits(ctx context.Context, userId int) ([]models.SpendingLimit, error)
+	SetSpendingLimit(ctx context.Context, limit *models.SpendingLimit) error
+	DeleteSpendingLimit(ctx context.Context, userId int, operation string) error
+}
+
+type Risk interface {
+	GetRiskDecisions(ctx context.Context, filter *models.RiskFilter) ([]models.RiskDecision, error)
+	ApproveRiskReview(ctx context.Context, id int, resolvedBy string) (*models.RiskDecision, error)
+	RejectRiskReview(ctx context.Context, id int, resolvedBy string) (*models.RiskDecision, error)
+}
+
+type Scheduler interface {
+	CreateSchedule(ctx context.Context, schedule *models.Schedule) error
+	GetSchedule(ctx context.Context, id int) (*models.Schedule, error)
+	GetSchedules(ctx context.Context, filter *models.ScheduleFilter) ([]models.Schedule, error)
+	GetScheduleRuns(ctx context.Context, id int, limit int) ([]models.ScheduleRun, error)
+	PauseSchedule(ctx context.Context, id int) (*models.Schedule, error)
+	ResumeSchedule(ctx context.Context, id int) (*models.Schedule, error)
+	CancelSchedule(ctx context.Context, id int) (*models.Schedule, error)
+	RunDueSchedules(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type PendingTransfers interface {
+	CreatePendingTransfer(ctx context.Context, money *models.Money) (*models.PendingTransfer, error)
+	GetPendingTransfer(ctx context.Context, id int) (*models.PendingTransfer, error)
+	GetPendingTransfers(ctx context.Context, filter *models.PendingTransferFilter) ([]models.PendingTransfer, error)
+	AcceptPendingTransfer(ctx context.Context, id int, userId int) (*models.PendingTransfer, error)
+	DeclinePendingTransfer(ctx context.Context, id int, userId int) (*models.PendingTransfer, error)
+	ExpirePendingTransfers(ctx context.Context) (int, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type Fees interface {
+	QuoteFee(ctx context.Context, request *models.FeeRequest) (*models.FeeQuote, error)
 }
 
 type Service struct {
 	Control
+	Snapshot
+	Reconciliation
+	Audit
+	Health
+	Auth
+	RateLimit
+	Signature
+	Limits
+	Risk
+	Scheduler
+	PendingTransfers
+	Fees
 }
 
-func NewService(repos *repository.Repository, conf *c.Config, db *sql.DB) *Service {
+// NewService создает сервисы, checker проверяет операции перед выполнением, nil - без проверки,
+// fees рассчитывает комиссию за операции, nil - без комиссии
+func NewService(repos *repository.Repository, conf c.Source, checker RiskChecker, fees FeeCalculator) *Service {
+	control := NewControlService(repos.Control, repos.UnitOfWork, conf, checker, fees)
+
 	return &Service{
-		Control: NewControlService(repos.Control, conf, db),
+		Control:          NewTracedControl(control),
+		Snapshot:         NewSnapshotService(repos.Control, repos.UnitOfWork),
+		Reconciliation:   NewReconciliationService(repos.Control),
+		Audit:            NewAuditService(repos.Control, conf.Get()),
+		Health:           NewHealthService(repos.Control, time.Duration(conf.Get().DBTimeout)*time.Second),
+		Auth:             NewAuthService(repos.Control, conf),
+		RateLimit:        NewRateLimitService(ratelimit.NewMemoryStore(), conf),
+		Signature:        NewSignatureService(signature.NewMemoryNonceStore(), conf),
+		Limits:           NewLimitService(repos.Control, conf),
+		Risk:             NewRiskService(control),
+		Scheduler:        NewSchedulerService(control),
+		PendingTransfers: NewPendingTransferService(control),
+		Fees:             NewFeeService(control),
 	}
 }
@@ -1,9 +1,9 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"testing"
 	"time"
@@ -12,7 +12,6 @@ import (
 	"userbalance/internal/repository"
 	mock_repository "userbalance/internal/repository/mocks"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -32,7 +31,7 @@ func TestGetBalance(t *testing.T) {
 			name:   "OK",
 			userId: 1,
 			mockBehavior: func(r *mock_repository.MockControl, userId int) {
-				r.EXPECT().GetUser(userId).Return(
+				r.EXPECT().GetUser(gomock.Any(), userId).Return(
 					&models.User{
 						Id:      1,
 						Balance: 100}, nil)
@@ -48,7 +47,7 @@ func TestGetBalance(t *testing.T) {
 			userId:  0,
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, userId int) {
-				r.EXPECT().GetUser(userId).Return(
+				r.EXPECT().GetUser(gomock.Any(), userId).Return(
 					nil, errors.New("пользователь не найден"))
 			},
 		},
@@ -58,7 +57,7 @@ func TestGetBalance(t *testing.T) {
 			userId:  0,
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, userId int) {
-				r.EXPECT().GetUser(userId).Return(
+				r.EXPECT().GetUser(gomock.Any(), userId).Return(
 					nil, errors.New("error database"))
 			},
 		},
@@ -74,9 +73,9 @@ func TestGetBalance(t *testing.T) {
 			testCase.mockBehavior(control, testCase.userId)
 
 			repository := &repository.Repository{Control: control}
-			s := NewControlService(repository, nil, nil)
+			s := NewControlService(repository, nil, nil, nil, nil)
 
-			got, err := s.GetBalance(testCase.userId)
+			got, err := s.GetBalance(context.Background(), testCase.userId)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -88,15 +87,108 @@ func TestGetBalance(t *testing.T) {
 	}
 }
 
-func TestReplenishmentBalance(t *testing.T) {
+func TestGetBalanceAt(t *testing.T) {
 
-	type mockBehavior func(s *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User)
+	type mockBehavior func(r *mock_repository.MockControl, userId int, at time.Time)
+
+	at := time.Date(2022, 3, 31, 23, 59, 59, 0, time.UTC)
+	takenAt := time.Date(2022, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		userId       int
+		want         *models.BalanceAt
+		wantErr      bool
+	}{
+		{
+			name:   "OK with snapshot",
+			userId: 1,
+			mockBehavior: func(r *mock_repository.MockControl, userId int, at time.Time) {
+				r.EXPECT().GetUser(gomock.Any(), userId).Return(&models.User{Id: 1, Balance: 100}, nil)
+				r.EXPECT().GetLastSnapshot(gomock.Any(), userId, at).Return(&models.Snapshot{
+					UserID:  1,
+					TakenAt: takenAt,
+					Balance: 500,
+					Reserve: 50,
+				}, nil)
+				r.EXPECT().GetLedgerSum(gomock.Any(), userId, takenAt, at).Return(map[string]int{
+					models.AccountMain:    -200,
+					models.AccountReserve: 200,
+				}, nil)
+			},
+			want: &models.BalanceAt{
+				UserID:  1,
+				Balance: 300,
+				Reserve: 250,
+				At:      at,
+			},
+		},
+
+		{
+			name:   "OK without snapshot",
+			userId: 1,
+			mockBehavior: func(r *mock_repository.MockControl, userId int, at time.Time) {
+				r.EXPECT().GetUser(gomock.Any(), userId).Return(&models.User{Id: 1, Balance: 100}, nil)
+				r.EXPECT().GetLastSnapshot(gomock.Any(), userId, at).Return(nil, nil)
+				r.EXPECT().GetLedgerSum(gomock.Any(), userId, time.Time{}, at).Return(map[string]int{
+					models.AccountMain: 400,
+				}, nil)
+			},
+			want: &models.BalanceAt{
+				UserID:  1,
+				Balance: 400,
+				At:      at,
+			},
+		},
+
+		{
+			name:    "error user not found",
+			userId:  1,
+			wantErr: true,
+			mockBehavior: func(r *mock_repository.MockControl, userId int, at time.Time) {
+				r.EXPECT().GetUser(gomock.Any(), userId).Return(nil, nil)
+			},
+		},
+
+		{
+			name:    "error ledger",
+			userId:  1,
+			wantErr: true,
+			mockBehavior: func(r *mock_repository.MockControl, userId int, at time.Time) {
+				r.EXPECT().GetUser(gomock.Any(), userId).Return(&models.User{Id: 1, Balance: 100}, nil)
+				r.EXPECT().GetLastSnapshot(gomock.Any(), userId, at).Return(nil, nil)
+				r.EXPECT().GetLedgerSum(gomock.Any(), userId, time.Time{}, at).Return(nil, errors.New("db error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			control := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(control, testCase.userId, at)
 
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		log.Fatal(err)
+			repository := &repository.Repository{Control: control}
+			s := NewControlService(repository, unitOfWork{repository}, nil, nil, nil)
+
+			got, err := s.GetBalanceAt(context.Background(), testCase.userId, at)
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
 	}
-	defer db.Close()
+}
+
+func TestReplenishmentBalance(t *testing.T) {
+
+	type mockBehavior func(s *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User)
 
 	testTable := []struct {
 		name          string
@@ -119,11 +211,10 @@ func TestReplenishmentBalance(t *testing.T) {
 				Balance: 200,
 			},
 			mockBehavior: func(r *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), replenishment.UserID).Return(user, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), replenishment.UserID, user.Balance+replenishment.Amount).Return(nil)
-				r.EXPECT().InsertLogTx(gomock.Any(), replenishment.UserID, time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC), replenishment.Amount, "Пополнение баланса").Return(nil)
-				mock.ExpectCommit()
+				r.EXPECT().UpdateBalance(gomock.Any(), replenishment.UserID, user.Balance+replenishment.Amount).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), replenishment.UserID, time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC), replenishment.Amount, "Пополнение баланса", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(replenishment.UserID, models.AccountMain, replenishment.Amount)).Return(nil)
 			},
 		},
 
@@ -136,12 +227,11 @@ func TestReplenishmentBalance(t *testing.T) {
 			},
 			date: time.Date(2022, 10, 01, 0, 0, 0, 0, time.Local),
 			mockBehavior: func(r *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), replenishment.UserID).Return(nil, nil)
-				r.EXPECT().InsertUserTx(gomock.Any(), replenishment.UserID, replenishment.Amount).Return(nil)
-				r.EXPECT().InsertMoneyReserveAccountsTx(gomock.Any(), replenishment.UserID).Return(nil)
-				r.EXPECT().InsertLogTx(gomock.Any(), replenishment.UserID, time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC), replenishment.Amount, "Пополнение баланса").Return(nil)
-				mock.ExpectCommit()
+				r.EXPECT().InsertUser(gomock.Any(), replenishment.UserID, replenishment.Amount).Return(nil)
+				r.EXPECT().InsertMoneyReserveAccounts(gomock.Any(), replenishment.UserID).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), replenishment.UserID, time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC), replenishment.Amount, "Пополнение баланса", models.Note{}).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(replenishment.UserID, models.AccountMain, replenishment.Amount)).Return(nil)
 			},
 		},
 
@@ -155,9 +245,7 @@ func TestReplenishmentBalance(t *testing.T) {
 			wantErr: true,
 			date:    time.Date(2022, 10, 01, 0, 0, 0, 0, time.Local),
 			mockBehavior: func(r *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), replenishment.UserID).Return(nil, errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -175,10 +263,8 @@ func TestReplenishmentBalance(t *testing.T) {
 			wantErr: true,
 			date:    time.Date(2022, 10, 01, 0, 0, 0, 0, time.Local),
 			mockBehavior: func(r *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), replenishment.UserID).Return(user, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), replenishment.UserID, user.Balance+replenishment.Amount).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().UpdateBalance(gomock.Any(), replenishment.UserID, user.Balance+replenishment.Amount).Return(errors.New("db error"))
 			},
 		},
 
@@ -196,11 +282,9 @@ func TestReplenishmentBalance(t *testing.T) {
 			wantErr: true,
 			date:    time.Date(2022, 10, 01, 0, 0, 0, 0, time.Local),
 			mockBehavior: func(r *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), replenishment.UserID).Return(user, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), replenishment.UserID, user.Balance+replenishment.Amount).Return(nil)
-				r.EXPECT().InsertLogTx(gomock.Any(), replenishment.UserID, time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC), replenishment.Amount, "Пополнение баланса").Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().UpdateBalance(gomock.Any(), replenishment.UserID, user.Balance+replenishment.Amount).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), replenishment.UserID, time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC), replenishment.Amount, "Пополнение баланса", models.Note{}).Return(errors.New("db error"))
 			},
 		},
 
@@ -214,10 +298,8 @@ func TestReplenishmentBalance(t *testing.T) {
 			wantErr: true,
 			date:    time.Date(2022, 10, 01, 0, 0, 0, 0, time.Local),
 			mockBehavior: func(r *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), replenishment.UserID).Return(nil, nil)
-				r.EXPECT().InsertUserTx(gomock.Any(), replenishment.UserID, replenishment.Amount).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().InsertUser(gomock.Any(), replenishment.UserID, replenishment.Amount).Return(errors.New("db error"))
 			},
 		},
 
@@ -231,11 +313,9 @@ func TestReplenishmentBalance(t *testing.T) {
 			wantErr: true,
 			date:    time.Date(2022, 10, 01, 0, 0, 0, 0, time.Local),
 			mockBehavior: func(r *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), replenishment.UserID).Return(nil, nil)
-				r.EXPECT().InsertUserTx(gomock.Any(), replenishment.UserID, replenishment.Amount).Return(nil)
-				r.EXPECT().InsertMoneyReserveAccountsTx(gomock.Any(), replenishment.UserID).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().InsertUser(gomock.Any(), replenishment.UserID, replenishment.Amount).Return(nil)
+				r.EXPECT().InsertMoneyReserveAccounts(gomock.Any(), replenishment.UserID).Return(errors.New("db error"))
 			},
 		},
 
@@ -249,12 +329,10 @@ func TestReplenishmentBalance(t *testing.T) {
 			wantErr: true,
 			date:    time.Date(2022, 10, 01, 0, 0, 0, 0, time.Local),
 			mockBehavior: func(r *mock_repository.MockControl, replenishment *models.Replenishment, user *models.User) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), replenishment.UserID).Return(nil, nil)
-				r.EXPECT().InsertUserTx(gomock.Any(), replenishment.UserID, replenishment.Amount).Return(nil)
-				r.EXPECT().InsertMoneyReserveAccountsTx(gomock.Any(), replenishment.UserID).Return(nil)
-				r.EXPECT().InsertLogTx(gomock.Any(), replenishment.UserID, time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC), replenishment.Amount, "Пополнение баланса").Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().InsertUser(gomock.Any(), replenishment.UserID, replenishment.Amount).Return(nil)
+				r.EXPECT().InsertMoneyReserveAccounts(gomock.Any(), replenishment.UserID).Return(nil)
+				r.EXPECT().InsertLog(gomock.Any(), replenishment.UserID, time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC), replenishment.Amount, "Пополнение баланса", models.Note{}).Return(errors.New("db error"))
 			},
 		},
 	}
@@ -270,9 +348,9 @@ func TestReplenishmentBalance(t *testing.T) {
 			testCase.mockBehavior(control, testCase.replenishment, testCase.user)
 
 			repository := &repository.Repository{Control: control}
-			s := NewControlService(repository, nil, db)
+			s := NewControlService(repository, unitOfWork{repository}, nil, nil, nil)
 
-			err = s.ReplenishmentBalance(testCase.replenishment)
+			err := s.ReplenishmentBalance(context.Background(), testCase.replenishment)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -284,11 +362,6 @@ func TestReplenishmentBalance(t *testing.T) {
 }
 
 func TestTransfer(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
 
 	type mockBehavior func(s *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money)
 
@@ -317,26 +390,115 @@ func TestTransfer(t *testing.T) {
 				Balance: 500,
 			},
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(toUser, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().UpdateBalance(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					money.FromUserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					money.Amount,
-					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID)).
+					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID),
+					models.Note{}).
 					Return(nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), money.ToUserID, toUser.Balance+money.Amount).Return(nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().UpdateBalance(gomock.Any(), money.ToUserID, toUser.Balance+money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					money.ToUserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					money.Amount,
-					fmt.Sprintf("Перевод средств от пользователя %d", money.FromUserID)).
+					fmt.Sprintf("Перевод средств от пользователя %d", money.FromUserID),
+					models.Note{}).
 					Return(nil)
-				mock.ExpectCommit()
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(money.FromUserID, models.AccountMain, -money.Amount)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(money.ToUserID, models.AccountMain, money.Amount)).Return(nil)
+			},
+		},
+
+		{
+			name: "OK with comment and metadata",
+			money: &models.Money{
+				FromUserID: 1,
+				ToUserID:   2,
+				Amount:     100,
+				Date:       "2022-10-01",
+				Note:       models.Note{Comment: "за обед", Metadata: models.Metadata{"order": "15"}},
+			},
+			fromUser: &models.User{
+				Id:      1,
+				Balance: 1000,
+			},
+			toUser: &models.User{
+				Id:      2,
+				Balance: 500,
+			},
+			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(toUser, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
+					gomock.Any(),
+					money.FromUserID,
+					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
+					money.Amount,
+					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID),
+					money.Note).
+					Return(nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), money.ToUserID, toUser.Balance+money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
+					gomock.Any(),
+					money.ToUserID,
+					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
+					money.Amount,
+					fmt.Sprintf("Перевод средств от пользователя %d", money.FromUserID),
+					money.Note).
+					Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(money.FromUserID, models.AccountMain, -money.Amount)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(money.ToUserID, models.AccountMain, money.Amount)).Return(nil)
+			},
+		},
+
+		{
+			name: "OK reverse direction locks users in ascending order",
+			money: &models.Money{
+				FromUserID: 2,
+				ToUserID:   1,
+				Amount:     100,
+				Date:       "2022-10-01",
+			},
+			fromUser: &models.User{
+				Id:      2,
+				Balance: 1000,
+			},
+			toUser: &models.User{
+				Id:      1,
+				Balance: 500,
+			},
+			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
+				gomock.InOrder(
+					r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(toUser, nil),
+					r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil),
+				)
+				r.EXPECT().UpdateBalance(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
+					gomock.Any(),
+					money.FromUserID,
+					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
+					money.Amount,
+					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID),
+					models.Note{}).
+					Return(nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), money.ToUserID, toUser.Balance+money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
+					gomock.Any(),
+					money.ToUserID,
+					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
+					money.Amount,
+					fmt.Sprintf("Перевод средств от пользователя %d", money.FromUserID),
+					models.Note{}).
+					Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(money.FromUserID, models.AccountMain, -money.Amount)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(money.ToUserID, models.AccountMain, money.Amount)).Return(nil)
 			},
 		},
 
@@ -354,9 +516,7 @@ func TestTransfer(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(nil, nil)
-				mock.ExpectRollback()
 			},
 		},
 
@@ -374,10 +534,8 @@ func TestTransfer(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(nil, nil)
-				mock.ExpectRollback()
 			},
 		},
 
@@ -399,10 +557,8 @@ func TestTransfer(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(toUser, nil)
-				mock.ExpectRollback()
 			},
 		},
 
@@ -416,9 +572,7 @@ func TestTransfer(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(nil, errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -436,10 +590,8 @@ func TestTransfer(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(nil, errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -461,11 +613,9 @@ func TestTransfer(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(toUser, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().UpdateBalance(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(errors.New("db error"))
 			},
 		},
 
@@ -487,18 +637,17 @@ func TestTransfer(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(toUser, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().UpdateBalance(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					money.FromUserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					money.Amount,
-					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID)).
+					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID),
+					models.Note{}).
 					Return(errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -520,19 +669,18 @@ func TestTransfer(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(toUser, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().UpdateBalance(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					money.FromUserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					money.Amount,
-					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID)).
+					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID),
+					models.Note{}).
 					Return(nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), money.ToUserID, toUser.Balance+money.Amount).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().UpdateBalance(gomock.Any(), money.ToUserID, toUser.Balance+money.Amount).Return(errors.New("db error"))
 			},
 		},
 
@@ -554,26 +702,26 @@ func TestTransfer(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, fromUser *models.User, toUser *models.User, money *models.Money) {
-				mock.ExpectBegin()
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.FromUserID).Return(fromUser, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), money.ToUserID).Return(toUser, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().UpdateBalance(gomock.Any(), money.FromUserID, fromUser.Balance-money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					money.FromUserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					money.Amount,
-					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID)).
+					fmt.Sprintf("Перевод средств пользователю %d", money.ToUserID),
+					models.Note{}).
 					Return(nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), money.ToUserID, toUser.Balance+money.Amount).Return(nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().UpdateBalance(gomock.Any(), money.ToUserID, toUser.Balance+money.Amount).Return(nil)
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					money.ToUserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					money.Amount,
-					fmt.Sprintf("Перевод средств от пользователя %d", money.FromUserID)).
+					fmt.Sprintf("Перевод средств от пользователя %d", money.FromUserID),
+					models.Note{}).
 					Return(errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 	}
@@ -587,9 +735,9 @@ func TestTransfer(t *testing.T) {
 			testCase.mockBehavior(control, testCase.fromUser, testCase.toUser, testCase.money)
 
 			repository := &repository.Repository{Control: control}
-			s := NewControlService(repository, nil, db)
+			s := NewControlService(repository, unitOfWork{repository}, nil, nil, nil)
 
-			err := s.Transfer(testCase.money)
+			err := s.Transfer(context.Background(), testCase.money)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -601,11 +749,6 @@ func TestTransfer(t *testing.T) {
 }
 
 func TestReservation(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
 
 	type mockBehavior func(
 		s *mock_repository.MockControl,
@@ -648,13 +791,12 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(nil)
-				r.EXPECT().UpdateMoneyReserveAccountsTx(gomock.Any(), transaction.UserID, reservBalance+transaction.Amount).Return(nil)
-				r.EXPECT().InsertMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), transaction.UserID, reservBalance+transaction.Amount).Return(nil)
+				r.EXPECT().InsertMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
@@ -662,14 +804,16 @@ func TestReservation(t *testing.T) {
 					transaction.Amount,
 					date).
 					Return(nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					transaction.UserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					transaction.Amount,
-					fmt.Sprintf("Заказ №%d, услуга \"%s\"", transaction.OrderID, service)).
+					fmt.Sprintf("Заказ №%d, услуга \"%s\"", transaction.OrderID, service),
+					models.Note{}).
 					Return(nil)
-				mock.ExpectCommit()
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(transaction.UserID, models.AccountMain, -transaction.Amount)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(transaction.UserID, models.AccountReserve, transaction.Amount)).Return(nil)
 			},
 		},
 
@@ -697,7 +841,7 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 			},
 		},
 
@@ -721,10 +865,8 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(nil, nil)
-				mock.ExpectRollback()
 			},
 		},
 
@@ -752,10 +894,8 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				mock.ExpectRollback()
 			},
 		},
 
@@ -782,7 +922,7 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, errors.New("db error"))
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, errors.New("db error"))
 			},
 		},
 
@@ -806,10 +946,8 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(nil, errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -836,11 +974,9 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, errors.New("db error"))
 			},
 		},
 
@@ -868,12 +1004,10 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(errors.New("db error"))
 			},
 		},
 
@@ -901,13 +1035,11 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(nil)
-				r.EXPECT().UpdateMoneyReserveAccountsTx(gomock.Any(), transaction.UserID, reservBalance+transaction.Amount).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), transaction.UserID, reservBalance+transaction.Amount).Return(errors.New("db error"))
 			},
 		},
 
@@ -935,13 +1067,12 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(nil)
-				r.EXPECT().UpdateMoneyReserveAccountsTx(gomock.Any(), transaction.UserID, reservBalance+transaction.Amount).Return(nil)
-				r.EXPECT().InsertMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), transaction.UserID, reservBalance+transaction.Amount).Return(nil)
+				r.EXPECT().InsertMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
@@ -949,7 +1080,6 @@ func TestReservation(t *testing.T) {
 					transaction.Amount,
 					date).
 					Return(errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -977,13 +1107,12 @@ func TestReservation(t *testing.T) {
 				service string,
 				reservBalance int,
 				date time.Time) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(nil)
-				r.EXPECT().UpdateMoneyReserveAccountsTx(gomock.Any(), transaction.UserID, reservBalance+transaction.Amount).Return(nil)
-				r.EXPECT().InsertMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().UpdateBalance(gomock.Any(), transaction.UserID, user.Balance-transaction.Amount).Return(nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), transaction.UserID, reservBalance+transaction.Amount).Return(nil)
+				r.EXPECT().InsertMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
@@ -991,14 +1120,14 @@ func TestReservation(t *testing.T) {
 					transaction.Amount,
 					date).
 					Return(nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					transaction.UserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					transaction.Amount,
-					fmt.Sprintf("Заказ №%d, услуга \"%s\"", transaction.OrderID, service)).
+					fmt.Sprintf("Заказ №%d, услуга \"%s\"", transaction.OrderID, service),
+					models.Note{}).
 					Return(errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 	}
@@ -1018,9 +1147,9 @@ func TestReservation(t *testing.T) {
 				testCase.date)
 
 			repository := &repository.Repository{Control: control}
-			s := NewControlService(repository, nil, db)
+			s := NewControlService(repository, unitOfWork{repository}, nil, nil, nil)
 
-			err := s.Reservation(testCase.transaction)
+			err := s.Reservation(context.Background(), testCase.transaction)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -1032,11 +1161,6 @@ func TestReservation(t *testing.T) {
 }
 
 func TestCancelReservation(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
 
 	type mockBehavior func(
 		s *mock_repository.MockControl,
@@ -1083,11 +1207,10 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
@@ -1095,16 +1218,18 @@ func TestCancelReservation(t *testing.T) {
 					transaction.Amount,
 					date).
 					Return(rows, nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					transaction.UserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					transaction.Amount,
-					fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service)).
+					fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service),
+					models.Note{}).
 					Return(nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), transaction.UserID, user.Balance+transaction.Amount).Return(nil)
-				r.EXPECT().UpdateMoneyReserveAccountsTx(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(nil)
-				mock.ExpectCommit()
+				r.EXPECT().UpdateBalance(gomock.Any(), transaction.UserID, user.Balance+transaction.Amount).Return(nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(transaction.UserID, models.AccountReserve, -transaction.Amount)).Return(nil)
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(transaction.UserID, models.AccountMain, transaction.Amount)).Return(nil)
 			},
 		},
 
@@ -1127,7 +1252,7 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 			},
 		},
 
@@ -1150,10 +1275,8 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(nil, nil)
-				mock.ExpectRollback()
 			},
 		},
 
@@ -1183,11 +1306,10 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
@@ -1195,7 +1317,6 @@ func TestCancelReservation(t *testing.T) {
 					transaction.Amount,
 					date).
 					Return(rows, nil)
-				mock.ExpectRollback()
 			},
 		},
 
@@ -1217,7 +1338,7 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, errors.New("db error"))
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, errors.New("db error"))
 			},
 		},
 
@@ -1240,10 +1361,8 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(nil, errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -1271,11 +1390,9 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, errors.New("db error"))
 			},
 		},
 
@@ -1304,11 +1421,10 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
@@ -1316,7 +1432,6 @@ func TestCancelReservation(t *testing.T) {
 					transaction.Amount,
 					date).
 					Return(rows, errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -1346,11 +1461,10 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
@@ -1358,14 +1472,14 @@ func TestCancelReservation(t *testing.T) {
 					transaction.Amount,
 					date).
 					Return(rows, nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					transaction.UserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					transaction.Amount,
-					fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service)).
+					fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service),
+					models.Note{}).
 					Return(errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -1395,11 +1509,10 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
@@ -1407,15 +1520,15 @@ func TestCancelReservation(t *testing.T) {
 					transaction.Amount,
 					date).
 					Return(rows, nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					transaction.UserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					transaction.Amount,
-					fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service)).
+					fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service),
+					models.Note{}).
 					Return(nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), transaction.UserID, user.Balance+transaction.Amount).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().UpdateBalance(gomock.Any(), transaction.UserID, user.Balance+transaction.Amount).Return(errors.New("db error"))
 			},
 		},
 
@@ -1445,11 +1558,10 @@ func TestCancelReservation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				r.EXPECT().GetService(transaction.ServiceID).Return(service, nil)
-				mock.ExpectBegin()
+				r.EXPECT().GetService(gomock.Any(), transaction.ServiceID).Return(service, nil)
 				r.EXPECT().GetUserForUpdate(gomock.Any(), transaction.UserID).Return(user, nil)
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
@@ -1457,16 +1569,16 @@ func TestCancelReservation(t *testing.T) {
 					transaction.Amount,
 					date).
 					Return(rows, nil)
-				r.EXPECT().InsertLogTx(
+				r.EXPECT().InsertLog(
 					gomock.Any(),
 					transaction.UserID,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 					transaction.Amount,
-					fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service)).
+					fmt.Sprintf("Отмена заказа №%d, услуга \"%s\"", transaction.OrderID, service),
+					models.Note{}).
 					Return(nil)
-				r.EXPECT().UpdateBalanceTx(gomock.Any(), transaction.UserID, user.Balance+transaction.Amount).Return(nil)
-				r.EXPECT().UpdateMoneyReserveAccountsTx(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().UpdateBalance(gomock.Any(), transaction.UserID, user.Balance+transaction.Amount).Return(nil)
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(errors.New("db error"))
 			},
 		},
 	}
@@ -1487,9 +1599,9 @@ func TestCancelReservation(t *testing.T) {
 				testCase.rows)
 
 			repository := &repository.Repository{Control: control}
-			s := NewControlService(repository, nil, db)
+			s := NewControlService(repository, unitOfWork{repository}, nil, nil, nil)
 
-			err := s.CancelReservation(testCase.transaction)
+			err := s.CancelReservation(context.Background(), testCase.transaction)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -1501,11 +1613,6 @@ func TestCancelReservation(t *testing.T) {
 }
 
 func TestConfirmation(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
 
 	type mockBehavior func(
 		s *mock_repository.MockControl,
@@ -1541,24 +1648,23 @@ func TestConfirmation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				mock.ExpectBegin()
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
 					transaction.OrderID,
 					transaction.Amount,
 					date).Return(rows, nil)
-				r.EXPECT().UpdateMoneyReserveAccountsTx(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(nil)
-				r.EXPECT().InsertReportTx(
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(nil)
+				r.EXPECT().InsertReport(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
 					transaction.Amount,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC)).
 					Return(nil)
-				mock.ExpectCommit()
+				r.EXPECT().InsertLedger(gomock.Any(), ledgerEntry(transaction.UserID, models.AccountReserve, -transaction.Amount)).Return(nil)
 			},
 		},
 
@@ -1580,16 +1686,14 @@ func TestConfirmation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				mock.ExpectBegin()
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
 					transaction.OrderID,
 					transaction.Amount,
 					date).Return(rows, nil)
-				mock.ExpectRollback()
 			},
 		},
 
@@ -1611,9 +1715,7 @@ func TestConfirmation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				mock.ExpectBegin()
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, errors.New("db error"))
 			},
 		},
 
@@ -1635,16 +1737,14 @@ func TestConfirmation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				mock.ExpectBegin()
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
 					transaction.OrderID,
 					transaction.Amount,
 					date).Return(rows, errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 
@@ -1667,17 +1767,15 @@ func TestConfirmation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				mock.ExpectBegin()
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
 					transaction.OrderID,
 					transaction.Amount,
 					date).Return(rows, nil)
-				r.EXPECT().UpdateMoneyReserveAccountsTx(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(errors.New("db error"))
-				mock.ExpectRollback()
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(errors.New("db error"))
 			},
 		},
 
@@ -1700,24 +1798,22 @@ func TestConfirmation(t *testing.T) {
 				reservBalance int,
 				date time.Time,
 				rows int64) {
-				mock.ExpectBegin()
-				r.EXPECT().GetBalanceReserveAccountsTx(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
-				r.EXPECT().DeleteMoneyReserveDetailsTx(
+				r.EXPECT().GetBalanceReserveAccounts(gomock.Any(), transaction.UserID).Return(reservBalance, nil)
+				r.EXPECT().DeleteMoneyReserveDetails(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
 					transaction.OrderID,
 					transaction.Amount,
 					date).Return(rows, nil)
-				r.EXPECT().UpdateMoneyReserveAccountsTx(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(nil)
-				r.EXPECT().InsertReportTx(
+				r.EXPECT().UpdateMoneyReserveAccounts(gomock.Any(), transaction.UserID, reservBalance-transaction.Amount).Return(nil)
+				r.EXPECT().InsertReport(
 					gomock.Any(),
 					transaction.UserID,
 					transaction.ServiceID,
 					transaction.Amount,
 					time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC)).
 					Return(errors.New("db error"))
-				mock.ExpectRollback()
 			},
 		},
 	}
@@ -1736,9 +1832,9 @@ func TestConfirmation(t *testing.T) {
 				testCase.rows)
 
 			repository := &repository.Repository{Control: control}
-			s := NewControlService(repository, nil, db)
+			s := NewControlService(repository, unitOfWork{repository}, nil, nil, nil)
 
-			err := s.Confirmation(testCase.transaction)
+			err := s.Confirmation(context.Background(), testCase.transaction)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -1750,11 +1846,6 @@ func TestConfirmation(t *testing.T) {
 }
 
 func TestCreateReport(t *testing.T) {
-	db, _, err := sqlmock.New()
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
 
 	conf := config.Config{
 		Host: "localhost:8081",
@@ -1789,7 +1880,7 @@ func TestCreateReport(t *testing.T) {
 				from time.Time,
 				report map[string]int) {
 				to := from.AddDate(0, 1, 0).Add(-time.Nanosecond)
-				r.EXPECT().GetReport(from, to).Return(report, nil)
+				r.EXPECT().GetReport(gomock.Any(), from, to).Return(report, nil)
 			},
 			want: "localhost:8081/file/",
 		},
@@ -1809,7 +1900,7 @@ func TestCreateReport(t *testing.T) {
 				from time.Time,
 				report map[string]int) {
 				to := from.AddDate(0, 1, 0).Add(-time.Nanosecond)
-				r.EXPECT().GetReport(from, to).Return(report, errors.New("db error"))
+				r.EXPECT().GetReport(gomock.Any(), from, to).Return(report, errors.New("db error"))
 			},
 		},
 	}
@@ -1827,9 +1918,9 @@ func TestCreateReport(t *testing.T) {
 				testCase.report)
 
 			repository := &repository.Repository{Control: control}
-			s := NewControlService(repository, &conf, db)
+			s := NewControlService(repository, unitOfWork{repository}, &conf, nil, nil)
 
-			got, err := s.CreateReport(&testCase.requestReport)
+			got, err := s.CreateReport(context.Background(), &testCase.requestReport)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -1845,11 +1936,6 @@ func TestCreateReport(t *testing.T) {
 func TestGetHistory(t *testing.T) {
 
 	type mockBehavior func(s *mock_repository.MockControl, requestHistory *models.RequestHistory)
-	db, _, err := sqlmock.New()
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer db.Close()
 
 	testTable := []struct {
 		name           string
@@ -1873,7 +1959,7 @@ func TestGetHistory(t *testing.T) {
 				},
 			},
 			mockBehavior: func(r *mock_repository.MockControl, requestHistory *models.RequestHistory) {
-				r.EXPECT().GetHistory(requestHistory).Return([]models.History{
+				r.EXPECT().GetHistory(gomock.Any(), requestHistory).Return([]models.History{
 					{
 						Date:        time.Date(2022, 10, 01, 0, 0, 0, 0, time.UTC),
 						Amount:      100,
@@ -1892,7 +1978,7 @@ func TestGetHistory(t *testing.T) {
 			},
 			wantErr: true,
 			mockBehavior: func(r *mock_repository.MockControl, requestHistory *models.RequestHistory) {
-				r.EXPECT().GetHistory(requestHistory).Return(nil, errors.New("db error"))
+				r.EXPECT().GetHistory(gomock.Any(), requestHistory).Return(nil, errors.New("db error"))
 			},
 		},
 	}
@@ -1906,9 +1992,9 @@ func TestGetHistory(t *testing.T) {
 			testCase.mockBehavior(control, &testCase.requestHistory)
 
 			repository := &repository.Repository{Control: control}
-			s := NewControlService(repository, nil, db)
+			s := NewControlService(repository, unitOfWork{repository}, nil, nil, nil)
 
-			got, err := s.GetHistory(&testCase.requestHistory)
+			got, err := s.GetHistory(context.Background(), &testCase.requestHistory)
 
 			if testCase.wantErr {
 				assert.Error(t, err)
@@ -1919,3 +2005,73 @@ func TestGetHistory(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorType(t *testing.T) {
+	testTable := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "user not found", err: ErrUserNotFound, want: "user_not_found"},
+		{name: "insufficient funds", err: fmt.Errorf("перевод: %w", ErrInsufficientFunds), want: "insufficient_funds"},
+		{name: "service not found", err: ErrServiceNotFound, want: "service_not_found"},
+		{name: "reserve not found", err: ErrReserveNotFound, want: "reserve_not_found"},
+		{name: "limit exceeded", err: &LimitExceededError{Operation: models.OperationTransfer}, want: "limit_exceeded"},
+		{name: "risk review", err: &RiskError{Decision: &models.RiskDecision{Decision: models.RiskReview}}, want: "risk_review"},
+		{name: "risk denied", err: &RiskError{Decision: &models.RiskDecision{Decision: models.RiskDeny}}, want: "risk_denied"},
+		{name: "timeout", err: context.DeadlineExceeded, want: "timeout"},
+		{name: "canceled", err: context.Canceled, want: "canceled"},
+		{name: "internal", err: errors.New("some error"), want: "internal"},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, errorType(testCase.err))
+		})
+	}
+}
+
+// unitOfWork выполняет fn без транзакции, передавая ей мок репозитория
+type unitOfWork struct {
+	repo repository.Control
+}
+
+func (u unitOfWork) WithinTx(ctx context.Context, fn func(repo repository.Control) error) error {
+	return fn(u.repo)
+}
+
+// ledgerEntryMatcher сравнивает запись журнала без учета времени ее создания и описания
+type ledgerEntryMatcher struct {
+	userId  int
+	account string
+	amount  int
+}
+
+func ledgerEntry(userId int, account string, amount int) gomock.Matcher {
+	return ledgerEntryMatcher{userId: userId, account: account, amount: amount}
+}
+
+func (m ledgerEntryMatcher) Matches(x interface{}) bool {
+	entry, ok := x.(*models.LedgerEntry)
+	return ok && entry.UserID == m.userId && entry.Account == m.account && entry.Amount == m.amount
+}
+
+func (m ledgerEntryMatcher) String() string {
+	return fmt.Sprintf("ledger entry of user %d, account %s, amount %d", m.userId, m.account, m.amount)
+}
+
+// ledgerOperationMatcher проверяет операцию, которой сделана запись журнала
+type ledgerOperationMatcher string
+
+func ledgerOperation(operation string) gomock.Matcher {
+	return ledgerOperationMatcher(operation)
+}
+
+func (m ledgerOperationMatcher) Matches(x interface{}) bool {
+	entry, ok := x.(*models.LedgerEntry)
+	return ok && entry.Operation == string(m)
+}
+
+func (m ledgerOperationMatcher) String() string {
+	return fmt.Sprintf("ledger entry of operation %s", string(m))
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	c "userbalance/internal/config"
+	"userbalance/internal/models"
+	"userbalance/internal/signature"
+)
+
+var ErrInvalidSignature = errors.New("подпись запроса не принята")
+
+// maxNonceLength ограничивает одноразовое значение, которое хранится до истечения окна подписи
+const maxNonceLength int = 128
+
+type SignatureService struct {
+	nonces signature.NonceStore
+	conf   c.Source
+	now    func() time.Time
+}
+
+func NewSignatureService(nonces signature.NonceStore, conf c.Source) *SignatureService {
+	return &SignatureService{
+		nonces: nonces,
+		conf:   conf,
+		now:    time.Now,
+	}
+}
+
+// SignatureRequired сообщает, что запросы маршрута route должны быть подписаны (ключ signedroutes)
+func (s *SignatureService) SignatureRequired(route string) bool {
+	for _, signed := range strings.Split(s.conf.Get().SignedRoutes, ",") {
+		if strings.TrimSpace(signed) == route {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxRequestBody возвращает наибольший размер тела запроса в байтах, которое читается для проверки подписи
+func (s *SignatureService) MaxRequestBody() int64 {
+	return int64(s.conf.Get().MaxRequestBody)
+}
+
+// VerifySignature проверяет подпись запроса секретом клиента, время подписи и то,
+// что одноразовое значение запроса не использовалось в пределах окна signatureskew
+func (s *SignatureService) VerifySignature(ctx context.Context, principal *models.Principal, request *models.SignedRequest) error {
+	if principal == nil || principal.SigningSecret == "" {
+		return fmt.Errorf("%w: клиенту не выдан секрет подписи", ErrInvalidSignature)
+	}
+	if request.Signature == "" || request.Timestamp == "" || request.Nonce == "" {
+		return fmt.Errorf("%w: не указаны заголовки %s, %s и %s", ErrInvalidSignature,
+			signature.HeaderSignature, signature.HeaderTimestamp, signature.HeaderNonce)
+	}
+	if len(request.Nonce) > maxNonceLength {
+		return fmt.Errorf("%w: одноразовое значение длиннее %d символов", ErrInvalidSignature, maxNonceLength)
+	}
+
+	timestamp, err := strconv.ParseInt(request.Timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: время подписи должно быть указано в секундах Unix", ErrInvalidSignature)
+	}
+	skew := time.Duration(s.conf.Get().SignatureSkew) * time.Second
+	if diff := s.now().Sub(time.Unix(timestamp, 0)); diff > skew || diff < -skew {
+		return fmt.Errorf("%w: время подписи расходится с временем сервера больше чем на %s", ErrInvalidSignature, skew)
+	}
+
+	if !signature.Verify(principal.SigningSecret, request.Method, request.URI, request.Timestamp, request.Nonce, request.Body, request.Signature) {
+		return fmt.Errorf("%w: подпись не совпадает", ErrInvalidSignature)
+	}
+
+	// запрос с тем же значением может прийти до истечения окна с любой стороны от времени подписи
+	fresh, err := s.nonces.Remember(ctx, principal.Client+":"+request.Nonce, 2*skew)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return fmt.Errorf("%w: запрос с таким одноразовым значением уже был выполнен", ErrInvalidSignature)
+	}
+
+	return nil
+}
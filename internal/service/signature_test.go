@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"userbalance/internal/config"
+	"userbalance/internal/models"
+	"userbalance/internal/signature"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureService_SignatureRequired(t *testing.T) {
+	s := NewSignatureService(signature.NewMemoryNonceStore(), &config.Config{SignedRoutes: "/topup, /transfer"})
+
+	assert.True(t, s.SignatureRequired("/topup"))
+	assert.True(t, s.SignatureRequired("/transfer"))
+	assert.False(t, s.SignatureRequired("/reserv"))
+}
+
+func TestSignatureService_VerifySignature(t *testing.T) {
+	const secret = "secret"
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	timestamp := "1664582400"
+	body := []byte(`{"userid":15,"amount":100}`)
+
+	billing := &models.Principal{Client: "billing", SigningSecret: secret}
+	signed := func(nonce, timestamp string) *models.SignedRequest {
+		return &models.SignedRequest{
+			Method:    "POST",
+			URI:       "/topup",
+			Timestamp: timestamp,
+			Nonce:     nonce,
+			Body:      body,
+			Signature: signature.Sign(secret, "POST", "/topup", timestamp, nonce, body),
+		}
+	}
+
+	testTable := []struct {
+		name      string
+		principal *models.Principal
+		requests  []*models.SignedRequest
+		wantErr   bool
+	}{
+		{
+			name:      "OK",
+			principal: billing,
+			requests:  []*models.SignedRequest{signed("n1", timestamp)},
+		},
+
+		{
+			name:      "OK within skew",
+			principal: billing,
+			requests:  []*models.SignedRequest{signed("n1", "1664582280")},
+		},
+
+		{
+			name:      "error replay",
+			principal: billing,
+			requests:  []*models.SignedRequest{signed("n1", timestamp), signed("n1", timestamp)},
+			wantErr:   true,
+		},
+
+		{
+			name:      "error outside skew",
+			principal: billing,
+			requests:  []*models.SignedRequest{signed("n1", "1664582000")},
+			wantErr:   true,
+		},
+
+		{
+			name:      "error tampered body",
+			principal: billing,
+			requests: []*models.SignedRequest{func() *models.SignedRequest {
+				request := signed("n1", timestamp)
+				request.Body = []byte(`{"userid":15,"amount":100000}`)
+				return request
+			}()},
+			wantErr: true,
+		},
+
+		{
+			name:      "error no headers",
+			principal: billing,
+			requests:  []*models.SignedRequest{{Method: "POST", URI: "/topup", Body: body}},
+			wantErr:   true,
+		},
+
+		{
+			name:      "error client without secret",
+			principal: &models.Principal{Client: "shop"},
+			requests:  []*models.SignedRequest{signed("n1", timestamp)},
+			wantErr:   true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			s := NewSignatureService(signature.NewMemoryNonceStore(), &config.Config{SignatureSkew: 300})
+			s.now = func() time.Time { return now }
+
+			var err error
+			for _, request := range testCase.requests {
+				err = s.VerifySignature(context.Background(), testCase.principal, request)
+			}
+
+			if testCase.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidSignature))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"time"
+	"userbalance/internal/logger"
+	"userbalance/internal/repository"
+)
+
+type SnapshotService struct {
+	repo repository.Control
+	uow  repository.UnitOfWork
+}
+
+func NewSnapshotService(repo repository.Control, uow repository.UnitOfWork) *SnapshotService {
+	return &SnapshotService{
+		repo: repo,
+		uow:  uow,
+	}
+}
+
+// CreateSnapshot сохраняет остатки всех пользователей по записям журнала, сделанным до takenAt
+func (s *SnapshotService) CreateSnapshot(ctx context.Context, takenAt time.Time) (int64, error) {
+	return s.repo.InsertSnapshots(ctx, takenAt)
+}
+
+// RebuildSnapshots пересоздает снимки на начало каждых суток, начиная с первой записи журнала
+func (s *SnapshotService) RebuildSnapshots(ctx context.Context) error {
+	return s.uow.WithinTx(ctx, func(repo repository.Control) error {
+		if err := repo.DeleteSnapshots(ctx); err != nil {
+			return err
+		}
+
+		first, err := repo.GetFirstLedgerDate(ctx)
+		if err != nil {
+			return err
+		}
+		if first.IsZero() {
+			return nil
+		}
+
+		now := time.Now()
+		for day := nextMidnight(first); !day.After(now); day = day.AddDate(0, 0, 1) {
+			if _, err = repo.InsertSnapshots(ctx, day); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Run каждую полночь сохраняет снимок остатков, пока не будет отменен ctx
+func (s *SnapshotService) Run(ctx context.Context) {
+	for {
+		next := nextMidnight(time.Now())
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if n, err := s.CreateSnapshot(ctx, next); err != nil {
+			logger.Error("ошибка при создании снимка остатков", "error", err)
+		} else {
+			logger.Info("снимок остатков создан", "takenat", next, "users", n)
+		}
+	}
+}
+
+func nextMidnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1)
+}
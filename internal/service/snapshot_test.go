@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"userbalance/internal/repository"
+	mock_repository "userbalance/internal/repository/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebuildSnapshots(t *testing.T) {
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	first := time.Now().AddDate(0, 0, -2)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().DeleteSnapshots(gomock.Any()).Return(nil)
+				r.EXPECT().GetFirstLedgerDate(gomock.Any()).Return(first, nil)
+				gomock.InOrder(
+					r.EXPECT().InsertSnapshots(gomock.Any(), nextMidnight(first)).Return(int64(1), nil),
+					r.EXPECT().InsertSnapshots(gomock.Any(), nextMidnight(first).AddDate(0, 0, 1)).Return(int64(1), nil),
+				)
+			},
+		},
+
+		{
+			name: "OK empty ledger",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().DeleteSnapshots(gomock.Any()).Return(nil)
+				r.EXPECT().GetFirstLedgerDate(gomock.Any()).Return(time.Time{}, nil)
+			},
+		},
+
+		{
+			name:    "error insert",
+			wantErr: true,
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().DeleteSnapshots(gomock.Any()).Return(nil)
+				r.EXPECT().GetFirstLedgerDate(gomock.Any()).Return(first, nil)
+				r.EXPECT().InsertSnapshots(gomock.Any(), nextMidnight(first)).Return(int64(0), errors.New("db error"))
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			control := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(control)
+
+			repository := &repository.Repository{Control: control}
+			s := NewSnapshotService(repository, unitOfWork{repository})
+
+			err := s.RebuildSnapshots(context.Background())
+
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNextMidnight(t *testing.T) {
+	assert.Equal(t,
+		time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC),
+		nextMidnight(time.Date(2022, 3, 31, 15, 4, 5, 0, time.UTC)))
+	assert.Equal(t,
+		time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC),
+		nextMidnight(time.Date(2022, 3, 31, 0, 0, 0, 0, time.UTC)))
+}
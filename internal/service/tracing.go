@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"time"
+	"userbalance/internal/models"
+	"userbalance/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedControl записывает span для каждого вызова операций next
+type TracedControl struct {
+	next Control
+}
+
+func NewTracedControl(next Control) *TracedControl {
+	return &TracedControl{next: next}
+}
+
+func userID(userId int) attribute.KeyValue {
+	return attribute.Int("user.id", userId)
+}
+
+func amount(amount int) attribute.KeyValue {
+	return attribute.Int("amount", amount)
+}
+
+func (t *TracedControl) ReplenishmentBalance(ctx context.Context, replenishment *models.Replenishment) error {
+	ctx, span := tracing.Start(ctx, "ControlService.ReplenishmentBalance", userID(replenishment.UserID), amount(replenishment.Amount))
+	err := t.next.ReplenishmentBalance(ctx, replenishment)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) Transfer(ctx context.Context, money *models.Money) error {
+	ctx, span := tracing.Start(ctx, "ControlService.Transfer",
+		attribute.Int("user.from", money.FromUserID), attribute.Int("user.to", money.ToUserID), amount(money.Amount))
+	err := t.next.Transfer(ctx, money)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) Reservation(ctx context.Context, transaction *models.Transaction) error {
+	ctx, span := tracing.Start(ctx, "ControlService.Reservation", transactionAttributes(transaction)...)
+	err := t.next.Reservation(ctx, transaction)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) CancelReservation(ctx context.Context, transaction *models.Transaction) error {
+	ctx, span := tracing.Start(ctx, "ControlService.CancelReservation", transactionAttributes(transaction)...)
+	err := t.next.CancelReservation(ctx, transaction)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) Confirmation(ctx context.Context, transaction *models.Transaction) error {
+	ctx, span := tracing.Start(ctx, "ControlService.Confirmation", transactionAttributes(transaction)...)
+	err := t.next.Confirmation(ctx, transaction)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedControl) GetBalance(ctx context.Context, userId int) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "ControlService.GetBalance", userID(userId))
+	user, err := t.next.GetBalance(ctx, userId)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (t *TracedControl) GetBalanceAt(ctx context.Context, userId int, at time.Time) (*models.BalanceAt, error) {
+	ctx, span := tracing.Start(ctx, "ControlService.GetBalanceAt", userID(userId))
+	balance, err := t.next.GetBalanceAt(ctx, userId, at)
+	tracing.End(span, err)
+	return balance, err
+}
+
+func (t *TracedControl) CreateReport(ctx context.Context, requestReport *models.RequestReport) (string, error) {
+	ctx, span := tracing.Start(ctx, "ControlService.CreateReport")
+	path, err := t.next.CreateReport(ctx, requestReport)
+	tracing.End(span, err)
+	return path, err
+}
+
+func (t *TracedControl) GetHistory(ctx context.Context, requestHistory *models.RequestHistory) ([]models.History, error) {
+	ctx, span := tracing.Start(ctx, "ControlService.GetHistory", userID(requestHistory.UserID))
+	history, err := t.next.GetHistory(ctx, requestHistory)
+	tracing.End(span, err)
+	return history, err
+}
+
+func (t *TracedControl) Batch(ctx context.Context, requestBatch *models.RequestBatch) (*models.BatchResults, error) {
+	ctx, span := tracing.Start(ctx, "ControlService.Batch",
+		attribute.String("batch.mode", requestBatch.Mode), attribute.Int("batch.operations", len(requestBatch.Operations)))
+	results, err := t.next.Batch(ctx, requestBatch)
+	tracing.End(span, err)
+	return results, err
+}
+
+func transactionAttributes(transaction *models.Transaction) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		userID(transaction.UserID),
+		attribute.Int("service.id", transaction.ServiceID),
+		attribute.Int("order.id", transaction.OrderID),
+		amount(transaction.Amount),
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"userbalance/internal/models"
+	mock_repository "userbalance/internal/repository/mocks"
+	"userbalance/internal/tracing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracedControl(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewProvider(sdktrace.WithSyncer(exporter), 1))
+
+	type mockBehavior func(r *mock_repository.MockControl)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		call         func(ctx context.Context, s Control) error
+		wantName     string
+		wantAttr     attribute.KeyValue
+		wantCode     codes.Code
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUser(gomock.Any(), 1).DoAndReturn(func(ctx context.Context, userId int) (*models.User, error) {
+					assert.True(t, trace.SpanContextFromContext(ctx).IsValid(), "span должен передаваться в репозиторий")
+					return &models.User{Id: 1, Balance: 100}, nil
+				})
+			},
+			call: func(ctx context.Context, s Control) error {
+				_, err := s.GetBalance(ctx, 1)
+				return err
+			},
+			wantName: "ControlService.GetBalance",
+			wantAttr: attribute.Int("user.id", 1),
+			wantCode: codes.Unset,
+		},
+
+		{
+			name: "error insufficient funds",
+			mockBehavior: func(r *mock_repository.MockControl) {
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 1).Return(&models.User{Id: 1, Balance: 10}, nil)
+				r.EXPECT().GetUserForUpdate(gomock.Any(), 2).Return(&models.User{Id: 2, Balance: 0}, nil)
+			},
+			call: func(ctx context.Context, s Control) error {
+				return s.Transfer(ctx, &models.Money{FromUserID: 1, ToUserID: 2, Amount: 100})
+			},
+			wantName: "ControlService.Transfer",
+			wantAttr: attribute.Int("amount", 100),
+			wantCode: codes.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			exporter.Reset()
+
+			repo := mock_repository.NewMockControl(c)
+			testCase.mockBehavior(repo)
+
+			s := NewTracedControl(NewControlService(repo, unitOfWork{repo: repo}, nil, nil, nil))
+			testCase.call(context.Background(), s)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, testCase.wantName, spans[0].Name)
+			assert.Contains(t, spans[0].Attributes, testCase.wantAttr)
+			assert.Equal(t, testCase.wantCode, spans[0].Status.Code)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Package signature подписывает запросы HMAC-SHA256 и защищает от их повторной отправки
+package signature
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Заголовки подписанного запроса
+const (
+	HeaderSignature string = "X-Signature"
+	HeaderTimestamp string = "X-Signature-Timestamp"
+	HeaderNonce     string = "X-Signature-Nonce"
+)
+
+// Sign возвращает подпись запроса: HMAC-SHA256 с секретом клиента от строки
+// METHOD\nURI\nTIMESTAMP\nNONCE\nSHA256(BODY), где URI - путь с параметрами запроса,
+// а хэш тела и подпись записываются в hex
+func Sign(secret string, method, uri, timestamp, nonce string, body []byte) string {
+	sum := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{
+		strings.ToUpper(method),
+		uri,
+		timestamp,
+		nonce,
+		hex.EncodeToString(sum[:]),
+	}, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify сравнивает подпись за постоянное время
+func Verify(secret string, method, uri, timestamp, nonce string, body []byte, signature string) bool {
+	expected := Sign(secret, method, uri, timestamp, nonce, body)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+// NonceStore запоминает одноразовые значения подписанных запросов. MemoryNonceStore подходит
+// для одного экземпляра сервиса, для нескольких экземпляров нужно общее хранилище
+type NonceStore interface {
+	// Remember запоминает key на ttl и возвращает false, если key уже был передан и еще не истек
+	Remember(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// MemoryNonceStore хранит одноразовые значения в памяти процесса
+type MemoryNonceStore struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		expires: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryNonceStore) Remember(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// истекшие значения удаляются не чаще раза в ttl, чтобы не обходить карту на каждом запросе
+	if now.Sub(s.lastSweep) >= ttl {
+		for k, expires := range s.expires {
+			if !now.Before(expires) {
+				delete(s.expires, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if expires, ok := s.expires[key]; ok && now.Before(expires) {
+		return false, nil
+	}
+	s.expires[key] = now.Add(ttl)
+
+	return true, nil
+}
@@ -0,0 +1,84 @@
+package signature
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	const secret = "secret"
+	body := []byte(`{"userid":15,"amount":100}`)
+	signature := Sign(secret, "POST", "/topup", "1664582400", "n1", body)
+
+	testTable := []struct {
+		name      string
+		method    string
+		uri       string
+		timestamp string
+		nonce     string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{
+			name: "OK", method: "POST", uri: "/topup", timestamp: "1664582400", nonce: "n1", body: body,
+			signature: signature, want: true,
+		},
+		{
+			name: "OK lowercase method", method: "post", uri: "/topup", timestamp: "1664582400", nonce: "n1", body: body,
+			signature: signature, want: true,
+		},
+		{
+			name: "changed body", method: "POST", uri: "/topup", timestamp: "1664582400", nonce: "n1",
+			body: []byte(`{"userid":15,"amount":1000}`), signature: signature,
+		},
+		{
+			name: "changed path", method: "POST", uri: "/transfer", timestamp: "1664582400", nonce: "n1", body: body,
+			signature: signature,
+		},
+		{
+			name: "changed timestamp", method: "POST", uri: "/topup", timestamp: "1664582401", nonce: "n1", body: body,
+			signature: signature,
+		},
+		{
+			name: "changed nonce", method: "POST", uri: "/topup", timestamp: "1664582400", nonce: "n2", body: body,
+			signature: signature,
+		},
+		{
+			name: "wrong secret", method: "POST", uri: "/topup", timestamp: "1664582400", nonce: "n1", body: body,
+			signature: Sign("other", "POST", "/topup", "1664582400", "n1", body),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := Verify(secret, testCase.method, testCase.uri, testCase.timestamp, testCase.nonce, testCase.body, testCase.signature)
+			assert.Equal(t, testCase.want, got)
+		})
+	}
+}
+
+func TestMemoryNonceStore_Remember(t *testing.T) {
+	current := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryNonceStore()
+	s.now = func() time.Time { return current }
+
+	ok, err := s.Remember(context.Background(), "billing:n1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, _ = s.Remember(context.Background(), "billing:n1", time.Minute)
+	assert.False(t, ok, "повтор одноразового значения")
+
+	ok, _ = s.Remember(context.Background(), "shop:n1", time.Minute)
+	assert.True(t, ok, "значения разных клиентов не пересекаются")
+
+	current = current.Add(time.Minute)
+	ok, _ = s.Remember(context.Background(), "billing:n2", time.Minute)
+	assert.True(t, ok)
+	assert.NotContains(t, s.expires, "billing:n1", "истекшие значения удаляются")
+}
@@ -0,0 +1,168 @@
+// Package tlsconfig загружает сертификат сервера и сертификаты удостоверяющих центров клиентов
+// и перечитывает их без перезапуска сервера
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+	"userbalance/internal/logger"
+)
+
+// Режимы проверки сертификатов клиентов
+const (
+	ClientAuthOptional string = "optional"
+	ClientAuthRequired string = "required"
+)
+
+// Reloader хранит текущую конфигурацию TLS. Новые соединения получают ее через
+// GetConfigForClient, поэтому перечитанный сертификат и список УЦ применяются без перезапуска,
+// а уже установленные соединения продолжают работать со старыми
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+
+	current atomic.Value
+
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+}
+
+// NewReloader загружает пару сертификат/ключ сервера и, если указан clientCAFile,
+// сертификаты УЦ, которыми должны быть подписаны сертификаты клиентов
+func NewReloader(certFile, keyFile, clientCAFile, clientAuth string) (*Reloader, error) {
+	r := &Reloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		clientAuth:   tls.NoClientCert,
+		modTimes:     make(map[string]time.Time),
+	}
+
+	if clientCAFile != "" {
+		switch clientAuth {
+		case ClientAuthOptional:
+			r.clientAuth = tls.VerifyClientCertIfGiven
+		case ClientAuthRequired:
+			r.clientAuth = tls.RequireAndVerifyClientCert
+		default:
+			return nil, fmt.Errorf("неизвестный режим проверки сертификатов клиентов: %s", clientAuth)
+		}
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig возвращает конфигурацию для http.Server. GetCertificate нужен
+// http.Server.ListenAndServeTLS без файлов сертификата, при установке соединения
+// действует конфигурация из GetConfigForClient
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current.Load().(*tls.Config).Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current.Load().(*tls.Config), nil
+		},
+	}
+}
+
+// Reload перечитывает файлы сертификатов. При ошибке продолжает действовать прежняя конфигурация
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки сертификата сервера: %w", err)
+	}
+
+	conf := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.clientAuth,
+		// только HTTP/1.1: таймауты чтения и записи применяются к соединению при начале каждого
+		// запроса, а в HTTP/2 одно соединение обслуживает много запросов одновременно
+		NextProtos: []string{"http/1.1"},
+	}
+
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения сертификатов УЦ клиентов: %w", err)
+		}
+		conf.ClientCAs = x509.NewCertPool()
+		if !conf.ClientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("в файле сертификатов УЦ клиентов нет сертификатов в формате PEM")
+		}
+	}
+
+	r.current.Store(conf)
+	for _, file := range r.files() {
+		if info, err := os.Stat(file); err == nil {
+			r.modTimes[file] = info.ModTime()
+		}
+	}
+
+	return nil
+}
+
+// Watch перечитывает сертификаты при изменении их файлов, которые проверяются с интервалом interval.
+// Так подхватываются сертификаты, обновленные внешним процессом без изменения конфигурации
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.filesChanged() {
+				continue
+			}
+		}
+
+		if err := r.Reload(); err != nil {
+			logger.Error("ошибка при перечитывании сертификатов", "error", err)
+			continue
+		}
+		logger.Info("сертификаты перечитаны")
+	}
+}
+
+func (r *Reloader) filesChanged() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
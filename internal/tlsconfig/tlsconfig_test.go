@@ -0,0 +1,105 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert создает самоподписанный сертификат с CN commonName и записывает его и ключ в dir
+func writeCert(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "server.crt")
+	keyFile = filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	conf, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(conf.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+	return cert.Subject.CommonName
+}
+
+func TestReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "first")
+
+	r, err := NewReloader(certFile, keyFile, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "first", commonName(t, r))
+	assert.False(t, r.filesChanged())
+
+	// HTTP/2 не предлагается: таймауты соединения действуют на каждый запрос HTTP/1.1
+	conf, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http/1.1"}, conf.NextProtos)
+
+	writeCert(t, dir, "second")
+	// время изменения файла может совпасть с прежним на файловых системах с грубым разрешением
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+
+	assert.True(t, r.filesChanged())
+	require.NoError(t, r.Reload())
+	assert.Equal(t, "second", commonName(t, r))
+	assert.False(t, r.filesChanged())
+
+	// при ошибке действует прежний сертификат
+	require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0600))
+	assert.Error(t, r.Reload())
+	assert.Equal(t, "second", commonName(t, r))
+}
+
+func TestNewReloader_ClientCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "server")
+
+	r, err := NewReloader(certFile, keyFile, certFile, ClientAuthRequired)
+	require.NoError(t, err)
+	conf, err := r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, conf.ClientAuth)
+	assert.NotNil(t, conf.ClientCAs)
+
+	r, err = NewReloader(certFile, keyFile, certFile, ClientAuthOptional)
+	require.NoError(t, err)
+	conf, _ = r.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	assert.Equal(t, tls.VerifyClientCertIfGiven, conf.ClientAuth)
+
+	_, err = NewReloader(certFile, keyFile, keyFile, ClientAuthRequired)
+	assert.Error(t, err, "в файле нет сертификатов")
+
+	_, err = NewReloader(certFile, keyFile, certFile, "always")
+	assert.Error(t, err)
+}
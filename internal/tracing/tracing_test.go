@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	c "userbalance/internal/config"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+func setupTest(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(NewProvider(sdktrace.WithSyncer(exporter), 1))
+
+	_, err := Setup(context.Background(), c.Default())
+	require.NoError(t, err)
+
+	return exporter
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := setupTest(t)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	testTable := []struct {
+		name        string
+		path        string
+		traceparent string
+		status      int
+		wantName    string
+		wantCode    codes.Code
+	}{
+		{
+			name:     "OK",
+			path:     "/users/15/balance",
+			status:   http.StatusOK,
+			wantName: "GET /users/{id:[0-9]+}/balance",
+			wantCode: codes.Unset,
+		},
+		{
+			name:        "parent from traceparent",
+			path:        "/users/16/balance",
+			traceparent: "00-" + traceID + "-00f067aa0ba902b7-01",
+			status:      http.StatusOK,
+			wantName:    "GET /users/{id:[0-9]+}/balance",
+			wantCode:    codes.Unset,
+		},
+		{
+			name:     "server error",
+			path:     "/users/17/balance",
+			status:   http.StatusInternalServerError,
+			wantName: "GET /users/{id:[0-9]+}/balance",
+			wantCode: codes.Error,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			exporter.Reset()
+
+			r := mux.NewRouter()
+			r.HandleFunc("/users/{id:[0-9]+}/balance", func(w http.ResponseWriter, r *http.Request) {
+				_, span := Start(r.Context(), "ControlService.GetBalanceAt")
+				span.End()
+				w.WriteHeader(testCase.status)
+			}).Methods("GET")
+			r.Use(Middleware)
+
+			req := httptest.NewRequest("GET", testCase.path, nil)
+			if testCase.traceparent != "" {
+				req.Header.Set("traceparent", testCase.traceparent)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 2)
+			child, server := spans[0], spans[1]
+
+			assert.Equal(t, testCase.wantName, server.Name)
+			assert.Equal(t, testCase.wantCode, server.Status.Code)
+			assert.Contains(t, server.Attributes, semconv.HTTPStatusCodeKey.Int(testCase.status))
+			assert.Equal(t, server.SpanContext.SpanID(), child.Parent.SpanID())
+			if testCase.traceparent != "" {
+				assert.Equal(t, traceID, server.SpanContext.TraceID().String())
+			}
+		})
+	}
+}
+
+func TestEnd(t *testing.T) {
+	exporter := setupTest(t)
+
+	_, span := Start(context.Background(), "ok")
+	End(span, nil)
+	_, span = Start(context.Background(), "error")
+	End(span, errors.New("недостаточно средств"))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+	assert.Equal(t, "недостаточно средств", spans[1].Status.Description)
+	require.Len(t, spans[1].Events, 1)
+	assert.Equal(t, "exception", spans[1].Events[0].Name)
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.ledger;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS public.ledger
+(
+    id bigint NOT NULL DEFAULT nextval('id_sequence'::regclass),
+    user_id bigint NOT NULL,
+    account character varying(16) COLLATE pg_catalog."default" NOT NULL,
+    amount bigint NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    description character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    CONSTRAINT ledger_pkey PRIMARY KEY (id),
+    CONSTRAINT ledger_account_check CHECK (account IN ('main', 'reserve')),
+    CONSTRAINT "user" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX IF NOT EXISTS ledger_user_id_created_at_idx ON public.ledger (user_id, created_at);
+
+INSERT INTO public.ledger (user_id, account, amount, description)
+    SELECT id, 'main', balance, 'Начальный остаток' FROM public.users WHERE balance <> 0;
+
+INSERT INTO public.ledger (user_id, account, amount, description)
+    SELECT user_id, 'reserve', balance, 'Начальный остаток' FROM public.money_reserve_accounts WHERE balance <> 0;
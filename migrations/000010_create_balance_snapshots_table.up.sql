@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS public.balance_snapshots
+(
+    user_id bigint NOT NULL,
+    taken_at timestamp with time zone NOT NULL,
+    balance bigint NOT NULL,
+    reserve bigint NOT NULL,
+    CONSTRAINT balance_snapshots_pkey PRIMARY KEY (user_id, taken_at),
+    CONSTRAINT "user" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS public.logs_user_id_id_idx;
+
+ALTER TABLE public.logs
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash;
@@ -0,0 +1,29 @@
+ALTER TABLE public.logs
+    ADD COLUMN IF NOT EXISTS prev_hash character varying(64) COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS hash character varying(64) COLLATE pg_catalog."default" NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS logs_user_id_id_idx ON public.logs (user_id, id);
+
+-- существующие записи связываются в цепочки по пользователям в порядке id,
+-- хэш считается так же, как в models.LogHash
+DO $$
+DECLARE
+    r record;
+    prev text := '';
+    last_user bigint := NULL;
+BEGIN
+    FOR r IN SELECT id, user_id, date, amount, description FROM public.logs ORDER BY user_id, id LOOP
+        IF last_user IS DISTINCT FROM r.user_id THEN
+            prev := '';
+            last_user := r.user_id;
+        END IF;
+
+        UPDATE public.logs
+        SET prev_hash = prev,
+            hash = encode(sha256(convert_to(
+                r.user_id || '|' || to_char(r.date, 'YYYY-MM-DD') || '|' || r.amount || '|' || r.description || '|' || prev,
+                'UTF8')), 'hex')
+        WHERE id = r.id
+        RETURNING hash INTO prev;
+    END LOOP;
+END $$;
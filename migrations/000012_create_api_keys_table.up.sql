@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS public.api_keys
+(
+    id bigserial NOT NULL,
+    name character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    key_hash character varying(64) COLLATE pg_catalog."default" NOT NULL,
+    scopes text[] NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    revoked_at timestamp with time zone,
+    CONSTRAINT api_keys_pkey PRIMARY KEY (id),
+    CONSTRAINT api_keys_key_hash_key UNIQUE (key_hash)
+);
@@ -0,0 +1,3 @@
+-- секрет подписи HMAC хранится открытым: он нужен для проверки подписи запросов клиента
+ALTER TABLE public.api_keys
+    ADD COLUMN IF NOT EXISTS signing_secret character varying(64) COLLATE pg_catalog."default";
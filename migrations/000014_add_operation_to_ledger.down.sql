@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.ledger_user_id_operation_created_at_idx;
+
+ALTER TABLE public.ledger
+    DROP COLUMN IF EXISTS operation;
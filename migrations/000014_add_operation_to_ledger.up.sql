@@ -0,0 +1,5 @@
+ALTER TABLE public.ledger
+    ADD COLUMN IF NOT EXISTS operation character varying(16) COLLATE pg_catalog."default";
+
+-- лимиты считаются по записям операции пользователя за последние сутки
+CREATE INDEX IF NOT EXISTS ledger_user_id_operation_created_at_idx ON public.ledger (user_id, operation, created_at);
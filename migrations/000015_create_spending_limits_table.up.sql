@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS public.spending_limits
+(
+    user_id bigint NOT NULL,
+    operation character varying(16) COLLATE pg_catalog."default" NOT NULL,
+    per_operation bigint NOT NULL DEFAULT 0,
+    daily bigint NOT NULL DEFAULT 0,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT spending_limits_pkey PRIMARY KEY (user_id, operation),
+    CONSTRAINT spending_limits_operation_check CHECK (operation IN ('topup', 'transfer', 'reserve')),
+    CONSTRAINT spending_limits_amount_check CHECK (per_operation >= 0 AND daily >= 0)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE public.ledger
+    DROP COLUMN IF EXISTS counterparty_id;
@@ -0,0 +1,2 @@
+ALTER TABLE public.ledger
+    ADD COLUMN IF NOT EXISTS counterparty_id bigint;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS public.risk_decisions
+(
+    id bigint NOT NULL DEFAULT nextval('id_sequence'::regclass),
+    operation character varying(16) COLLATE pg_catalog."default" NOT NULL,
+    user_id bigint NOT NULL,
+    to_user_id bigint,
+    service_id bigint,
+    order_id bigint,
+    amount bigint NOT NULL,
+    date character varying(10) COLLATE pg_catalog."default",
+    decision character varying(8) COLLATE pg_catalog."default" NOT NULL,
+    reasons text[] NOT NULL DEFAULT '{}',
+    status character varying(16) COLLATE pg_catalog."default",
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    resolved_at timestamp with time zone,
+    resolved_by character varying(100) COLLATE pg_catalog."default",
+    CONSTRAINT risk_decisions_pkey PRIMARY KEY (id),
+    CONSTRAINT risk_decisions_operation_check CHECK (operation IN ('transfer', 'reserve')),
+    CONSTRAINT risk_decisions_decision_check CHECK (decision IN ('allow', 'review', 'deny')),
+    CONSTRAINT risk_decisions_status_check CHECK (status IN ('pending', 'approved', 'rejected'))
+);
+
+CREATE INDEX IF NOT EXISTS risk_decisions_user_id_created_at_idx ON public.risk_decisions (user_id, created_at);
+
+-- операции, ожидающие решения администратора
+CREATE INDEX IF NOT EXISTS risk_decisions_pending_idx ON public.risk_decisions (id) WHERE status = 'pending';
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS public.schedules
+(
+    id bigint NOT NULL DEFAULT nextval('id_sequence'::regclass),
+    operation character varying(16) COLLATE pg_catalog."default" NOT NULL,
+    user_id bigint NOT NULL,
+    to_user_id bigint,
+    service_id bigint,
+    order_id bigint,
+    amount bigint NOT NULL,
+    kind character varying(16) COLLATE pg_catalog."default" NOT NULL,
+    run_at timestamp with time zone,
+    repeat_interval character varying(32) COLLATE pg_catalog."default",
+    cron character varying(100) COLLATE pg_catalog."default",
+    status character varying(16) COLLATE pg_catalog."default" NOT NULL DEFAULT 'active',
+    next_run_at timestamp with time zone,
+    attempt integer NOT NULL DEFAULT 0,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT schedules_pkey PRIMARY KEY (id),
+    CONSTRAINT schedules_operation_check CHECK (operation IN ('transfer', 'reserve')),
+    CONSTRAINT schedules_kind_check CHECK (kind IN ('once', 'interval', 'cron')),
+    CONSTRAINT schedules_status_check CHECK (status IN ('active', 'paused', 'completed', 'failed', 'canceled')),
+    CONSTRAINT schedules_amount_check CHECK (amount > 0)
+);
+
+CREATE INDEX IF NOT EXISTS schedules_user_id_idx ON public.schedules (user_id);
+
+-- расписания, ожидающие выполнения
+CREATE INDEX IF NOT EXISTS schedules_next_run_at_idx ON public.schedules (next_run_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS public.schedule_runs
+(
+    id bigint NOT NULL DEFAULT nextval('id_sequence'::regclass),
+    schedule_id bigint NOT NULL,
+    attempt integer NOT NULL,
+    status character varying(16) COLLATE pg_catalog."default" NOT NULL,
+    error text,
+    due_at timestamp with time zone NOT NULL,
+    executed_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT schedule_runs_pkey PRIMARY KEY (id),
+    CONSTRAINT schedule_runs_schedule_id_fkey FOREIGN KEY (schedule_id)
+        REFERENCES public.schedules (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT schedule_runs_status_check CHECK (status IN ('succeeded', 'retry', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS schedule_runs_schedule_id_idx ON public.schedule_runs (schedule_id, id);
@@ -0,0 +1,4 @@
+ALTER TABLE public.risk_decisions
+    DROP COLUMN IF EXISTS pending;
+
+DROP TABLE IF EXISTS public.pending_transfers;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS public.pending_transfers
+(
+    id bigint NOT NULL DEFAULT nextval('id_sequence'::regclass),
+    from_user_id bigint NOT NULL,
+    to_user_id bigint NOT NULL,
+    amount bigint NOT NULL,
+    status character varying(16) COLLATE pg_catalog."default" NOT NULL DEFAULT 'pending',
+    expires_at timestamp with time zone NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    resolved_at timestamp with time zone,
+    CONSTRAINT pending_transfers_pkey PRIMARY KEY (id),
+    CONSTRAINT pending_transfers_status_check CHECK (status IN ('pending', 'accepted', 'declined', 'expired')),
+    CONSTRAINT pending_transfers_amount_check CHECK (amount > 0)
+);
+
+CREATE INDEX IF NOT EXISTS pending_transfers_from_user_id_idx ON public.pending_transfers (from_user_id);
+
+CREATE INDEX IF NOT EXISTS pending_transfers_to_user_id_idx ON public.pending_transfers (to_user_id);
+
+-- переводы, ожидающие подтверждения, по сроку возврата
+CREATE INDEX IF NOT EXISTS pending_transfers_expires_at_idx ON public.pending_transfers (expires_at) WHERE status = 'pending';
+
+-- одобренный после проверки перевод, ожидающий подтверждения, создается заново, а не выполняется сразу
+ALTER TABLE public.risk_decisions
+    ADD COLUMN IF NOT EXISTS pending boolean NOT NULL DEFAULT false;
@@ -0,0 +1,9 @@
+ALTER TABLE public.risk_decisions
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS comment;
+
+DROP INDEX IF EXISTS public.logs_metadata_idx;
+
+ALTER TABLE public.logs
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS comment;
@@ -0,0 +1,12 @@
+-- комментарий и метаданные операции хранятся в ее записях истории
+ALTER TABLE public.logs
+    ADD COLUMN IF NOT EXISTS comment character varying(255) COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS metadata jsonb NOT NULL DEFAULT '{}';
+
+-- отбор истории по ключу метаданных (metadata ? 'key') и паре ключ-значение (metadata @> '{"key": "value"}')
+CREATE INDEX IF NOT EXISTS logs_metadata_idx ON public.logs USING gin (metadata);
+
+-- операция, отправленная на проверку, выполняется после одобрения с исходными комментарием и метаданными
+ALTER TABLE public.risk_decisions
+    ADD COLUMN IF NOT EXISTS comment character varying(255) COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS metadata jsonb NOT NULL DEFAULT '{}';
@@ -77,6 +77,8 @@ CREATE TABLE IF NOT EXISTS public.logs
     date date NOT NULL,
     description character varying(100) COLLATE pg_catalog."default" NOT NULL,
     amount bigint NOT NULL,
+    prev_hash character varying(64) COLLATE pg_catalog."default" NOT NULL DEFAULT '',
+    hash character varying(64) COLLATE pg_catalog."default" NOT NULL DEFAULT '',
     CONSTRAINT report_pkey PRIMARY KEY (id),
     CONSTRAINT "user" FOREIGN KEY (user_id)
         REFERENCES public.users (id) MATCH SIMPLE
@@ -84,6 +86,39 @@ CREATE TABLE IF NOT EXISTS public.logs
         ON DELETE NO ACTION
 );
 
+CREATE INDEX IF NOT EXISTS logs_user_id_id_idx ON public.logs (user_id, id);
+
+CREATE TABLE IF NOT EXISTS public.ledger
+(
+    id bigint NOT NULL DEFAULT nextval('id_sequence'::regclass),
+    user_id bigint NOT NULL,
+    account character varying(16) COLLATE pg_catalog."default" NOT NULL,
+    amount bigint NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    description character varying(100) COLLATE pg_catalog."default" NOT NULL,
+    CONSTRAINT ledger_pkey PRIMARY KEY (id),
+    CONSTRAINT ledger_account_check CHECK (account IN ('main', 'reserve')),
+    CONSTRAINT "user" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX IF NOT EXISTS ledger_user_id_created_at_idx ON public.ledger (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS public.balance_snapshots
+(
+    user_id bigint NOT NULL,
+    taken_at timestamp with time zone NOT NULL,
+    balance bigint NOT NULL,
+    reserve bigint NOT NULL,
+    CONSTRAINT balance_snapshots_pkey PRIMARY KEY (user_id, taken_at),
+    CONSTRAINT "user" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
 INSERT INTO public.services(
 	id, title)
 	VALUES (1, 'Услуга 1');